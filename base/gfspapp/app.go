@@ -50,15 +50,17 @@ type GfSpBaseApp struct {
 	uploader   module.Uploader
 	metrics    module.Modular
 	pprof      module.Modular
+	tracing    module.Modular
 
 	appCtx    context.Context
 	appCancel context.CancelFunc
 	services  []corelifecycle.Service
 
-	uploadSpeed    int64
-	downloadSpeed  int64
-	replicateSpeed int64
-	receiveSpeed   int64
+	uploadSpeed     int64
+	downloadSpeed   int64
+	replicateSpeed  int64
+	receiveSpeed    int64
+	tierObjectSpeed int64
 
 	sealObjectTimeout int64
 	gcObjectTimeout   int64
@@ -71,6 +73,7 @@ type GfSpBaseApp struct {
 	gcObjectRetry       int64
 	gcZombieRetry       int64
 	gcMetaRetry         int64
+	tierObjectRetry     int64
 }
 
 // AppID returns the GfSpBaseApp ID, the default value is prefix(gfsp) add
@@ -4,6 +4,7 @@ import (
 	"math"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/bnb-chain/greenfield-storage-provider/base/gfspclient"
 	"github.com/bnb-chain/greenfield-storage-provider/base/gfspconfig"
@@ -17,6 +18,7 @@ import (
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/metrics"
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/pprof"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/tracing"
 	"github.com/bnb-chain/greenfield-storage-provider/store/bsdb"
 	"github.com/bnb-chain/greenfield-storage-provider/store/config"
 	piecestoreclient "github.com/bnb-chain/greenfield-storage-provider/store/piecestore/client"
@@ -32,6 +34,12 @@ const (
 	DefaultMetricsAddress = "localhost:24367"
 	// DefaultPprofAddress defines the default pprof service address.
 	DefaultPprofAddress = "localhost:24368"
+	// DefaultTracingExporter defines the default tracing exporter, it works offline.
+	DefaultTracingExporter = tracing.FileExporter
+	// DefaultTracingFilePath defines the default file path for tracing file exporter.
+	DefaultTracingFilePath = "./gfsp_trace.json"
+	// DefaultTracingSampleRatio defines the default sample ratio of the root spans.
+	DefaultTracingSampleRatio = 1.0
 
 	// DefaultChainID defines the default greenfield chain ID.
 	DefaultChainID = "greenfield_9000-1741"
@@ -69,6 +77,7 @@ func DefaultStaticOption(app *GfSpBaseApp, cfg *gfspconfig.GfSpConfig) error {
 	app.downloadSpeed = cfg.Task.DownloadTaskSpeed
 	app.replicateSpeed = cfg.Task.ReplicateTaskSpeed
 	app.receiveSpeed = cfg.Task.ReceiveTaskSpeed
+	app.tierObjectSpeed = cfg.Task.TierObjectTaskSpeed
 	app.sealObjectTimeout = cfg.Task.SealObjectTaskTimeout
 	app.gcObjectTimeout = cfg.Task.GcObjectTaskTimeout
 	app.gcZombieTimeout = cfg.Task.GcZombieTaskTimeout
@@ -79,6 +88,7 @@ func DefaultStaticOption(app *GfSpBaseApp, cfg *gfspconfig.GfSpConfig) error {
 	app.gcObjectRetry = cfg.Task.GcObjectTaskRetry
 	app.gcZombieRetry = cfg.Task.GcZombieTaskRetry
 	app.gcMetaRetry = cfg.Task.GcMetaTaskRetry
+	app.tierObjectRetry = cfg.Task.TierObjectTaskRetry
 	app.approver = &coremodule.NullModular{}
 	app.authorizer = &coremodule.NullModular{}
 	app.downloader = &coremodule.NilModular{}
@@ -286,7 +296,18 @@ func DefaultGfSpPieceStoreOption(app *GfSpBaseApp, cfg *gfspconfig.GfSpConfig) e
 	if cfg.PieceStore.Store.IAMType == "" {
 		cfg.PieceStore.Store.IAMType = "SA"
 	}
-	pieceStore, err := piecestoreclient.NewStoreClient(&cfg.PieceStore)
+	if cfg.PieceStore.Tiering.Enable {
+		if cfg.PieceStore.Tiering.ColdStore.MaxRetries == 0 {
+			cfg.PieceStore.Tiering.ColdStore.MaxRetries = 5
+		}
+		if cfg.PieceStore.Tiering.ColdStore.MinRetryDelay == 0 {
+			cfg.PieceStore.Tiering.ColdStore.MinRetryDelay = 1
+		}
+		if cfg.PieceStore.Tiering.ColdStore.IAMType == "" {
+			cfg.PieceStore.Tiering.ColdStore.IAMType = "SA"
+		}
+	}
+	pieceStore, err := piecestoreclient.NewStoreClient(&cfg.PieceStore, app.gfSpDB, app.gfSpDB, app.gfSpDB)
 	if err != nil {
 		log.Warnw("if not use piece store, please ignore: failed to new piece store", "error", err)
 		return nil
@@ -345,6 +366,17 @@ func DefaultGfSpResourceManagerOption(app *GfSpBaseApp, cfg *gfspconfig.GfSpConf
 	return nil
 }
 
+// MakeFailoverConfig returns the failover config of the chain endpoints.
+func MakeFailoverConfig(cfg *gfspconfig.GfSpConfig) gnfd.FailoverConfig {
+	return gnfd.FailoverConfig{
+		MaxAttempts:         cfg.Chain.Failover.MaxAttempts,
+		FailureThreshold:    cfg.Chain.Failover.FailureThreshold,
+		OpenTimeout:         time.Duration(cfg.Chain.Failover.OpenTimeout) * time.Second,
+		MaxHeightLag:        cfg.Chain.Failover.MaxHeightLag,
+		HealthCheckInterval: time.Duration(cfg.Chain.Failover.HealthCheckInterval) * time.Second,
+	}
+}
+
 func DefaultGfSpConsensusOption(app *GfSpBaseApp, cfg *gfspconfig.GfSpConfig) error {
 	if cfg.Customize.Consensus != nil {
 		app.chain = cfg.Customize.Consensus
@@ -359,12 +391,25 @@ func DefaultGfSpConsensusOption(app *GfSpBaseApp, cfg *gfspconfig.GfSpConfig) er
 	gnfdCfg := &gnfd.GnfdChainConfig{
 		ChainID:      cfg.Chain.ChainID,
 		ChainAddress: cfg.Chain.ChainAddress,
+		Failover:     MakeFailoverConfig(cfg),
 	}
 	chain, err := gnfd.NewGnfd(gnfdCfg)
 	if err != nil {
 		return err
 	}
 	app.chain = chain
+	if cfg.Chain.Cache.Enable {
+		app.chain = gnfd.NewCacheConsensus(chain, gnfd.CacheConfig{
+			BucketTTL:           time.Duration(cfg.Chain.Cache.BucketTTL) * time.Second,
+			ObjectTTL:           time.Duration(cfg.Chain.Cache.ObjectTTL) * time.Second,
+			PaymentTTL:          time.Duration(cfg.Chain.Cache.PaymentTTL) * time.Second,
+			ParamsTTL:           time.Duration(cfg.Chain.Cache.ParamsTTL) * time.Second,
+			NegativeTTL:         time.Duration(cfg.Chain.Cache.NegativeTTL) * time.Second,
+			HeightInterval:      time.Duration(cfg.Chain.Cache.HeightInterval) * time.Second,
+			MaxEntries:          cfg.Chain.Cache.MaxEntries,
+			MaxHistoricalParams: cfg.Chain.Cache.MaxHistoricalParams,
+		})
+	}
 	return nil
 }
 
@@ -427,6 +472,26 @@ func DefaultGfSpPprofOption(app *GfSpBaseApp, cfg *gfspconfig.GfSpConfig) error
 	return nil
 }
 
+func DefaultGfSpTracingOption(app *GfSpBaseApp, cfg *gfspconfig.GfSpConfig) error {
+	if !cfg.Monitor.EnableTracing {
+		return nil
+	}
+	if cfg.Monitor.TracingExporter == "" {
+		cfg.Monitor.TracingExporter = DefaultTracingExporter
+	}
+	if cfg.Monitor.TracingFilePath == "" {
+		cfg.Monitor.TracingFilePath = DefaultTracingFilePath
+	}
+	if cfg.Monitor.TracingSampleRatio == nil {
+		sampleRatio := DefaultTracingSampleRatio
+		cfg.Monitor.TracingSampleRatio = &sampleRatio
+	}
+	app.tracing = tracing.NewTracing(cfg.AppID, cfg.Monitor.TracingExporter, cfg.Monitor.TracingEndpoint,
+		cfg.Monitor.TracingFilePath, *cfg.Monitor.TracingSampleRatio)
+	app.RegisterServices(app.tracing)
+	return nil
+}
+
 var gfspBaseAppDefaultOptions = []Option{
 	DefaultStaticOption,
 	DefaultGfSpClientOption,
@@ -440,6 +505,7 @@ var gfspBaseAppDefaultOptions = []Option{
 	DefaultGfSpModulusOption,
 	DefaultGfSpMetricOption,
 	DefaultGfSpPprofOption,
+	DefaultGfSpTracingOption,
 }
 
 func NewGfSpBaseApp(cfg *gfspconfig.GfSpConfig, opts ...gfspconfig.Option) (*GfSpBaseApp, error) {
@@ -0,0 +1,61 @@
+package gfspapp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/greenfield-storage-provider/base/gfspconfig"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/tracing"
+)
+
+func TestDefaultGfSpTracingOption_SampleRatio(t *testing.T) {
+	cases := []struct {
+		name        string
+		sampleRatio string
+		expected    float64
+		sampled     bool
+	}{
+		{name: "unset", expected: DefaultTracingSampleRatio, sampled: true},
+		{name: "zero", sampleRatio: "TracingSampleRatio = 0.0", expected: 0, sampled: false},
+		{name: "one", sampleRatio: "TracingSampleRatio = 1", expected: 1, sampled: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "trace.json")
+			content := fmt.Sprintf("[Monitor]\nEnableTracing = true\nTracingExporter = '%s'\nTracingFilePath = '%s'\n%s\n",
+				tracing.FileExporter, filePath, c.sampleRatio)
+			cfg := &gfspconfig.GfSpConfig{}
+			assert.NoError(t, toml.Unmarshal([]byte(content), cfg))
+
+			app := &GfSpBaseApp{}
+			assert.NoError(t, DefaultGfSpTracingOption(app, cfg))
+			assert.NotNil(t, cfg.Monitor.TracingSampleRatio)
+			assert.Equal(t, c.expected, *cfg.Monitor.TracingSampleRatio)
+
+			ctx := context.Background()
+			assert.NoError(t, app.tracing.Start(ctx))
+			_, span := tracing.StartSpan(ctx, "test")
+			assert.Equal(t, c.sampled, span.SpanContext().IsSampled())
+			span.End()
+			assert.NoError(t, app.tracing.Stop(ctx))
+
+			spans, err := os.ReadFile(filePath)
+			assert.NoError(t, err)
+			assert.Equal(t, c.sampled, len(spans) > 0)
+		})
+	}
+}
+
+func TestDefaultGfSpTracingOption_Disabled(t *testing.T) {
+	cfg := &gfspconfig.GfSpConfig{}
+	app := &GfSpBaseApp{}
+	assert.NoError(t, DefaultGfSpTracingOption(app, cfg))
+	assert.Nil(t, app.tracing)
+	assert.Nil(t, cfg.Monitor.TracingSampleRatio)
+}
@@ -2,7 +2,9 @@ package gfspapp
 
 import (
 	"context"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfsperrors"
 	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfspserver"
@@ -18,48 +20,65 @@ var (
 
 var _ gfspserver.GfSpDownloadServiceServer = &GfSpBaseApp{}
 
-func (g *GfSpBaseApp) GfSpDownloadObject(ctx context.Context, req *gfspserver.GfSpDownloadObjectRequest) (
-	*gfspserver.GfSpDownloadObjectResponse, error) {
+func (g *GfSpBaseApp) GfSpDownloadObject(req *gfspserver.GfSpDownloadObjectRequest,
+	stream gfspserver.GfSpDownloadService_GfSpDownloadObjectServer) error {
+	ctx := stream.Context()
 	downloadObjectTask := req.GetDownloadObjectTask()
 	if downloadObjectTask == nil {
 		log.Error("failed to download object due to task pointer dangling")
-		return &gfspserver.GfSpDownloadObjectResponse{Err: ErrDownloadTaskDangling}, nil
+		return stream.Send(&gfspserver.GfSpDownloadObjectResponse{Err: ErrDownloadTaskDangling})
 	}
 	ctx = log.WithValue(ctx, log.CtxKeyTask, downloadObjectTask.Key().String())
 	span, err := g.downloader.ReserveResource(ctx, downloadObjectTask.EstimateLimit().ScopeStat())
 	if err != nil {
 		log.CtxErrorw(ctx, "failed to reserve download object resource", "error", err)
-		return &gfspserver.GfSpDownloadObjectResponse{Err: ErrDownloadExhaustResource}, nil
+		return stream.Send(&gfspserver.GfSpDownloadObjectResponse{Err: ErrDownloadExhaustResource})
 	}
 	defer span.Done()
 	metrics.DownloadObjectSizeHistogram.WithLabelValues(
 		g.downloader.Name()).Observe(float64(downloadObjectTask.GetSize()))
-	data, err := g.OnDownloadObjectTask(ctx, downloadObjectTask)
-	log.CtxDebugw(ctx, "finished to download object", "len", len(data), "error", err)
-	return &gfspserver.GfSpDownloadObjectResponse{
-		Err:  gfsperrors.MakeGfSpError(err),
-		Data: data}, nil
+	w := &downloadObjectWriter{stream: stream}
+	err = g.OnDownloadObjectTask(ctx, downloadObjectTask, w)
+	log.CtxDebugw(ctx, "finished to download object", "len", w.size, "error", err)
+	if err != nil {
+		return stream.Send(&gfspserver.GfSpDownloadObjectResponse{Err: gfsperrors.MakeGfSpError(err)})
+	}
+	return nil
 }
 
-func (g *GfSpBaseApp) OnDownloadObjectTask(ctx context.Context, downloadObjectTask task.DownloadObjectTask) (
-	[]byte, error) {
+// downloadObjectWriter sends the written data to the download object stream.
+type downloadObjectWriter struct {
+	stream gfspserver.GfSpDownloadService_GfSpDownloadObjectServer
+	size   int
+}
+
+func (w *downloadObjectWriter) Write(p []byte) (int, error) {
+	if err := w.stream.Send(&gfspserver.GfSpDownloadObjectResponse{Data: p}); err != nil {
+		return 0, err
+	}
+	w.size += len(p)
+	return len(p), nil
+}
+
+func (g *GfSpBaseApp) OnDownloadObjectTask(ctx context.Context, downloadObjectTask task.DownloadObjectTask,
+	w io.Writer) error {
 	if downloadObjectTask == nil || downloadObjectTask.GetObjectInfo() == nil {
 		log.CtxError(ctx, "failed to download object due to task pointer dangling")
-		return nil, ErrDownloadTaskDangling
+		return ErrDownloadTaskDangling
 	}
 	err := g.downloader.PreDownloadObject(ctx, downloadObjectTask)
 	if err != nil {
 		log.CtxErrorw(ctx, "failed to pre download object", "task_info", downloadObjectTask.Info(), "error", err)
-		return nil, err
+		return err
 	}
-	data, err := g.downloader.HandleDownloadObjectTask(ctx, downloadObjectTask)
+	err = g.downloader.HandleDownloadObjectTask(ctx, downloadObjectTask, w)
 	if err != nil {
 		log.CtxErrorw(ctx, "failed to download object", "error", err)
-		return nil, err
+		return err
 	}
 	g.downloader.PostDownloadObject(ctx, downloadObjectTask)
 	log.CtxDebugw(ctx, "succeed to download object")
-	return data, nil
+	return nil
 }
 
 func (g *GfSpBaseApp) GfSpDownloadPiece(ctx context.Context, req *gfspserver.GfSpDownloadPieceRequest) (
@@ -151,3 +170,44 @@ func (g *GfSpBaseApp) OnChallengePieceTask(ctx context.Context, challengePieceTa
 	log.CtxDebugw(ctx, "succeed to challenge piece")
 	return integrity, checksums, data, nil
 }
+
+func (g *GfSpBaseApp) GfSpPresignObject(ctx context.Context, req *gfspserver.GfSpPresignObjectRequest) (
+	*gfspserver.GfSpPresignObjectResponse, error) {
+	downloadObjectTask := req.GetDownloadObjectTask()
+	if downloadObjectTask == nil {
+		log.Error("failed to presign object due to task pointer dangling")
+		return &gfspserver.GfSpPresignObjectResponse{Err: ErrDownloadTaskDangling}, nil
+	}
+	ctx = log.WithValue(ctx, log.CtxKeyTask, downloadObjectTask.Key().String())
+	url, err := g.OnPresignObjectTask(ctx, downloadObjectTask,
+		time.Duration(req.GetExpireSeconds())*time.Second, req.GetContentDisposition())
+	log.CtxDebugw(ctx, "finished to presign object", "presigned", url != "", "error", err)
+	return &gfspserver.GfSpPresignObjectResponse{
+		Err: gfsperrors.MakeGfSpError(err),
+		Url: url}, nil
+}
+
+func (g *GfSpBaseApp) OnPresignObjectTask(ctx context.Context, downloadObjectTask task.DownloadObjectTask,
+	expire time.Duration, contentDisposition string) (string, error) {
+	if downloadObjectTask == nil || downloadObjectTask.GetObjectInfo() == nil {
+		log.CtxError(ctx, "failed to presign object due to task pointer dangling")
+		return "", ErrDownloadTaskDangling
+	}
+	url, err := g.downloader.HandlePresignObjectTask(ctx, downloadObjectTask, expire, contentDisposition)
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to presign object", "error", err)
+		return "", err
+	}
+	if url == "" {
+		return "", nil
+	}
+	// the read quota is charged only if the object is read by the presigned url, otherwise
+	// the object is downloaded by the gateway and charged by the download task.
+	if err = g.downloader.PreDownloadObject(ctx, downloadObjectTask); err != nil {
+		log.CtxErrorw(ctx, "failed to pre download object", "task_info", downloadObjectTask.Info(), "error", err)
+		return "", err
+	}
+	g.downloader.PostDownloadObject(ctx, downloadObjectTask)
+	log.CtxDebugw(ctx, "succeed to presign object")
+	return url, nil
+}
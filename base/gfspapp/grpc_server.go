@@ -30,6 +30,7 @@ func (g *GfSpBaseApp) newRpcServer(options ...grpc.ServerOption) {
 	if g.EnableMetrics() {
 		options = append(options, utilgrpc.GetDefaultServerInterceptor()...)
 	}
+	options = append(options, utilgrpc.GetDefaultServerTraceInterceptor()...)
 	g.server = grpc.NewServer(options...)
 	gfspserver.RegisterGfSpApprovalServiceServer(g.server, g)
 	gfspserver.RegisterGfSpAuthorizationServiceServer(g.server, g)
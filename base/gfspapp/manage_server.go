@@ -9,6 +9,7 @@ import (
 	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfspserver"
 	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfsptask"
 	corercmgr "github.com/bnb-chain/greenfield-storage-provider/core/rcmgr"
+	"github.com/bnb-chain/greenfield-storage-provider/core/spdb"
 	coretask "github.com/bnb-chain/greenfield-storage-provider/core/task"
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/metrics"
@@ -91,6 +92,10 @@ func (g *GfSpBaseApp) GfSpAskTask(ctx context.Context, req *gfspserver.GfSpAskTa
 		resp.Response = &gfspserver.GfSpAskTaskResponse_GcMetaTask{
 			GcMetaTask: t,
 		}
+	case *gfsptask.GfSpTierObjectTask:
+		resp.Response = &gfspserver.GfSpAskTaskResponse_TierObjectTask{
+			TierObjectTask: t,
+		}
 	default:
 		log.CtxErrorw(ctx, "[BUG] Unsupported task type to dispatch")
 		return &gfspserver.GfSpAskTaskResponse{Err: ErrUnsupportedTaskType}, nil
@@ -206,6 +211,13 @@ func (g *GfSpBaseApp) GfSpReportTask(ctx context.Context, req *gfspserver.GfSpRe
 		log.CtxInfow(ctx, "begin to handle reported task", "task_info", task.Info())
 
 		err = g.manager.HandleGCMetaTask(ctx, t.GcMetaTask)
+	case *gfspserver.GfSpReportTaskRequest_TierObjectTask:
+		task := t.TierObjectTask
+		ctx = log.WithValue(ctx, log.CtxKeyTask, task.Key().String())
+		task.SetAddress(RpcRemoteAddress(ctx))
+		log.CtxInfow(ctx, "begin to handle reported task", "task_info", task.Info())
+
+		err = g.manager.HandleTierObjectTask(ctx, t.TierObjectTask)
 	case *gfspserver.GfSpReportTaskRequest_DownloadObjectTask:
 		task := t.DownloadObjectTask
 		ctx = log.WithValue(ctx, log.CtxKeyTask, task.Key().String())
@@ -231,3 +243,34 @@ func (g *GfSpBaseApp) GfSpReportTask(ctx context.Context, req *gfspserver.GfSpRe
 	log.CtxInfow(ctx, "succeed to handle reported task")
 	return &gfspserver.GfSpReportTaskResponse{}, nil
 }
+
+func (g *GfSpBaseApp) GfSpQueryTaskEvents(ctx context.Context, req *gfspserver.GfSpQueryTaskEventsRequest) (
+	*gfspserver.GfSpQueryTaskEventsResponse, error) {
+	timeRange := &spdb.TaskEventTimeRange{
+		StartTimestampSecond: req.GetStartTimestamp(),
+		EndTimestampSecond:   req.GetEndTimestamp(),
+		LimitNum:             int(req.GetLimit()),
+	}
+	if timeRange.EndTimestampSecond == 0 {
+		timeRange.EndTimestampSecond = time.Now().Unix() + 1
+	}
+	events, err := g.manager.QueryTaskEvents(ctx, req.GetObjectId(), timeRange)
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to query task events", "object_id", req.GetObjectId(), "error", err)
+		return &gfspserver.GfSpQueryTaskEventsResponse{Err: gfsperrors.MakeGfSpError(err)}, nil
+	}
+	resp := &gfspserver.GfSpQueryTaskEventsResponse{}
+	for _, event := range events {
+		resp.Events = append(resp.Events, &gfspserver.GfSpTaskEvent{
+			TaskKey:          event.TaskKey,
+			TaskType:         event.TaskType,
+			ObjectId:         event.ObjectID,
+			Event:            string(event.Event),
+			Address:          event.Address,
+			Retry:            event.Retry,
+			ErrorDescription: event.ErrorDescription,
+			Timestamp:        event.TimestampSecond,
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,58 @@
+package gfspapp
+
+import (
+	"context"
+
+	"github.com/bnb-chain/greenfield-storage-provider/core/spdb"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/tracing"
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
+)
+
+// PrimaryReplicateIdx defines the replicate idx of the primary segment pieces in piece usage.
+const PrimaryReplicateIdx = int32(-1)
+
+// RecordPieceUsage records the bytes of the object pieces stored in piece store, the
+// replicateIdx is PrimaryReplicateIdx for the primary segment pieces. The bucketInfo is
+// carried by the task, it is only queried from chain if the task does not carry it, e.g.
+// the task is reloaded from db or sent by an older primary SP. The error is only logged
+// because the usage accounting should not break the upload or replicate workflow.
+func (g *GfSpBaseApp) RecordPieceUsage(ctx context.Context, bucketInfo *storagetypes.BucketInfo,
+	objectInfo *storagetypes.ObjectInfo, replicateIdx int32, size int64) {
+	if objectInfo == nil {
+		return
+	}
+	usage := &spdb.PieceUsage{
+		ObjectID:     objectInfo.Id.Uint64(),
+		ReplicateIdx: replicateIdx,
+		BucketName:   objectInfo.GetBucketName(),
+		Size:         size,
+	}
+	var err error
+	if bucketInfo == nil {
+		if bucketInfo, err = g.Consensus().QueryBucketInfo(ctx, objectInfo.GetBucketName()); err != nil {
+			log.CtxWarnw(ctx, "failed to query bucket info for piece usage", "bucket_name",
+				objectInfo.GetBucketName(), "error", err)
+		}
+	}
+	usage.PaymentAccount = bucketInfo.GetPaymentAddress()
+	_, dbSpan := tracing.StartSpan(ctx, "SpDB.SetPieceUsage")
+	err = g.GfSpDB().SetPieceUsage(usage)
+	tracing.EndSpan(dbSpan, err)
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to record piece usage", "object_id", usage.ObjectID,
+			"replicate_idx", replicateIdx, "error", err)
+	}
+}
+
+// DeletePieceUsage deletes the piece usage record after the pieces are deleted from
+// piece store, the error is only logged as the same as RecordPieceUsage.
+func (g *GfSpBaseApp) DeletePieceUsage(ctx context.Context, objectID uint64, replicateIdx int32) {
+	_, dbSpan := tracing.StartSpan(ctx, "SpDB.DeletePieceUsage")
+	err := g.GfSpDB().DeletePieceUsage(objectID, replicateIdx)
+	tracing.EndSpan(dbSpan, err)
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to delete piece usage", "object_id", objectID,
+			"replicate_idx", replicateIdx, "error", err)
+	}
+}
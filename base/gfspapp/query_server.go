@@ -3,10 +3,25 @@ package gfspapp
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfsperrors"
 	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfspserver"
+	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfsptask"
+	"github.com/bnb-chain/greenfield-storage-provider/core/spdb"
 	coretask "github.com/bnb-chain/greenfield-storage-provider/core/task"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+	storetypes "github.com/bnb-chain/greenfield-storage-provider/store/types"
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
+)
+
+const (
+	// InspectRolePrimary defines the sp is the primary sp of the inspected object.
+	InspectRolePrimary = "primary"
+	// InspectRoleSecondary defines the sp is the secondary sp of the inspected object.
+	InspectRoleSecondary = "secondary"
+	// InspectRoleNone defines the sp is neither primary nor secondary sp of the inspected object.
+	InspectRoleNone = "none"
 )
 
 var _ gfspserver.GfSpQueryTaskServiceServer = &GfSpBaseApp{}
@@ -18,12 +33,21 @@ func (g *GfSpBaseApp) GfSpQueryTasks(ctx context.Context, req *gfspserver.GfSpQu
 		return &gfspserver.GfSpQueryTasksResponse{
 			Err: gfsperrors.MakeGfSpError(fmt.Errorf("invalid query key"))}, nil
 	}
-	approverTasks, _ := g.approver.QueryTasks(ctx, coretask.TKey(subKey))
-	downloaderTasks, _ := g.downloader.QueryTasks(ctx, coretask.TKey(subKey))
-	managerTasks, _ := g.manager.QueryTasks(ctx, coretask.TKey(subKey))
-	p2pTasks, _ := g.p2p.QueryTasks(ctx, coretask.TKey(subKey))
-	receiverTasks, _ := g.receiver.QueryTasks(ctx, coretask.TKey(subKey))
-	uploaderTasks, _ := g.uploader.QueryTasks(ctx, coretask.TKey(subKey))
+	taskInfo := g.queryTasks(ctx, coretask.TKey(subKey))
+	if len(taskInfo) == 0 {
+		return &gfspserver.GfSpQueryTasksResponse{
+			Err: gfsperrors.MakeGfSpError(fmt.Errorf("no match tasks"))}, nil
+	}
+	return &gfspserver.GfSpQueryTasksResponse{TaskInfo: taskInfo}, nil
+}
+
+func (g *GfSpBaseApp) queryTasks(ctx context.Context, subKey coretask.TKey) []string {
+	approverTasks, _ := g.approver.QueryTasks(ctx, subKey)
+	downloaderTasks, _ := g.downloader.QueryTasks(ctx, subKey)
+	managerTasks, _ := g.manager.QueryTasks(ctx, subKey)
+	p2pTasks, _ := g.p2p.QueryTasks(ctx, subKey)
+	receiverTasks, _ := g.receiver.QueryTasks(ctx, subKey)
+	uploaderTasks, _ := g.uploader.QueryTasks(ctx, subKey)
 
 	var taskInfo []string
 	for _, task := range approverTasks {
@@ -44,9 +68,186 @@ func (g *GfSpBaseApp) GfSpQueryTasks(ctx context.Context, req *gfspserver.GfSpQu
 	for _, task := range uploaderTasks {
 		taskInfo = append(taskInfo, task.Info())
 	}
-	if len(taskInfo) == 0 {
-		return &gfspserver.GfSpQueryTasksResponse{
-			Err: gfsperrors.MakeGfSpError(fmt.Errorf("no match tasks"))}, nil
+	return taskInfo
+}
+
+func (g *GfSpBaseApp) GfSpInspectObject(ctx context.Context, req *gfspserver.GfSpInspectObjectRequest) (
+	*gfspserver.GfSpInspectObjectResponse, error) {
+	var (
+		objectInfo *storagetypes.ObjectInfo
+		err        error
+	)
+	switch {
+	case len(req.GetObjectId()) != 0:
+		objectInfo, err = g.Consensus().QueryObjectInfoByID(ctx, req.GetObjectId())
+	case len(req.GetBucketName()) != 0 && len(req.GetObjectName()) != 0:
+		objectInfo, err = g.Consensus().QueryObjectInfo(ctx, req.GetBucketName(), req.GetObjectName())
+	default:
+		return &gfspserver.GfSpInspectObjectResponse{
+			Err: gfsperrors.MakeGfSpError(fmt.Errorf("object id or bucket and object name should be set"))}, nil
 	}
-	return &gfspserver.GfSpQueryTasksResponse{TaskInfo: taskInfo}, nil
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to query object info", "request", req.String(), "error", err)
+		return &gfspserver.GfSpInspectObjectResponse{
+			Err: gfsperrors.MakeGfSpError(fmt.Errorf("failed to query object info on chain: %v", err))}, nil
+	}
+	return g.inspectObject(ctx, objectInfo), nil
+}
+
+// inspectObject aggregates the chain, db, piece store and queue state of the object,
+// and gives the diagnosis of the object.
+func (g *GfSpBaseApp) inspectObject(ctx context.Context, objectInfo *storagetypes.ObjectInfo) *gfspserver.GfSpInspectObjectResponse {
+	var (
+		objectID  = objectInfo.Id.Uint64()
+		sealed    = objectInfo.GetObjectStatus() == storagetypes.OBJECT_STATUS_SEALED
+		diagnosis []string
+	)
+	resp := &gfspserver.GfSpInspectObjectResponse{
+		ObjectInfo:   objectInfo,
+		Role:         InspectRoleNone,
+		ReplicateIdx: -1,
+	}
+	bucketInfo, err := g.Consensus().QueryBucketInfo(ctx, objectInfo.GetBucketName())
+	if err != nil {
+		diagnosis = append(diagnosis, fmt.Sprintf("failed to query bucket info on chain: %v", err))
+	} else if strings.EqualFold(bucketInfo.GetPrimarySpAddress(), g.OperateAddress()) {
+		resp.Role = InspectRolePrimary
+	}
+	for i, address := range objectInfo.GetSecondarySpAddresses() {
+		if strings.EqualFold(address, g.OperateAddress()) {
+			resp.ReplicateIdx = int32(i)
+			if resp.Role == InspectRoleNone {
+				resp.Role = InspectRoleSecondary
+			}
+			break
+		}
+	}
+	if resp.Role == InspectRoleNone {
+		diagnosis = append(diagnosis, "the sp is neither the primary nor the secondary sp of the object")
+	}
+
+	state, err := g.GfSpDB().GetUploadState(objectID)
+	if err != nil {
+		resp.UploadState = "unknown"
+		if resp.Role == InspectRolePrimary && !sealed {
+			diagnosis = append(diagnosis, fmt.Sprintf("no upload progress record in db: %v", err))
+		}
+	} else {
+		resp.UploadState = storetypes.StateToDescription(state)
+		if state == storetypes.TaskState_TASK_STATE_UPLOAD_OBJECT_ERROR ||
+			state == storetypes.TaskState_TASK_STATE_REPLICATE_OBJECT_ERROR ||
+			state == storetypes.TaskState_TASK_STATE_SEAL_OBJECT_ERROR {
+			diagnosis = append(diagnosis, fmt.Sprintf("upload progress failed at state %s", resp.UploadState))
+		}
+	}
+
+	params, err := g.Consensus().QueryStorageParamsByTimestamp(ctx, objectInfo.GetCreateAt())
+	if err != nil {
+		diagnosis = append(diagnosis, fmt.Sprintf("failed to query storage params on chain: %v", err))
+		resp.Diagnosis = diagnosis
+		return resp
+	}
+	maxSegmentSize := params.VersionedParams.GetMaxSegmentSize()
+	segmentCount := g.PieceOp().SegmentPieceCount(objectInfo.GetPayloadSize(), maxSegmentSize)
+
+	integrity, err := g.GfSpDB().GetObjectIntegrity(objectID)
+	if err == nil {
+		resp.HasIntegrity = true
+		resp.IntegrityChecksum = integrity.IntegrityChecksum
+		resp.PieceChecksumCount = int32(len(integrity.PieceChecksumList))
+		if uint32(len(integrity.PieceChecksumList)) != segmentCount {
+			diagnosis = append(diagnosis, fmt.Sprintf("integrity meta has %d piece checksums, but expect %d",
+				len(integrity.PieceChecksumList), segmentCount))
+		}
+	} else if sealed && resp.Role != InspectRoleNone {
+		diagnosis = append(diagnosis, "object is sealed but integrity meta is missing, the challenge will fail")
+	}
+
+	var missing int
+	for segIdx := uint32(0); segIdx < segmentCount; segIdx++ {
+		var piece *gfspserver.GfSpInspectPiece
+		switch {
+		case resp.Role == InspectRolePrimary:
+			piece = &gfspserver.GfSpInspectPiece{
+				PieceKey:     g.PieceOp().SegmentPieceKey(objectID, segIdx),
+				ExpectedSize: g.PieceOp().SegmentPieceSize(objectInfo.GetPayloadSize(), segIdx, maxSegmentSize),
+			}
+		case resp.Role == InspectRoleSecondary &&
+			objectInfo.GetRedundancyType() == storagetypes.REDUNDANCY_EC_TYPE:
+			piece = &gfspserver.GfSpInspectPiece{
+				PieceKey: g.PieceOp().ECPieceKey(objectID, segIdx, uint32(resp.ReplicateIdx)),
+				ExpectedSize: g.PieceOp().ECPieceSize(objectInfo.GetPayloadSize(), segIdx, maxSegmentSize,
+					params.VersionedParams.GetRedundantDataChunkNum()),
+			}
+		case resp.Role == InspectRoleSecondary:
+			piece = &gfspserver.GfSpInspectPiece{
+				PieceKey:     g.PieceOp().SegmentPieceKey(objectID, segIdx),
+				ExpectedSize: g.PieceOp().SegmentPieceSize(objectInfo.GetPayloadSize(), segIdx, maxSegmentSize),
+			}
+		default:
+			continue
+		}
+		size, headErr := g.PieceStore().HeadPiece(ctx, piece.GetPieceKey())
+		if headErr != nil {
+			missing++
+			piece.ActualSize = -1
+			piece.Error = headErr.Error()
+		} else {
+			piece.ActualSize = size
+			if size != piece.GetExpectedSize() {
+				diagnosis = append(diagnosis, fmt.Sprintf("piece %s size mismatch, expect %d, actual %d",
+					piece.GetPieceKey(), piece.GetExpectedSize(), size))
+			}
+		}
+		resp.Pieces = append(resp.Pieces, piece)
+	}
+	if missing != 0 && sealed {
+		diagnosis = append(diagnosis, fmt.Sprintf("object is sealed but %d of %d pieces are missing in piece store",
+			missing, len(resp.GetPieces())))
+	}
+
+	resp.TaskInfo = g.queryTasks(ctx, coretask.TKey(gfsptask.CombineKey(objectInfo.GetBucketName(),
+		objectInfo.GetObjectName(), objectInfo.Id.String())))
+	if !sealed && len(resp.GetTaskInfo()) == 0 && resp.Role == InspectRolePrimary {
+		diagnosis = append(diagnosis, "object is not sealed and no task is running, the upload may be lost")
+	}
+	if len(diagnosis) == 0 {
+		diagnosis = append(diagnosis, "object looks healthy")
+	}
+	resp.Diagnosis = diagnosis
+	return resp
+}
+
+func (g *GfSpBaseApp) GfSpQueryPieceUsage(ctx context.Context, req *gfspserver.GfSpQueryPieceUsageRequest) (
+	*gfspserver.GfSpQueryPieceUsageResponse, error) {
+	var (
+		stats []*spdb.PieceUsageStat
+		err   error
+	)
+	if req.GetByPaymentAccount() {
+		stats, err = g.GfSpDB().GetPieceUsageStatsByPaymentAccount(req.GetKey())
+	} else {
+		stats, err = g.GfSpDB().GetPieceUsageStatsByBucket(req.GetKey())
+	}
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to query piece usage stats", "error", err)
+		return &gfspserver.GfSpQueryPieceUsageResponse{Err: gfsperrors.MakeGfSpError(err)}, nil
+	}
+	resp := &gfspserver.GfSpQueryPieceUsageResponse{}
+	for _, stat := range stats {
+		resp.Stats = append(resp.Stats, &gfspserver.GfSpPieceUsageStat{
+			Key:           stat.Key,
+			ObjectCount:   stat.ObjectCount,
+			PrimarySize:   stat.PrimarySize,
+			SecondarySize: stat.SecondarySize,
+		})
+	}
+	total, available, err := g.PieceStore().Capacity(ctx)
+	if err != nil {
+		resp.CapacityError = err.Error()
+	} else {
+		resp.TotalCapacity = total
+		resp.AvailableCapacity = available
+	}
+	return resp, nil
 }
@@ -0,0 +1,261 @@
+package gfspapp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/greenfield-storage-provider/base/gfsppieceop"
+	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfspserver"
+	"github.com/bnb-chain/greenfield-storage-provider/core/consensus"
+	coremodule "github.com/bnb-chain/greenfield-storage-provider/core/module"
+	"github.com/bnb-chain/greenfield-storage-provider/core/piecestore"
+	"github.com/bnb-chain/greenfield-storage-provider/core/spdb"
+	coretask "github.com/bnb-chain/greenfield-storage-provider/core/task"
+	storetypes "github.com/bnb-chain/greenfield-storage-provider/store/types"
+)
+
+const (
+	testInspectSP          = "sp"
+	testInspectBucket      = "bucket"
+	testInspectObject      = "object"
+	testInspectObjectID    = uint64(1)
+	testInspectSegmentSize = uint64(10)
+	testInspectPayloadSize = uint64(25)
+)
+
+// mockInspectDB returns the upload state and the integrity meta of the object.
+type mockInspectDB struct {
+	spdb.SPDB
+	state     *storetypes.TaskState
+	integrity *spdb.IntegrityMeta
+}
+
+func (db *mockInspectDB) GetUploadState(uint64) (storetypes.TaskState, error) {
+	if db.state == nil {
+		return 0, errors.New("record not found")
+	}
+	return *db.state, nil
+}
+
+func (db *mockInspectDB) GetObjectIntegrity(uint64) (*spdb.IntegrityMeta, error) {
+	if db.integrity == nil {
+		return nil, errors.New("record not found")
+	}
+	return db.integrity, nil
+}
+
+// mockInspectPieceStore returns the size of the stored pieces.
+type mockInspectPieceStore struct {
+	piecestore.PieceStore
+	sizes map[string]int64
+}
+
+func (p *mockInspectPieceStore) HeadPiece(_ context.Context, key string) (int64, error) {
+	size, ok := p.sizes[key]
+	if !ok {
+		return 0, errors.New("piece not found")
+	}
+	return size, nil
+}
+
+// mockInspectManager returns the running tasks of the manager.
+type mockInspectManager struct {
+	coremodule.NullModular
+	tasks []coretask.Task
+}
+
+func (m *mockInspectManager) QueryTasks(context.Context, coretask.TKey) ([]coretask.Task, error) {
+	return m.tasks, nil
+}
+
+type mockInspectTask struct {
+	coretask.Task
+	info string
+}
+
+func (t *mockInspectTask) Info() string { return t.info }
+
+func setupInspectTest(t *testing.T, primary string, secondaries []string, status storagetypes.ObjectStatus,
+	redundancy storagetypes.RedundancyType) (*GfSpBaseApp, *storagetypes.ObjectInfo, *mockInspectDB,
+	*mockInspectPieceStore, *mockInspectManager) {
+	t.Helper()
+	chain := consensus.NewMemoryConsensus()
+	params := storagetypes.DefaultParams()
+	params.VersionedParams.MaxSegmentSize = testInspectSegmentSize
+	chain.SetStorageParams(&params)
+	chain.PutBucket(&storagetypes.BucketInfo{BucketName: testInspectBucket, PrimarySpAddress: primary})
+	objectInfo := &storagetypes.ObjectInfo{
+		Id:                   sdkmath.NewUint(testInspectObjectID),
+		BucketName:           testInspectBucket,
+		ObjectName:           testInspectObject,
+		PayloadSize:          testInspectPayloadSize,
+		ObjectStatus:         status,
+		RedundancyType:       redundancy,
+		SecondarySpAddresses: secondaries,
+	}
+	chain.PutObject(objectInfo)
+	db := &mockInspectDB{}
+	pieceStore := &mockInspectPieceStore{sizes: make(map[string]int64)}
+	manager := &mockInspectManager{}
+	app := &GfSpBaseApp{
+		operateAddress: testInspectSP,
+		chain:          chain,
+		gfSpDB:         db,
+		pieceStore:     pieceStore,
+		pieceOp:        &gfsppieceop.GfSpPieceOp{},
+		approver:       &coremodule.NullModular{},
+		downloader:     &coremodule.NilModular{},
+		manager:        manager,
+		p2p:            &coremodule.NilModular{},
+		receiver:       &coremodule.NullReceiveModular{},
+		uploader:       &coremodule.NullModular{},
+	}
+	return app, objectInfo, db, pieceStore, manager
+}
+
+// storeSegmentPieces stores the segment pieces of the object with the expected sizes.
+func storeSegmentPieces(pieceStore *mockInspectPieceStore) []string {
+	op := &gfsppieceop.GfSpPieceOp{}
+	count := op.SegmentPieceCount(testInspectPayloadSize, testInspectSegmentSize)
+	keys := make([]string, 0, count)
+	for segIdx := uint32(0); segIdx < count; segIdx++ {
+		key := op.SegmentPieceKey(testInspectObjectID, segIdx)
+		pieceStore.sizes[key] = op.SegmentPieceSize(testInspectPayloadSize, segIdx, testInspectSegmentSize)
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestGfSpBaseApp_InspectObject(t *testing.T) {
+	sealDone := storetypes.TaskState_TASK_STATE_SEAL_OBJECT_DONE
+	replicateError := storetypes.TaskState_TASK_STATE_REPLICATE_OBJECT_ERROR
+
+	t.Run("healthy primary", func(t *testing.T) {
+		app, objectInfo, db, pieceStore, _ := setupInspectTest(t, testInspectSP, []string{"sp1", "sp2"},
+			storagetypes.OBJECT_STATUS_SEALED, storagetypes.REDUNDANCY_REPLICA_TYPE)
+		db.state = &sealDone
+		db.integrity = &spdb.IntegrityMeta{IntegrityChecksum: []byte("checksum"),
+			PieceChecksumList: [][]byte{{1}, {2}, {3}}}
+		keys := storeSegmentPieces(pieceStore)
+
+		resp := app.inspectObject(context.Background(), objectInfo)
+		assert.Equal(t, InspectRolePrimary, resp.GetRole())
+		assert.Equal(t, int32(-1), resp.GetReplicateIdx())
+		assert.Equal(t, storetypes.StateToDescription(sealDone), resp.GetUploadState())
+		assert.True(t, resp.GetHasIntegrity())
+		assert.Equal(t, []byte("checksum"), resp.GetIntegrityChecksum())
+		assert.Equal(t, int32(3), resp.GetPieceChecksumCount())
+		assert.Len(t, resp.GetPieces(), len(keys))
+		for i, piece := range resp.GetPieces() {
+			assert.Equal(t, keys[i], piece.GetPieceKey())
+			assert.Equal(t, piece.GetExpectedSize(), piece.GetActualSize())
+			assert.Empty(t, piece.GetError())
+		}
+		assert.Equal(t, []string{"object looks healthy"}, resp.GetDiagnosis())
+	})
+
+	t.Run("sealed primary with broken pieces", func(t *testing.T) {
+		app, objectInfo, db, pieceStore, _ := setupInspectTest(t, testInspectSP, nil,
+			storagetypes.OBJECT_STATUS_SEALED, storagetypes.REDUNDANCY_REPLICA_TYPE)
+		db.state = &sealDone
+		keys := storeSegmentPieces(pieceStore)
+		delete(pieceStore.sizes, keys[0])
+		pieceStore.sizes[keys[2]] = 1
+
+		resp := app.inspectObject(context.Background(), objectInfo)
+		assert.False(t, resp.GetHasIntegrity())
+		assert.Equal(t, int64(-1), resp.GetPieces()[0].GetActualSize())
+		assert.Equal(t, "piece not found", resp.GetPieces()[0].GetError())
+		assert.Equal(t, []string{
+			"object is sealed but integrity meta is missing, the challenge will fail",
+			"piece 1_s2 size mismatch, expect 5, actual 1",
+			"object is sealed but 1 of 3 pieces are missing in piece store",
+		}, resp.GetDiagnosis())
+	})
+
+	t.Run("secondary ec", func(t *testing.T) {
+		app, objectInfo, db, pieceStore, _ := setupInspectTest(t, "sp0", []string{"sp1", testInspectSP},
+			storagetypes.OBJECT_STATUS_SEALED, storagetypes.REDUNDANCY_EC_TYPE)
+		db.integrity = &spdb.IntegrityMeta{PieceChecksumList: [][]byte{{1}, {2}, {3}}}
+		op := &gfsppieceop.GfSpPieceOp{}
+		params := storagetypes.DefaultParams()
+		dataChunkNum := params.VersionedParams.GetRedundantDataChunkNum()
+		for segIdx := uint32(0); segIdx < 3; segIdx++ {
+			pieceStore.sizes[op.ECPieceKey(testInspectObjectID, segIdx, 1)] = op.ECPieceSize(
+				testInspectPayloadSize, segIdx, testInspectSegmentSize, dataChunkNum)
+		}
+
+		resp := app.inspectObject(context.Background(), objectInfo)
+		assert.Equal(t, InspectRoleSecondary, resp.GetRole())
+		assert.Equal(t, int32(1), resp.GetReplicateIdx())
+		assert.Equal(t, "unknown", resp.GetUploadState())
+		assert.Len(t, resp.GetPieces(), 3)
+		for segIdx, piece := range resp.GetPieces() {
+			assert.Equal(t, op.ECPieceKey(testInspectObjectID, uint32(segIdx), 1), piece.GetPieceKey())
+			assert.Equal(t, piece.GetExpectedSize(), piece.GetActualSize())
+		}
+		assert.Equal(t, []string{"object looks healthy"}, resp.GetDiagnosis())
+	})
+
+	t.Run("unrelated sp", func(t *testing.T) {
+		app, objectInfo, _, _, _ := setupInspectTest(t, "sp0", []string{"sp1"},
+			storagetypes.OBJECT_STATUS_SEALED, storagetypes.REDUNDANCY_EC_TYPE)
+
+		resp := app.inspectObject(context.Background(), objectInfo)
+		assert.Equal(t, InspectRoleNone, resp.GetRole())
+		assert.Empty(t, resp.GetPieces())
+		assert.Equal(t, []string{"the sp is neither the primary nor the secondary sp of the object"},
+			resp.GetDiagnosis())
+	})
+
+	t.Run("unsealed primary", func(t *testing.T) {
+		app, objectInfo, db, _, manager := setupInspectTest(t, testInspectSP, nil,
+			storagetypes.OBJECT_STATUS_CREATED, storagetypes.REDUNDANCY_EC_TYPE)
+		db.state = &replicateError
+
+		resp := app.inspectObject(context.Background(), objectInfo)
+		assert.Empty(t, resp.GetTaskInfo())
+		assert.Equal(t, []string{
+			"upload progress failed at state " + storetypes.StateToDescription(replicateError),
+			"object is not sealed and no task is running, the upload may be lost",
+		}, resp.GetDiagnosis())
+
+		// the running task is listed and the upload is not lost
+		manager.tasks = []coretask.Task{&mockInspectTask{info: "replicate task"}}
+		db.state = nil
+		resp = app.inspectObject(context.Background(), objectInfo)
+		assert.Equal(t, []string{"replicate task"}, resp.GetTaskInfo())
+		assert.Len(t, resp.GetDiagnosis(), 1)
+		assert.Contains(t, resp.GetDiagnosis()[0], "no upload progress record in db")
+	})
+}
+
+func TestGfSpBaseApp_GfSpInspectObject(t *testing.T) {
+	app, _, _, _, _ := setupInspectTest(t, testInspectSP, nil,
+		storagetypes.OBJECT_STATUS_SEALED, storagetypes.REDUNDANCY_EC_TYPE)
+	ctx := context.Background()
+
+	resp, err := app.GfSpInspectObject(ctx, &gfspserver.GfSpInspectObjectRequest{})
+	assert.NoError(t, err)
+	assert.NotNil(t, resp.GetErr())
+
+	resp, err = app.GfSpInspectObject(ctx, &gfspserver.GfSpInspectObjectRequest{ObjectId: "2"})
+	assert.NoError(t, err)
+	assert.NotNil(t, resp.GetErr())
+
+	resp, err = app.GfSpInspectObject(ctx, &gfspserver.GfSpInspectObjectRequest{ObjectId: "1"})
+	assert.NoError(t, err)
+	assert.Nil(t, resp.GetErr())
+	assert.Equal(t, testInspectObject, resp.GetObjectInfo().GetObjectName())
+
+	resp, err = app.GfSpInspectObject(ctx, &gfspserver.GfSpInspectObjectRequest{
+		BucketName: testInspectBucket, ObjectName: testInspectObject})
+	assert.NoError(t, err)
+	assert.Nil(t, resp.GetErr())
+	assert.Equal(t, testInspectObjectID, resp.GetObjectInfo().Id.Uint64())
+}
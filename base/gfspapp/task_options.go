@@ -43,6 +43,10 @@ const (
 	MinGCMetaTime int64 = 300
 	// MaxGCMetaTime defines the max timeout to gc meta.
 	MaxGCMetaTime int64 = 600
+	// MinTierObjectTime defines the min timeout to migrate object pieces between tiers.
+	MinTierObjectTime int64 = 30
+	// MaxTierObjectTime defines the max timeout to migrate object pieces between tiers.
+	MaxTierObjectTime int64 = 1800
 
 	// NotUseRetry defines the default task max retry.
 	NotUseRetry int64 = 0
@@ -62,6 +66,10 @@ const (
 	MinGCObjectRetry = 3
 	// MaxGCObjectRetry defines the min retry number to gc object.
 	MaxGCObjectRetry = 5
+	// MinTierObjectRetry defines the min retry number to migrate object pieces between tiers.
+	MinTierObjectRetry = 3
+	// MaxTierObjectRetry defines the max retry number to migrate object pieces between tiers.
+	MaxTierObjectRetry = 5
 )
 
 // TaskTimeout returns the task timeout by task type and some task need payload size
@@ -151,6 +159,15 @@ func (g *GfSpBaseApp) TaskTimeout(task coretask.Task, size uint64) int64 {
 			return MaxGCMetaTime
 		}
 		return g.gcMetaTimeout
+	case coretask.TypeTaskTierObject:
+		timeout := int64(size) / (g.tierObjectSpeed + 1) / (MinSpeed)
+		if timeout < MinTierObjectTime {
+			return MinTierObjectTime
+		}
+		if timeout > MaxTierObjectTime {
+			return MaxTierObjectTime
+		}
+		return timeout
 	}
 	return NotUseTimeout
 }
@@ -218,6 +235,14 @@ func (g *GfSpBaseApp) TaskMaxRetry(task coretask.Task) int64 {
 			return MaxGCObjectRetry
 		}
 		return g.gcMetaRetry
+	case coretask.TypeTaskTierObject:
+		if g.tierObjectRetry < MinTierObjectRetry {
+			return MinTierObjectRetry
+		}
+		if g.tierObjectRetry > MaxTierObjectRetry {
+			return MaxTierObjectRetry
+		}
+		return g.tierObjectRetry
 	}
 	return 0
 }
@@ -250,6 +275,8 @@ func (g *GfSpBaseApp) TaskPriority(task coretask.Task) coretask.TPriority {
 		return coretask.UnSchedulingPriority
 	case coretask.TypeTaskGCMeta:
 		return coretask.UnSchedulingPriority
+	case coretask.TypeTaskTierObject:
+		return coretask.DefaultSmallerPriority
 	}
 	return coretask.UnKnownTaskPriority
 }
@@ -81,6 +81,7 @@ func NewGfSpClient(
 
 func (s *GfSpClient) Connection(ctx context.Context, address string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
 	options := append(DefaultClientOptions(), opts...)
+	options = append(options, utilgrpc.GetDefaultClientTraceInterceptor()...)
 	return grpc.DialContext(ctx, address, options...)
 }
 
@@ -2,6 +2,8 @@ package gfspclient
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"google.golang.org/grpc"
 
@@ -11,26 +13,78 @@ import (
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
 )
 
+// GetObject returns the reader of the object data streamed from the downloader, the errors
+// occurred before the data is streamed, e.g. the quota is exceeded, are returned directly.
+// The caller must close the reader.
 func (s *GfSpClient) GetObject(ctx context.Context, downloadObjectTask coretask.DownloadObjectTask, opts ...grpc.DialOption) (
-	[]byte, error) {
+	io.ReadCloser, error) {
 	conn, connErr := s.Connection(ctx, s.downloaderEndpoint, opts...)
 	if connErr != nil {
 		log.CtxErrorw(ctx, "client failed to connect downloader", "error", connErr)
 		return nil, ErrRpcUnknown
 	}
-	defer conn.Close()
+	ctx, cancel := context.WithCancel(ctx)
 	req := &gfspserver.GfSpDownloadObjectRequest{
 		DownloadObjectTask: downloadObjectTask.(*gfsptask.GfSpDownloadObjectTask),
 	}
-	resp, err := gfspserver.NewGfSpDownloadServiceClient(conn).GfSpDownloadObject(ctx, req)
+	stream, err := gfspserver.NewGfSpDownloadServiceClient(conn).GfSpDownloadObject(ctx, req)
 	if err != nil {
+		cancel()
+		conn.Close()
 		log.CtxErrorw(ctx, "client failed to download object", "error", err)
 		return nil, ErrRpcUnknown
 	}
-	if resp.GetErr() != nil {
-		return nil, resp.GetErr()
+	r := &downloadObjectReader{ctx: ctx, stream: stream, closeFn: func() {
+		cancel()
+		conn.Close()
+	}}
+	if err = r.recv(); err != nil && err != io.EOF {
+		r.Close()
+		return nil, err
 	}
-	return resp.GetData(), nil
+	return r, nil
+}
+
+// downloadObjectReader reads the object data from the download object stream.
+type downloadObjectReader struct {
+	ctx     context.Context
+	stream  gfspserver.GfSpDownloadService_GfSpDownloadObjectClient
+	data    []byte
+	err     error
+	closeFn func()
+}
+
+func (r *downloadObjectReader) recv() error {
+	resp, err := r.stream.Recv()
+	switch {
+	case err == io.EOF:
+		r.err = io.EOF
+	case err != nil:
+		log.CtxErrorw(r.ctx, "client failed to receive object data", "error", err)
+		r.err = ErrRpcUnknown
+	case resp.GetErr() != nil:
+		r.err = resp.GetErr()
+	default:
+		r.data = resp.GetData()
+	}
+	return r.err
+}
+
+func (r *downloadObjectReader) Read(p []byte) (int, error) {
+	for len(r.data) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.recv()
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func (r *downloadObjectReader) Close() error {
+	r.closeFn()
+	return nil
 }
 
 func (s *GfSpClient) GetPiece(ctx context.Context, downloadPieceTask coretask.DownloadPieceTask, opts ...grpc.DialOption) (
@@ -76,3 +130,29 @@ func (s *GfSpClient) GetChallengeInfo(ctx context.Context, challengePieceTask co
 	}
 	return resp.GetIntegrityHash(), resp.GetChecksums(), resp.GetData(), nil
 }
+
+// PresignObject returns the short-lived url to read the object from the backend storage
+// directly, the url is empty if the object should be downloaded by GetObject.
+func (s *GfSpClient) PresignObject(ctx context.Context, downloadObjectTask coretask.DownloadObjectTask,
+	expire time.Duration, contentDisposition string, opts ...grpc.DialOption) (string, error) {
+	conn, connErr := s.Connection(ctx, s.downloaderEndpoint, opts...)
+	if connErr != nil {
+		log.CtxErrorw(ctx, "client failed to connect downloader", "error", connErr)
+		return "", ErrRpcUnknown
+	}
+	defer conn.Close()
+	req := &gfspserver.GfSpPresignObjectRequest{
+		DownloadObjectTask: downloadObjectTask.(*gfsptask.GfSpDownloadObjectTask),
+		ExpireSeconds:      int64(expire / time.Second),
+		ContentDisposition: contentDisposition,
+	}
+	resp, err := gfspserver.NewGfSpDownloadServiceClient(conn).GfSpPresignObject(ctx, req)
+	if err != nil {
+		log.CtxErrorw(ctx, "client failed to presign object", "error", err)
+		return "", ErrRpcUnknown
+	}
+	if resp.GetErr() != nil {
+		return "", resp.GetErr()
+	}
+	return resp.GetUrl(), nil
+}
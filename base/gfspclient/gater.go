@@ -8,9 +8,13 @@ import (
 	"fmt"
 	"net/http"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfsptask"
 	coretask "github.com/bnb-chain/greenfield-storage-provider/core/task"
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/tracing"
 )
 
 // spilt server and client const definition avoids circular references
@@ -33,7 +37,10 @@ func (s *GfSpClient) ReplicatePieceToSecondary(
 	endpoint string,
 	approval coretask.ApprovalReplicatePieceTask,
 	receive coretask.ReceivePieceTask,
-	data []byte) error {
+	data []byte) (err error) {
+	ctx, span := tracing.StartSpanWithKind(ctx, "ReplicatePieceToSecondary", trace.SpanKindClient,
+		attribute.String("endpoint", endpoint))
+	defer func() { tracing.EndSpan(span, err) }()
 	req, err := http.NewRequest(http.MethodPut, endpoint+ReplicateObjectPiecePath, bytes.NewReader(data))
 	if err != nil {
 		log.CtxErrorw(ctx, "client failed to connect gateway", "endpoint", endpoint, "error", err)
@@ -54,6 +61,7 @@ func (s *GfSpClient) ReplicatePieceToSecondary(
 	receiveHeader := hex.EncodeToString(receiveMsg)
 	req.Header.Add(GnfdReplicatePieceApprovalHeader, approvalHeader)
 	req.Header.Add(GnfdReceiveMsgHeader, receiveHeader)
+	tracing.InjectHTTPHeader(ctx, req.Header)
 	resp, err := s.HttpClient(ctx).Do(req)
 	if err != nil {
 		return err
@@ -66,7 +74,10 @@ func (s *GfSpClient) ReplicatePieceToSecondary(
 }
 
 func (s *GfSpClient) DoneReplicatePieceToSecondary(ctx context.Context, endpoint string, approval coretask.ApprovalReplicatePieceTask,
-	receive coretask.ReceivePieceTask) ([]byte, []byte, error) {
+	receive coretask.ReceivePieceTask) (integrity []byte, signature []byte, err error) {
+	ctx, span := tracing.StartSpanWithKind(ctx, "DoneReplicatePieceToSecondary", trace.SpanKindClient,
+		attribute.String("endpoint", endpoint))
+	defer func() { tracing.EndSpan(span, err) }()
 	req, err := http.NewRequest(http.MethodPut, endpoint+ReplicateObjectPiecePath, nil)
 	if err != nil {
 		log.CtxErrorw(ctx, "client failed to connect gateway", "endpoint", endpoint, "error", err)
@@ -87,6 +98,7 @@ func (s *GfSpClient) DoneReplicatePieceToSecondary(ctx context.Context, endpoint
 	receiveHeader := hex.EncodeToString(receiveMsg)
 	req.Header.Add(GnfdReplicatePieceApprovalHeader, approvalHeader)
 	req.Header.Add(GnfdReceiveMsgHeader, receiveHeader)
+	tracing.InjectHTTPHeader(ctx, req.Header)
 	resp, err := s.HttpClient(ctx).Do(req)
 	if err != nil {
 		return nil, nil, err
@@ -95,11 +107,11 @@ func (s *GfSpClient) DoneReplicatePieceToSecondary(ctx context.Context, endpoint
 	if resp.StatusCode != http.StatusOK {
 		return nil, nil, fmt.Errorf("failed to replicate piece, StatusCode(%d)", resp.StatusCode)
 	}
-	integrity, err := hex.DecodeString(resp.Header.Get(GnfdIntegrityHashHeader))
+	integrity, err = hex.DecodeString(resp.Header.Get(GnfdIntegrityHashHeader))
 	if err != nil {
 		return nil, nil, err
 	}
-	signature, err := hex.DecodeString(resp.Header.Get(GnfdIntegrityHashSignatureHeader))
+	signature, err = hex.DecodeString(resp.Header.Get(GnfdIntegrityHashSignatureHeader))
 	if err != nil {
 		return nil, nil, err
 	}
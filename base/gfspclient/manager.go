@@ -65,6 +65,8 @@ func (s *GfSpClient) AskTask(ctx context.Context, limit corercmgr.Limit) (coreta
 		return t.GcZombiePieceTask, nil
 	case *gfspserver.GfSpAskTaskResponse_GcMetaTask:
 		return t.GcMetaTask, nil
+	case *gfspserver.GfSpAskTaskResponse_TierObjectTask:
+		return t.TierObjectTask, nil
 	default:
 		return nil, ErrTypeMismatch
 	}
@@ -109,6 +111,10 @@ func (s *GfSpClient) ReportTask(ctx context.Context, report coretask.Task) error
 		req.Request = &gfspserver.GfSpReportTaskRequest_GcMetaTask{
 			GcMetaTask: t,
 		}
+	case *gfsptask.GfSpTierObjectTask:
+		req.Request = &gfspserver.GfSpReportTaskRequest_TierObjectTask{
+			TierObjectTask: t,
+		}
 	case *gfsptask.GfSpDownloadObjectTask:
 		req.Request = &gfspserver.GfSpReportTaskRequest_DownloadObjectTask{
 			DownloadObjectTask: t,
@@ -143,9 +143,10 @@ func (s *GfSpClient) GetBucketByBucketID(ctx context.Context, bucketId int64, in
 	return resp, nil
 }
 
-// ListExpiredBucketsBySp list buckets that are expired by specific sp
+// ListExpiredBucketsBySp list buckets that are expired by specific sp after the cursor of the create time
+// and the bucket id
 func (s *GfSpClient) ListExpiredBucketsBySp(ctx context.Context, createAt int64, primarySpAddress string,
-	limit int64, opts ...grpc.DialOption) ([]*types.Bucket, error) {
+	limit int64, startAfterCreateAt int64, startAfterBucketID int64, opts ...grpc.DialOption) ([]*types.Bucket, error) {
 	conn, err := s.Connection(ctx, s.metadataEndpoint, opts...)
 	if err != nil {
 		return nil, err
@@ -153,9 +154,11 @@ func (s *GfSpClient) ListExpiredBucketsBySp(ctx context.Context, createAt int64,
 	defer conn.Close()
 
 	req := &types.GfSpListExpiredBucketsBySpRequest{
-		CreateAt:         createAt,
-		PrimarySpAddress: primarySpAddress,
-		Limit:            limit,
+		CreateAt:           createAt,
+		PrimarySpAddress:   primarySpAddress,
+		Limit:              limit,
+		StartAfterCreateAt: startAfterCreateAt,
+		StartAfterBucketId: startAfterBucketID,
 	}
 
 	resp, err := types.NewGfSpMetadataServiceClient(conn).GfSpListExpiredBucketsBySp(ctx, req)
@@ -262,6 +265,23 @@ func (s *GfSpClient) VerifyPermission(ctx context.Context, Operator string, buck
 	return &resp.Effect, nil
 }
 
+// GetLatestBlockNumber get the latest block number indexed by the metadata service
+func (s *GfSpClient) GetLatestBlockNumber(ctx context.Context, opts ...grpc.DialOption) (int64, error) {
+	conn, err := s.Connection(ctx, s.metadataEndpoint, opts...)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	resp, err := types.NewGfSpMetadataServiceClient(conn).GfSpGetLatestBlockNumber(ctx,
+		&types.GfSpGetLatestBlockNumberRequest{})
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to send get latest block number rpc", "error", err)
+		return 0, err
+	}
+	return resp.GetBlockNumber(), nil
+}
+
 // GetBucketMeta get bucket info along with its related info such as payment
 func (s *GfSpClient) GetBucketMeta(ctx context.Context, bucketName string, includePrivate bool,
 	opts ...grpc.DialOption) (*types.Bucket, *payment_types.StreamRecord, error) {
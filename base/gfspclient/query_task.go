@@ -27,3 +27,75 @@ func (s *GfSpClient) QueryTasks(ctx context.Context, endpoint string, subKey str
 	}
 	return resp.GetTaskInfo(), nil
 }
+
+func (s *GfSpClient) QueryTaskEvents(ctx context.Context, endpoint string, objectID string,
+	startTimestamp int64, endTimestamp int64, limit int32) ([]*gfspserver.GfSpTaskEvent, error) {
+	conn, connErr := s.Connection(ctx, endpoint)
+	if connErr != nil {
+		log.CtxErrorw(ctx, "client failed to connect gfsp server", "error", connErr)
+		return nil, ErrRpcUnknown
+	}
+	defer conn.Close()
+	req := &gfspserver.GfSpQueryTaskEventsRequest{
+		ObjectId:       objectID,
+		StartTimestamp: startTimestamp,
+		EndTimestamp:   endTimestamp,
+		Limit:          limit,
+	}
+	resp, err := gfspserver.NewGfSpManageServiceClient(conn).GfSpQueryTaskEvents(ctx, req)
+	if err != nil {
+		log.CtxErrorw(ctx, "client failed to query task events", "error", err)
+		return nil, ErrRpcUnknown
+	}
+	if resp.GetErr() != nil {
+		return nil, resp.GetErr()
+	}
+	return resp.GetEvents(), nil
+}
+
+func (s *GfSpClient) InspectObject(ctx context.Context, endpoint string, bucket, object, objectID string) (
+	*gfspserver.GfSpInspectObjectResponse, error) {
+	conn, connErr := s.Connection(ctx, endpoint)
+	if connErr != nil {
+		log.CtxErrorw(ctx, "client failed to connect gfsp server", "error", connErr)
+		return nil, ErrRpcUnknown
+	}
+	defer conn.Close()
+	req := &gfspserver.GfSpInspectObjectRequest{
+		BucketName: bucket,
+		ObjectName: object,
+		ObjectId:   objectID,
+	}
+	resp, err := gfspserver.NewGfSpQueryTaskServiceClient(conn).GfSpInspectObject(ctx, req)
+	if err != nil {
+		log.CtxErrorw(ctx, "client failed to inspect object", "error", err)
+		return nil, ErrRpcUnknown
+	}
+	if resp.GetErr() != nil {
+		return nil, resp.GetErr()
+	}
+	return resp, nil
+}
+
+func (s *GfSpClient) QueryPieceUsage(ctx context.Context, endpoint string, byPaymentAccount bool, key string) (
+	*gfspserver.GfSpQueryPieceUsageResponse, error) {
+	conn, connErr := s.Connection(ctx, endpoint)
+	if connErr != nil {
+		log.CtxErrorw(ctx, "client failed to connect gfsp server", "error", connErr)
+		return nil, ErrRpcUnknown
+	}
+	defer conn.Close()
+	req := &gfspserver.GfSpQueryPieceUsageRequest{
+		ByPaymentAccount: byPaymentAccount,
+		Key:              key,
+	}
+	resp, err := gfspserver.NewGfSpQueryTaskServiceClient(conn).GfSpQueryPieceUsage(ctx, req)
+	if err != nil {
+		log.CtxErrorw(ctx, "client failed to query piece usage", "error", err)
+		return nil, ErrRpcUnknown
+	}
+	if resp.GetErr() != nil {
+		return nil, resp.GetErr()
+	}
+	return resp, nil
+}
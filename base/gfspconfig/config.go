@@ -45,6 +45,7 @@ type GfSpConfig struct {
 	SpAccount      SpAccountConfig
 	Endpoint       EndpointConfig
 	Approval       ApprovalConfig
+	Signer         SignerConfig
 	Bucket         BucketConfig
 	Gateway        GatewayConfig
 	Executor       ExecutorConfig
@@ -58,6 +59,7 @@ type GfSpConfig struct {
 	BlockSyncer    BlockSyncerConfig
 	APIRateLimiter localhttp.RateLimiterConfig
 	Manager        ManagerConfig
+	Authorizer     AuthorizerConfig
 }
 
 // Apply sets the customized implement to the GfSp configuration, it will be called
@@ -87,6 +89,33 @@ type ChainConfig struct {
 	ChainID      string
 	ChainAddress []string
 	GasLimit     uint64
+	Cache        ChainCacheConfig
+	Failover     ChainFailoverConfig
+}
+
+// ChainCacheConfig defines the cache of the chain queries, the ttl are in seconds and the zero
+// values use the defaults. The cached bucket and object info are also dropped once a new block arrives.
+type ChainCacheConfig struct {
+	Enable              bool
+	BucketTTL           int64
+	ObjectTTL           int64
+	PaymentTTL          int64
+	ParamsTTL           int64
+	NegativeTTL         int64
+	HeightInterval      int64
+	MaxEntries          int
+	MaxHistoricalParams int
+}
+
+// ChainFailoverConfig defines the failover of the calls across the chain addresses, the circuit of
+// the address opens after the consecutive failures or if it lags behind the highest address. The
+// durations are in seconds and the zero values use the defaults.
+type ChainFailoverConfig struct {
+	MaxAttempts         int
+	FailureThreshold    int
+	OpenTimeout         int64
+	MaxHeightLag        uint64
+	HealthCheckInterval int64
 }
 
 type SpAccountConfig struct {
@@ -96,6 +125,28 @@ type SpAccountConfig struct {
 	SealPrivateKey     string
 	ApprovalPrivateKey string
 	GcPrivateKey       string
+	// KeyProvider is the source of the private keys of the signer, it supports plaintext, keystore
+	// and remote, the default plaintext uses the hex private keys above.
+	KeyProvider  string
+	Keystore     KeystoreConfig
+	RemoteSigner RemoteSignerConfig
+}
+
+type KeystoreConfig struct {
+	// Dir holds the encrypted keystore files of the accounts, named as operator.json, funding.json,
+	// seal.json, approval.json and gc.json.
+	Dir string
+	// PassphraseFile holds the passphrase of the keystore files, the passphrase is read from the
+	// env variable SIGNER_KEYSTORE_PASSPHRASE if it is empty.
+	PassphraseFile string
+}
+
+type RemoteSignerConfig struct {
+	// Endpoint is the http address of the remote signing service, the auth token is read from the
+	// env variable SIGNER_REMOTE_AUTH_TOKEN.
+	Endpoint string
+	// TimeoutSeconds is the timeout of the requests to the remote signing service.
+	TimeoutSeconds int64
 }
 
 type EndpointConfig struct {
@@ -110,6 +161,28 @@ type EndpointConfig struct {
 	AuthorizerEndpoint string
 }
 
+type SignerConfig struct {
+	// SealBatchSize is the max number of the seal messages broadcast in one tx, 1 disables batching.
+	SealBatchSize int
+	// SealBatchWindow is the max milliseconds that a seal message waits for the batch.
+	SealBatchWindow int64
+	// ApprovalKeyring is the file of the approval keys managed by the approval.key commands, the active
+	// key signs the approvals instead of the approval private key of the key provider if it is set.
+	// The keyring only records the key names, the keys are held by the key provider.
+	ApprovalKeyring string
+	// ApprovalKeyGracePeriod is the seconds that the signatures of the retired approval key are accepted.
+	ApprovalKeyGracePeriod int64
+	// GasAdjustment multiplies the simulated gas used as the gas limit of the tx.
+	GasAdjustment float64
+	// MaxGasLimit caps the estimated gas limit of the tx.
+	MaxGasLimit uint64
+	// GasPrice is the gas price used with the static Chain.GasLimit if the simulation is unavailable.
+	GasPrice string
+	// GcTxRateLimit is the max txs per second of the gc account, it limits the rate of discontinuing
+	// buckets, a negative value disables the limit.
+	GcTxRateLimit float64
+}
+
 type ApprovalConfig struct {
 	BucketApprovalTimeoutHeight uint64
 	ObjectApprovalTimeoutHeight uint64
@@ -124,8 +197,10 @@ type BucketConfig struct {
 }
 
 type GatewayConfig struct {
-	Domain      string
-	HttpAddress string
+	Domain               string
+	HttpAddress          string
+	PresignRedirect      bool
+	PresignExpireSeconds int64
 }
 
 type ExecutorConfig struct {
@@ -157,6 +232,7 @@ type ParallelConfig struct {
 	GlobalGCObjectParallel             int
 	GlobalGCZombieParallel             int
 	GlobalGCMetaParallel               int
+	GlobalTierObjectParallel           int
 	GlobalDownloadObjectTaskCacheSize  int
 	GlobalChallengePieceTaskCacheSize  int
 	GlobalBatchGcObjectTimeInterval    int
@@ -174,6 +250,13 @@ type ParallelConfig struct {
 	DiscontinueBucketEnabled       bool
 	DiscontinueBucketTimeInterval  int
 	DiscontinueBucketKeepAliveDays int
+	// DiscontinueBucketRules are the discontinue rules evaluated in order: keep_alive, frozen_payment,
+	// policy_violation and admin_request, the last two match the buckets flagged by the operator.
+	DiscontinueBucketRules []string
+	// DiscontinueBucketDryRun reports the buckets to discontinue without sending the txs.
+	DiscontinueBucketDryRun bool
+	// DiscontinueBucketNotifyURLs are the webhooks that receive the report of discontinuing buckets.
+	DiscontinueBucketNotifyURLs []string
 }
 
 type TaskConfig struct {
@@ -181,6 +264,7 @@ type TaskConfig struct {
 	DownloadTaskSpeed       int64
 	ReplicateTaskSpeed      int64
 	ReceiveTaskSpeed        int64
+	TierObjectTaskSpeed     int64
 	SealObjectTaskTimeout   int64
 	GcObjectTaskTimeout     int64
 	GcZombieTaskTimeout     int64
@@ -191,6 +275,7 @@ type TaskConfig struct {
 	GcObjectTaskRetry       int64
 	GcZombieTaskRetry       int64
 	GcMetaTaskRetry         int64
+	TierObjectTaskRetry     int64
 }
 
 type MonitorConfig struct {
@@ -198,6 +283,14 @@ type MonitorConfig struct {
 	DisablePProf       bool
 	MetricsHttpAddress string
 	PProfHttpAddress   string
+	EnableTracing      bool
+	TracingExporter    string
+	TracingEndpoint    string
+	TracingFilePath    string
+	// TracingSampleRatio is the ratio of the sampled root spans, 0.0 disables the sampling and
+	// the default is used if it is unset. The value is written as a float, the toml decoder
+	// fails on the integer 0.
+	TracingSampleRatio *float64
 }
 
 type RcmgrConfig struct {
@@ -224,6 +317,32 @@ type MetadataConfig struct {
 	BsDBSwitchCheckIntervalSec int64
 }
 
+type AuthorizerConfig struct {
+	// MetadataPermissionEnabled enables verifying the object permissions by the metadata service if
+	// its index is caught up with the chain, the chain is queried otherwise.
+	MetadataPermissionEnabled bool
+	// MetadataMaxBlockLag is the max number of blocks that the metadata index can lag behind the chain
+	MetadataMaxBlockLag uint64
+	// MetadataLagCheckInterval is the seconds that the checked lag of the metadata index is reused
+	MetadataLagCheckInterval int64
+	// ConsistencyCheckRate is the ratio of the metadata verifications that are compared with the
+	// chain in the background, the disagreements are logged.
+	ConsistencyCheckRate float64
+}
+
 type ManagerConfig struct {
 	EnableLoadTask bool
+	// RejectUnsealEnabled enables rejecting the objects that are stuck in the upload progress
+	RejectUnsealEnabled bool
+	// RejectUnsealInterval is the seconds between two sweeps of the stuck objects
+	RejectUnsealInterval int64
+	// RejectUnsealDeadline is the seconds that the upload progress of an object is not updated
+	// before it is considered stuck
+	RejectUnsealDeadline int64
+	// RejectUnsealBatchSize is the max number of the objects rejected in one sweep
+	RejectUnsealBatchSize int
+	// TaskEventRetentionDays is the days that the task events are kept before they are purged
+	TaskEventRetentionDays int
+	// TaskEventPurgeInterval is the seconds between two purges of the expired task events
+	TaskEventPurgeInterval int64
 }
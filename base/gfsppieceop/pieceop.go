@@ -2,6 +2,8 @@ package gfsppieceop
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/bnb-chain/greenfield-storage-provider/core/piecestore"
 )
@@ -26,6 +28,38 @@ func (p *GfSpPieceOp) ChallengePieceKey(objectID uint64, segmentIdx uint32, repl
 	return p.ECPieceKey(objectID, segmentIdx, uint32(replicateIdx))
 }
 
+// ParsePieceKey parses the object id, segment index and replicate index from the
+// piece key, the replicate index is -1 if the key is a segment piece key.
+func ParsePieceKey(key string) (objectID uint64, segmentIdx uint32, replicateIdx int32, err error) {
+	fields := strings.Split(key, "_")
+	if len(fields) != 2 && len(fields) != 3 {
+		return 0, 0, 0, fmt.Errorf("invalid piece key: %s", key)
+	}
+	if objectID, err = strconv.ParseUint(fields[0], 10, 64); err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid piece key: %s", key)
+	}
+	if !strings.HasPrefix(fields[1], "s") {
+		return 0, 0, 0, fmt.Errorf("invalid piece key: %s", key)
+	}
+	idx, err := strconv.ParseUint(fields[1][1:], 10, 32)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid piece key: %s", key)
+	}
+	segmentIdx = uint32(idx)
+	replicateIdx = -1
+	if len(fields) == 3 {
+		if !strings.HasPrefix(fields[2], "p") {
+			return 0, 0, 0, fmt.Errorf("invalid piece key: %s", key)
+		}
+		idx, err = strconv.ParseUint(fields[2][1:], 10, 31)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("invalid piece key: %s", key)
+		}
+		replicateIdx = int32(idx)
+	}
+	return objectID, segmentIdx, replicateIdx, nil
+}
+
 func (p *GfSpPieceOp) MaxSegmentPieceSize(payloadSize uint64, maxSegmentSize uint64) int64 {
 	if payloadSize > maxSegmentSize {
 		return int64(maxSegmentSize)
@@ -0,0 +1,329 @@
+package gnfd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	paymenttypes "github.com/bnb-chain/greenfield/x/payment/types"
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/bnb-chain/greenfield-storage-provider/core/consensus"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/metrics"
+)
+
+const (
+	// DefaultCacheBucketTTL defines the default ttl of the cached bucket info
+	DefaultCacheBucketTTL = 10 * time.Second
+	// DefaultCacheObjectTTL defines the default ttl of the cached object info
+	DefaultCacheObjectTTL = 10 * time.Second
+	// DefaultCachePaymentTTL defines the default ttl of the cached payment stream record
+	DefaultCachePaymentTTL = 10 * time.Second
+	// DefaultCacheParamsTTL defines the default ttl of the cached latest storage params
+	DefaultCacheParamsTTL = 60 * time.Second
+	// DefaultCacheNegativeTTL defines the default ttl of the cached not found result
+	DefaultCacheNegativeTTL = 2 * time.Second
+	// DefaultCacheHeightInterval defines the default interval of checking the new blocks
+	DefaultCacheHeightInterval = time.Second
+	// DefaultCacheMaxEntries defines the default max number of the entries in the cache
+	DefaultCacheMaxEntries = 100000
+	// DefaultCacheMaxHistoricalParams defines the default max number of the timestamps of the
+	// cached historical storage params
+	DefaultCacheMaxHistoricalParams = 10000
+
+	// historicalParamsDelay defines the age of the timestamp whose storage params are regarded as
+	// immutable, the params update with an earlier timestamp is impossible after the delay.
+	historicalParamsDelay = time.Minute
+)
+
+// CacheConfig defines the ttl of the cached consensus data, the zero values are set to defaults.
+type CacheConfig struct {
+	BucketTTL           time.Duration
+	ObjectTTL           time.Duration
+	PaymentTTL          time.Duration
+	ParamsTTL           time.Duration
+	NegativeTTL         time.Duration
+	HeightInterval      time.Duration
+	MaxEntries          int
+	MaxHistoricalParams int
+}
+
+func (cfg *CacheConfig) setDefaults() {
+	if cfg.BucketTTL == 0 {
+		cfg.BucketTTL = DefaultCacheBucketTTL
+	}
+	if cfg.ObjectTTL == 0 {
+		cfg.ObjectTTL = DefaultCacheObjectTTL
+	}
+	if cfg.PaymentTTL == 0 {
+		cfg.PaymentTTL = DefaultCachePaymentTTL
+	}
+	if cfg.ParamsTTL == 0 {
+		cfg.ParamsTTL = DefaultCacheParamsTTL
+	}
+	if cfg.NegativeTTL == 0 {
+		cfg.NegativeTTL = DefaultCacheNegativeTTL
+	}
+	if cfg.HeightInterval == 0 {
+		cfg.HeightInterval = DefaultCacheHeightInterval
+	}
+	if cfg.MaxEntries == 0 {
+		cfg.MaxEntries = DefaultCacheMaxEntries
+	}
+	if cfg.MaxHistoricalParams == 0 {
+		cfg.MaxHistoricalParams = DefaultCacheMaxHistoricalParams
+	}
+}
+
+type cacheEntry struct {
+	value    interface{}
+	err      error
+	expireAt time.Time
+}
+
+var _ consensus.Consensus = &CacheConsensus{}
+
+// CacheConsensus decorates the Consensus with the cache of the bucket info, object info, payment
+// stream record and storage params. The cached bucket and object info are dropped once a new block
+// arrives, and all the cached data expires by the ttl, the not found results are cached by the
+// shorter negative ttl. The versioned storage params of the historical timestamps never change,
+// they are cached by the queried timestamps without expiration.
+//
+// The cached values are shared by the callers, they must not be modified.
+type CacheConsensus struct {
+	consensus.Consensus
+	cfg CacheConfig
+
+	mu     sync.RWMutex
+	height uint64
+	// blockEntries holds the bucket and object info that are dropped by the new block
+	blockEntries map[string]*cacheEntry
+	// entries holds the payment stream records and the latest storage params that expire by ttl
+	entries map[string]*cacheEntry
+	// historical holds the params of the queried historical timestamps, the params between two
+	// cached timestamps are unknown even if they are the same, the params may be changed and
+	// changed back between them
+	historical map[int64]*storagetypes.Params
+
+	stopCh  chan struct{}
+	stopped chan struct{}
+}
+
+// NewCacheConsensus returns the CacheConsensus that decorates the consensus, and starts to watch
+// the new blocks to invalidate the cache.
+func NewCacheConsensus(inner consensus.Consensus, cfg CacheConfig) *CacheConsensus {
+	cfg.setDefaults()
+	c := &CacheConsensus{
+		Consensus:    inner,
+		cfg:          cfg,
+		blockEntries: make(map[string]*cacheEntry),
+		entries:      make(map[string]*cacheEntry),
+		historical:   make(map[int64]*storagetypes.Params),
+		stopCh:       make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+	c.updateHeight()
+	go c.watchHeight()
+	return c
+}
+
+func (c *CacheConsensus) watchHeight() {
+	defer close(c.stopped)
+	ticker := time.NewTicker(c.cfg.HeightInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.updateHeight()
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+// updateHeight drops the cached bucket and object info if a new block arrives.
+func (c *CacheConsensus) updateHeight() {
+	height, err := c.Consensus.CurrentHeight(context.Background())
+	if err != nil {
+		log.Warnw("failed to get current height to invalidate consensus cache", "error", err)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if height == c.height {
+		return
+	}
+	c.height = height
+	c.blockEntries = make(map[string]*cacheEntry)
+}
+
+// get returns the cached result of the key, or queries it by the query func and caches the result.
+// The result of the block entry is dropped by the new block, otherwise it only expires by the ttl.
+func (c *CacheConsensus) get(method, key string, ttl time.Duration, block bool,
+	query func() (interface{}, error)) (interface{}, error) {
+	c.mu.RLock()
+	entries := c.entries
+	if block {
+		entries = c.blockEntries
+	}
+	entry, ok := entries[key]
+	height := c.height
+	c.mu.RUnlock()
+	if ok && time.Now().Before(entry.expireAt) {
+		metrics.ConsensusCacheCounter.WithLabelValues(method, "hit").Inc()
+		return entry.value, entry.err
+	}
+	metrics.ConsensusCacheCounter.WithLabelValues(method, "miss").Inc()
+
+	value, err := query()
+	if err != nil && !isNotFound(err) {
+		return value, err
+	}
+	if err != nil {
+		ttl = c.cfg.NegativeTTL
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries = c.entries
+	if block {
+		// the result queried before the new block may be stale, it is not cached
+		if height != c.height {
+			return value, err
+		}
+		entries = c.blockEntries
+	}
+	if len(entries) >= c.cfg.MaxEntries {
+		entries = make(map[string]*cacheEntry)
+		if block {
+			c.blockEntries = entries
+		} else {
+			c.entries = entries
+		}
+	}
+	entries[key] = &cacheEntry{value: value, err: err, expireAt: time.Now().Add(ttl)}
+	return value, err
+}
+
+// isNotFound returns whether the error is the not found result that can be cached.
+func isNotFound(err error) bool {
+	if errors.Is(err, consensus.ErrNotFound) || status.Code(err) == codes.NotFound {
+		return true
+	}
+	return strings.Contains(err.Error(), storagetypes.ErrNoSuchBucket.Error()) ||
+		strings.Contains(err.Error(), storagetypes.ErrNoSuchObject.Error())
+}
+
+// QueryStorageParams returns the cached latest storage params.
+func (c *CacheConsensus) QueryStorageParams(ctx context.Context) (*storagetypes.Params, error) {
+	value, err := c.get("QueryStorageParams", "params", c.cfg.ParamsTTL, false, func() (interface{}, error) {
+		return c.Consensus.QueryStorageParams(ctx)
+	})
+	params, _ := value.(*storagetypes.Params)
+	return params, err
+}
+
+// QueryStorageParamsByTimestamp returns the cached storage params by timestamp, the params of the
+// historical timestamp are cached by the timestamp without expiration.
+func (c *CacheConsensus) QueryStorageParamsByTimestamp(ctx context.Context, timestamp int64) (
+	*storagetypes.Params, error) {
+	if timestamp > time.Now().Add(-historicalParamsDelay).Unix() {
+		value, err := c.get("QueryStorageParamsByTimestamp", "params/latest", c.cfg.ParamsTTL, false,
+			func() (interface{}, error) {
+				return c.Consensus.QueryStorageParamsByTimestamp(ctx, timestamp)
+			})
+		params, _ := value.(*storagetypes.Params)
+		return params, err
+	}
+
+	c.mu.RLock()
+	params := c.historical[timestamp]
+	c.mu.RUnlock()
+	if params != nil {
+		metrics.ConsensusCacheCounter.WithLabelValues("QueryStorageParamsByTimestamp", "hit").Inc()
+		return params, nil
+	}
+	metrics.ConsensusCacheCounter.WithLabelValues("QueryStorageParamsByTimestamp", "miss").Inc()
+	params, err := c.Consensus.QueryStorageParamsByTimestamp(ctx, timestamp)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.insertHistoricalParams(timestamp, params)
+	return params, nil
+}
+
+// insertHistoricalParams caches the params of the timestamp, the cache is reset if it is full.
+func (c *CacheConsensus) insertHistoricalParams(timestamp int64, params *storagetypes.Params) {
+	if len(c.historical) >= c.cfg.MaxHistoricalParams {
+		c.historical = make(map[int64]*storagetypes.Params)
+	}
+	c.historical[timestamp] = params
+}
+
+// QueryBucketInfo returns the cached bucket info by bucket name.
+func (c *CacheConsensus) QueryBucketInfo(ctx context.Context, bucket string) (*storagetypes.BucketInfo, error) {
+	value, err := c.get("QueryBucketInfo", "bucket/"+bucket, c.cfg.BucketTTL, true, func() (interface{}, error) {
+		return c.Consensus.QueryBucketInfo(ctx, bucket)
+	})
+	bucketInfo, _ := value.(*storagetypes.BucketInfo)
+	return bucketInfo, err
+}
+
+// QueryObjectInfo returns the cached object info by bucket and object name.
+func (c *CacheConsensus) QueryObjectInfo(ctx context.Context, bucket, object string) (
+	*storagetypes.ObjectInfo, error) {
+	value, err := c.get("QueryObjectInfo", "object/"+bucket+"/"+object, c.cfg.ObjectTTL, true,
+		func() (interface{}, error) {
+			return c.Consensus.QueryObjectInfo(ctx, bucket, object)
+		})
+	objectInfo, _ := value.(*storagetypes.ObjectInfo)
+	return objectInfo, err
+}
+
+// QueryObjectInfoByID returns the cached object info by object ID.
+func (c *CacheConsensus) QueryObjectInfoByID(ctx context.Context, objectID string) (*storagetypes.ObjectInfo, error) {
+	value, err := c.get("QueryObjectInfoByID", "object_id/"+objectID, c.cfg.ObjectTTL, true,
+		func() (interface{}, error) {
+			return c.Consensus.QueryObjectInfoByID(ctx, objectID)
+		})
+	objectInfo, _ := value.(*storagetypes.ObjectInfo)
+	return objectInfo, err
+}
+
+// QueryBucketInfoAndObjectInfo returns the cached bucket and object info by bucket and object name.
+func (c *CacheConsensus) QueryBucketInfoAndObjectInfo(ctx context.Context, bucket, object string) (
+	*storagetypes.BucketInfo, *storagetypes.ObjectInfo, error) {
+	bucketInfo, err := c.QueryBucketInfo(ctx, bucket)
+	if err != nil {
+		return nil, nil, err
+	}
+	objectInfo, err := c.QueryObjectInfo(ctx, bucket, object)
+	if err != nil {
+		return bucketInfo, nil, err
+	}
+	return bucketInfo, objectInfo, nil
+}
+
+// QueryPaymentStreamRecord returns the cached payment stream record by account.
+func (c *CacheConsensus) QueryPaymentStreamRecord(ctx context.Context, account string) (
+	*paymenttypes.StreamRecord, error) {
+	value, err := c.get("QueryPaymentStreamRecord", "payment/"+account, c.cfg.PaymentTTL, false,
+		func() (interface{}, error) {
+			return c.Consensus.QueryPaymentStreamRecord(ctx, account)
+		})
+	record, _ := value.(*paymenttypes.StreamRecord)
+	return record, err
+}
+
+// Close stops watching the new blocks and closes the decorated consensus.
+func (c *CacheConsensus) Close() error {
+	close(c.stopCh)
+	<-c.stopped
+	return c.Consensus.Close()
+}
@@ -0,0 +1,132 @@
+package gnfd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sdkmath "cosmossdk.io/math"
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/greenfield-storage-provider/core/consensus"
+)
+
+func setupCacheConsensusTest(t *testing.T) (*consensus.MemoryConsensus, *CacheConsensus) {
+	m := consensus.NewMemoryConsensus()
+	m.SetHeight(1)
+	m.PutBucket(&storagetypes.BucketInfo{BucketName: "bucket", Id: sdkmath.NewUint(1)})
+	// the height is updated by the test
+	c := NewCacheConsensus(m, CacheConfig{HeightInterval: time.Hour})
+	t.Cleanup(func() { _ = c.Close() })
+	return m, c
+}
+
+func TestCacheConsensus_InvalidateByHeight(t *testing.T) {
+	m, c := setupCacheConsensusTest(t)
+	ctx := context.TODO()
+	errQuery := errors.New("query failed")
+
+	bucket, err := c.QueryBucketInfo(ctx, "bucket")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), bucket.Id.Uint64())
+	// the cached bucket is returned without querying the chain
+	m.InjectError("QueryBucketInfo", errQuery, 0)
+	_, err = c.QueryBucketInfo(ctx, "bucket")
+	assert.Nil(t, err)
+
+	// the cache is dropped by the new block, the error is not cached
+	m.AdvanceHeight(1)
+	c.updateHeight()
+	_, err = c.QueryBucketInfo(ctx, "bucket")
+	assert.Equal(t, errQuery, err)
+	m.InjectError("QueryBucketInfo", nil, 0)
+	_, err = c.QueryBucketInfo(ctx, "bucket")
+	assert.Nil(t, err)
+}
+
+func TestCacheConsensus_ParamsNotInvalidateByHeight(t *testing.T) {
+	m, c := setupCacheConsensusTest(t)
+	ctx := context.TODO()
+
+	params, err := c.QueryStorageParams(ctx)
+	assert.Nil(t, err)
+	m.InjectError("QueryStorageParams", errors.New("query failed"), 0)
+	// the latest params are kept by the new block until the ttl expires
+	m.AdvanceHeight(1)
+	c.updateHeight()
+	cached, err := c.QueryStorageParams(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, params, cached)
+}
+
+func TestCacheConsensus_Negative(t *testing.T) {
+	m, c := setupCacheConsensusTest(t)
+	ctx := context.TODO()
+	c.cfg.NegativeTTL = 20 * time.Millisecond
+
+	_, err := c.QueryObjectInfo(ctx, "bucket", "object")
+	assert.Equal(t, consensus.ErrNotFound, err)
+	m.PutObject(&storagetypes.ObjectInfo{BucketName: "bucket", ObjectName: "object", Id: sdkmath.NewUint(2)})
+	_, err = c.QueryObjectInfo(ctx, "bucket", "object")
+	assert.Equal(t, consensus.ErrNotFound, err)
+	time.Sleep(30 * time.Millisecond)
+	object, err := c.QueryObjectInfo(ctx, "bucket", "object")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), object.Id.Uint64())
+}
+
+func TestCacheConsensus_HistoricalParams(t *testing.T) {
+	m, c := setupCacheConsensusTest(t)
+	ctx := context.TODO()
+	historical := time.Now().Add(-time.Hour).Unix()
+
+	params, err := c.QueryStorageParamsByTimestamp(ctx, historical)
+	assert.Nil(t, err)
+	m.InjectError("QueryStorageParamsByTimestamp", errors.New("query failed"), 0)
+	// the historical params are not dropped by the new block
+	m.AdvanceHeight(1)
+	c.updateHeight()
+	cached, err := c.QueryStorageParamsByTimestamp(ctx, historical)
+	assert.Nil(t, err)
+	assert.Equal(t, params, cached)
+	_, err = c.QueryStorageParamsByTimestamp(ctx, time.Now().Unix())
+	assert.NotNil(t, err)
+}
+
+func TestCacheConsensus_HistoricalParamsTimestamp(t *testing.T) {
+	m, c := setupCacheConsensusTest(t)
+	ctx := context.TODO()
+	errQuery := errors.New("query failed")
+	base := time.Now().Add(-time.Hour).Unix()
+	queryFresh := func(timestamp int64) *storagetypes.Params {
+		m.InjectError("QueryStorageParamsByTimestamp", nil, 0)
+		params, err := c.QueryStorageParamsByTimestamp(ctx, timestamp)
+		assert.Nil(t, err)
+		m.InjectError("QueryStorageParamsByTimestamp", errQuery, 0)
+		return params
+	}
+
+	// the params go A -> B -> A, the timestamp between the cached ones is not served by the cache
+	// even if the cached params around it are the same
+	first := queryFresh(base)
+	params := *first
+	params.VersionedParams.MaxSegmentSize++
+	m.SetStorageParams(&params)
+	second := queryFresh(base + 100)
+	m.SetStorageParams(first)
+	queryFresh(base + 200)
+	assert.Equal(t, 3, len(c.historical))
+
+	cached, err := c.QueryStorageParamsByTimestamp(ctx, base)
+	assert.Nil(t, err)
+	assert.Equal(t, first, cached)
+	cached, err = c.QueryStorageParamsByTimestamp(ctx, base+100)
+	assert.Nil(t, err)
+	assert.Equal(t, second, cached)
+	_, err = c.QueryStorageParamsByTimestamp(ctx, base+50)
+	assert.Equal(t, errQuery, err)
+	_, err = c.QueryStorageParamsByTimestamp(ctx, base+150)
+	assert.Equal(t, errQuery, err)
+}
@@ -0,0 +1,369 @@
+package gnfd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/metrics"
+)
+
+const (
+	// DefaultFailoverMaxAttempts defines the default max number of the endpoints tried by one call
+	DefaultFailoverMaxAttempts = 3
+	// DefaultFailoverFailureThreshold defines the default number of the consecutive failures that
+	// opens the circuit of the endpoint
+	DefaultFailoverFailureThreshold = 3
+	// DefaultFailoverOpenTimeout defines the default duration that the circuit keeps open before
+	// the endpoint is probed again
+	DefaultFailoverOpenTimeout = 30 * time.Second
+	// DefaultFailoverMaxHeightLag defines the default number of blocks that the endpoint can lag
+	// behind the highest endpoint before it is regarded as unhealthy
+	DefaultFailoverMaxHeightLag = 10
+	// DefaultFailoverHealthCheckInterval defines the default interval of checking the block height
+	// of the endpoints
+	DefaultFailoverHealthCheckInterval = 10 * time.Second
+)
+
+// FailoverConfig defines the failover and circuit breaking of the chain endpoints, the zero
+// values are set to defaults.
+type FailoverConfig struct {
+	MaxAttempts         int
+	FailureThreshold    int
+	OpenTimeout         time.Duration
+	MaxHeightLag        uint64
+	HealthCheckInterval time.Duration
+}
+
+func (cfg *FailoverConfig) setDefaults() {
+	if cfg.MaxAttempts == 0 {
+		cfg.MaxAttempts = DefaultFailoverMaxAttempts
+	}
+	if cfg.FailureThreshold == 0 {
+		cfg.FailureThreshold = DefaultFailoverFailureThreshold
+	}
+	if cfg.OpenTimeout == 0 {
+		cfg.OpenTimeout = DefaultFailoverOpenTimeout
+	}
+	if cfg.MaxHeightLag == 0 {
+		cfg.MaxHeightLag = DefaultFailoverMaxHeightLag
+	}
+	if cfg.HealthCheckInterval == 0 {
+		cfg.HealthCheckInterval = DefaultFailoverHealthCheckInterval
+	}
+}
+
+// the ranks of the endpoints, the endpoints are tried from the lower rank.
+const (
+	endpointRankHealthy = iota
+	// endpointRankProbe is the endpoint whose circuit is open but the open timeout passed
+	endpointRankProbe
+	endpointRankLagging
+	endpointRankOpen
+)
+
+type endpointState struct {
+	address   string
+	failures  int
+	openUntil time.Time
+	height    uint64
+	lagging   bool
+	// probing is set while one call probes the endpoint whose open timeout passed, the other
+	// calls regard the endpoint as open until the probe is done
+	probing bool
+}
+
+func (e *endpointState) rank(now time.Time) int {
+	switch {
+	case e.openUntil.IsZero():
+		if e.lagging {
+			return endpointRankLagging
+		}
+		return endpointRankHealthy
+	case now.After(e.openUntil) && !e.probing:
+		return endpointRankProbe
+	default:
+		return endpointRankOpen
+	}
+}
+
+// HeightFunc returns the latest block height of the endpoint by index.
+type HeightFunc func(ctx context.Context, idx int) (uint64, error)
+
+// EndpointPool tracks the health of the chain endpoints and fails over the calls across them.
+// The circuit of the endpoint opens after the consecutive transport failures, and the endpoint
+// is probed by a call again after the open timeout. The endpoints lagging behind the highest
+// one are only used if no healthy endpoint is available.
+type EndpointPool struct {
+	name string
+	cfg  FailoverConfig
+
+	mu        sync.Mutex
+	endpoints []*endpointState
+
+	stopCh  chan struct{}
+	stopped chan struct{}
+}
+
+// NewEndpointPool returns the EndpointPool of the addresses, the name labels the metrics of the
+// pool.
+func NewEndpointPool(name string, addresses []string, cfg FailoverConfig) *EndpointPool {
+	cfg.setDefaults()
+	endpoints := make([]*endpointState, 0, len(addresses))
+	for _, address := range addresses {
+		endpoints = append(endpoints, &endpointState{address: address})
+		metrics.ChainEndpointCircuitGauge.WithLabelValues(name, address).Set(0)
+	}
+	return &EndpointPool{
+		name:      name,
+		cfg:       cfg,
+		endpoints: endpoints,
+	}
+}
+
+// Address returns the address of the endpoint by index.
+func (p *EndpointPool) Address(idx int) string {
+	return p.endpoints[idx].address
+}
+
+// Best returns the index of the endpoint that the next call tries first.
+func (p *EndpointPool) Best() int {
+	return p.candidates()[0]
+}
+
+// candidates returns the indexes of the endpoints in the order of trying, the endpoints with
+// the lower rank, the fewer failures and the higher block height are tried first.
+func (p *EndpointPool) candidates() []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	indexes := make([]int, len(p.endpoints))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		a, b := p.endpoints[indexes[i]], p.endpoints[indexes[j]]
+		if a.rank(now) != b.rank(now) {
+			return a.rank(now) < b.rank(now)
+		}
+		if a.failures != b.failures {
+			return a.failures < b.failures
+		}
+		return a.height > b.height
+	})
+	return indexes
+}
+
+// Do calls the fn with the index of the endpoints in the order of health, and fails over to the
+// next endpoint if fn returns the transport error, the other errors are returned to the caller
+// directly. At most MaxAttempts endpoints are tried.
+func (p *EndpointPool) Do(ctx context.Context, method string, fn func(idx int) error) error {
+	return p.DoIf(ctx, method, IsFailoverError, fn)
+}
+
+// DoIf is the same as Do except that it only fails over to the next endpoint if the transport
+// error is retryable, e.g. the call that is not idempotent is only retried if it is not sent.
+// The transport error counts as a failure of the endpoint whether it is retryable or not.
+func (p *EndpointPool) DoIf(ctx context.Context, method string, retryable func(err error) bool,
+	fn func(idx int) error) error {
+	var err error
+	for attempt, idx := range p.candidates() {
+		if attempt >= p.cfg.MaxAttempts {
+			break
+		}
+		if attempt > 0 {
+			log.CtxWarnw(ctx, "fail over to the next chain endpoint", "pool", p.name, "method", method,
+				"endpoint", p.endpoints[idx].address, "error", err)
+		}
+		probe := p.startProbe(idx)
+		err = fn(idx)
+		if err == nil || !IsFailoverError(err) {
+			p.recordSuccess(idx)
+			return err
+		}
+		if ctx.Err() != nil {
+			// the call is canceled by the caller, it is not the fault of the endpoint
+			if probe {
+				p.stopProbe(idx)
+			}
+			return err
+		}
+		p.recordFailure(idx, err)
+		if !retryable(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// startProbe marks the endpoint as probing if its open timeout passed, returns an indicator
+// whether the call is the probe of the endpoint.
+func (p *EndpointPool) startProbe(idx int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e := p.endpoints[idx]
+	if e.rank(time.Now()) != endpointRankProbe {
+		return false
+	}
+	e.probing = true
+	return true
+}
+
+func (p *EndpointPool) stopProbe(idx int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.endpoints[idx].probing = false
+}
+
+func (p *EndpointPool) recordSuccess(idx int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e := p.endpoints[idx]
+	metrics.ChainEndpointRequestCounter.WithLabelValues(p.name, e.address, "success").Inc()
+	if !e.openUntil.IsZero() {
+		log.Infow("chain endpoint circuit is closed", "pool", p.name, "endpoint", e.address)
+		metrics.ChainEndpointCircuitGauge.WithLabelValues(p.name, e.address).Set(0)
+	}
+	e.failures = 0
+	e.openUntil = time.Time{}
+	e.probing = false
+}
+
+func (p *EndpointPool) recordFailure(idx int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e := p.endpoints[idx]
+	metrics.ChainEndpointRequestCounter.WithLabelValues(p.name, e.address, "failure").Inc()
+	e.probing = false
+	e.failures++
+	if e.failures < p.cfg.FailureThreshold {
+		return
+	}
+	// the failed probe reopens the circuit for another open timeout
+	if e.openUntil.IsZero() {
+		log.Errorw("chain endpoint circuit is open", "pool", p.name, "endpoint", e.address,
+			"failures", e.failures, "error", err)
+		metrics.ChainEndpointCircuitGauge.WithLabelValues(p.name, e.address).Set(1)
+	}
+	e.openUntil = time.Now().Add(p.cfg.OpenTimeout)
+}
+
+// UpdateHeight records the latest block height of the endpoint, the failure of getting the
+// height counts as a failure of the endpoint.
+func (p *EndpointPool) UpdateHeight(idx int, height uint64, err error) {
+	if err != nil {
+		p.recordFailure(idx, err)
+		return
+	}
+	p.recordSuccess(idx)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.endpoints[idx].height = height
+	var maxHeight uint64
+	for _, e := range p.endpoints {
+		if e.height > maxHeight {
+			maxHeight = e.height
+		}
+	}
+	for _, e := range p.endpoints {
+		lag := maxHeight - e.height
+		lagging := lag > p.cfg.MaxHeightLag
+		if lagging != e.lagging {
+			log.Warnw("chain endpoint lagging state changed", "pool", p.name, "endpoint", e.address,
+				"lagging", lagging, "height", e.height, "max_height", maxHeight)
+		}
+		e.lagging = lagging
+		metrics.ChainEndpointHeightLagGauge.WithLabelValues(p.name, e.address).Set(float64(lag))
+	}
+}
+
+// StartHealthCheck starts to update the block height of the endpoints by the heightFn every
+// HealthCheckInterval until Stop is called.
+func (p *EndpointPool) StartHealthCheck(heightFn HeightFunc) {
+	p.stopCh = make(chan struct{})
+	p.stopped = make(chan struct{})
+	p.checkHeight(heightFn)
+	go func() {
+		defer close(p.stopped)
+		ticker := time.NewTicker(p.cfg.HealthCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.checkHeight(heightFn)
+			case <-p.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+func (p *EndpointPool) checkHeight(heightFn HeightFunc) {
+	for idx := range p.endpoints {
+		ctx, cancel := context.WithTimeout(context.Background(), p.cfg.HealthCheckInterval)
+		height, err := heightFn(ctx, idx)
+		cancel()
+		if err != nil {
+			log.Errorw("failed to get latest block height", "pool", p.name,
+				"endpoint", p.endpoints[idx].address, "error", err)
+		}
+		p.UpdateHeight(idx, height, err)
+	}
+}
+
+// Stop stops the health check if it is started.
+func (p *EndpointPool) Stop() {
+	if p.stopCh == nil {
+		return
+	}
+	close(p.stopCh)
+	<-p.stopped
+}
+
+// IsFailoverError returns an indicator whether the call fails due to the endpoint rather than
+// the request, the call can be retried by another endpoint.
+func IsFailoverError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+			return true
+		default:
+			return false
+		}
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// IsPreSendError returns an indicator whether the call fails before the request is sent to the
+// endpoint, e.g. the connection is refused. The other failover errors such as the timeout and
+// the reset connection are ambiguous, the request may have been processed by the endpoint.
+func IsPreSendError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case codes.Unavailable, codes.ResourceExhausted:
+			return true
+		default:
+			return false
+		}
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) || errors.Is(err, syscall.ECONNREFUSED)
+}
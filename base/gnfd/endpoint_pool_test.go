@@ -0,0 +1,188 @@
+package gnfd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestEndpointPool_Failover(t *testing.T) {
+	p := NewEndpointPool("test", []string{"a", "b", "c"}, FailoverConfig{})
+	ctx := context.TODO()
+	errUnavailable := status.Error(codes.Unavailable, "connection refused")
+
+	var called []int
+	err := p.Do(ctx, "query", func(idx int) error {
+		called = append(called, idx)
+		if idx == 0 {
+			return errUnavailable
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 1}, called)
+
+	// the application error is returned without fail over
+	errNotFound := status.Error(codes.NotFound, "No such bucket")
+	called = nil
+	err = p.Do(ctx, "query", func(idx int) error {
+		called = append(called, idx)
+		return errNotFound
+	})
+	assert.Equal(t, errNotFound, err)
+	assert.Equal(t, []int{1}, called)
+
+	// the canceled call does not count as the failure of the endpoint
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	called = nil
+	err = p.Do(canceled, "query", func(idx int) error {
+		called = append(called, idx)
+		return status.Error(codes.DeadlineExceeded, "context canceled")
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, len(called))
+}
+
+func TestEndpointPool_CircuitBreaker(t *testing.T) {
+	p := NewEndpointPool("test", []string{"a", "b"}, FailoverConfig{
+		MaxAttempts:      1,
+		FailureThreshold: 2,
+		OpenTimeout:      50 * time.Millisecond,
+	})
+	ctx := context.TODO()
+	errUnavailable := status.Error(codes.Unavailable, "connection refused")
+	healthy := map[int]bool{0: false, 1: true}
+	call := func() int {
+		var tried int
+		_ = p.Do(ctx, "query", func(idx int) error {
+			tried = idx
+			if !healthy[idx] {
+				return errUnavailable
+			}
+			return nil
+		})
+		return tried
+	}
+
+	// the endpoint with fewer failures is preferred, the circuit of a opens after 2 failures
+	assert.Equal(t, 0, call())
+	assert.Equal(t, 1, call())
+	p.recordFailure(0, errUnavailable)
+	assert.Equal(t, 1, p.Best())
+	p.recordSuccess(1)
+
+	// the open endpoint is probed by a call after the open timeout if no healthy endpoint is
+	// available, the failed probe reopens the circuit
+	time.Sleep(60 * time.Millisecond)
+	p.recordFailure(1, errUnavailable)
+	p.recordFailure(1, errUnavailable)
+	assert.Equal(t, 0, call())
+	assert.Equal(t, 1, p.Best())
+
+	// the succeeded health check closes the circuit
+	healthy[0] = true
+	p.UpdateHeight(0, 1, nil)
+	assert.Equal(t, 0, p.Best())
+	assert.Equal(t, 0, call())
+	assert.True(t, p.endpoints[0].openUntil.IsZero())
+}
+
+func TestEndpointPool_SingleProbe(t *testing.T) {
+	p := NewEndpointPool("test", []string{"a", "b"}, FailoverConfig{
+		MaxAttempts:      1,
+		FailureThreshold: 1,
+		OpenTimeout:      10 * time.Millisecond,
+	})
+	errUnavailable := status.Error(codes.Unavailable, "connection refused")
+	p.recordFailure(0, errUnavailable)
+	p.recordFailure(1, errUnavailable)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 0, p.Best())
+
+	// the other calls regard the endpoint as open while it is probed
+	probing, release, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = p.Do(context.TODO(), "query", func(idx int) error {
+			close(probing)
+			<-release
+			return nil
+		})
+	}()
+	<-probing
+	assert.Equal(t, 1, p.Best())
+	close(release)
+	<-done
+	assert.Equal(t, 0, p.Best())
+	assert.True(t, p.endpoints[0].openUntil.IsZero())
+}
+
+func TestEndpointPool_DoIf(t *testing.T) {
+	p := NewEndpointPool("test", []string{"a", "b"}, FailoverConfig{})
+	errTimeout := status.Error(codes.DeadlineExceeded, "timeout")
+
+	// the ambiguous error is not retried by the next endpoint but counts as a failure
+	var called []int
+	err := p.DoIf(context.TODO(), "broadcast", IsPreSendError, func(idx int) error {
+		called = append(called, idx)
+		return errTimeout
+	})
+	assert.Equal(t, errTimeout, err)
+	assert.Equal(t, []int{0}, called)
+	assert.Equal(t, 1, p.endpoints[0].failures)
+
+	called = nil
+	err = p.DoIf(context.TODO(), "broadcast", IsPreSendError, func(idx int) error {
+		called = append(called, idx)
+		if idx == 1 {
+			return status.Error(codes.Unavailable, "connection refused")
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 0}, called)
+}
+
+func TestEndpointPool_HeightLag(t *testing.T) {
+	p := NewEndpointPool("test", []string{"a", "b"}, FailoverConfig{MaxHeightLag: 5})
+	p.UpdateHeight(0, 100, nil)
+	p.UpdateHeight(1, 103, nil)
+	assert.Equal(t, 1, p.Best())
+
+	// the lagging endpoint is used only if no healthy endpoint is available
+	p.UpdateHeight(1, 120, nil)
+	assert.True(t, p.endpoints[0].lagging)
+	p.UpdateHeight(1, 0, errors.New("connection refused"))
+	p.UpdateHeight(1, 0, errors.New("connection refused"))
+	p.UpdateHeight(1, 0, errors.New("connection refused"))
+	assert.Equal(t, 0, p.Best())
+
+	p.UpdateHeight(0, 118, nil)
+	assert.False(t, p.endpoints[0].lagging)
+}
+
+func TestIsFailoverError(t *testing.T) {
+	assert.True(t, IsFailoverError(status.Error(codes.Unavailable, "")))
+	assert.True(t, IsFailoverError(context.DeadlineExceeded))
+	assert.False(t, IsFailoverError(status.Error(codes.Unknown, "Object already sealed")))
+	assert.False(t, IsFailoverError(context.Canceled))
+	assert.False(t, IsFailoverError(errors.New("tx not found")))
+}
+
+func TestIsPreSendError(t *testing.T) {
+	assert.True(t, IsPreSendError(status.Error(codes.Unavailable, "")))
+	assert.True(t, IsPreSendError(&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}))
+	assert.False(t, IsPreSendError(status.Error(codes.DeadlineExceeded, "")))
+	assert.False(t, IsPreSendError(io.EOF))
+	assert.False(t, IsPreSendError(syscall.ECONNRESET))
+	assert.False(t, IsPreSendError(context.DeadlineExceeded))
+}
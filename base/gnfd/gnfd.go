@@ -4,20 +4,17 @@ import (
 	"context"
 	"errors"
 	"net/http"
-	"sync"
-	"time"
 
 	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfsperrors"
 	"github.com/bnb-chain/greenfield-storage-provider/core/consensus"
-	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
 	chainClient "github.com/bnb-chain/greenfield/sdk/client"
 	chttp "github.com/cometbft/cometbft/rpc/client/http"
 )
 
 const (
 	GreenFieldChain = "GreenfieldChain"
-	// UpdateClientInternal defines the period of updating the best chain client
-	UpdateClientInternal = 60
+	// GnfdEndpointPool is the name of the endpoint pool of the chain queries
+	GnfdEndpointPool = "query"
 	// ExpectedOutputBlockInternal defines the time of estimating output block time
 	ExpectedOutputBlockInternal = 2
 )
@@ -29,10 +26,8 @@ var (
 
 // GreenfieldClient the greenfield chain client, only use to query.
 type GreenfieldClient struct {
-	chainClient   *chainClient.GreenfieldClient
-	currentHeight int64
-	updatedAt     time.Time
-	Provider      string
+	chainClient *chainClient.GreenfieldClient
+	Provider    string
 }
 
 // GnfdClient returns the greenfield chain client.
@@ -45,15 +40,15 @@ var _ consensus.Consensus = &Gnfd{}
 type GnfdChainConfig struct {
 	ChainID      string
 	ChainAddress []string
+	Failover     FailoverConfig
 }
 
+// Gnfd queries the greenfield chain by the clients of the chain addresses, every query fails
+// over across the healthy clients by the endpoint pool.
 type Gnfd struct {
-	client          *GreenfieldClient
-	backUpClients   []*GreenfieldClient
-	wsClient        *chttp.HTTP
-	backUpWsClients []*chttp.HTTP
-	stopCh          chan struct{}
-	mutex           sync.RWMutex
+	clients   []*GreenfieldClient
+	wsClients []*chttp.HTTP
+	pool      *EndpointPool
 }
 
 // NewGnfd returns the Greenfield instance.
@@ -80,84 +75,33 @@ func NewGnfd(cfg *GnfdChainConfig) (*Gnfd, error) {
 		wsClients = append(wsClients, wsClient)
 	}
 	greenfield := &Gnfd{
-		client:          clients[0],
-		backUpClients:   clients,
-		wsClient:        wsClients[0],
-		backUpWsClients: wsClients,
-		stopCh:          make(chan struct{}),
+		clients:   clients,
+		wsClients: wsClients,
+		pool:      NewEndpointPool(GnfdEndpointPool, cfg.ChainAddress, cfg.Failover),
 	}
-
-	go greenfield.updateClient()
+	greenfield.pool.StartHealthCheck(greenfield.latestHeight)
 	return greenfield, nil
 }
 
 // Close the Greenfield instance.
 func (g *Gnfd) Close() error {
-	close(g.stopCh)
+	g.pool.Stop()
 	return nil
 }
 
-// getCurrentClient returns the current client to use.
-func (g *Gnfd) getCurrentClient() *GreenfieldClient {
-	g.mutex.RLock()
-	defer g.mutex.RUnlock()
-	return g.client
-}
-
-// setCurrentClient sets client to current client for using.
-func (g *Gnfd) setCurrentClient(client *GreenfieldClient) {
-	g.mutex.Lock()
-	defer g.mutex.Unlock()
-	g.client = client
-}
-
-// getCurrentWsClient returns the current websocket client to get last block height use.
-func (g *Gnfd) getCurrentWsClient() *chttp.HTTP {
-	g.mutex.RLock()
-	defer g.mutex.RUnlock()
-	return g.wsClient
-}
-
-// setCurrentWsAddress sets client to current websocket client for get last block height using.
-func (g *Gnfd) setCurrentWsAddress(client *chttp.HTTP) {
-	g.mutex.RLock()
-	defer g.mutex.RUnlock()
-	g.wsClient = client
+// latestHeight returns the latest block height of the client by index.
+func (g *Gnfd) latestHeight(ctx context.Context, idx int) (uint64, error) {
+	info, err := g.wsClients[idx].ABCIInfo(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(info.Response.LastBlockHeight), nil
 }
 
-// updateClient selects the client that block height is the largest and set to current client.
-func (g *Gnfd) updateClient() {
-	ticker := time.NewTicker(UpdateClientInternal * time.Second)
-	for {
-		select {
-		case <-ticker.C:
-			var (
-				maxHeight, _      = g.CurrentHeight(context.Background())
-				maxHeightClient   = g.getCurrentClient()
-				maxHeightWsClient = g.getCurrentWsClient()
-			)
-			for idx, client := range g.backUpWsClients {
-				info, err := client.ABCIInfo(context.Background())
-				if err != nil {
-					log.Errorw("failed to get latest block height",
-						"node_addr", g.backUpClients[idx].Provider, "error", err)
-					continue
-				}
-				currentHeight := uint64(info.Response.LastBlockHeight)
-				if currentHeight > maxHeight {
-					maxHeight = currentHeight
-					maxHeightClient = g.backUpClients[idx]
-					maxHeightWsClient = client
-				}
-				g.backUpClients[idx].currentHeight = (int64)(currentHeight)
-				g.backUpClients[idx].updatedAt = time.Now()
-			}
-			if maxHeightClient != g.getCurrentClient() {
-				g.setCurrentClient(maxHeightClient)
-				g.setCurrentWsAddress(maxHeightWsClient)
-			}
-		case <-g.stopCh:
-			return
-		}
-	}
+// call calls the fn with the clients in the order of health, and fails over to the next client
+// if the client is unavailable.
+func (g *Gnfd) call(ctx context.Context, method string, fn func(client *chainClient.GreenfieldClient) error) error {
+	return g.pool.Do(ctx, method, func(idx int) error {
+		return fn(g.clients[idx].GnfdClient())
+	})
 }
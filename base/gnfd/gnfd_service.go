@@ -6,11 +6,13 @@ import (
 	"strconv"
 	"time"
 
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
 	"github.com/cosmos/cosmos-sdk/types/query"
 	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
 	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
 
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+	chainClient "github.com/bnb-chain/greenfield/sdk/client"
 	paymenttypes "github.com/bnb-chain/greenfield/x/payment/types"
 	permissiontypes "github.com/bnb-chain/greenfield/x/permission/types"
 	sptypes "github.com/bnb-chain/greenfield/x/sp/types"
@@ -19,10 +21,13 @@ import (
 
 // CurrentHeight the block height sub one as the stable height.
 func (g *Gnfd) CurrentHeight(ctx context.Context) (uint64, error) {
-	resp, err := g.getCurrentWsClient().ABCIInfo(ctx)
+	var resp *ctypes.ResultABCIInfo
+	err := g.pool.Do(ctx, "CurrentHeight", func(idx int) (err error) {
+		resp, err = g.wsClients[idx].ABCIInfo(ctx)
+		return err
+	})
 	if err != nil {
-		log.CtxErrorw(ctx, "get latest block height failed", "node_addr",
-			g.getCurrentWsClient().Remote(), "error", err)
+		log.CtxErrorw(ctx, "get latest block height failed", "error", err)
 		return 0, err
 	}
 	return (uint64)(resp.Response.LastBlockHeight), nil
@@ -30,8 +35,11 @@ func (g *Gnfd) CurrentHeight(ctx context.Context) (uint64, error) {
 
 // HasAccount returns an indication of the existence of address.
 func (g *Gnfd) HasAccount(ctx context.Context, address string) (bool, error) {
-	client := g.getCurrentClient().GnfdClient()
-	resp, err := client.Account(ctx, &authtypes.QueryAccountRequest{Address: address})
+	var resp *authtypes.QueryAccountResponse
+	err := g.call(ctx, "HasAccount", func(client *chainClient.GreenfieldClient) (err error) {
+		resp, err = client.Account(ctx, &authtypes.QueryAccountRequest{Address: address})
+		return err
+	})
 	if err != nil {
 		log.CtxErrorw(ctx, "failed to query account", "address", address, "error", err)
 		return false, err
@@ -41,13 +49,16 @@ func (g *Gnfd) HasAccount(ctx context.Context, address string) (bool, error) {
 
 // ListSPs returns the list of storage provider info.
 func (g *Gnfd) ListSPs(ctx context.Context) ([]*sptypes.StorageProvider, error) {
-	client := g.getCurrentClient().GnfdClient()
 	var spInfos []*sptypes.StorageProvider
-	resp, err := client.StorageProviders(ctx, &sptypes.QueryStorageProvidersRequest{
-		Pagination: &query.PageRequest{
-			Offset: 0,
-			Limit:  math.MaxUint64,
-		},
+	var resp *sptypes.QueryStorageProvidersResponse
+	err := g.call(ctx, "ListSPs", func(client *chainClient.GreenfieldClient) (err error) {
+		resp, err = client.StorageProviders(ctx, &sptypes.QueryStorageProvidersRequest{
+			Pagination: &query.PageRequest{
+				Offset: 0,
+				Limit:  math.MaxUint64,
+			},
+		})
+		return err
 	})
 	if err != nil {
 		log.Errorw("failed to list storage providers", "error", err)
@@ -61,9 +72,12 @@ func (g *Gnfd) ListSPs(ctx context.Context) ([]*sptypes.StorageProvider, error)
 
 // ListBondedValidators returns the list of bonded validators.
 func (g *Gnfd) ListBondedValidators(ctx context.Context) ([]stakingtypes.Validator, error) {
-	client := g.getCurrentClient().GnfdClient()
 	var validators []stakingtypes.Validator
-	resp, err := client.Validators(ctx, &stakingtypes.QueryValidatorsRequest{Status: "BOND_STATUS_BONDED"})
+	var resp *stakingtypes.QueryValidatorsResponse
+	err := g.call(ctx, "ListBondedValidators", func(client *chainClient.GreenfieldClient) (err error) {
+		resp, err = client.Validators(ctx, &stakingtypes.QueryValidatorsRequest{Status: "BOND_STATUS_BONDED"})
+		return err
+	})
 	if err != nil {
 		log.Errorw("failed to list validators", "error", err)
 		return validators, err
@@ -76,8 +90,11 @@ func (g *Gnfd) ListBondedValidators(ctx context.Context) ([]stakingtypes.Validat
 
 // QueryStorageParams returns storage params
 func (g *Gnfd) QueryStorageParams(ctx context.Context) (params *storagetypes.Params, err error) {
-	client := g.getCurrentClient().GnfdClient()
-	resp, err := client.StorageQueryClient.Params(ctx, &storagetypes.QueryParamsRequest{})
+	var resp *storagetypes.QueryParamsResponse
+	err = g.call(ctx, "QueryStorageParams", func(client *chainClient.GreenfieldClient) (err error) {
+		resp, err = client.StorageQueryClient.Params(ctx, &storagetypes.QueryParamsRequest{})
+		return err
+	})
 	if err != nil {
 		log.CtxErrorw(ctx, "failed to query storage params", "error", err)
 		return nil, err
@@ -90,9 +107,12 @@ func (g *Gnfd) QueryStorageParamsByTimestamp(
 	ctx context.Context,
 	timestamp int64) (
 	params *storagetypes.Params, err error) {
-	client := g.getCurrentClient().GnfdClient()
-	resp, err := client.StorageQueryClient.QueryParamsByTimestamp(ctx,
-		&storagetypes.QueryParamsByTimestampRequest{Timestamp: timestamp})
+	var resp *storagetypes.QueryParamsByTimestampResponse
+	err = g.call(ctx, "QueryStorageParamsByTimestamp", func(client *chainClient.GreenfieldClient) (err error) {
+		resp, err = client.StorageQueryClient.QueryParamsByTimestamp(ctx,
+			&storagetypes.QueryParamsByTimestampRequest{Timestamp: timestamp})
+		return err
+	})
 	if err != nil {
 		log.CtxErrorw(ctx, "failed to query storage params", "error", err)
 		return nil, err
@@ -102,8 +122,11 @@ func (g *Gnfd) QueryStorageParamsByTimestamp(
 
 // QueryBucketInfo returns the bucket info by name.
 func (g *Gnfd) QueryBucketInfo(ctx context.Context, bucket string) (*storagetypes.BucketInfo, error) {
-	client := g.getCurrentClient().GnfdClient()
-	resp, err := client.HeadBucket(ctx, &storagetypes.QueryHeadBucketRequest{BucketName: bucket})
+	var resp *storagetypes.QueryHeadBucketResponse
+	err := g.call(ctx, "QueryBucketInfo", func(client *chainClient.GreenfieldClient) (err error) {
+		resp, err = client.HeadBucket(ctx, &storagetypes.QueryHeadBucketRequest{BucketName: bucket})
+		return err
+	})
 	if err != nil {
 		log.CtxErrorw(ctx, "failed to query bucket", "bucket_name", bucket, "error", err)
 		return nil, err
@@ -113,10 +136,13 @@ func (g *Gnfd) QueryBucketInfo(ctx context.Context, bucket string) (*storagetype
 
 // QueryObjectInfo returns the object info by name.
 func (g *Gnfd) QueryObjectInfo(ctx context.Context, bucket, object string) (*storagetypes.ObjectInfo, error) {
-	client := g.getCurrentClient().GnfdClient()
-	resp, err := client.HeadObject(ctx, &storagetypes.QueryHeadObjectRequest{
-		BucketName: bucket,
-		ObjectName: object,
+	var resp *storagetypes.QueryHeadObjectResponse
+	err := g.call(ctx, "QueryObjectInfo", func(client *chainClient.GreenfieldClient) (err error) {
+		resp, err = client.HeadObject(ctx, &storagetypes.QueryHeadObjectRequest{
+			BucketName: bucket,
+			ObjectName: object,
+		})
+		return err
 	})
 	if err != nil {
 		log.CtxErrorw(ctx, "failed to query object", "bucket_name", bucket, "object_name", object, "error", err)
@@ -127,9 +153,12 @@ func (g *Gnfd) QueryObjectInfo(ctx context.Context, bucket, object string) (*sto
 
 // QueryObjectInfoByID returns the object info by name.
 func (g *Gnfd) QueryObjectInfoByID(ctx context.Context, objectID string) (*storagetypes.ObjectInfo, error) {
-	client := g.getCurrentClient().GnfdClient()
-	resp, err := client.HeadObjectById(ctx, &storagetypes.QueryHeadObjectByIdRequest{
-		ObjectId: objectID,
+	var resp *storagetypes.QueryHeadObjectResponse
+	err := g.call(ctx, "QueryObjectInfoByID", func(client *chainClient.GreenfieldClient) (err error) {
+		resp, err = client.HeadObjectById(ctx, &storagetypes.QueryHeadObjectByIdRequest{
+			ObjectId: objectID,
+		})
+		return err
 	})
 	if err != nil {
 		log.CtxErrorw(ctx, "failed to query object", "object_id", objectID, "error", err)
@@ -180,9 +209,12 @@ func (g *Gnfd) ListenObjectSeal(ctx context.Context, objectID uint64, timeoutHei
 
 // QueryPaymentStreamRecord returns the steam record info by account.
 func (g *Gnfd) QueryPaymentStreamRecord(ctx context.Context, account string) (*paymenttypes.StreamRecord, error) {
-	client := g.getCurrentClient().GnfdClient()
-	resp, err := client.StreamRecord(ctx, &paymenttypes.QueryGetStreamRecordRequest{
-		Account: account,
+	var resp *paymenttypes.QueryGetStreamRecordResponse
+	err := g.call(ctx, "QueryPaymentStreamRecord", func(client *chainClient.GreenfieldClient) (err error) {
+		resp, err = client.StreamRecord(ctx, &paymenttypes.QueryGetStreamRecordRequest{
+			Account: account,
+		})
+		return err
 	})
 	if err != nil {
 		log.CtxErrorw(ctx, "failed to query stream record", "account", account, "error", err)
@@ -193,12 +225,15 @@ func (g *Gnfd) QueryPaymentStreamRecord(ctx context.Context, account string) (*p
 
 // VerifyGetObjectPermission verifies get object permission.
 func (g *Gnfd) VerifyGetObjectPermission(ctx context.Context, account, bucket, object string) (bool, error) {
-	client := g.getCurrentClient().GnfdClient()
-	resp, err := client.VerifyPermission(ctx, &storagetypes.QueryVerifyPermissionRequest{
-		Operator:   account,
-		BucketName: bucket,
-		ObjectName: object,
-		ActionType: permissiontypes.ACTION_GET_OBJECT,
+	var resp *storagetypes.QueryVerifyPermissionResponse
+	err := g.call(ctx, "VerifyGetObjectPermission", func(client *chainClient.GreenfieldClient) (err error) {
+		resp, err = client.VerifyPermission(ctx, &storagetypes.QueryVerifyPermissionRequest{
+			Operator:   account,
+			BucketName: bucket,
+			ObjectName: object,
+			ActionType: permissiontypes.ACTION_GET_OBJECT,
+		})
+		return err
 	})
 	if err != nil {
 		log.CtxErrorw(ctx, "failed to verify get object permission", "account", account, "error", err)
@@ -213,13 +248,16 @@ func (g *Gnfd) VerifyGetObjectPermission(ctx context.Context, account, bucket, o
 // VerifyPutObjectPermission verifies put object permission.
 func (g *Gnfd) VerifyPutObjectPermission(ctx context.Context, account, bucket, object string) (bool, error) {
 	_ = object
-	client := g.getCurrentClient().GnfdClient()
-	resp, err := client.VerifyPermission(ctx, &storagetypes.QueryVerifyPermissionRequest{
-		Operator:   account,
-		BucketName: bucket,
-		// TODO: Polish the function interface according to the semantics
-		// ObjectName: object,
-		ActionType: permissiontypes.ACTION_CREATE_OBJECT,
+	var resp *storagetypes.QueryVerifyPermissionResponse
+	err := g.call(ctx, "VerifyPutObjectPermission", func(client *chainClient.GreenfieldClient) (err error) {
+		resp, err = client.VerifyPermission(ctx, &storagetypes.QueryVerifyPermissionRequest{
+			Operator:   account,
+			BucketName: bucket,
+			// TODO: Polish the function interface according to the semantics
+			// ObjectName: object,
+			ActionType: permissiontypes.ACTION_CREATE_OBJECT,
+		})
+		return err
 	})
 	if err != nil {
 		log.CtxErrorw(ctx, "failed to verify put object permission", "account", account, "error", err)
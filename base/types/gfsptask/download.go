@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfsplimit"
+	"github.com/bnb-chain/greenfield-storage-provider/core/piecestore"
 	corercmgr "github.com/bnb-chain/greenfield-storage-provider/core/rcmgr"
 	coretask "github.com/bnb-chain/greenfield-storage-provider/core/task"
 	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
@@ -123,7 +124,11 @@ func (m *GfSpDownloadObjectTask) SetPriority(priority coretask.TPriority) {
 }
 
 func (m *GfSpDownloadObjectTask) EstimateLimit() corercmgr.Limit {
-	l := &gfsplimit.GfSpLimit{Memory: m.GetSize()}
+	// the segments are streamed to the response by the buffer.
+	l := &gfsplimit.GfSpLimit{Memory: piecestore.StreamBufferSize}
+	if m.GetSize() < piecestore.StreamBufferSize {
+		l.Memory = m.GetSize()
+	}
 	l.Add(LimitEstimateByPriority(m.GetPriority()))
 	return l
 }
@@ -98,6 +98,15 @@ func (m *GfSpTask) SetError(err error) {
 	m.Err = gfsperrors.MakeGfSpError(err)
 }
 
+// SetTraceCarrier sets the trace context carrier, the task consumer extracts the
+// trace context from it to continue the trace of the task producer.
+func (m *GfSpTask) SetTraceCarrier(carrier map[string]string) {
+	if m == nil {
+		return
+	}
+	m.TraceCarrier = carrier
+}
+
 func LimitEstimateByPriority(priority coretask.TPriority) rcmgr.Limit {
 	if priority < coretask.DefaultSmallerPriority {
 		return &gfsplimit.GfSpLimit{Tasks: 1, TasksLowPriority: 1}
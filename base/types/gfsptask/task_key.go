@@ -19,6 +19,7 @@ const (
 	KeyPrefixGfSpReplicatePieceTask         = "Replicating"
 	KeyPrefixGfSpSealObjectTask             = "Sealing"
 	KeyPrefixGfSpReceivePieceTask           = "ReceivePiece"
+	KeyPrefixGfSpTierObjectTask             = "TierObject"
 )
 
 var (
@@ -71,6 +72,10 @@ func GfSpReceivePieceTaskKey(bucket, object, id string, rIdx uint32, pIdx int32)
 		"rIdx:"+fmt.Sprint(rIdx), "pIdx:"+fmt.Sprint(pIdx)))
 }
 
+func GfSpTierObjectTaskKey(bucket, object, id string) task.TKey {
+	return task.TKey(KeyPrefixGfSpTierObjectTask + CombineKey(bucket, object, id))
+}
+
 func GfSpGCObjectTaskKey(start, end uint64, time int64) task.TKey {
 	return task.TKey(KeyPrefixGfSpGCObjectTask + CombineKey(
 		fmt.Sprint(start), fmt.Sprint(end), fmt.Sprint(time)))
@@ -0,0 +1,149 @@
+package gfsptask
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfsplimit"
+	corercmgr "github.com/bnb-chain/greenfield-storage-provider/core/rcmgr"
+	coretask "github.com/bnb-chain/greenfield-storage-provider/core/task"
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
+)
+
+var _ coretask.TierObjectTask = &GfSpTierObjectTask{}
+
+func (m *GfSpTierObjectTask) InitTierObjectTask(object *storagetypes.ObjectInfo, params *storagetypes.Params, tier string,
+	priority coretask.TPriority, timeout int64, retry int64) {
+	m.Reset()
+	m.Task = &GfSpTask{}
+	m.SetCreateTime(time.Now().Unix())
+	m.SetUpdateTime(time.Now().Unix())
+	m.SetObjectInfo(object)
+	m.SetStorageParams(params)
+	m.SetTargetTier(tier)
+	m.SetPriority(priority)
+	m.SetTimeout(timeout)
+	m.SetMaxRetry(retry)
+}
+
+func (m *GfSpTierObjectTask) Key() coretask.TKey {
+	return GfSpTierObjectTaskKey(
+		m.GetObjectInfo().GetBucketName(),
+		m.GetObjectInfo().GetObjectName(),
+		m.GetObjectInfo().Id.String())
+}
+
+func (m *GfSpTierObjectTask) Type() coretask.TType {
+	return coretask.TypeTaskTierObject
+}
+
+func (m *GfSpTierObjectTask) Info() string {
+	return fmt.Sprintf("key[%s], type[%s], priority[%d], tier[%s], limit[%s], %s",
+		m.Key(), coretask.TaskTypeName(m.Type()), m.GetPriority(), m.GetTargetTier(),
+		m.EstimateLimit().String(), m.GetTask().Info())
+}
+
+func (m *GfSpTierObjectTask) GetAddress() string {
+	return m.GetTask().GetAddress()
+}
+
+func (m *GfSpTierObjectTask) SetAddress(address string) {
+	m.GetTask().SetAddress(address)
+}
+
+func (m *GfSpTierObjectTask) GetCreateTime() int64 {
+	return m.GetTask().GetCreateTime()
+}
+
+func (m *GfSpTierObjectTask) SetCreateTime(time int64) {
+	m.GetTask().SetCreateTime(time)
+}
+
+func (m *GfSpTierObjectTask) GetUpdateTime() int64 {
+	return m.GetTask().GetUpdateTime()
+}
+
+func (m *GfSpTierObjectTask) SetUpdateTime(time int64) {
+	m.GetTask().SetUpdateTime(time)
+}
+
+func (m *GfSpTierObjectTask) GetTimeout() int64 {
+	return m.GetTask().GetTimeout()
+}
+
+func (m *GfSpTierObjectTask) SetTimeout(time int64) {
+	m.GetTask().SetTimeout(time)
+}
+
+func (m *GfSpTierObjectTask) ExceedTimeout() bool {
+	return m.GetTask().ExceedTimeout()
+}
+
+func (m *GfSpTierObjectTask) GetRetry() int64 {
+	return m.GetTask().GetRetry()
+}
+
+func (m *GfSpTierObjectTask) IncRetry() {
+	m.GetTask().IncRetry()
+}
+
+func (m *GfSpTierObjectTask) SetRetry(retry int) {
+	m.GetTask().SetRetry(retry)
+}
+
+func (m *GfSpTierObjectTask) GetMaxRetry() int64 {
+	return m.GetTask().GetMaxRetry()
+}
+
+func (m *GfSpTierObjectTask) SetMaxRetry(limit int64) {
+	m.GetTask().SetMaxRetry(limit)
+}
+
+func (m *GfSpTierObjectTask) ExceedRetry() bool {
+	return m.GetTask().ExceedRetry()
+}
+
+func (m *GfSpTierObjectTask) Expired() bool {
+	return m.GetTask().Expired()
+}
+
+func (m *GfSpTierObjectTask) GetPriority() coretask.TPriority {
+	return m.GetTask().GetPriority()
+}
+
+func (m *GfSpTierObjectTask) SetPriority(priority coretask.TPriority) {
+	m.GetTask().SetPriority(priority)
+}
+
+// EstimateLimit estimates the memory by one segment, the pieces of the object are
+// migrated one by one.
+func (m *GfSpTierObjectTask) EstimateLimit() corercmgr.Limit {
+	l := &gfsplimit.GfSpLimit{}
+	if m.GetObjectInfo().GetPayloadSize() >= m.GetStorageParams().VersionedParams.GetMaxSegmentSize() {
+		l.Memory = int64(m.GetStorageParams().VersionedParams.GetMaxSegmentSize())
+	} else {
+		l.Memory = int64(m.GetObjectInfo().GetPayloadSize())
+	}
+	l.Add(LimitEstimateByPriority(m.GetPriority()))
+	return l
+}
+
+func (m *GfSpTierObjectTask) Error() error {
+	return m.GetTask().Error()
+}
+
+func (m *GfSpTierObjectTask) SetError(err error) {
+	m.GetTask().SetError(err)
+}
+
+func (m *GfSpTierObjectTask) SetObjectInfo(object *storagetypes.ObjectInfo) {
+	m.ObjectInfo = object
+}
+
+func (m *GfSpTierObjectTask) SetStorageParams(param *storagetypes.Params) {
+	m.StorageParams = param
+}
+
+func (m *GfSpTierObjectTask) SetTargetTier(tier string) {
+	m.TargetTier = tier
+}
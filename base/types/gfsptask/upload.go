@@ -9,6 +9,7 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 
 	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfsplimit"
+	"github.com/bnb-chain/greenfield-storage-provider/core/piecestore"
 	corercmgr "github.com/bnb-chain/greenfield-storage-provider/core/rcmgr"
 	coretask "github.com/bnb-chain/greenfield-storage-provider/core/task"
 	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
@@ -119,11 +120,13 @@ func (m *GfSpUploadObjectTask) SetPriority(priority coretask.TPriority) {
 }
 
 func (m *GfSpUploadObjectTask) EstimateLimit() corercmgr.Limit {
-	l := &gfsplimit.GfSpLimit{}
+	// the segments are streamed to piece store by the buffer, and the backend storage may
+	// hold one segment in memory.
+	l := &gfsplimit.GfSpLimit{Memory: piecestore.StreamBufferSize}
 	if m.GetObjectInfo().GetPayloadSize() >= m.GetStorageParams().VersionedParams.GetMaxSegmentSize() {
-		l.Memory = int64(m.GetStorageParams().VersionedParams.GetMaxSegmentSize()) * 2
+		l.Memory += int64(m.GetStorageParams().VersionedParams.GetMaxSegmentSize())
 	} else {
-		l.Memory = int64(m.GetObjectInfo().GetPayloadSize()) * 2
+		l.Memory += int64(m.GetObjectInfo().GetPayloadSize())
 	}
 	l.Add(LimitEstimateByPriority(m.GetPriority()))
 	return l
@@ -145,6 +148,10 @@ func (m *GfSpUploadObjectTask) SetStorageParams(param *storagetypes.Params) {
 	m.StorageParams = param
 }
 
+func (m *GfSpUploadObjectTask) SetBucketInfo(bucket *storagetypes.BucketInfo) {
+	m.BucketInfo = bucket
+}
+
 func (m *GfSpReplicatePieceTask) InitReplicatePieceTask(object *storagetypes.ObjectInfo, params *storagetypes.Params,
 	priority coretask.TPriority, timeout int64, retry int64) {
 	m.Reset()
@@ -279,6 +286,10 @@ func (m *GfSpReplicatePieceTask) SetSealed(sealed bool) {
 	m.Sealed = sealed
 }
 
+func (m *GfSpReplicatePieceTask) SetBucketInfo(bucket *storagetypes.BucketInfo) {
+	m.BucketInfo = bucket
+}
+
 func (m *GfSpReplicatePieceTask) SetSecondarySignatures(signatures [][]byte) {
 	m.SecondarySignatures = signatures
 }
@@ -598,6 +609,10 @@ func (m *GfSpReceivePieceTask) SetSealed(seal bool) {
 	m.Sealed = seal
 }
 
+func (m *GfSpReceivePieceTask) SetBucketInfo(bucket *storagetypes.BucketInfo) {
+	m.BucketInfo = bucket
+}
+
 func (m *GfSpReceivePieceTask) SetSignature(signature []byte) {
 	m.Signature = signature
 }
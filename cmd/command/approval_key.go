@@ -0,0 +1,186 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/bnb-chain/greenfield-storage-provider/cmd/utils"
+	"github.com/bnb-chain/greenfield-storage-provider/modular/signer"
+	sptypes "github.com/bnb-chain/greenfield/x/sp/types"
+)
+
+var keyringFlag = &cli.StringFlag{
+	Name:     "keyring",
+	Usage:    "The approval keyring file, it is the same as the Signer.ApprovalKeyring config",
+	Required: true,
+}
+
+var approvalKeyNameFlag = &cli.StringFlag{
+	Name: "key.name",
+	Usage: "The name of the approval key in the key provider, e.g. the keystore file ${dir}/${key.name}.json " +
+		"or the remote key ${endpoint}/v1/keys/${key.name}",
+	Required: true,
+}
+
+var approvalAddressFlag = &cli.StringFlag{
+	Name:     "address",
+	Usage:    "The address of the staged approval key to promote",
+	Required: true,
+}
+
+var ApprovalKeyGenerateCmd = &cli.Command{
+	Action: approvalKeyGenerateAction,
+	Name:   "approval.key.generate",
+	Usage:  "Generate a new approval key and stage it in the approval keyring",
+	Flags: []cli.Flag{
+		utils.ConfigFileFlag,
+		keyringFlag,
+		approvalKeyNameFlag,
+	},
+	Category: "SIGNER COMMANDS",
+	Description: `The approval.key.generate command generates a new approval key of the name by the
+key provider of the config and stages it in the approval keyring, only the keystore key provider
+supports generating keys. The staged key does not sign until it is promoted by the
+approval.key.promote command, the keyring file is created if it does not exist.`,
+}
+
+var ApprovalKeyStageCmd = &cli.Command{
+	Action: approvalKeyStageAction,
+	Name:   "approval.key.stage",
+	Usage:  "Stage an existing approval key in the approval keyring",
+	Flags: []cli.Flag{
+		utils.ConfigFileFlag,
+		keyringFlag,
+		approvalKeyNameFlag,
+	},
+	Category: "SIGNER COMMANDS",
+	Description: `The approval.key.stage command stages the key of the name held by the key provider of
+the config in the approval keyring, the keyring only records the key name and the address.`,
+}
+
+var ApprovalKeyPromoteCmd = &cli.Command{
+	Action: approvalKeyPromoteAction,
+	Name:   "approval.key.promote",
+	Usage:  "Promote the staged approval key to sign the approvals",
+	Flags: []cli.Flag{
+		utils.ConfigFileFlag,
+		keyringFlag,
+		approvalAddressFlag,
+	},
+	Category: "SIGNER COMMANDS",
+	Description: `The approval.key.promote command makes the staged key active and retires the current
+active key, the running signer reloads the keyring and signs by the new key. The chain verifies
+the approvals by the approval address of the SP, so the command refuses to promote until the
+on-chain approval address is updated to the staged key. The signatures of the retired key are
+still accepted in the Signer.ApprovalKeyGracePeriod.`,
+}
+
+var ApprovalKeyListCmd = &cli.Command{
+	Action: approvalKeyListAction,
+	Name:   "approval.key.list",
+	Usage:  "List the approval keys in the approval keyring",
+	Flags: []cli.Flag{
+		keyringFlag,
+	},
+	Category: "SIGNER COMMANDS",
+}
+
+func approvalKeyGenerateAction(ctx *cli.Context) error {
+	cfg, err := utils.MakeConfig(ctx)
+	if err != nil {
+		return err
+	}
+	provider, err := signer.NewKeyProvider(cfg)
+	if err != nil {
+		return err
+	}
+	keyring, err := signer.LoadApprovalKeyring(ctx.String(keyringFlag.Name))
+	if err != nil {
+		return err
+	}
+	key, err := keyring.Generate(provider, ctx.String(approvalKeyNameFlag.Name))
+	if err != nil {
+		return err
+	}
+	if err = keyring.Save(ctx.String(keyringFlag.Name)); err != nil {
+		return err
+	}
+	fmt.Printf("staged approval key address: %s\n", key.Address)
+	return nil
+}
+
+func approvalKeyStageAction(ctx *cli.Context) error {
+	cfg, err := utils.MakeConfig(ctx)
+	if err != nil {
+		return err
+	}
+	provider, err := signer.NewKeyProvider(cfg)
+	if err != nil {
+		return err
+	}
+	keyring, err := signer.LoadApprovalKeyring(ctx.String(keyringFlag.Name))
+	if err != nil {
+		return err
+	}
+	key, err := keyring.Stage(provider, ctx.String(approvalKeyNameFlag.Name))
+	if err != nil {
+		return err
+	}
+	if err = keyring.Save(ctx.String(keyringFlag.Name)); err != nil {
+		return err
+	}
+	fmt.Printf("staged approval key address: %s\n", key.Address)
+	return nil
+}
+
+func approvalKeyPromoteAction(ctx *cli.Context) error {
+	cfg, err := utils.MakeConfig(ctx)
+	if err != nil {
+		return err
+	}
+	chain, err := utils.MakeGnfd(cfg)
+	if err != nil {
+		return err
+	}
+	defer chain.Close()
+	sps, err := chain.ListSPs(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to list storage providers, error: %v", err)
+	}
+	var spInfo *sptypes.StorageProvider
+	for _, sp := range sps {
+		if sp.GetOperatorAddress() == cfg.SpAccount.SpOperateAddress {
+			spInfo = sp
+			break
+		}
+	}
+	if spInfo == nil {
+		return fmt.Errorf("storage provider %s not found on chain", cfg.SpAccount.SpOperateAddress)
+	}
+	keyring, err := signer.LoadApprovalKeyring(ctx.String(keyringFlag.Name))
+	if err != nil {
+		return err
+	}
+	if err = keyring.Promote(ctx.String(approvalAddressFlag.Name), spInfo.GetApprovalAddress()); err != nil {
+		return err
+	}
+	if err = keyring.Save(ctx.String(keyringFlag.Name)); err != nil {
+		return err
+	}
+	fmt.Printf("active approval key address: %s\n", ctx.String(approvalAddressFlag.Name))
+	return nil
+}
+
+func approvalKeyListAction(ctx *cli.Context) error {
+	keyring, err := signer.LoadApprovalKeyring(ctx.String(keyringFlag.Name))
+	if err != nil {
+		return err
+	}
+	for _, key := range keyring.Keys {
+		fmt.Printf("%s %-8s %s\n", key.Address, key.Status, time.Unix(key.UpdatedAt, 0).Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package command
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/bnb-chain/greenfield-storage-provider/cmd/utils"
+	"github.com/bnb-chain/greenfield-storage-provider/core/spdb"
+	"github.com/bnb-chain/greenfield-storage-provider/modular/manager"
+)
+
+var discontinueBucketFlag = &cli.StringFlag{
+	Name:     "bucket",
+	Usage:    "The name of the bucket to flag",
+	Required: true,
+}
+
+var discontinueRuleFlag = &cli.StringFlag{
+	Name:  "rule",
+	Usage: "The discontinue rule of the flag, policy_violation or admin_request",
+	Value: manager.DiscontinueRuleAdminRequest,
+}
+
+var discontinueReasonFlag = &cli.StringFlag{
+	Name:  "reason",
+	Usage: "The reason of discontinuing the bucket on chain, default is the rule name",
+}
+
+var discontinueLimitFlag = &cli.IntFlag{
+	Name:  "limit",
+	Usage: "The max number of the flagged buckets to list",
+	Value: 100,
+}
+
+var DiscontinueFlagCmd = &cli.Command{
+	Action: discontinueFlagAction,
+	Name:   "discontinue.flag",
+	Usage:  "Flag the bucket to be discontinued by the manager",
+	Flags: []cli.Flag{
+		utils.ConfigFileFlag,
+		discontinueBucketFlag,
+		discontinueRuleFlag,
+		discontinueReasonFlag,
+	},
+	Category: "MANAGER COMMANDS",
+	Description: `The discontinue.flag command records the bucket in sp db, the manager discontinues
+the flagged bucket with the reason if the rule of the flag is enabled in Parallel.DiscontinueBucketRules,
+the flag is deleted after the bucket is discontinued.`,
+}
+
+var DiscontinueUnflagCmd = &cli.Command{
+	Action: discontinueUnflagAction,
+	Name:   "discontinue.unflag",
+	Usage:  "Delete the flag of the bucket to be discontinued",
+	Flags: []cli.Flag{
+		utils.ConfigFileFlag,
+		discontinueBucketFlag,
+	},
+	Category: "MANAGER COMMANDS",
+}
+
+var DiscontinueListCmd = &cli.Command{
+	Action: discontinueListAction,
+	Name:   "discontinue.list",
+	Usage:  "List the buckets flagged to be discontinued",
+	Flags: []cli.Flag{
+		utils.ConfigFileFlag,
+		discontinueLimitFlag,
+	},
+	Category: "MANAGER COMMANDS",
+}
+
+func discontinueFlagAction(ctx *cli.Context) error {
+	rule := ctx.String(discontinueRuleFlag.Name)
+	if rule != manager.DiscontinueRulePolicyViolation && rule != manager.DiscontinueRuleAdminRequest {
+		return fmt.Errorf("invalid rule %s, only policy_violation and admin_request can be flagged", rule)
+	}
+	db, err := makeSPDB(ctx)
+	if err != nil {
+		return err
+	}
+	if err = db.InsertDiscontinueBucketFlag(&spdb.DiscontinueBucketFlag{
+		BucketName: ctx.String(discontinueBucketFlag.Name),
+		Rule:       rule,
+		Reason:     ctx.String(discontinueReasonFlag.Name),
+	}); err != nil {
+		return err
+	}
+	fmt.Printf("flagged bucket[%s] rule[%s]\n", ctx.String(discontinueBucketFlag.Name), rule)
+	return nil
+}
+
+func discontinueUnflagAction(ctx *cli.Context) error {
+	db, err := makeSPDB(ctx)
+	if err != nil {
+		return err
+	}
+	return db.DeleteDiscontinueBucketFlag(ctx.String(discontinueBucketFlag.Name))
+}
+
+func discontinueListAction(ctx *cli.Context) error {
+	db, err := makeSPDB(ctx)
+	if err != nil {
+		return err
+	}
+	flags, err := db.ListDiscontinueBucketFlags(ctx.Int(discontinueLimitFlag.Name))
+	if err != nil {
+		return err
+	}
+	for _, flag := range flags {
+		fmt.Printf("time[%s] bucket[%s] rule[%s] reason[%s]\n",
+			time.Unix(flag.CreateTimestampSecond, 0).Format(time.RFC3339), flag.BucketName, flag.Rule, flag.Reason)
+	}
+	return nil
+}
+
+func makeSPDB(ctx *cli.Context) (spdb.SPDB, error) {
+	cfg, err := utils.MakeConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return utils.MakeSPDB(cfg)
+}
@@ -0,0 +1,182 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/greenfield-storage-provider/base/gfsppieceop"
+	"github.com/bnb-chain/greenfield-storage-provider/cmd/utils"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+	"github.com/bnb-chain/greenfield-storage-provider/store/piecestore/piece"
+	"github.com/bnb-chain/greenfield-storage-provider/store/piecestore/storage"
+)
+
+var dstStorageFlag = &cli.StringFlag{
+	Name:     "dst.storage",
+	Usage:    "The destination backend storage type, e.g. s3, b2, minio, file",
+	Required: true,
+}
+
+var dstBucketURLFlag = &cli.StringFlag{
+	Name:     "dst.bucket",
+	Usage:    "The destination bucket url of object storage",
+	Required: true,
+}
+
+var dstShardsFlag = &cli.IntFlag{
+	Name:  "dst.shards",
+	Usage: "The destination number of shards",
+	Value: 0,
+}
+
+var dstIAMTypeFlag = &cli.StringFlag{
+	Name:  "dst.iam",
+	Usage: "The destination identity and access management type, AKSK or SA",
+	Value: storage.AKSKIAMType,
+}
+
+var migrateConcurrencyFlag = &cli.IntFlag{
+	Name:  "concurrency",
+	Usage: "The number of pieces that are migrated concurrently",
+	Value: piece.DefaultMigrateConcurrency,
+}
+
+var migrateCheckpointFlag = &cli.StringFlag{
+	Name:  "checkpoint",
+	Usage: "The checkpoint file to save the progress, the migration resumes from it if it exists",
+	Value: "./piecestore_migrate.checkpoint",
+}
+
+var migratePrefixFlag = &cli.StringFlag{
+	Name:  "prefix",
+	Usage: "Only migrate the pieces whose key has the prefix",
+}
+
+var migrateDryRunFlag = &cli.BoolFlag{
+	Name:  "dry-run",
+	Usage: "Only scan the pieces that need to be migrated and do not write the destination",
+}
+
+var migrateVerifyFlag = &cli.BoolFlag{
+	Name:  "verify",
+	Usage: "Verify the source pieces checksum against the integrity meta in sp db",
+}
+
+var PieceStoreMigrateCmd = &cli.Command{
+	Action: pieceStoreMigrateAction,
+	Name:   "piecestore.migrate",
+	Usage:  "Migrate all the pieces from the configured piece store to another backend storage",
+	Flags: []cli.Flag{
+		utils.ConfigFileFlag,
+		dstStorageFlag,
+		dstBucketURLFlag,
+		dstShardsFlag,
+		dstIAMTypeFlag,
+		migrateConcurrencyFlag,
+		migrateCheckpointFlag,
+		migratePrefixFlag,
+		migrateDryRunFlag,
+		migrateVerifyFlag,
+	},
+	Category: "PIECE STORE COMMANDS",
+	Description: `The piecestore.migrate command streams all the pieces from the
+piece store in config file to the destination storage, the pieces that already
+exist in destination with the same size are skipped. The progress is saved to
+the checkpoint file, and the migration resumes from the checkpoint if restarted.`,
+}
+
+func pieceStoreMigrateAction(ctx *cli.Context) error {
+	cfg, err := utils.MakeConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if _, ok := os.LookupEnv(storage.BucketURL); ok {
+		return fmt.Errorf("env %s overrides both source and destination bucket url, please unset it",
+			storage.BucketURL)
+	}
+	dstCfg := &storage.PieceStoreConfig{
+		Shards: ctx.Int(dstShardsFlag.Name),
+		Store: storage.ObjectStorageConfig{
+			Storage:       ctx.String(dstStorageFlag.Name),
+			BucketURL:     ctx.String(dstBucketURLFlag.Name),
+			MaxRetries:    cfg.PieceStore.Store.MaxRetries,
+			MinRetryDelay: cfg.PieceStore.Store.MinRetryDelay,
+			IAMType:       ctx.String(dstIAMTypeFlag.Name),
+		},
+		// the pieces are decrypted from source and encrypted again by a new data key.
+		Encryption:  cfg.PieceStore.Encryption,
+		Compression: cfg.PieceStore.Compression,
+	}
+	// the pack files are migrated as the normal objects, and the index of the packed
+	// pieces in sp db is still valid for the destination.
+	if cfg.PieceStore.Pack.Enable {
+		log.Infow("migrate the pack files as the normal objects, the packed pieces can not be verified")
+		cfg.PieceStore.Pack.Enable = false
+	}
+	// the deduplicated contents are migrated as the normal objects, and the index of the
+	// deduplicated pieces in sp db is still valid for the destination.
+	if cfg.PieceStore.Dedup.Enable {
+		log.Infow("migrate the dedup contents as the normal objects, the deduplicated pieces can not be verified")
+		cfg.PieceStore.Dedup.Enable = false
+	}
+	src, err := piece.NewPieceStore(&cfg.PieceStore)
+	if err != nil {
+		return fmt.Errorf("failed to create source piece store, error: %v", err)
+	}
+	dst, err := piece.NewPieceStore(dstCfg)
+	if err != nil {
+		return fmt.Errorf("failed to create destination piece store, error: %v", err)
+	}
+	migrateCfg := &piece.MigrateConfig{
+		Prefix:         ctx.String(migratePrefixFlag.Name),
+		Concurrency:    ctx.Int(migrateConcurrencyFlag.Name),
+		DryRun:         ctx.Bool(migrateDryRunFlag.Name),
+		CheckpointFile: ctx.String(migrateCheckpointFlag.Name),
+	}
+	if ctx.Bool(migrateVerifyFlag.Name) {
+		db, err := utils.MakeSPDB(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create sp db, error: %v", err)
+		}
+		migrateCfg.ExpectedChecksum = func(key string) ([]byte, error) {
+			objectID, segmentIdx, _, err := gfsppieceop.ParsePieceKey(key)
+			if err != nil {
+				log.Warnw("skip to verify the unknown piece key", "piece_key", key)
+				return nil, nil
+			}
+			integrity, err := db.GetObjectIntegrity(objectID)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			if int(segmentIdx) >= len(integrity.PieceChecksumList) {
+				return nil, fmt.Errorf("segment index %d exceeds the piece checksum count %d",
+					segmentIdx, len(integrity.PieceChecksumList))
+			}
+			return integrity.PieceChecksumList[segmentIdx], nil
+		}
+	}
+
+	progress, err := piece.NewMigrator(src, dst, migrateCfg).Migrate(context.Background())
+	if progress != nil {
+		fmt.Printf("scanned[%d] migrated[%d] skipped[%d] failed[%d] bytes[%d] marker[%s]\n",
+			progress.Scanned, progress.Migrated, progress.Skipped, progress.Failed, progress.Bytes, progress.Marker)
+		for _, key := range progress.FailedKeys {
+			fmt.Printf("failed piece: %s\n", key)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to migrate piece store, error: %v", err)
+	}
+	if progress.Failed > 0 {
+		return fmt.Errorf("%d pieces failed to migrate, please retry", progress.Failed)
+	}
+	return nil
+}
@@ -5,9 +5,11 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bnb-chain/greenfield-common/go/hash"
 	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfsperrors"
@@ -38,6 +40,53 @@ var keyFlag = &cli.StringFlag{
 	Value: "",
 }
 
+var taskObjectIDFlag = &cli.StringFlag{
+	Name:  "i",
+	Usage: "The ID key of Object to query the task history",
+	Value: "",
+}
+
+var startTimeFlag = &cli.Int64Flag{
+	Name:  "start",
+	Usage: "The start unix timestamp(second) of the task history, included",
+	Value: 0,
+}
+
+var endTimeFlag = &cli.Int64Flag{
+	Name:  "end",
+	Usage: "The end unix timestamp(second) of the task history, excluded, default is now",
+	Value: 0,
+}
+
+var limitFlag = &cli.IntFlag{
+	Name:  "l",
+	Usage: "The max number of the task history, unlimited if is not set",
+	Value: 0,
+}
+
+var bucketNameFlag = &cli.StringFlag{
+	Name:  "b",
+	Usage: "The bucket name of object",
+	Value: "",
+}
+
+var objectNameFlag = &cli.StringFlag{
+	Name:  "o",
+	Usage: "The object name of object",
+	Value: "",
+}
+
+var paymentAccountFlag = &cli.StringFlag{
+	Name:  "p",
+	Usage: "The payment account of bucket",
+	Value: "",
+}
+
+var groupByPaymentFlag = &cli.BoolFlag{
+	Name:  "by-payment",
+	Usage: "Group the usage by payment account, otherwise by bucket",
+}
+
 var objectIDFlag = &cli.StringFlag{
 	Name:     "i",
 	Usage:    "The ID key of Object",
@@ -88,15 +137,58 @@ server to get object payload data`,
 var QueryTaskCmd = &cli.Command{
 	Action:   queryTasksAction,
 	Name:     "query.task",
-	Usage:    "Query running tasks in modules by task sub key",
+	Usage:    "Query running tasks in modules by task sub key or task history by object id and time range",
 	Category: "QUERY COMMANDS",
 	Flags: []cli.Flag{
 		utils.ConfigFileFlag,
 		endpointFlag,
 		keyFlag,
+		taskObjectIDFlag,
+		startTimeFlag,
+		endTimeFlag,
+		limitFlag,
 	},
 	Description: `Query running tasks in modules by task sub key, 
-show the tasks that task key contains the inout key detail info`,
+show the tasks that task key contains the inout key detail info.
+If the task sub key is not set, query the task state transition history
+recorded by manager by object id and time range, the history includes
+the finished and retired tasks`,
+}
+
+var InspectObjectCmd = &cli.Command{
+	Action:   inspectObjectAction,
+	Name:     "inspect.object",
+	Usage:    "Inspect object state in chain, db, piece store and task queues",
+	Category: "QUERY COMMANDS",
+	Flags: []cli.Flag{
+		utils.ConfigFileFlag,
+		endpointFlag,
+		bucketNameFlag,
+		objectNameFlag,
+		taskObjectIDFlag,
+	},
+	Description: `The inspect.object command send rpc request to sp to aggregate 
+the object info on chain, the upload progress and integrity meta in db, the 
+pieces in piece store and the running tasks in modules, and output the diagnosis.
+The object is specified by bucket name and object name or object id.`,
+}
+
+var QueryUsageCmd = &cli.Command{
+	Action:   queryUsageAction,
+	Name:     "query.usage",
+	Usage:    "Query piece store usage by bucket or payment account and capacity",
+	Category: "QUERY COMMANDS",
+	Flags: []cli.Flag{
+		utils.ConfigFileFlag,
+		endpointFlag,
+		bucketNameFlag,
+		paymentAccountFlag,
+		groupByPaymentFlag,
+	},
+	Description: `The query.usage command send rpc request to sp to query the bytes
+of pieces stored in piece store grouped by bucket or payment account, and the
+total and available capacity of piece store. If the bucket name or payment account
+is set, only query the usage of it.`,
 }
 
 var ChallengePieceCmd = &cli.Command{
@@ -155,7 +247,7 @@ func queryTasksAction(ctx *cli.Context) error {
 		endpoint = ctx.String(endpointFlag.Name)
 	}
 	if !ctx.IsSet(keyFlag.Name) {
-		return fmt.Errorf("query key should be set")
+		return queryTaskEvents(ctx, endpoint)
 	}
 	key := ctx.String(keyFlag.Name)
 	if len(key) == 0 {
@@ -175,6 +267,118 @@ func queryTasksAction(ctx *cli.Context) error {
 	return nil
 }
 
+func queryTaskEvents(ctx *cli.Context, endpoint string) error {
+	if !ctx.IsSet(taskObjectIDFlag.Name) && !ctx.IsSet(startTimeFlag.Name) && !ctx.IsSet(endTimeFlag.Name) {
+		return fmt.Errorf("query key or object id or time range should be set")
+	}
+	client := &gfspclient.GfSpClient{}
+	events, err := client.QueryTaskEvents(context.Background(), endpoint, ctx.String(taskObjectIDFlag.Name),
+		ctx.Int64(startTimeFlag.Name), ctx.Int64(endTimeFlag.Name), int32(ctx.Int(limitFlag.Name)))
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return fmt.Errorf("no task history match the query")
+	}
+	for _, event := range events {
+		fmt.Printf("time[%s] object_id[%d] task_type[%s] event[%s] address[%s] retry[%d] error[%s] task_key[%s]\n",
+			time.Unix(event.GetTimestamp(), 0).Format(time.RFC3339), event.GetObjectId(), event.GetTaskType(),
+			event.GetEvent(), event.GetAddress(), event.GetRetry(), event.GetErrorDescription(), event.GetTaskKey())
+	}
+	return nil
+}
+
+func inspectObjectAction(ctx *cli.Context) error {
+	endpoint := gfspapp.DefaultGrpcAddress
+	if ctx.IsSet(utils.ConfigFileFlag.Name) {
+		cfg := &gfspconfig.GfSpConfig{}
+		err := utils.LoadConfig(ctx.String(utils.ConfigFileFlag.Name), cfg)
+		if err != nil {
+			log.Errorw("failed to load config file", "error", err)
+			return err
+		}
+		endpoint = cfg.GrpcAddress
+	}
+	if ctx.IsSet(endpointFlag.Name) {
+		endpoint = ctx.String(endpointFlag.Name)
+	}
+	if !ctx.IsSet(taskObjectIDFlag.Name) && (!ctx.IsSet(bucketNameFlag.Name) || !ctx.IsSet(objectNameFlag.Name)) {
+		return fmt.Errorf("object id or bucket and object name should be set")
+	}
+	client := &gfspclient.GfSpClient{}
+	resp, err := client.InspectObject(context.Background(), endpoint, ctx.String(bucketNameFlag.Name),
+		ctx.String(objectNameFlag.Name), ctx.String(taskObjectIDFlag.Name))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("ObjectInfo: %s\n\n", resp.GetObjectInfo().String())
+	fmt.Printf("role[%s] replicate_idx[%d] upload_state[%s]\n\n",
+		resp.GetRole(), resp.GetReplicateIdx(), resp.GetUploadState())
+	if resp.GetHasIntegrity() {
+		fmt.Printf("integrity hash[%s] piece_checksum_count[%d]\n\n",
+			hex.EncodeToString(resp.GetIntegrityChecksum()), resp.GetPieceChecksumCount())
+	} else {
+		fmt.Printf("integrity meta not found\n\n")
+	}
+	for _, piece := range resp.GetPieces() {
+		fmt.Printf("piece[%s] expected_size[%d] actual_size[%d] error[%s]\n",
+			piece.GetPieceKey(), piece.GetExpectedSize(), piece.GetActualSize(), piece.GetError())
+	}
+	fmt.Printf("\nrunning tasks:\n")
+	for _, info := range resp.GetTaskInfo() {
+		fmt.Printf("%s\n", info)
+	}
+	fmt.Printf("\ndiagnosis:\n")
+	for _, diagnosis := range resp.GetDiagnosis() {
+		fmt.Printf("- %s\n", diagnosis)
+	}
+	return nil
+}
+
+func queryUsageAction(ctx *cli.Context) error {
+	endpoint := gfspapp.DefaultGrpcAddress
+	if ctx.IsSet(utils.ConfigFileFlag.Name) {
+		cfg := &gfspconfig.GfSpConfig{}
+		err := utils.LoadConfig(ctx.String(utils.ConfigFileFlag.Name), cfg)
+		if err != nil {
+			log.Errorw("failed to load config file", "error", err)
+			return err
+		}
+		endpoint = cfg.GrpcAddress
+	}
+	if ctx.IsSet(endpointFlag.Name) {
+		endpoint = ctx.String(endpointFlag.Name)
+	}
+	if ctx.IsSet(bucketNameFlag.Name) && ctx.IsSet(paymentAccountFlag.Name) {
+		return fmt.Errorf("bucket name and payment account can not be set at the same time")
+	}
+	byPayment := ctx.Bool(groupByPaymentFlag.Name) || ctx.IsSet(paymentAccountFlag.Name)
+	key := ctx.String(bucketNameFlag.Name)
+	if byPayment {
+		key = ctx.String(paymentAccountFlag.Name)
+	}
+	client := &gfspclient.GfSpClient{}
+	resp, err := client.QueryPieceUsage(context.Background(), endpoint, byPayment, key)
+	if err != nil {
+		return err
+	}
+	if resp.GetCapacityError() != "" {
+		fmt.Printf("capacity: unknown, error[%s]\n\n", resp.GetCapacityError())
+	} else {
+		fmt.Printf("capacity: total[%d] available[%d]\n\n", resp.GetTotalCapacity(), resp.GetAvailableCapacity())
+	}
+	if len(resp.GetStats()) == 0 {
+		fmt.Printf("no usage match the query\n")
+		return nil
+	}
+	for _, stat := range resp.GetStats() {
+		fmt.Printf("key[%s] object_count[%d] primary_size[%d] secondary_size[%d] total_size[%d]\n",
+			stat.GetKey(), stat.GetObjectCount(), stat.GetPrimarySize(), stat.GetSecondarySize(),
+			stat.GetPrimarySize()+stat.GetSecondarySize())
+	}
+	return nil
+}
+
 func getObjectAction(ctx *cli.Context) error {
 	cfg, err := utils.MakeConfig(ctx)
 	if err != nil {
@@ -202,11 +406,12 @@ func getObjectAction(ctx *cli.Context) error {
 	task := &gfsptask.GfSpDownloadObjectTask{}
 	task.InitDownloadObjectTask(objectInfo, bucketInfo, params, coretask.UnSchedulingPriority,
 		GfSpCliUserName, 0, int64(objectInfo.GetPayloadSize()-1), 0, 0)
-	data, err := client.GetObject(context.Background(), task)
+	reader, err := client.GetObject(context.Background(), task)
 	if err != nil {
 		return fmt.Errorf("failed to get object, error: %v", err)
 	}
-	if err = os.WriteFile("./"+objectInfo.GetObjectName(), data, os.ModePerm); err != nil {
+	defer reader.Close()
+	if err = writeObjectFile("./"+objectInfo.GetObjectName(), reader); err != nil {
 		fmt.Printf("failed to create file to wirte object payload data, error: %v", err)
 	}
 	fmt.Printf("succeed to get object\n\n"+
@@ -217,6 +422,19 @@ func getObjectAction(ctx *cli.Context) error {
 	return nil
 }
 
+// writeObjectFile writes the object payload data read from the reader to the file.
+func writeObjectFile(path string, reader io.Reader) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(file, reader); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
 func challengePieceAction(ctx *cli.Context) error {
 	cfg, err := utils.MakeConfig(ctx)
 	if err != nil {
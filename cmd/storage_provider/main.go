@@ -79,6 +79,12 @@ var (
 		utils.PProfDisableFlag,
 		utils.PProfHTTPFlag,
 	}
+
+	tracingFlags = []cli.Flag{
+		utils.TracingEnableFlag,
+		utils.TracingExporterFlag,
+		utils.TracingEndpointFlag,
+	}
 )
 
 func init() {
@@ -93,6 +99,7 @@ func init() {
 		logFlags,
 		metricsFlags,
 		pprofFlags,
+		tracingFlags,
 	)
 	app.Commands = []*cli.Command{
 		// config category commands
@@ -104,8 +111,21 @@ func init() {
 		command.GetObjectCmd,
 		command.ChallengePieceCmd,
 		command.GetSegmentIntegrityCmd,
+		command.InspectObjectCmd,
+		command.QueryUsageCmd,
+		// piece store category commands
+		command.PieceStoreMigrateCmd,
 		// p2p category commands
 		command.P2PCreateKeysCmd,
+		// signer category commands
+		command.ApprovalKeyGenerateCmd,
+		command.ApprovalKeyStageCmd,
+		command.ApprovalKeyPromoteCmd,
+		command.ApprovalKeyListCmd,
+		// manager category commands
+		command.DiscontinueFlagCmd,
+		command.DiscontinueUnflagCmd,
+		command.DiscontinueListCmd,
 		// miscellaneous category commands
 		VersionCmd,
 		// debug commands
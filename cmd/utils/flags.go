@@ -76,6 +76,23 @@ var (
 		Category: PerfCategory,
 		Usage:    "Specify pprof HTTP server listening address",
 	}
+
+	// Tracing flags
+	TracingEnableFlag = &cli.BoolFlag{
+		Name:     "tracing.enable",
+		Category: MetricsCategory,
+		Usage:    "Enable the OpenTelemetry distributed tracing",
+	}
+	TracingExporterFlag = &cli.StringFlag{
+		Name:     "tracing.exporter",
+		Category: MetricsCategory,
+		Usage:    "Specify tracing exporter, one of stdout, file and otlp",
+	}
+	TracingEndpointFlag = &cli.StringFlag{
+		Name:     "tracing.endpoint",
+		Category: MetricsCategory,
+		Usage:    "Specify OTLP collector gRPC address for otlp tracing exporter",
+	}
 )
 
 // MergeFlags merges the given flag slices.
@@ -43,6 +43,15 @@ func MakeConfig(ctx *cli.Context) (*gfspconfig.GfSpConfig, error) {
 	if ctx.IsSet(PProfHTTPFlag.Name) {
 		cfg.Monitor.PProfHttpAddress = ctx.String(PProfHTTPFlag.Name)
 	}
+	if ctx.IsSet(TracingEnableFlag.Name) {
+		cfg.Monitor.EnableTracing = ctx.Bool(TracingEnableFlag.Name)
+	}
+	if ctx.IsSet(TracingExporterFlag.Name) {
+		cfg.Monitor.TracingExporter = ctx.String(TracingExporterFlag.Name)
+	}
+	if ctx.IsSet(TracingEndpointFlag.Name) {
+		cfg.Monitor.TracingEndpoint = ctx.String(TracingEndpointFlag.Name)
+	}
 	if ctx.IsSet(DisableResourceManagerFlag.Name) {
 		cfg.Rcmgr.DisableRcmgr = ctx.Bool(DisableResourceManagerFlag.Name)
 	}
@@ -150,6 +159,7 @@ func MakeGnfd(cfg *gfspconfig.GfSpConfig) (*gnfd.Gnfd, error) {
 	gnfdCfg := &gnfd.GnfdChainConfig{
 		ChainID:      cfg.Chain.ChainID,
 		ChainAddress: cfg.Chain.ChainAddress,
+		Failover:     gfspapp.MakeFailoverConfig(cfg),
 	}
 	return gnfd.NewGnfd(gnfdCfg)
 }
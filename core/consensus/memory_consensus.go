@@ -0,0 +1,458 @@
+package consensus
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	paymenttypes "github.com/bnb-chain/greenfield/x/payment/types"
+	sptypes "github.com/bnb-chain/greenfield/x/sp/types"
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+)
+
+var (
+	// ErrNotFound is returned by MemoryConsensus if the queried bucket, object or record does not exist.
+	ErrNotFound = errors.New("consensus data not found")
+	// ErrSealTimeout is returned by MemoryConsensus if the object is not sealed before the timeout height.
+	ErrSealTimeout = errors.New("seal object timeout")
+)
+
+// DefaultMemoryBlockInterval defines the default interval that MemoryConsensus waits for one block
+// when listening the object seal.
+const DefaultMemoryBlockInterval = 10 * time.Millisecond
+
+var _ Consensus = (*MemoryConsensus)(nil)
+
+// MemoryConsensus is the scriptable in-memory Consensus, the consensus data, the chain height and
+// the faults of the queries are set by the test, it can be selected by gfspconfig.Customize to run
+// the modules without greenfield chain.
+type MemoryConsensus struct {
+	mu            sync.RWMutex
+	height        uint64
+	heightLag     uint64
+	blockInterval time.Duration
+	accounts      map[string]bool
+	sps           []*sptypes.StorageProvider
+	validators    []stakingtypes.Validator
+	params        *storagetypes.Params
+	buckets       map[string]*storagetypes.BucketInfo
+	objects       map[string]*storagetypes.ObjectInfo
+	streamRecords map[string]*paymenttypes.StreamRecord
+	permissions   map[string]bool
+	defaultGet    bool
+	defaultPut    bool
+	faults        map[string]*memoryFault
+}
+
+type memoryFault struct {
+	err     error
+	times   int
+	latency time.Duration
+}
+
+// NewMemoryConsensus returns an empty MemoryConsensus with the default storage params, the
+// accounts have no permission to get or put objects by default.
+func NewMemoryConsensus() *MemoryConsensus {
+	params := storagetypes.DefaultParams()
+	return &MemoryConsensus{
+		blockInterval: DefaultMemoryBlockInterval,
+		accounts:      make(map[string]bool),
+		params:        &params,
+		buckets:       make(map[string]*storagetypes.BucketInfo),
+		objects:       make(map[string]*storagetypes.ObjectInfo),
+		streamRecords: make(map[string]*paymenttypes.StreamRecord),
+		permissions:   make(map[string]bool),
+		faults:        make(map[string]*memoryFault),
+	}
+}
+
+// SetHeight sets the latest height of the chain.
+func (m *MemoryConsensus) SetHeight(height uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.height = height
+}
+
+// AdvanceHeight increases the latest height of the chain by n blocks.
+func (m *MemoryConsensus) AdvanceHeight(n uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.height += n
+}
+
+// SetHeightLag makes CurrentHeight lag behind the latest height by lag blocks, it simulates
+// the full node that falls behind the chain.
+func (m *MemoryConsensus) SetHeightLag(lag uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.heightLag = lag
+}
+
+// SetBlockInterval sets the interval of one block that ListenObjectSeal waits for.
+func (m *MemoryConsensus) SetBlockInterval(interval time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blockInterval = interval
+}
+
+// SetAccount sets whether the account has been created.
+func (m *MemoryConsensus) SetAccount(account string, exist bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.accounts[account] = exist
+}
+
+// SetSPs sets the storage providers.
+func (m *MemoryConsensus) SetSPs(sps []*sptypes.StorageProvider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sps = sps
+}
+
+// SetValidators sets the bonded validators.
+func (m *MemoryConsensus) SetValidators(validators []stakingtypes.Validator) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.validators = validators
+}
+
+// SetStorageParams sets the storage params, the params are returned for all timestamps.
+func (m *MemoryConsensus) SetStorageParams(params *storagetypes.Params) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.params = params
+}
+
+// PutBucket inserts or replaces the bucket.
+func (m *MemoryConsensus) PutBucket(bucket *storagetypes.BucketInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.buckets[bucket.GetBucketName()] = bucket
+}
+
+// PutObject inserts or replaces the object.
+func (m *MemoryConsensus) PutObject(object *storagetypes.ObjectInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[objectPath(object.GetBucketName(), object.GetObjectName())] = object
+}
+
+// DeleteObject deletes the object, e.g. the object is rejected to seal or deleted by the user.
+func (m *MemoryConsensus) DeleteObject(bucket, object string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, objectPath(bucket, object))
+}
+
+// SetObjectStatus sets the status of the object by id, e.g. seals the object, returns
+// ErrNotFound if the object does not exist.
+func (m *MemoryConsensus) SetObjectStatus(objectID uint64, status storagetypes.ObjectStatus) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	object := m.objectByID(objectID)
+	if object == nil {
+		return ErrNotFound
+	}
+	object.ObjectStatus = status
+	return nil
+}
+
+// SetStreamRecord sets the payment stream record of the account.
+func (m *MemoryConsensus) SetStreamRecord(account string, record *paymenttypes.StreamRecord) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.streamRecords[account] = record
+}
+
+// SetPermission sets whether the account has permission to get and put the object.
+func (m *MemoryConsensus) SetPermission(account, bucket, object string, get, put bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.permissions[permissionKey("get", account, bucket, object)] = get
+	m.permissions[permissionKey("put", account, bucket, object)] = put
+}
+
+// SetDefaultPermission sets the permission of the accounts whose permission is not set.
+func (m *MemoryConsensus) SetDefaultPermission(get, put bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.defaultGet, m.defaultPut = get, put
+}
+
+// InjectError makes the next times calls of the method return err, times <= 0 means all the
+// calls fail until the faults are cleared. The method is the name of the Consensus method,
+// e.g. "QueryObjectInfo".
+func (m *MemoryConsensus) InjectError(method string, err error, times int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fault(method).err, m.fault(method).times = err, times
+}
+
+// InjectLatency delays the calls of the method by latency.
+func (m *MemoryConsensus) InjectLatency(method string, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fault(method).latency = latency
+}
+
+// ClearFaults removes all the injected errors and latencies.
+func (m *MemoryConsensus) ClearFaults() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.faults = make(map[string]*memoryFault)
+}
+
+func (m *MemoryConsensus) fault(method string) *memoryFault {
+	f, ok := m.faults[method]
+	if !ok {
+		f = &memoryFault{}
+		m.faults[method] = f
+	}
+	return f
+}
+
+// call applies the injected faults of the method before it is served.
+func (m *MemoryConsensus) call(ctx context.Context, method string) error {
+	var (
+		latency time.Duration
+		err     error
+	)
+	m.mu.Lock()
+	if f, ok := m.faults[method]; ok {
+		latency = f.latency
+		if f.err != nil {
+			err = f.err
+			if f.times > 0 {
+				if f.times--; f.times == 0 {
+					f.err = nil
+				}
+			}
+		}
+	}
+	m.mu.Unlock()
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+func (m *MemoryConsensus) CurrentHeight(ctx context.Context) (uint64, error) {
+	if err := m.call(ctx, "CurrentHeight"); err != nil {
+		return 0, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.height < m.heightLag {
+		return 0, nil
+	}
+	return m.height - m.heightLag, nil
+}
+
+func (m *MemoryConsensus) HasAccount(ctx context.Context, account string) (bool, error) {
+	if err := m.call(ctx, "HasAccount"); err != nil {
+		return false, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.accounts[account], nil
+}
+
+func (m *MemoryConsensus) ListSPs(ctx context.Context) ([]*sptypes.StorageProvider, error) {
+	if err := m.call(ctx, "ListSPs"); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]*sptypes.StorageProvider(nil), m.sps...), nil
+}
+
+func (m *MemoryConsensus) ListBondedValidators(ctx context.Context) ([]stakingtypes.Validator, error) {
+	if err := m.call(ctx, "ListBondedValidators"); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]stakingtypes.Validator(nil), m.validators...), nil
+}
+
+func (m *MemoryConsensus) QueryStorageParams(ctx context.Context) (*storagetypes.Params, error) {
+	if err := m.call(ctx, "QueryStorageParams"); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	params := *m.params
+	return &params, nil
+}
+
+func (m *MemoryConsensus) QueryStorageParamsByTimestamp(ctx context.Context, timestamp int64) (*storagetypes.Params, error) {
+	if err := m.call(ctx, "QueryStorageParamsByTimestamp"); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	params := *m.params
+	return &params, nil
+}
+
+func (m *MemoryConsensus) QueryBucketInfo(ctx context.Context, bucket string) (*storagetypes.BucketInfo, error) {
+	if err := m.call(ctx, "QueryBucketInfo"); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.bucketInfo(bucket)
+}
+
+func (m *MemoryConsensus) QueryObjectInfo(ctx context.Context, bucket, object string) (*storagetypes.ObjectInfo, error) {
+	if err := m.call(ctx, "QueryObjectInfo"); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.objectInfo(bucket, object)
+}
+
+func (m *MemoryConsensus) QueryObjectInfoByID(ctx context.Context, objectID string) (*storagetypes.ObjectInfo, error) {
+	if err := m.call(ctx, "QueryObjectInfoByID"); err != nil {
+		return nil, err
+	}
+	id, err := strconv.ParseUint(objectID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	object := m.objectByID(id)
+	if object == nil {
+		return nil, ErrNotFound
+	}
+	info := *object
+	return &info, nil
+}
+
+func (m *MemoryConsensus) QueryBucketInfoAndObjectInfo(ctx context.Context, bucket, object string) (
+	*storagetypes.BucketInfo, *storagetypes.ObjectInfo, error) {
+	if err := m.call(ctx, "QueryBucketInfoAndObjectInfo"); err != nil {
+		return nil, nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	bucketInfo, err := m.bucketInfo(bucket)
+	if err != nil {
+		return nil, nil, err
+	}
+	objectInfo, err := m.objectInfo(bucket, object)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bucketInfo, objectInfo, nil
+}
+
+func (m *MemoryConsensus) QueryPaymentStreamRecord(ctx context.Context, account string) (*paymenttypes.StreamRecord, error) {
+	if err := m.call(ctx, "QueryPaymentStreamRecord"); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	record, ok := m.streamRecords[account]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	info := *record
+	return &info, nil
+}
+
+func (m *MemoryConsensus) VerifyGetObjectPermission(ctx context.Context, account, bucket, object string) (bool, error) {
+	if err := m.call(ctx, "VerifyGetObjectPermission"); err != nil {
+		return false, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if allowed, ok := m.permissions[permissionKey("get", account, bucket, object)]; ok {
+		return allowed, nil
+	}
+	return m.defaultGet, nil
+}
+
+func (m *MemoryConsensus) VerifyPutObjectPermission(ctx context.Context, account, bucket, object string) (bool, error) {
+	if err := m.call(ctx, "VerifyPutObjectPermission"); err != nil {
+		return false, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if allowed, ok := m.permissions[permissionKey("put", account, bucket, object)]; ok {
+		return allowed, nil
+	}
+	return m.defaultPut, nil
+}
+
+// ListenObjectSeal checks the object status once a block interval, returns ErrSealTimeout if
+// the object is not sealed in timeOutHeight blocks.
+func (m *MemoryConsensus) ListenObjectSeal(ctx context.Context, objectID uint64, timeOutHeight int) (bool, error) {
+	if err := m.call(ctx, "ListenObjectSeal"); err != nil {
+		return false, err
+	}
+	m.mu.RLock()
+	interval := m.blockInterval
+	m.mu.RUnlock()
+	for i := 0; i < timeOutHeight; i++ {
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+		m.mu.RLock()
+		object := m.objectByID(objectID)
+		sealed := object != nil && object.GetObjectStatus() == storagetypes.OBJECT_STATUS_SEALED
+		m.mu.RUnlock()
+		if sealed {
+			return true, nil
+		}
+	}
+	return false, ErrSealTimeout
+}
+
+func (m *MemoryConsensus) Close() error { return nil }
+
+func (m *MemoryConsensus) bucketInfo(bucket string) (*storagetypes.BucketInfo, error) {
+	info, ok := m.buckets[bucket]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	bucketInfo := *info
+	return &bucketInfo, nil
+}
+
+func (m *MemoryConsensus) objectInfo(bucket, object string) (*storagetypes.ObjectInfo, error) {
+	info, ok := m.objects[objectPath(bucket, object)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	objectInfo := *info
+	return &objectInfo, nil
+}
+
+func (m *MemoryConsensus) objectByID(objectID uint64) *storagetypes.ObjectInfo {
+	for _, object := range m.objects {
+		if object.Id.Uint64() == objectID {
+			return object
+		}
+	}
+	return nil
+}
+
+func objectPath(bucket, object string) string {
+	return bucket + "/" + object
+}
+
+func permissionKey(action, account, bucket, object string) string {
+	return action + ":" + account + ":" + objectPath(bucket, object)
+}
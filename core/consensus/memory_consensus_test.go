@@ -0,0 +1,107 @@
+package consensus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sdkmath "cosmossdk.io/math"
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupMemoryConsensusTest() *MemoryConsensus {
+	m := NewMemoryConsensus()
+	m.SetBlockInterval(time.Millisecond)
+	m.PutBucket(&storagetypes.BucketInfo{BucketName: "bucket", Id: sdkmath.NewUint(1)})
+	m.PutObject(&storagetypes.ObjectInfo{
+		BucketName:   "bucket",
+		ObjectName:   "object",
+		Id:           sdkmath.NewUint(2),
+		ObjectStatus: storagetypes.OBJECT_STATUS_CREATED,
+	})
+	return m
+}
+
+func TestMemoryConsensus_Query(t *testing.T) {
+	m := setupMemoryConsensusTest()
+	ctx := context.TODO()
+	bucket, object, err := m.QueryBucketInfoAndObjectInfo(ctx, "bucket", "object")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), bucket.Id.Uint64())
+	assert.Equal(t, uint64(2), object.Id.Uint64())
+	object, err = m.QueryObjectInfoByID(ctx, "2")
+	assert.Nil(t, err)
+	assert.Equal(t, "object", object.GetObjectName())
+	_, err = m.QueryObjectInfo(ctx, "bucket", "none")
+	assert.Equal(t, ErrNotFound, err)
+	params, err := m.QueryStorageParamsByTimestamp(ctx, 0)
+	assert.Nil(t, err)
+	assert.True(t, params.VersionedParams.GetMaxSegmentSize() > 0)
+
+	m.SetPermission("alice", "bucket", "object", true, false)
+	allowed, err := m.VerifyGetObjectPermission(ctx, "alice", "bucket", "object")
+	assert.Nil(t, err)
+	assert.True(t, allowed)
+	allowed, err = m.VerifyPutObjectPermission(ctx, "alice", "bucket", "object")
+	assert.Nil(t, err)
+	assert.False(t, allowed)
+	m.SetDefaultPermission(true, true)
+	allowed, err = m.VerifyPutObjectPermission(ctx, "bob", "bucket", "object")
+	assert.Nil(t, err)
+	assert.True(t, allowed)
+}
+
+func TestMemoryConsensus_Height(t *testing.T) {
+	m := setupMemoryConsensusTest()
+	m.SetHeight(100)
+	m.AdvanceHeight(5)
+	m.SetHeightLag(10)
+	height, err := m.CurrentHeight(context.TODO())
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(95), height)
+	m.SetHeightLag(200)
+	height, err = m.CurrentHeight(context.TODO())
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), height)
+}
+
+func TestMemoryConsensus_Fault(t *testing.T) {
+	m := setupMemoryConsensusTest()
+	ctx := context.TODO()
+	injected := errors.New("rpc unavailable")
+	m.InjectError("QueryBucketInfo", injected, 2)
+	for i := 0; i < 2; i++ {
+		_, err := m.QueryBucketInfo(ctx, "bucket")
+		assert.Equal(t, injected, err)
+	}
+	_, err := m.QueryBucketInfo(ctx, "bucket")
+	assert.Nil(t, err)
+
+	m.InjectError("HasAccount", injected, 0)
+	m.InjectLatency("HasAccount", 20*time.Millisecond)
+	start := time.Now()
+	_, err = m.HasAccount(ctx, "alice")
+	assert.Equal(t, injected, err)
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+	m.ClearFaults()
+	_, err = m.HasAccount(ctx, "alice")
+	assert.Nil(t, err)
+}
+
+func TestMemoryConsensus_ListenObjectSeal(t *testing.T) {
+	m := setupMemoryConsensusTest()
+	sealed, err := m.ListenObjectSeal(context.TODO(), 2, 3)
+	assert.Equal(t, ErrSealTimeout, err)
+	assert.False(t, sealed)
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		assert.Nil(t, m.SetObjectStatus(2, storagetypes.OBJECT_STATUS_SEALED))
+	}()
+	sealed, err = m.ListenObjectSeal(context.TODO(), 2, 1000)
+	assert.Nil(t, err)
+	assert.True(t, sealed)
+	assert.Equal(t, ErrNotFound, m.SetObjectStatus(3, storagetypes.OBJECT_STATUS_SEALED))
+}
@@ -3,6 +3,7 @@ package module
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfspp2p"
 	"github.com/bnb-chain/greenfield-storage-provider/core/lifecycle"
@@ -94,8 +95,9 @@ type Downloader interface {
 	// PreDownloadObject prepares to handle DownloadObject, it can do some checks
 	// Example: check for duplicates, if limit specified by SP is reached, etc.
 	PreDownloadObject(ctx context.Context, task task.DownloadObjectTask) error
-	// HandleDownloadObjectTask handles the DownloadObject, get data from piece store.
-	HandleDownloadObjectTask(ctx context.Context, task task.DownloadObjectTask) ([]byte, error)
+	// HandleDownloadObjectTask handles the DownloadObject, get data from piece store and write
+	// it to the writer by stream.
+	HandleDownloadObjectTask(ctx context.Context, task task.DownloadObjectTask, w io.Writer) error
 	// PostDownloadObject is called after HandleDownloadObjectTask, it can recycle
 	// resources, statistics and other operations.
 	PostDownloadObject(ctx context.Context, task task.DownloadObjectTask)
@@ -118,6 +120,13 @@ type Downloader interface {
 	// PostChallengePiece is called after HandleChallengePiece, it can recycle
 	// resources, statistics and other operations.
 	PostChallengePiece(ctx context.Context, task task.ChallengePieceTask)
+
+	// HandlePresignObjectTask returns the short-lived url to read the object from the
+	// backend storage directly, the url is empty if the object can not be read as is,
+	// e.g. the object has multiple segments or the piece store does not support it.
+	// The read quota is charged only if the url is returned.
+	HandlePresignObjectTask(ctx context.Context, task task.DownloadObjectTask, expire time.Duration,
+		contentDisposition string) (string, error)
 	// QueryTasks queries download/challenge tasks that running on downloader by
 	// task sub key.
 	QueryTasks(ctx context.Context, subKey task.TKey) ([]task.Task, error)
@@ -126,7 +135,7 @@ type Downloader interface {
 // TaskExecutor is the interface to handle background task, it will ask task from
 // manager modular, handle the task and report the result or status to the manager
 // modular includes: ReplicatePieceTask, SealObjectTask, ReceivePieceTask, GCObjectTask
-// GCZombiePieceTask, GCMetaTask.
+// GCZombiePieceTask, GCMetaTask, TierObjectTask.
 type TaskExecutor interface {
 	Modular
 	// AskTask asks the task by remaining limit from manager modular.
@@ -147,6 +156,9 @@ type TaskExecutor interface {
 	HandleGCZombiePieceTask(ctx context.Context, task task.GCZombiePieceTask)
 	// HandleGCMetaTask handles the GCMetaTask that is asked from manager modular.
 	HandleGCMetaTask(ctx context.Context, task task.GCMetaTask)
+	// HandleTierObjectTask handles the TierObjectTask that is asked from manager modular.
+	// It migrates all the pieces of the object to the target piece store tier.
+	HandleTierObjectTask(ctx context.Context, task task.TierObjectTask)
 	// ReportTask reports the result or status of running task to manager modular.
 	ReportTask(ctx context.Context, task task.Task) error
 }
@@ -160,6 +172,9 @@ type Manager interface {
 	DispatchTask(ctx context.Context, limit rcmgr.Limit) (task.Task, error)
 	// QueryTasks queries tasks that hold on manager by task sub key.
 	QueryTasks(ctx context.Context, subKey task.TKey) ([]task.Task, error)
+	// QueryTaskEvents queries the task state transition events that recorded by
+	// manager, if the objectID is empty, returns the events of all objects.
+	QueryTaskEvents(ctx context.Context, objectID string, timeRange *spdb.TaskEventTimeRange) ([]*spdb.TaskEvent, error)
 	// HandleCreateUploadObjectTask handles the CreateUploadObject request from
 	// Uploader, before Uploader handles the user's UploadObject request, it should
 	// send CreateUploadObject request to Manager ask if it's ok. Through this
@@ -192,6 +207,9 @@ type Manager interface {
 	// HandleGCMetaTask handles the result or status GCMetaTask, the request comes
 	// from TaskExecutor.
 	HandleGCMetaTask(ctx context.Context, task task.GCMetaTask) error
+	// HandleTierObjectTask handles the result or status TierObjectTask, the request
+	// comes from TaskExecutor.
+	HandleTierObjectTask(ctx context.Context, task task.TierObjectTask) error
 	// HandleDownloadObjectTask handles the result DownloadObjectTask, the request comes
 	// from Downloader.
 	HandleDownloadObjectTask(ctx context.Context, task task.DownloadObjectTask) error
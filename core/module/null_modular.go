@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"time"
 
 	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfspp2p"
 	"github.com/bnb-chain/greenfield-storage-provider/core/rcmgr"
@@ -68,6 +69,9 @@ func (*NullModular) DispatchTask(context.Context, rcmgr.Limit) (task.Task, error
 func (*NullModular) QueryTask(context.Context, task.TKey) (task.Task, error) {
 	return nil, ErrNilModular
 }
+func (*NullModular) QueryTaskEvents(context.Context, string, *corespdb.TaskEventTimeRange) ([]*corespdb.TaskEvent, error) {
+	return nil, ErrNilModular
+}
 func (*NullModular) HandleCreateUploadObjectTask(context.Context, task.UploadObjectTask) error {
 	return ErrNilModular
 }
@@ -90,6 +94,9 @@ func (*NullModular) HandleGCZombiePieceTask(context.Context, task.GCZombiePieceT
 	return ErrNilModular
 }
 func (*NullModular) HandleGCMetaTask(context.Context, task.GCMetaTask) error { return ErrNilModular }
+func (*NullModular) HandleTierObjectTask(context.Context, task.TierObjectTask) error {
+	return ErrNilModular
+}
 func (*NullModular) HandleDownloadObjectTask(context.Context, task.DownloadObjectTask) error {
 	return ErrNilModular
 }
@@ -132,8 +139,8 @@ func (*NilModular) QueryTasks(ctx context.Context, keyPrefix task.TKey) ([]task.
 func (*NilModular) PreDownloadObject(context.Context, task.DownloadObjectTask) error {
 	return ErrNilModular
 }
-func (*NilModular) HandleDownloadObjectTask(context.Context, task.DownloadObjectTask) ([]byte, error) {
-	return nil, ErrNilModular
+func (*NilModular) HandleDownloadObjectTask(context.Context, task.DownloadObjectTask, io.Writer) error {
+	return ErrNilModular
 }
 func (*NilModular) PostDownloadObject(context.Context, task.DownloadObjectTask) {}
 
@@ -151,6 +158,9 @@ func (*NilModular) PreChallengePiece(context.Context, task.ChallengePieceTask) e
 func (*NilModular) HandleChallengePiece(context.Context, task.ChallengePieceTask) ([]byte, [][]byte, []byte, error) {
 	return nil, nil, nil, ErrNilModular
 }
+func (*NilModular) HandlePresignObjectTask(context.Context, task.DownloadObjectTask, time.Duration, string) (string, error) {
+	return "", ErrNilModular
+}
 func (*NilModular) AskTask(context.Context, rcmgr.Limit)                              {}
 func (*NilModular) PostChallengePiece(context.Context, task.ChallengePieceTask)       {}
 func (*NilModular) ReportTask(context.Context, task.Task) error                       { return ErrNilModular }
@@ -160,6 +170,7 @@ func (*NilModular) HandleReceivePieceTask(context.Context, task.ReceivePieceTask
 func (*NilModular) HandleGCObjectTask(context.Context, task.GCObjectTask)             {}
 func (*NilModular) HandleGCZombiePieceTask(context.Context, task.GCZombiePieceTask)   {}
 func (*NilModular) HandleGCMetaTask(context.Context, task.GCMetaTask)                 {}
+func (*NilModular) HandleTierObjectTask(context.Context, task.TierObjectTask)         {}
 func (*NilModular) HandleReplicatePieceApproval(context.Context, task.ApprovalReplicatePieceTask, int32, int32, int64) ([]task.ApprovalReplicatePieceTask, error) {
 	return nil, ErrNilModular
 }
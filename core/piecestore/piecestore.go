@@ -2,6 +2,8 @@ package piecestore
 
 import (
 	"context"
+	"io"
+	"time"
 )
 
 // PieceOp is the helper interface for piece key operator and piece size calculate.
@@ -27,6 +29,19 @@ type PieceOp interface {
 	ECPieceSize(payloadSize uint64, segmentIdx uint32, maxSegmentSize uint64, chunkNum uint32) int64
 }
 
+const (
+	// HotTier defines the piece store tier of the frequently read objects, the pieces
+	// are stored in the hot tier by default.
+	HotTier = "hot"
+	// ColdTier defines the piece store tier of the objects that are not read for a long
+	// time, it is usually backed by the cheaper storage.
+	ColdTier = "cold"
+)
+
+// StreamBufferSize defines the buffer size of reading and writing the piece data by stream,
+// the callers of the streaming api only hold the buffer instead of the whole piece in memory.
+const StreamBufferSize = 64 * 1024
+
 // PieceStore is the interface to piece store that store the object payload data.
 type PieceStore interface {
 	// GetPiece returns the piece data from piece store by piece key.
@@ -35,7 +50,45 @@ type PieceStore interface {
 	// PutPiece puts the piece data to piece store, it can put segment
 	// or ec piece data.
 	PutPiece(ctx context.Context, key string, value []byte) error
+	// GetPieceReader returns the reader of the piece data from piece store by piece key,
+	// the caller should close the reader after reading.
+	GetPieceReader(ctx context.Context, key string, offset, limit int64) (io.ReadCloser, error)
+	// PutPieceReader puts the piece data read from the reader to piece store until EOF.
+	PutPieceReader(ctx context.Context, key string, reader io.Reader) error
 	// DeletePiece deletes the piece data from piece store, it can delete
 	// segment or ec piece data.
 	DeletePiece(ctx context.Context, key string) error
+	// DeletePieces deletes the pieces from piece store in batch, returns the errors
+	// of the pieces that fail to be deleted, so that the caller can retry them.
+	DeletePieces(ctx context.Context, keys []string) map[string]error
+	// HeadPiece returns the size of the piece data in piece store, returns
+	// error if the piece does not exist.
+	HeadPiece(ctx context.Context, key string) (int64, error)
+	// PresignGetPiece returns the short-lived url to read the piece from the backend storage
+	// directly, the response headers of the url are overridden by the non-empty contentType
+	// and contentDisposition. It returns error if the backend storage does not support it or
+	// the piece can not be read as is.
+	PresignGetPiece(ctx context.Context, key string, expire time.Duration, contentType, contentDisposition string) (
+		string, error)
+	// Capacity returns the total and available bytes of the piece store, returns
+	// error if the backend storage does not support to probe the capacity.
+	Capacity(ctx context.Context) (uint64, uint64, error)
+	// CopyPieceToTier copies the piece data to the piece store tier, it is used to
+	// migrate the pieces of the object between the hot and cold tiers.
+	CopyPieceToTier(ctx context.Context, key string, tier string) error
+	// DeletePieceFromTier deletes the piece data from the piece store tier, it is used
+	// to delete the source pieces after migrating the object to the other tier.
+	DeletePieceFromTier(ctx context.Context, key string, tier string) error
+	// SetObjectTier records the tier of the object after all its pieces are copied to the
+	// tier, the reads of the object are routed to the tier after it.
+	SetObjectTier(objectID uint64, tier string) error
+	// CompactPacks compacts the pack files that have too many deleted pieces if packing
+	// the small pieces is enabled, it should be called by only one module because all
+	// the modules share the pack index.
+	CompactPacks(ctx context.Context)
+	// RemoveOrphanFiles removes the orphan files left by the writes interrupted by crash if
+	// the backend storage is the disk file, and the raw pieces shadowed by the deduplicated
+	// ones if dedup is enabled, it should be called by only one module because it walks the
+	// whole storage.
+	RemoveOrphanFiles(ctx context.Context)
 }
@@ -87,3 +87,116 @@ type OffChainAuthKey struct {
 	CreatedTime  time.Time
 	ModifiedTime time.Time
 }
+
+// TaskEventType defines the task state transition type.
+type TaskEventType string
+
+const (
+	// TaskEventCreated defines the task is created and pushed to queue.
+	TaskEventCreated TaskEventType = "created"
+	// TaskEventDispatched defines the task is dispatched to the executor.
+	TaskEventDispatched TaskEventType = "dispatched"
+	// TaskEventRetried defines the failed task is pushed to queue again to retry.
+	TaskEventRetried TaskEventType = "retried"
+	// TaskEventErrored defines the task is reported with error.
+	TaskEventErrored TaskEventType = "errored"
+	// TaskEventSucceeded defines the task is reported successfully.
+	TaskEventSucceeded TaskEventType = "succeeded"
+	// TaskEventRetired defines the task is removed from queue due to expired or exceed retry.
+	TaskEventRetired TaskEventType = "retired"
+)
+
+// TaskEvent defines a task state transition record, it is used to audit the task lifecycle.
+type TaskEvent struct {
+	TaskKey          string
+	TaskType         string
+	ObjectID         uint64
+	Event            TaskEventType
+	Address          string // Address is the executor address that the task is dispatched to or reported from.
+	Retry            int64
+	ErrorDescription string
+	TimestampSecond  int64
+}
+
+// TaskEventTimeRange is used by query, return events in [StartTimestampSecond, EndTimestampSecond).
+type TaskEventTimeRange struct {
+	StartTimestampSecond int64
+	EndTimestampSecond   int64
+	LimitNum             int // is unlimited if LimitNum <= 0.
+}
+
+// PieceUsage defines the bytes of the pieces of an object stored in piece store.
+type PieceUsage struct {
+	ObjectID       uint64
+	ReplicateIdx   int32 // ReplicateIdx is -1 if the pieces are the primary segment pieces, otherwise is the secondary index.
+	BucketName     string
+	PaymentAccount string
+	Size           int64
+}
+
+// PieceUsageStat defines the aggregated piece usage grouped by bucket or payment account.
+type PieceUsageStat struct {
+	Key           string // Key is the bucket name or the payment account.
+	ObjectCount   int64
+	PrimarySize   int64 // PrimarySize is the bytes of the primary segment pieces.
+	SecondarySize int64 // SecondarySize is the bytes of the secondary ec or replica pieces.
+}
+
+// PackedPiece defines the location of a small piece that is packed in a pack file.
+type PackedPiece struct {
+	PieceKey string
+	PackKey  string
+	Offset   int64
+	Length   int64
+	// PrevPackKey is only used by compaction, the piece is moved only if it is still
+	// in the previous pack, otherwise it has been deleted or overwritten.
+	PrevPackKey string
+}
+
+// PackFile defines a pack file which is composed of many small pieces.
+type PackFile struct {
+	PackKey               string
+	Size                  int64
+	LiveSize              int64 // LiveSize is the bytes of the pieces that are not deleted or overwritten.
+	CreateTimestampSecond int64
+}
+
+// DedupPiece defines the mapping from a deduplicated piece to the content that stores its data.
+type DedupPiece struct {
+	PieceKey   string
+	ContentKey string
+	ObjectKey  string // ObjectKey is the key of the data of the content in the object storage.
+	Size       int64
+}
+
+// DedupContent defines the deduplicated content which is shared by the pieces of the same data.
+type DedupContent struct {
+	ContentKey            string
+	ObjectKey             string // ObjectKey is the key of the data of the content in the object storage.
+	Size                  int64
+	RefCount              int64 // RefCount is the number of the pieces that reference the content.
+	CreateTimestampSecond int64
+}
+
+// DiscontinueBucketCursor defines the position of the last listed bucket of the discontinue
+// policy, the next round lists the buckets after it.
+type DiscontinueBucketCursor struct {
+	CreateAt int64 // CreateAt is the create timestamp of the last listed bucket.
+	BucketID int64
+}
+
+// DiscontinueBucketFlag defines the bucket flagged by the operator to be discontinued.
+type DiscontinueBucketFlag struct {
+	BucketName            string
+	Rule                  string // Rule is the discontinue rule that the flag matches, e.g. admin_request.
+	Reason                string
+	CreateTimestampSecond int64
+}
+
+// GCZombiePiece defines the piece that fails to be deleted by gc object task.
+type GCZombiePiece struct {
+	PieceKey              string
+	ObjectID              uint64
+	ErrorDescription      string
+	CreateTimestampSecond int64
+}
@@ -23,6 +23,9 @@ type UploadObjectProgressDB interface {
 	// GetUploadMetasToSeal queries the latest replicate_done/seal_doing object to continue seal.
 	// It is only used in startup.
 	GetUploadMetasToSeal(limit int) ([]*UploadObjectMeta, error)
+	// GetUploadMetasToReject queries the unsealed objects whose upload progress is not updated since
+	// updatedBefore, the oldest first. It is used by the reject unseal sweeper.
+	GetUploadMetasToReject(updatedBefore int64, limit int) ([]*UploadObjectMeta, error)
 }
 
 // GCObjectProgressDB interface which records gc object related progress.
@@ -38,6 +41,123 @@ type GCObjectProgressDB interface {
 	GetGCMetasToGC(limit int) ([]*GCObjectMeta, error)
 }
 
+// GCZombiePieceDB interface which records the pieces that fail to be deleted by gc, they
+// are left for the later zombie piece cleanup instead of being dropped.
+type GCZombiePieceDB interface {
+	// InsertGCZombiePieces inserts or updates the zombie pieces.
+	InsertGCZombiePieces(pieces []*GCZombiePiece) error
+	// ListGCZombiePieces returns the earliest recorded zombie pieces.
+	ListGCZombiePieces(limit int) ([]*GCZombiePiece, error)
+	// DeleteGCZombiePieces deletes the records of the zombie pieces.
+	DeleteGCZombiePieces(pieceKeys []string) error
+}
+
+// DiscontinueBucketFlagDB interface which records the buckets flagged by the operators to be
+// discontinued, such as the policy violations and the explicit admin requests.
+type DiscontinueBucketFlagDB interface {
+	// InsertDiscontinueBucketFlag inserts or updates the flag of the bucket.
+	InsertDiscontinueBucketFlag(flag *DiscontinueBucketFlag) error
+	// ListDiscontinueBucketFlags returns the earliest flagged buckets.
+	ListDiscontinueBucketFlags(limit int) ([]*DiscontinueBucketFlag, error)
+	// DeleteDiscontinueBucketFlag deletes the flag of the bucket.
+	DeleteDiscontinueBucketFlag(bucketName string) error
+	// GetDiscontinueBucketCursor returns the cursor of the listed buckets, returns the zero cursor
+	// if it is not recorded.
+	GetDiscontinueBucketCursor() (*DiscontinueBucketCursor, error)
+	// UpdateDiscontinueBucketCursor inserts or updates the cursor of the listed buckets.
+	UpdateDiscontinueBucketCursor(cursor *DiscontinueBucketCursor) error
+}
+
+// TaskEventDB interface which records the task state transitions, it is used to
+// audit the task lifecycle after the task leaves the queue.
+type TaskEventDB interface {
+	// InsertTaskEvents inserts the task state transition events in batch.
+	InsertTaskEvents(events []*TaskEvent) error
+	// DeleteTaskEventsBefore deletes at most limit task events created before the timestamp,
+	// returns the number of the deleted events.
+	DeleteTaskEventsBefore(timestampSecond int64, limit int) (int64, error)
+	// GetTaskEventsByObjectID return the task events of the object by time range.
+	GetTaskEventsByObjectID(objectID uint64, timeRange *TaskEventTimeRange) ([]*TaskEvent, error)
+	// GetTaskEventsByTimeRange return the task events of all objects by time range.
+	GetTaskEventsByTimeRange(timeRange *TaskEventTimeRange) ([]*TaskEvent, error)
+}
+
+// PieceUsageDB interface which records the bytes of the pieces stored in piece store,
+// it is used to account the piece store usage by bucket and payment account.
+type PieceUsageDB interface {
+	// SetPieceUsage inserts or updates the piece usage of the object by object id and replicate idx.
+	SetPieceUsage(usage *PieceUsage) error
+	// DeletePieceUsage deletes the piece usage of the object by object id and replicate idx.
+	DeletePieceUsage(objectID uint64, replicateIdx int32) error
+	// GetPieceUsageByObjectID returns all the piece usages of the object.
+	GetPieceUsageByObjectID(objectID uint64) ([]*PieceUsage, error)
+	// GetPieceUsageStatsByBucket returns the piece usage stats grouped by bucket, only
+	// returns the stat of the bucket if bucketName is not empty.
+	GetPieceUsageStatsByBucket(bucketName string) ([]*PieceUsageStat, error)
+	// GetPieceUsageStatsByPaymentAccount returns the piece usage stats grouped by payment
+	// account, only returns the stat of the payment account if paymentAccount is not empty.
+	GetPieceUsageStatsByPaymentAccount(paymentAccount string) ([]*PieceUsageStat, error)
+}
+
+// ObjectTierDB interface which records the piece store tier of the objects, the pieces
+// of the object without tier record are stored in the hot tier.
+type ObjectTierDB interface {
+	// GetObjectTier returns the piece store tier of the object, returns the empty string
+	// if the object has no tier record.
+	GetObjectTier(objectID uint64) (string, error)
+	// SetObjectTier inserts or updates the piece store tier of the object.
+	SetObjectTier(objectID uint64, tier string) error
+	// DeleteObjectTier deletes the tier record of the object.
+	DeleteObjectTier(objectID uint64) error
+	// GetObjectsToDemote returns the objects that are stored in piece store but not in
+	// the tier, and have been neither written nor read since the timestamp.
+	GetObjectsToDemote(tier string, sinceTimestampSecond int64, limit int) ([]uint64, error)
+	// GetObjectsToPromote returns the objects that are in the tier and have been read at
+	// least minReadCount times since the timestamp.
+	GetObjectsToPromote(tier string, sinceTimestampSecond int64, minReadCount int, limit int) ([]uint64, error)
+}
+
+// PackDB interface which is the index of the small pieces packed in pack files.
+type PackDB interface {
+	// InsertPack inserts the pack file and the index of the pieces in it, the previous
+	// location of the overwritten pieces becomes garbage of the previous pack.
+	InsertPack(pack *PackFile, pieces []*PackedPiece) error
+	// GetPackedPiece returns the location of the piece, returns nil if the piece is not packed.
+	GetPackedPiece(pieceKey string) (*PackedPiece, error)
+	// DeletePackedPiece deletes the index of the piece, returns false if the piece is not packed.
+	DeletePackedPiece(pieceKey string) (bool, error)
+	// ListPackedPieces returns all the pieces in the pack file.
+	ListPackedPieces(packKey string) ([]*PackedPiece, error)
+	// GetPacksToCompact returns the pack files whose live size ratio is less than liveRatio.
+	GetPacksToCompact(liveRatio float64, limit int) ([]*PackFile, error)
+	// DeletePackFile deletes the pack file record.
+	DeletePackFile(packKey string) error
+}
+
+// DedupDB interface which maps the deduplicated pieces to the contents keyed by checksum,
+// and counts the references of the contents.
+type DedupDB interface {
+	// GetDedupPiece returns the content mapping of the piece, returns nil if the piece is not deduplicated.
+	GetDedupPiece(pieceKey string) (*DedupPiece, error)
+	// GetDedupContent returns the content, returns nil if the content does not exist. The content
+	// whose reference count is zero is being released.
+	GetDedupContent(contentKey string) (*DedupContent, error)
+	// InsertDedupPiece maps the piece to the content and increases the reference count of the
+	// content, the content is created if it is not referenced before, and the data of the created
+	// content is the object of the piece. If the piece is overwritten, the reference count of the
+	// previous content is decreased. It returns whether the content is created and the previous
+	// content key if the previous content is no longer referenced.
+	InsertDedupPiece(piece *DedupPiece) (created bool, released string, err error)
+	// DeleteDedupPiece deletes the content mapping of the piece and decreases the reference count
+	// of the content. It returns false if the piece is not deduplicated, and the content key if
+	// the content is no longer referenced.
+	DeleteDedupPiece(pieceKey string) (found bool, released string, err error)
+	// ReleaseDedupContent deletes the content record if it is no longer referenced, the record is
+	// locked while deleteFn deletes the data of the content. It returns false if the content is
+	// referenced again.
+	ReleaseDedupContent(contentKey string, deleteFn func(objectKey string) error) (released bool, err error)
+}
+
 // SignatureDB abstract object integrity interface.
 type SignatureDB interface {
 	/*
@@ -108,6 +228,13 @@ type OffChainAuthKeyDB interface {
 type SPDB interface {
 	UploadObjectProgressDB
 	GCObjectProgressDB
+	GCZombiePieceDB
+	DiscontinueBucketFlagDB
+	TaskEventDB
+	PieceUsageDB
+	ObjectTierDB
+	PackDB
+	DedupDB
 	SignatureDB
 	TrafficDB
 	SPInfoDB
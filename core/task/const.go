@@ -44,6 +44,9 @@ const (
 	TypeTaskGCZombiePiece
 	// TypeTaskGCMeta defines the type of collecting SP metadata task.
 	TypeTaskGCMeta
+	// TypeTaskTierObject defines the type of migrating object pieces between the piece
+	// store tiers task.
+	TypeTaskTierObject
 )
 
 var TypeTaskMap = map[TType]string{
@@ -60,6 +63,7 @@ var TypeTaskMap = map[TType]string{
 	TypeTaskGCObject:               "GCObjectTask",
 	TypeTaskGCZombiePiece:          "GCZombiePieceTask",
 	TypeTaskGCMeta:                 "GCMetaTask",
+	TypeTaskTierObject:             "TierObjectTask",
 }
 
 func TaskTypeName(taskType TType) string {
@@ -271,6 +271,10 @@ type UploadObjectTask interface {
 	ObjectTask
 	// InitUploadObjectTask inits the UploadObjectTask by ObjectInfo and Params.
 	InitUploadObjectTask(object *storagetypes.ObjectInfo, params *storagetypes.Params, timeout int64)
+	// GetBucketInfo returns the bucket of the object, it is used to account the piece usage.
+	GetBucketInfo() *storagetypes.BucketInfo
+	// SetBucketInfo sets the bucket of the object.
+	SetBucketInfo(*storagetypes.BucketInfo)
 }
 
 // The ReplicatePieceTask is the interface to record the information for replicating
@@ -298,6 +302,11 @@ type ReplicatePieceTask interface {
 	GetSecondarySignatures() [][]byte
 	// SetSecondarySignatures sets the secondary SP's signatures.
 	SetSecondarySignatures([][]byte)
+	// GetBucketInfo returns the bucket of the object, it is passed to the secondary SPs
+	// to account the piece usage.
+	GetBucketInfo() *storagetypes.BucketInfo
+	// SetBucketInfo sets the bucket of the object.
+	SetBucketInfo(*storagetypes.BucketInfo)
 }
 
 // The ReceivePieceTask is the interface to record the information for receiving pieces
@@ -340,6 +349,11 @@ type ReceivePieceTask interface {
 	GetSealed() bool
 	// SetSealed sets the object of receiving piece data whether is successfully sealed.
 	SetSealed(bool)
+	// GetBucketInfo returns the bucket of the object, it is used to account the piece usage
+	// and is not signed by the primary SP.
+	GetBucketInfo() *storagetypes.BucketInfo
+	// SetBucketInfo sets the bucket of the object.
+	SetBucketInfo(*storagetypes.BucketInfo)
 }
 
 // The SealObjectTask is the interface to  record the information for sealing object to
@@ -356,6 +370,21 @@ type SealObjectTask interface {
 	GetSecondarySignatures() [][]byte
 }
 
+// The TierObjectTask is the interface to record the information for migrating all the
+// pieces of the object between the hot and cold piece store tiers, the cold objects are
+// demoted to the cold tier and the frequently read objects are promoted to the hot tier.
+type TierObjectTask interface {
+	ObjectTask
+	// InitTierObjectTask inits the TierObjectTask by ObjectInfo, params, the target tier,
+	// task priority, timeout and max retry.
+	InitTierObjectTask(object *storagetypes.ObjectInfo, params *storagetypes.Params, tier string,
+		priority TPriority, timeout int64, retry int64)
+	// GetTargetTier returns the tier that the object pieces are migrated to.
+	GetTargetTier() string
+	// SetTargetTier sets the tier that the object pieces are migrated to.
+	SetTargetTier(string)
+}
+
 // The DownloadObjectTask is the interface to record the information for downloading
 // pieces of object payload data.
 type DownloadObjectTask interface {
@@ -44,8 +44,9 @@ var (
 var _ module.Authorizer = &AuthorizeModular{}
 
 type AuthorizeModular struct {
-	baseApp *gfspapp.GfSpBaseApp
-	scope   rcmgr.ResourceScope
+	baseApp    *gfspapp.GfSpBaseApp
+	scope      rcmgr.ResourceScope
+	permission *permissionVerifier
 }
 
 func (a *AuthorizeModular) Name() string {
@@ -219,9 +220,9 @@ func (a *AuthorizeModular) VerifyAuthorize(
 			log.CtxErrorw(ctx, "object state is not sealed", "state", objectInfo.GetObjectStatus())
 			return false, ErrNotCreatedState
 		}
-		allow, err := a.baseApp.Consensus().VerifyPutObjectPermission(ctx, account, bucket, object)
+		allow, err := a.permission.VerifyPutObjectPermission(ctx, account, bucket, object)
 		if err != nil {
-			log.CtxErrorw(ctx, "failed to verify put object permission from consensus", "error", err)
+			log.CtxErrorw(ctx, "failed to verify put object permission", "error", err)
 			return false, ErrConsensus
 		}
 		return allow, nil
@@ -247,9 +248,9 @@ func (a *AuthorizeModular) VerifyAuthorize(
 			log.CtxErrorw(ctx, "object state is not created", "state", objectInfo.GetObjectStatus())
 			return false, ErrNotCreatedState
 		}
-		allow, err := a.baseApp.Consensus().VerifyPutObjectPermission(ctx, account, bucket, object)
+		allow, err := a.permission.VerifyPutObjectPermission(ctx, account, bucket, object)
 		if err != nil {
-			log.CtxErrorw(ctx, "failed to verify put object permission from consensus", "error", err)
+			log.CtxErrorw(ctx, "failed to verify put object permission", "error", err)
 			return false, ErrConsensus
 		}
 		return allow, nil
@@ -284,9 +285,9 @@ func (a *AuthorizeModular) VerifyAuthorize(
 			log.CtxErrorw(ctx, "failed to check payment due to account status is not active", "status", streamRecord.Status)
 			return false, ErrPaymentState
 		}
-		allow, err := a.baseApp.Consensus().VerifyGetObjectPermission(ctx, account, bucket, object)
+		allow, err := a.permission.VerifyGetObjectPermission(ctx, account, bucket, object)
 		if err != nil {
-			log.CtxErrorw(ctx, "failed to get bucket and object info from consensus", "error", err)
+			log.CtxErrorw(ctx, "failed to verify get object permission", "error", err)
 			return false, ErrConsensus
 		}
 		return allow, nil
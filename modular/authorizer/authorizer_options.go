@@ -1,6 +1,8 @@
 package authorizer
 
 import (
+	"time"
+
 	"github.com/bnb-chain/greenfield-storage-provider/base/gfspapp"
 	"github.com/bnb-chain/greenfield-storage-provider/base/gfspconfig"
 	coremodule "github.com/bnb-chain/greenfield-storage-provider/core/module"
@@ -8,5 +10,28 @@ import (
 
 func NewAuthorizeModular(app *gfspapp.GfSpBaseApp, cfg *gfspconfig.GfSpConfig) (coremodule.Modular, error) {
 	authorize := &AuthorizeModular{baseApp: app}
+	if err := DefaultAuthorizerOptions(authorize, cfg); err != nil {
+		return nil, err
+	}
 	return authorize, nil
 }
+
+func DefaultAuthorizerOptions(authorize *AuthorizeModular, cfg *gfspconfig.GfSpConfig) error {
+	if cfg.Authorizer.MetadataMaxBlockLag == 0 {
+		cfg.Authorizer.MetadataMaxBlockLag = DefaultMetadataMaxBlockLag
+	}
+	if cfg.Authorizer.MetadataLagCheckInterval == 0 {
+		cfg.Authorizer.MetadataLagCheckInterval = DefaultMetadataLagCheckInterval
+	}
+	authorize.permission = &permissionVerifier{
+		chain:                authorize.baseApp.Consensus(),
+		maxBlockLag:          cfg.Authorizer.MetadataMaxBlockLag,
+		lagCheckInterval:     time.Duration(cfg.Authorizer.MetadataLagCheckInterval) * time.Second,
+		consistencyCheckRate: cfg.Authorizer.ConsistencyCheckRate,
+		consistencyChecks:    make(chan struct{}, permissionConsistencyCheckConcurrency),
+	}
+	if cfg.Authorizer.MetadataPermissionEnabled {
+		authorize.permission.index = authorize.baseApp.GfSpClient()
+	}
+	return nil
+}
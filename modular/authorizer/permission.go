@@ -0,0 +1,244 @@
+package authorizer
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	permissiontypes "github.com/bnb-chain/greenfield/x/permission/types"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/grpc"
+
+	"github.com/bnb-chain/greenfield-storage-provider/core/consensus"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/metrics"
+)
+
+const (
+	// DefaultMetadataMaxBlockLag defines the default max number of blocks that the metadata index
+	// can lag behind the chain to verify the permissions
+	DefaultMetadataMaxBlockLag = 5
+	// DefaultMetadataLagCheckInterval defines the default seconds that the checked lag of the
+	// metadata index is reused
+	DefaultMetadataLagCheckInterval = 2
+
+	// permissionConsistencyCheckTimeout defines the timeout of verifying the sampled permission
+	// by the chain
+	permissionConsistencyCheckTimeout = 10 * time.Second
+	// permissionConsistencyCheckConcurrency defines the max number of the running consistency
+	// checks, the sampled verifications are not checked if the limit is reached
+	permissionConsistencyCheckConcurrency = 16
+	// permissionLagCheckTimeout defines the timeout of checking the lag of the metadata index
+	permissionLagCheckTimeout = 5 * time.Second
+
+	permissionSourceChain    = "chain"
+	permissionSourceMetadata = "metadata"
+)
+
+// permissionIndex is the metadata service that evaluates the permissions by the policies indexed
+// from the chain.
+type permissionIndex interface {
+	VerifyPermission(ctx context.Context, operator string, bucketName string, objectName string,
+		actionType permissiontypes.ActionType, opts ...grpc.DialOption) (*permissiontypes.Effect, error)
+	GetLatestBlockNumber(ctx context.Context, opts ...grpc.DialOption) (int64, error)
+}
+
+// permissionVerifier verifies the object permissions by the metadata index if the index is caught
+// up with the chain, and falls back to the chain if the index lags behind or fails. The sampled
+// verifications of the index are compared with the chain in the background, and the disagreements
+// are logged.
+type permissionVerifier struct {
+	chain consensus.Consensus
+	// index is nil if the metadata permission is disabled
+	index                permissionIndex
+	maxBlockLag          uint64
+	lagCheckInterval     time.Duration
+	consistencyCheckRate float64
+	// consistencyChecks is the semaphore of the running consistency checks, the checks are
+	// disabled if it is nil
+	consistencyChecks chan struct{}
+
+	// lagCheck shares one lag check among the concurrent verifications
+	lagCheck    singleflight.Group
+	mu          sync.Mutex
+	caughtUp    bool
+	indexHeight uint64
+	checkedAt   time.Time
+}
+
+// VerifyGetObjectPermission verifies the permission of getting the object.
+func (v *permissionVerifier) VerifyGetObjectPermission(ctx context.Context, account, bucket, object string) (
+	bool, error) {
+	return v.verify(ctx, account, bucket, object, permissiontypes.ACTION_GET_OBJECT)
+}
+
+// VerifyPutObjectPermission verifies the permission of creating the object in the bucket.
+func (v *permissionVerifier) VerifyPutObjectPermission(ctx context.Context, account, bucket, object string) (
+	bool, error) {
+	return v.verify(ctx, account, bucket, object, permissiontypes.ACTION_CREATE_OBJECT)
+}
+
+func (v *permissionVerifier) verify(ctx context.Context, account, bucket, object string,
+	action permissiontypes.ActionType) (bool, error) {
+	var (
+		indexHeight uint64
+		caughtUp    bool
+	)
+	if v.index != nil {
+		indexHeight, caughtUp = v.indexCaughtUp(ctx)
+	}
+	if caughtUp {
+		allow, err := v.verifyByIndex(ctx, account, bucket, object, action)
+		if err == nil {
+			metrics.AuthorizerPermissionCounter.WithLabelValues(permissionSourceMetadata, effectLabel(allow)).Inc()
+			if v.consistencyCheckRate > 0 && rand.Float64() < v.consistencyCheckRate {
+				v.startConsistencyCheck(account, bucket, object, action, allow, indexHeight)
+			}
+			return allow, nil
+		}
+		log.CtxWarnw(ctx, "failed to verify permission by metadata, fall back to chain", "account", account,
+			"bucket_name", bucket, "object_name", object, "action", action.String(), "error", err)
+		metrics.AuthorizerPermissionCounter.WithLabelValues(permissionSourceMetadata, "error").Inc()
+	}
+	allow, err := v.verifyByChain(ctx, account, bucket, object, action)
+	if err != nil {
+		metrics.AuthorizerPermissionCounter.WithLabelValues(permissionSourceChain, "error").Inc()
+		return false, err
+	}
+	metrics.AuthorizerPermissionCounter.WithLabelValues(permissionSourceChain, effectLabel(allow)).Inc()
+	return allow, nil
+}
+
+func (v *permissionVerifier) verifyByIndex(ctx context.Context, account, bucket, object string,
+	action permissiontypes.ActionType) (bool, error) {
+	if action == permissiontypes.ACTION_CREATE_OBJECT {
+		// the put permission is granted on the bucket, the same as the verification on chain
+		object = ""
+	}
+	effect, err := v.index.VerifyPermission(ctx, account, bucket, object, action)
+	if err != nil {
+		return false, err
+	}
+	return *effect == permissiontypes.EFFECT_ALLOW, nil
+}
+
+func (v *permissionVerifier) verifyByChain(ctx context.Context, account, bucket, object string,
+	action permissiontypes.ActionType) (bool, error) {
+	if action == permissiontypes.ACTION_CREATE_OBJECT {
+		return v.chain.VerifyPutObjectPermission(ctx, account, bucket, object)
+	}
+	return v.chain.VerifyGetObjectPermission(ctx, account, bucket, object)
+}
+
+// indexCaughtUp returns the block height of the metadata index and whether it lags behind the
+// chain within the max block lag, the result is reused in the lag check interval. The concurrent
+// verifications share one lag check.
+func (v *permissionVerifier) indexCaughtUp(ctx context.Context) (uint64, bool) {
+	v.mu.Lock()
+	if time.Since(v.checkedAt) < v.lagCheckInterval {
+		indexHeight, caughtUp := v.indexHeight, v.caughtUp
+		v.mu.Unlock()
+		return indexHeight, caughtUp
+	}
+	v.mu.Unlock()
+
+	_, _, _ = v.lagCheck.Do("lag", func() (interface{}, error) {
+		// the lag check is shared, it is not canceled by the verification that starts it
+		checkCtx, cancel := context.WithTimeout(context.Background(), permissionLagCheckTimeout)
+		defer cancel()
+		v.checkLag(checkCtx)
+		return nil, nil
+	})
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.indexHeight, v.caughtUp
+}
+
+func (v *permissionVerifier) checkLag(ctx context.Context) {
+	caughtUp := false
+	chainHeight, err := v.chain.CurrentHeight(ctx)
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to get chain height to check metadata lag", "error", err)
+	}
+	indexHeight, indexErr := v.index.GetLatestBlockNumber(ctx)
+	if indexErr != nil {
+		log.CtxErrorw(ctx, "failed to get metadata block number to check metadata lag", "error", indexErr)
+	}
+	if err == nil && indexErr == nil && indexHeight >= 0 {
+		var lag uint64
+		if chainHeight > uint64(indexHeight) {
+			lag = chainHeight - uint64(indexHeight)
+		}
+		metrics.AuthorizerMetadataBlockLagGauge.Set(float64(lag))
+		caughtUp = lag <= v.maxBlockLag
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if caughtUp != v.caughtUp {
+		log.CtxInfow(ctx, "metadata permission state changed", "caught_up", caughtUp,
+			"chain_height", chainHeight, "metadata_height", indexHeight)
+	}
+	v.caughtUp = caughtUp
+	if caughtUp {
+		v.indexHeight = uint64(indexHeight)
+	}
+	v.checkedAt = time.Now()
+}
+
+// startConsistencyCheck checks the consistency in the background if the number of the running
+// checks is under the limit, otherwise the check is skipped.
+func (v *permissionVerifier) startConsistencyCheck(account, bucket, object string,
+	action permissiontypes.ActionType, indexAllow bool, indexHeight uint64) {
+	select {
+	case v.consistencyChecks <- struct{}{}:
+	default:
+		metrics.AuthorizerPermissionConsistencyCounter.WithLabelValues("skipped").Inc()
+		return
+	}
+	go func() {
+		defer func() { <-v.consistencyChecks }()
+		v.checkConsistency(account, bucket, object, action, indexAllow, indexHeight)
+	}()
+}
+
+// checkConsistency verifies the permission by the chain and logs the disagreement with the
+// metadata index. The disagreement is labeled as lagging if the chain has blocks after the
+// index height that the index result is based on, the permission may be changed by them.
+func (v *permissionVerifier) checkConsistency(account, bucket, object string, action permissiontypes.ActionType,
+	indexAllow bool, indexHeight uint64) {
+	ctx, cancel := context.WithTimeout(context.Background(), permissionConsistencyCheckTimeout)
+	defer cancel()
+	chainAllow, err := v.verifyByChain(ctx, account, bucket, object, action)
+	if err != nil {
+		log.CtxWarnw(ctx, "failed to verify permission by chain to check consistency", "account", account,
+			"bucket_name", bucket, "object_name", object, "action", action.String(), "error", err)
+		metrics.AuthorizerPermissionConsistencyCounter.WithLabelValues("error").Inc()
+		return
+	}
+	if chainAllow == indexAllow {
+		metrics.AuthorizerPermissionConsistencyCounter.WithLabelValues("match").Inc()
+		return
+	}
+	chainHeight, err := v.chain.CurrentHeight(ctx)
+	if err == nil && chainHeight > indexHeight {
+		log.CtxWarnw(ctx, "permission of lagging metadata disagrees with chain", "account", account,
+			"bucket_name", bucket, "object_name", object, "action", action.String(),
+			"metadata_allow", indexAllow, "chain_allow", chainAllow, "metadata_height", indexHeight,
+			"chain_height", chainHeight)
+		metrics.AuthorizerPermissionConsistencyCounter.WithLabelValues("lagging_mismatch").Inc()
+		return
+	}
+	log.CtxErrorw(ctx, "permission of metadata disagrees with chain", "account", account,
+		"bucket_name", bucket, "object_name", object, "action", action.String(),
+		"metadata_allow", indexAllow, "chain_allow", chainAllow, "metadata_height", indexHeight)
+	metrics.AuthorizerPermissionConsistencyCounter.WithLabelValues("mismatch").Inc()
+}
+
+func effectLabel(allow bool) string {
+	if allow {
+		return "allow"
+	}
+	return "deny"
+}
@@ -0,0 +1,156 @@
+package authorizer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	permissiontypes "github.com/bnb-chain/greenfield/x/permission/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+
+	"github.com/bnb-chain/greenfield-storage-provider/core/consensus"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/metrics"
+)
+
+// mockPermissionIndex allows the accounts in allowed, the verification fails if err is set.
+type mockPermissionIndex struct {
+	mu          sync.Mutex
+	blockNumber int64
+	allowed     map[string]bool
+	err         error
+	calls       int
+	lagChecks   int
+}
+
+func (i *mockPermissionIndex) VerifyPermission(_ context.Context, operator string, _ string, _ string,
+	_ permissiontypes.ActionType, _ ...grpc.DialOption) (*permissiontypes.Effect, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.calls++
+	if i.err != nil {
+		return nil, i.err
+	}
+	effect := permissiontypes.EFFECT_DENY
+	if i.allowed[operator] {
+		effect = permissiontypes.EFFECT_ALLOW
+	}
+	return &effect, nil
+}
+
+func (i *mockPermissionIndex) GetLatestBlockNumber(_ context.Context, _ ...grpc.DialOption) (int64, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.lagChecks++
+	return i.blockNumber, nil
+}
+
+func setupPermissionVerifierTest() (*consensus.MemoryConsensus, *mockPermissionIndex, *permissionVerifier) {
+	chain := consensus.NewMemoryConsensus()
+	chain.SetHeight(100)
+	chain.SetPermission("user", "bucket", "object", true, true)
+	index := &mockPermissionIndex{blockNumber: 98, allowed: map[string]bool{"user": true}}
+	v := &permissionVerifier{
+		chain:       chain,
+		index:       index,
+		maxBlockLag: DefaultMetadataMaxBlockLag,
+	}
+	return chain, index, v
+}
+
+func TestPermissionVerifier_Metadata(t *testing.T) {
+	chain, index, v := setupPermissionVerifierTest()
+	ctx := context.TODO()
+	// the chain is not queried if the metadata index is caught up
+	chain.InjectError("VerifyGetObjectPermission", errors.New("chain unavailable"), 0)
+
+	allow, err := v.VerifyGetObjectPermission(ctx, "user", "bucket", "object")
+	assert.Nil(t, err)
+	assert.True(t, allow)
+	allow, err = v.VerifyGetObjectPermission(ctx, "other", "bucket", "object")
+	assert.Nil(t, err)
+	assert.False(t, allow)
+	assert.Equal(t, 2, index.calls)
+}
+
+func TestPermissionVerifier_Fallback(t *testing.T) {
+	chain, index, v := setupPermissionVerifierTest()
+	ctx := context.TODO()
+
+	// the metadata index lags behind the chain
+	chain.AdvanceHeight(10)
+	allow, err := v.VerifyPutObjectPermission(ctx, "other", "bucket", "object")
+	assert.Nil(t, err)
+	assert.False(t, allow)
+	assert.Equal(t, 0, index.calls)
+
+	// the metadata index fails
+	index.blockNumber = 110
+	v.checkedAt = time.Time{}
+	index.err = errors.New("no such object")
+	allow, err = v.VerifyGetObjectPermission(ctx, "user", "bucket", "object")
+	assert.Nil(t, err)
+	assert.True(t, allow)
+	assert.Equal(t, 1, index.calls)
+
+	// the checked lag is reused in the lag check interval
+	v.lagCheckInterval = time.Hour
+	index.err = nil
+	_, _ = v.VerifyGetObjectPermission(ctx, "user", "bucket", "object")
+	chain.AdvanceHeight(10)
+	_, _ = v.VerifyGetObjectPermission(ctx, "user", "bucket", "object")
+	assert.Equal(t, 3, index.calls)
+}
+
+func TestPermissionVerifier_Consistency(t *testing.T) {
+	_, _, v := setupPermissionVerifierTest()
+	counter := func(result string) float64 {
+		return testutil.ToFloat64(metrics.AuthorizerPermissionConsistencyCounter.WithLabelValues(result))
+	}
+	match, mismatch, lagging := counter("match"), counter("mismatch"), counter("lagging_mismatch")
+
+	v.checkConsistency("user", "bucket", "object", permissiontypes.ACTION_GET_OBJECT, true, 98)
+	v.checkConsistency("user", "bucket", "object", permissiontypes.ACTION_GET_OBJECT, false, 100)
+	assert.Equal(t, match+1, counter("match"))
+	assert.Equal(t, mismatch+1, counter("mismatch"))
+
+	// the disagreement may be caused by the blocks after the index height
+	v.checkConsistency("user", "bucket", "object", permissiontypes.ACTION_GET_OBJECT, false, 98)
+	assert.Equal(t, mismatch+1, counter("mismatch"))
+	assert.Equal(t, lagging+1, counter("lagging_mismatch"))
+}
+
+func TestPermissionVerifier_ConsistencyCheckLimit(t *testing.T) {
+	_, _, v := setupPermissionVerifierTest()
+	v.consistencyChecks = make(chan struct{}, 1)
+	skipped := testutil.ToFloat64(metrics.AuthorizerPermissionConsistencyCounter.WithLabelValues("skipped"))
+
+	// the check is skipped if the running checks reach the limit
+	v.consistencyChecks <- struct{}{}
+	v.startConsistencyCheck("user", "bucket", "object", permissiontypes.ACTION_GET_OBJECT, true, 98)
+	assert.Equal(t, skipped+1,
+		testutil.ToFloat64(metrics.AuthorizerPermissionConsistencyCounter.WithLabelValues("skipped")))
+	<-v.consistencyChecks
+}
+
+func TestPermissionVerifier_SharedLagCheck(t *testing.T) {
+	chain, index, v := setupPermissionVerifierTest()
+	v.lagCheckInterval = time.Hour
+	chain.InjectLatency("CurrentHeight", 50*time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			height, caughtUp := v.indexCaughtUp(context.TODO())
+			assert.True(t, caughtUp)
+			assert.Equal(t, uint64(98), height)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, index.lagChecks)
+}
@@ -3,7 +3,9 @@ package downloader
 import (
 	"context"
 	"errors"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfsperrors"
 	"github.com/bnb-chain/greenfield-storage-provider/core/module"
@@ -13,6 +15,8 @@ import (
 	"github.com/bnb-chain/greenfield-storage-provider/core/task"
 	"github.com/bnb-chain/greenfield-storage-provider/core/taskqueue"
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/tracing"
+	"github.com/bnb-chain/greenfield-storage-provider/store/piecestore/storage"
 	"github.com/bnb-chain/greenfield-storage-provider/store/sqldb"
 	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
 )
@@ -42,7 +46,8 @@ func (d *DownloadModular) PreDownloadObject(ctx context.Context, downloadObjectT
 		return ErrRepeatedTask
 	}
 	// TODO:: spilt check and add record steps, check in pre download, add record in post download
-	if err := d.baseApp.GfSpDB().CheckQuotaAndAddReadRecord(
+	_, dbSpan := tracing.StartSpan(ctx, "SpDB.CheckQuotaAndAddReadRecord")
+	err := d.baseApp.GfSpDB().CheckQuotaAndAddReadRecord(
 		&spdb.ReadRecord{
 			BucketID:        downloadObjectTask.GetBucketInfo().Id.Uint64(),
 			ObjectID:        downloadObjectTask.GetObjectInfo().Id.Uint64(),
@@ -55,7 +60,9 @@ func (d *DownloadModular) PreDownloadObject(ctx context.Context, downloadObjectT
 		&spdb.BucketQuota{
 			ReadQuotaSize: downloadObjectTask.GetBucketInfo().GetChargedReadQuota() + d.bucketFreeQuota,
 		},
-	); err != nil {
+	)
+	tracing.EndSpan(dbSpan, err)
+	if err != nil {
 		log.CtxErrorw(ctx, "failed to check bucket quota", "error", err)
 		if errors.Is(err, sqldb.ErrCheckQuotaEnough) {
 			return ErrExceedBucketQuota
@@ -67,7 +74,8 @@ func (d *DownloadModular) PreDownloadObject(ctx context.Context, downloadObjectT
 	return nil
 }
 
-func (d *DownloadModular) HandleDownloadObjectTask(ctx context.Context, downloadObjectTask task.DownloadObjectTask) ([]byte, error) {
+func (d *DownloadModular) HandleDownloadObjectTask(ctx context.Context, downloadObjectTask task.DownloadObjectTask,
+	w io.Writer) error {
 	var err error
 	defer func() {
 		if err != nil {
@@ -77,25 +85,66 @@ func (d *DownloadModular) HandleDownloadObjectTask(ctx context.Context, download
 	}()
 	if err = d.downloadQueue.Push(downloadObjectTask); err != nil {
 		log.CtxErrorw(ctx, "failed to push download queue", "error", err)
-		return nil, err
+		return err
 	}
 	defer d.downloadQueue.PopByKey(downloadObjectTask.Key())
 	pieceInfos, err := SplitToSegmentPieceInfos(downloadObjectTask, d.baseApp.PieceOp())
 	if err != nil {
 		log.CtxErrorw(ctx, "failed to generate piece info to download", "error", err)
-		return nil, err
+		return err
 	}
-	var data []byte
+	// the pieces are streamed to the writer by the buffer, the memory is the buffer size.
+	buf := make([]byte, piecestore.StreamBufferSize)
 	for _, pInfo := range pieceInfos {
-		piece, err := d.baseApp.PieceStore().GetPiece(ctx, pInfo.SegmentPieceKey,
-			int64(pInfo.Offset), int64(pInfo.Length))
-		if err != nil {
-			log.CtxErrorw(ctx, "failed to get piece data from piece store", "error", err)
-			return nil, ErrPieceStore
+		if err = d.copyPiece(ctx, pInfo, w, buf); err != nil {
+			log.CtxErrorw(ctx, "failed to stream piece data from piece store", "piece_key", pInfo.SegmentPieceKey,
+				"error", err)
+			err = ErrPieceStore
+			return ErrPieceStore
 		}
-		data = append(data, piece...)
 	}
-	return data, nil
+	return nil
+}
+
+// copyPiece streams the segment piece data of the range to the writer by the buffer.
+func (d *DownloadModular) copyPiece(ctx context.Context, pInfo *SegmentPieceInfo, w io.Writer, buf []byte) error {
+	rc, err := d.baseApp.PieceStore().GetPieceReader(ctx, pInfo.SegmentPieceKey,
+		int64(pInfo.Offset), int64(pInfo.Length))
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	n, err := io.CopyBuffer(w, io.LimitReader(rc, int64(pInfo.Length)), buf)
+	if err == nil && n != int64(pInfo.Length) {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// HandlePresignObjectTask returns the presigned url of the segment piece if the object has
+// only one segment, the url is empty if the piece store can not presign the piece.
+func (d *DownloadModular) HandlePresignObjectTask(ctx context.Context, downloadObjectTask task.DownloadObjectTask,
+	expire time.Duration, contentDisposition string) (string, error) {
+	if downloadObjectTask == nil || downloadObjectTask.GetObjectInfo() == nil || downloadObjectTask.GetStorageParams() == nil {
+		log.CtxErrorw(ctx, "failed to presign object due to pointer dangling")
+		return "", ErrDanglingPointer
+	}
+	objectInfo := downloadObjectTask.GetObjectInfo()
+	segmentCount := d.baseApp.PieceOp().SegmentPieceCount(objectInfo.GetPayloadSize(),
+		downloadObjectTask.GetStorageParams().VersionedParams.GetMaxSegmentSize())
+	if objectInfo.GetPayloadSize() == 0 || segmentCount != 1 {
+		return "", nil
+	}
+	pieceKey := d.baseApp.PieceOp().SegmentPieceKey(objectInfo.Id.Uint64(), 0)
+	url, err := d.baseApp.PieceStore().PresignGetPiece(ctx, pieceKey, expire,
+		objectInfo.GetContentType(), contentDisposition)
+	if err != nil {
+		if !errors.Is(err, storage.ErrUnsupportedMethod) {
+			log.CtxWarnw(ctx, "failed to presign segment piece, fallback to download", "piece_key", pieceKey, "error", err)
+		}
+		return "", nil
+	}
+	return url, nil
 }
 
 type SegmentPieceInfo struct {
@@ -177,7 +226,8 @@ func (d *DownloadModular) PreDownloadPiece(ctx context.Context, downloadPieceTas
 	}
 
 	if downloadPieceTask.GetEnableCheck() {
-		if err := d.baseApp.GfSpDB().CheckQuotaAndAddReadRecord(
+		_, dbSpan := tracing.StartSpan(ctx, "SpDB.CheckQuotaAndAddReadRecord")
+		err := d.baseApp.GfSpDB().CheckQuotaAndAddReadRecord(
 			&spdb.ReadRecord{
 				BucketID:        downloadPieceTask.GetBucketInfo().Id.Uint64(),
 				ObjectID:        downloadPieceTask.GetObjectInfo().Id.Uint64(),
@@ -190,7 +240,9 @@ func (d *DownloadModular) PreDownloadPiece(ctx context.Context, downloadPieceTas
 			&spdb.BucketQuota{
 				ReadQuotaSize: downloadPieceTask.GetBucketInfo().GetChargedReadQuota() + d.bucketFreeQuota,
 			},
-		); err != nil {
+		)
+		tracing.EndSpan(dbSpan, err)
+		if err != nil {
 			log.CtxErrorw(ctx, "failed to check bucket quota", "error", err)
 			if errors.Is(err, sqldb.ErrCheckQuotaEnough) {
 				return ErrExceedBucketQuota
@@ -267,7 +319,9 @@ func (d *DownloadModular) HandleChallengePiece(ctx context.Context, downloadPiec
 		downloadPieceTask.GetObjectInfo().Id.Uint64(),
 		downloadPieceTask.GetSegmentIdx(),
 		downloadPieceTask.GetRedundancyIdx())
+	_, dbSpan := tracing.StartSpan(ctx, "SpDB.GetObjectIntegrity")
 	integrity, err = d.baseApp.GfSpDB().GetObjectIntegrity(downloadPieceTask.GetObjectInfo().Id.Uint64())
+	tracing.EndSpan(dbSpan, err)
 	if err != nil {
 		log.CtxErrorw(ctx, "failed to get integrity hash", "error", err)
 		return nil, nil, nil, ErrGfSpDB
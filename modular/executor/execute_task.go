@@ -7,8 +7,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bnb-chain/greenfield-storage-provider/base/gfspapp"
 	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfsperrors"
 	"github.com/bnb-chain/greenfield-storage-provider/core/module"
+	"github.com/bnb-chain/greenfield-storage-provider/core/piecestore"
+	"github.com/bnb-chain/greenfield-storage-provider/core/spdb"
 	coretask "github.com/bnb-chain/greenfield-storage-provider/core/task"
 	"github.com/bnb-chain/greenfield-storage-provider/modular/manager"
 	"github.com/bnb-chain/greenfield-storage-provider/modular/metadata/types"
@@ -28,6 +31,13 @@ var (
 	ErrGfSpDB                  = gfsperrors.Register(module.ExecuteModularName, http.StatusInternalServerError, 45201, "server slipped away, try again later")
 )
 
+const (
+	// gcDeletePieceMaxRetry defines the max retry number of deleting the pieces that fail to be deleted by gc
+	gcDeletePieceMaxRetry = 3
+	// gcDeletePieceRetryInterval defines the interval of retrying to delete the failed pieces
+	gcDeletePieceRetryInterval = time.Second
+)
+
 func (e *ExecuteModular) HandleSealObjectTask(ctx context.Context, task coretask.SealObjectTask) {
 	if task == nil || task.GetObjectInfo() == nil {
 		log.CtxErrorw(ctx, "failed to handle seal object, task pointer dangling")
@@ -149,6 +159,7 @@ func (e *ExecuteModular) HandleReceivePieceTask(ctx context.Context, task coreta
 				log.CtxErrorw(ctx, "failed to delete piece data", "piece_key", pieceKey)
 			}
 		}
+		e.baseApp.DeletePieceUsage(ctx, onChainObject.Id.Uint64(), int32(task.GetReplicateIdx()))
 		return
 	}
 	log.CtxDebugw(ctx, "succeed to handle confirm receive piece task")
@@ -225,12 +236,9 @@ func (e *ExecuteModular) HandleGCObjectTask(ctx context.Context, task coretask.G
 		}
 		segmentCount := e.baseApp.PieceOp().SegmentPieceCount(
 			objectInfo.GetPayloadSize(), storageParams.VersionedParams.GetMaxSegmentSize())
+		var pieceKeys []string
 		for segIdx := uint32(0); segIdx < segmentCount; segIdx++ {
-			pieceKey := e.baseApp.PieceOp().SegmentPieceKey(currentGCObjectID, segIdx)
-			// ignore this delete api error, TODO: refine gc workflow by enrich metadata index.
-			deleteErr := e.baseApp.PieceStore().DeletePiece(ctx, pieceKey)
-			log.CtxDebugw(ctx, "delete the primary sp pieces",
-				"object_info", objectInfo, "piece_key", pieceKey, "error", deleteErr)
+			pieceKeys = append(pieceKeys, e.baseApp.PieceOp().SegmentPieceKey(currentGCObjectID, segIdx))
 		}
 		for rIdx, address := range objectInfo.GetSecondarySpAddresses() {
 			if strings.Compare(e.baseApp.OperateAddress(), address) == 0 {
@@ -239,16 +247,22 @@ func (e *ExecuteModular) HandleGCObjectTask(ctx context.Context, task coretask.G
 					if objectInfo.GetRedundancyType() == storagetypes.REDUNDANCY_REPLICA_TYPE {
 						pieceKey = e.baseApp.PieceOp().SegmentPieceKey(objectInfo.Id.Uint64(), segIdx)
 					}
-					// ignore this delete api error, TODO: refine gc workflow by enrich metadata index.
-					deleteErr := e.baseApp.PieceStore().DeletePiece(ctx, pieceKey)
-					log.CtxDebugw(ctx, "delete the secondary sp pieces",
-						"object_info", objectInfo, "piece_key", pieceKey, "error", deleteErr)
+					pieceKeys = append(pieceKeys, pieceKey)
 				}
 			}
 		}
+		e.gcPieces(ctx, currentGCObjectID, pieceKeys)
+		e.baseApp.DeletePieceUsage(ctx, currentGCObjectID, gfspapp.PrimaryReplicateIdx)
+		for rIdx, address := range objectInfo.GetSecondarySpAddresses() {
+			if strings.Compare(e.baseApp.OperateAddress(), address) == 0 {
+				e.baseApp.DeletePieceUsage(ctx, currentGCObjectID, int32(rIdx))
+			}
+		}
 		// ignore this delete api error, TODO: refine gc workflow by enrich metadata index.
 		deleteErr := e.baseApp.GfSpDB().DeleteObjectIntegrity(objectInfo.Id.Uint64())
 		log.CtxDebugw(ctx, "delete the object integrity meta", "object_info", objectInfo, "error", deleteErr)
+		deleteErr = e.baseApp.GfSpDB().DeleteObjectTier(objectInfo.Id.Uint64())
+		log.CtxDebugw(ctx, "delete the object tier record", "object_info", objectInfo, "error", deleteErr)
 		task.SetCurrentBlockNumber(currentGCBlockID)
 		task.SetLastDeletedObjectId(currentGCObjectID)
 		metrics.GCObjectCounter.WithLabelValues(e.Name()).Inc()
@@ -262,6 +276,106 @@ func (e *ExecuteModular) HandleGCObjectTask(ctx context.Context, task coretask.G
 	isSucceed = true
 }
 
+// gcPieces deletes the pieces of the object in batch, and retries the pieces that fail to
+// be deleted. The pieces that still fail are recorded as the zombie pieces for the later
+// cleanup instead of being dropped.
+func (e *ExecuteModular) gcPieces(ctx context.Context, objectID uint64, pieceKeys []string) {
+	if len(pieceKeys) == 0 {
+		return
+	}
+	failed := e.baseApp.PieceStore().DeletePieces(ctx, pieceKeys)
+	for retry := 0; retry < gcDeletePieceMaxRetry && len(failed) > 0; retry++ {
+		time.Sleep(gcDeletePieceRetryInterval)
+		retryKeys := make([]string, 0, len(failed))
+		for key := range failed {
+			retryKeys = append(retryKeys, key)
+		}
+		log.CtxWarnw(ctx, "retry to delete the failed pieces", "object_id", objectID,
+			"piece_number", len(retryKeys), "retry", retry)
+		failed = e.baseApp.PieceStore().DeletePieces(ctx, retryKeys)
+	}
+	if len(failed) == 0 {
+		log.CtxDebugw(ctx, "succeed to delete the pieces", "object_id", objectID, "piece_number", len(pieceKeys))
+		return
+	}
+	zombies := make([]*spdb.GCZombiePiece, 0, len(failed))
+	for key, err := range failed {
+		zombies = append(zombies, &spdb.GCZombiePiece{
+			PieceKey:         key,
+			ObjectID:         objectID,
+			ErrorDescription: err.Error(),
+		})
+	}
+	log.CtxErrorw(ctx, "failed to delete the pieces, record them as zombie pieces", "object_id", objectID,
+		"failed_number", len(failed))
+	if err := e.baseApp.GfSpDB().InsertGCZombiePieces(zombies); err != nil {
+		log.CtxErrorw(ctx, "failed to insert the zombie pieces", "object_id", objectID,
+			"failed_number", len(failed), "error", err)
+	}
+}
+
+// HandleTierObjectTask migrates all the pieces of the object that stored in this SP to the
+// target tier. The pieces are copied to the target tier before updating the tier record,
+// and deleted from the source tier after that, so the reads are always served by one tier.
+func (e *ExecuteModular) HandleTierObjectTask(ctx context.Context, task coretask.TierObjectTask) {
+	if task == nil || task.GetObjectInfo() == nil || task.GetStorageParams() == nil {
+		log.CtxErrorw(ctx, "failed to handle tier object, task pointer dangling")
+		task.SetError(ErrDanglingPointer)
+		return
+	}
+	var (
+		objectID   = task.GetObjectInfo().Id.Uint64()
+		targetTier = task.GetTargetTier()
+		sourceTier = piecestore.HotTier
+		pieceKeys  []string
+	)
+	if targetTier == piecestore.HotTier {
+		sourceTier = piecestore.ColdTier
+	}
+	usages, err := e.baseApp.GfSpDB().GetPieceUsageByObjectID(objectID)
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to get piece usage", "error", err)
+		task.SetError(ErrGfSpDB)
+		return
+	}
+	segmentCount := e.baseApp.PieceOp().SegmentPieceCount(task.GetObjectInfo().GetPayloadSize(),
+		task.GetStorageParams().VersionedParams.GetMaxSegmentSize())
+	for _, usage := range usages {
+		replicateIdx := usage.ReplicateIdx
+		if task.GetObjectInfo().GetRedundancyType() == storagetypes.REDUNDANCY_REPLICA_TYPE {
+			replicateIdx = gfspapp.PrimaryReplicateIdx
+		}
+		for segIdx := uint32(0); segIdx < segmentCount; segIdx++ {
+			pieceKeys = append(pieceKeys, e.baseApp.PieceOp().ChallengePieceKey(objectID, segIdx, replicateIdx))
+		}
+	}
+	if len(pieceKeys) == 0 {
+		// the object without the recorded pieces is not moved, keep its tier unchanged.
+		log.CtxWarnw(ctx, "no piece to tier object", "tier", targetTier)
+		return
+	}
+	for _, pieceKey := range pieceKeys {
+		if err = e.baseApp.PieceStore().CopyPieceToTier(ctx, pieceKey, targetTier); err != nil {
+			log.CtxErrorw(ctx, "failed to copy piece to tier", "piece_key", pieceKey,
+				"tier", targetTier, "error", err)
+			task.SetError(err)
+			return
+		}
+	}
+	if err = e.baseApp.PieceStore().SetObjectTier(objectID, targetTier); err != nil {
+		log.CtxErrorw(ctx, "failed to set object tier", "tier", targetTier, "error", err)
+		task.SetError(ErrGfSpDB)
+		return
+	}
+	for _, pieceKey := range pieceKeys {
+		// ignore this delete api error, the piece in the source tier is not read any more.
+		deleteErr := e.baseApp.PieceStore().DeletePieceFromTier(ctx, pieceKey, sourceTier)
+		log.CtxDebugw(ctx, "delete the piece from the source tier", "piece_key", pieceKey,
+			"tier", sourceTier, "error", deleteErr)
+	}
+	log.CtxDebugw(ctx, "succeed to tier object", "tier", targetTier, "piece_number", len(pieceKeys))
+}
+
 func (e *ExecuteModular) HandleGCZombiePieceTask(ctx context.Context, task coretask.GCZombiePieceTask) {
 	log.CtxWarn(ctx, "gc zombie piece future support")
 }
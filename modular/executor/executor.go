@@ -14,6 +14,7 @@ import (
 	coretask "github.com/bnb-chain/greenfield-storage-provider/core/task"
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/metrics"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/tracing"
 )
 
 var _ module.TaskExecutor = &ExecuteModular{}
@@ -41,6 +42,7 @@ type ExecuteModular struct {
 	doingGCObjectTaskCnt       int64
 	doingGCZombiePieceTaskCnt  int64
 	doingGCGCMetaTaskCnt       int64
+	doingTierObjectTaskCnt     int64
 }
 
 func (e *ExecuteModular) Name() string {
@@ -127,6 +129,12 @@ func (e *ExecuteModular) AskTask(ctx context.Context, limit corercmgr.Limit) {
 	defer e.ReleaseResource(ctx, span)
 	defer e.ReportTask(ctx, askTask)
 	ctx = log.WithValue(ctx, log.CtxKeyTask, askTask.Key().String())
+	// continue the trace of the task producer, e.g. the upload object request.
+	if carrier, ok := askTask.(interface{ GetTask() *gfsptask.GfSpTask }); ok {
+		ctx = tracing.ExtractMap(ctx, carrier.GetTask().GetTraceCarrier())
+	}
+	ctx, traceSpan := tracing.StartSpan(ctx, "Executor."+coretask.TaskTypeName(askTask.Type()))
+	defer func() { tracing.EndSpan(traceSpan, askTask.Error()) }()
 	switch t := askTask.(type) {
 	case *gfsptask.GfSpReplicatePieceTask:
 		metrics.ExecutorReplicatePieceTaskCounter.WithLabelValues(e.Name()).Inc()
@@ -158,6 +166,11 @@ func (e *ExecuteModular) AskTask(ctx context.Context, limit corercmgr.Limit) {
 		atomic.AddInt64(&e.doingGCGCMetaTaskCnt, 1)
 		defer atomic.AddInt64(&e.doingGCGCMetaTaskCnt, -1)
 		e.HandleGCMetaTask(ctx, t)
+	case *gfsptask.GfSpTierObjectTask:
+		metrics.ExecutorTierObjectTaskCounter.WithLabelValues(e.Name()).Inc()
+		atomic.AddInt64(&e.doingTierObjectTaskCnt, 1)
+		defer atomic.AddInt64(&e.doingTierObjectTaskCnt, -1)
+		e.HandleTierObjectTask(ctx, t)
 	default:
 		log.CtxErrorw(ctx, "unsupported task type")
 	}
@@ -200,12 +213,13 @@ func (e *ExecuteModular) ReleaseResource(
 
 func (e *ExecuteModular) Statistics() string {
 	return fmt.Sprintf(
-		"maxAsk[%d], asking[%d], replicate[%d], seal[%d], receive[%d], gcObject[%d], gcZombie[%d], gcMeta[%d]",
+		"maxAsk[%d], asking[%d], replicate[%d], seal[%d], receive[%d], gcObject[%d], gcZombie[%d], gcMeta[%d], tierObject[%d]",
 		atomic.LoadInt64(&e.maxExecuteNum), atomic.LoadInt64(&e.executingNum),
 		atomic.LoadInt64(&e.doingReplicatePieceTaskCnt),
 		atomic.LoadInt64(&e.doingSpSealObjectTaskCnt),
 		atomic.LoadInt64(&e.doingReceivePieceTaskCnt),
 		atomic.LoadInt64(&e.doingGCObjectTaskCnt),
 		atomic.LoadInt64(&e.doingGCZombiePieceTaskCnt),
-		atomic.LoadInt64(&e.doingGCGCMetaTaskCnt))
+		atomic.LoadInt64(&e.doingGCGCMetaTaskCnt),
+		atomic.LoadInt64(&e.doingTierObjectTaskCnt))
 }
@@ -5,12 +5,15 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/bnb-chain/greenfield-storage-provider/base/gfspapp"
 	"github.com/bnb-chain/greenfield-storage-provider/core/module"
 	"github.com/bnb-chain/greenfield-storage-provider/core/rcmgr"
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/metrics"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/tracing"
 )
 
 var _ module.Modular = &GateModular{}
@@ -24,6 +27,10 @@ type GateModular struct {
 
 	maxListReadQuota int64
 	maxPayloadSize   uint64
+	// presignRedirect redirects the public object download to the presigned url of
+	// the backend storage.
+	presignRedirect      bool
+	presignExpireSeconds int64
 }
 
 func (g *GateModular) Name() string {
@@ -45,6 +52,7 @@ func (g *GateModular) server(ctx context.Context) {
 	if g.baseApp.EnableMetrics() {
 		router.Use(metrics.DefaultHTTPServerMetrics.InstrumentationHandler)
 	}
+	router.Use(tracingHandler)
 	g.RegisterHandler(router)
 	server := &http.Server{
 		Addr:    g.httpAddress,
@@ -57,6 +65,22 @@ func (g *GateModular) server(ctx context.Context) {
 	}
 }
 
+// tracingHandler starts a server span for every request, the trace context sent
+// by the other SPs, e.g. replicate piece request, is extracted from the header.
+func tracingHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		routerName := r.Method
+		if route := mux.CurrentRoute(r); route != nil {
+			routerName = route.GetName()
+		}
+		ctx, span := tracing.StartSpanWithKind(tracing.ExtractHTTPHeader(r.Context(), r.Header),
+			module.GateModularName+"/"+routerName, trace.SpanKindServer,
+			attribute.String("http.method", r.Method), attribute.String("http.target", r.URL.Path))
+		defer span.End()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 func (g *GateModular) Stop(ctx context.Context) error {
 	g.scope.Release()
 	g.httpServer.Shutdown(ctx)
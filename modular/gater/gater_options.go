@@ -12,6 +12,9 @@ const (
 	DefaultGatewayDomain    = "localhost:9133"
 	DefaultMaxListReadQuota = 100
 	DefaultMaxPayloadSize   = 2 * 1024 * 1024 * 1024
+	// DefaultPresignExpireSeconds defines the default expiration of the presigned url
+	// that the public object download is redirected to.
+	DefaultPresignExpireSeconds = 300
 )
 
 func NewGateModular(app *gfspapp.GfSpBaseApp, cfg *gfspconfig.GfSpConfig) (coremodule.Modular, error) {
@@ -39,6 +42,11 @@ func DefaultGaterOptions(gater *GateModular, cfg *gfspconfig.GfSpConfig) error {
 	gater.domain = cfg.Gateway.Domain
 	gater.httpAddress = cfg.Gateway.HttpAddress
 	gater.maxListReadQuota = cfg.Bucket.MaxListReadQuotaNumber
+	if cfg.Gateway.PresignExpireSeconds == 0 {
+		cfg.Gateway.PresignExpireSeconds = DefaultPresignExpireSeconds
+	}
+	gater.presignRedirect = cfg.Gateway.PresignRedirect
+	gater.presignExpireSeconds = cfg.Gateway.PresignExpireSeconds
 	rateCfg := makeAPIRateLimitCfg(cfg.APIRateLimiter)
 	if err := localhttp.NewAPILimiter(rateCfg); err != nil {
 		log.Errorw("failed to new api limiter", "err", err)
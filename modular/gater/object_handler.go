@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
@@ -28,6 +29,7 @@ func (g *GateModular) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 		err        error
 		reqCtx     *RequestContext
 		authorized bool
+		bucketInfo *storagetypes.BucketInfo
 		objectInfo *storagetypes.ObjectInfo
 		params     *storagetypes.Params
 	)
@@ -62,9 +64,10 @@ func (g *GateModular) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	objectInfo, err = g.baseApp.Consensus().QueryObjectInfo(reqCtx.Context(), reqCtx.bucketName, reqCtx.objectName)
+	bucketInfo, objectInfo, err = g.baseApp.Consensus().QueryBucketInfoAndObjectInfo(reqCtx.Context(),
+		reqCtx.bucketName, reqCtx.objectName)
 	if err != nil {
-		log.CtxErrorw(reqCtx.Context(), "failed to get object info from consensus", "error", err)
+		log.CtxErrorw(reqCtx.Context(), "failed to get bucket and object info from consensus", "error", err)
 		err = ErrConsensus
 		return
 	}
@@ -81,6 +84,7 @@ func (g *GateModular) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	task := &gfsptask.GfSpUploadObjectTask{}
 	task.InitUploadObjectTask(objectInfo, params, g.baseApp.TaskTimeout(task, objectInfo.GetPayloadSize()))
+	task.SetBucketInfo(bucketInfo)
 	ctx := log.WithValue(reqCtx.Context(), log.CtxKeyTask, task.Key().String())
 	err = g.baseApp.GfSpClient().UploadObject(ctx, task, r.Body)
 	if err != nil {
@@ -125,16 +129,17 @@ func parseRange(rangeStr string) (bool, int64, int64) {
 // getObjectHandler handles the download object request.
 func (g *GateModular) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 	var (
-		err        error
-		reqCtxErr  error
-		reqCtx     *RequestContext
-		authorized bool
-		objectInfo *storagetypes.ObjectInfo
-		bucketInfo *storagetypes.BucketInfo
-		params     *storagetypes.Params
-		lowOffset  int64
-		highOffset int64
-		pieceInfos []*downloader.SegmentPieceInfo
+		err          error
+		reqCtxErr    error
+		reqCtx       *RequestContext
+		authorized   bool
+		publicObject bool
+		objectInfo   *storagetypes.ObjectInfo
+		bucketInfo   *storagetypes.BucketInfo
+		params       *storagetypes.Params
+		lowOffset    int64
+		highOffset   int64
+		pieceInfos   []*downloader.SegmentPieceInfo
 	)
 	defer func() {
 		reqCtx.Cancel()
@@ -155,6 +160,7 @@ func (g *GateModular) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 		err = ErrConsensus
 		return
 	}
+	publicObject = authorized
 	if !authorized {
 		if reqCtxErr != nil {
 			err = reqCtxErr
@@ -218,6 +224,13 @@ func (g *GateModular) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 		log.CtxErrorw(reqCtx.Context(), "failed to download object", "error", err)
 		return
 	}
+	// only the public object is redirected, the presigned url can be shared with anyone.
+	if publicObject && !isRange {
+		var redirected bool
+		if redirected, err = g.redirectToPresignedURL(w, r, task, ""); err != nil || redirected {
+			return
+		}
+	}
 	w.Header().Set(ContentTypeHeader, objectInfo.GetContentType())
 	if isRange {
 		w.Header().Set(ContentRangeHeader, "bytes "+util.Uint64ToString(uint64(lowOffset))+
@@ -405,7 +418,8 @@ func (g *GateModular) getObjectByUniversalEndpointHandler(w http.ResponseWriter,
 		return
 	}
 
-	if isPrivateObject(getBucketInfoRes.GetBucketInfo(), getObjectInfoRes.GetObjectInfo()) {
+	privateObject := isPrivateObject(getBucketInfoRes.GetBucketInfo(), getObjectInfoRes.GetObjectInfo())
+	if privateObject {
 		// for private files, we return a built-in dapp and help users provide a signature for verification
 
 		var (
@@ -505,17 +519,25 @@ func (g *GateModular) getObjectByUniversalEndpointHandler(w http.ResponseWriter,
 	task := &gfsptask.GfSpDownloadObjectTask{}
 	task.InitDownloadObjectTask(getObjectInfoRes.GetObjectInfo(), getBucketInfoRes.GetBucketInfo(), params, g.baseApp.TaskPriority(task), reqCtx.Account(),
 		low, high, g.baseApp.TaskTimeout(task, uint64(high-low+1)), g.baseApp.TaskMaxRetry(task))
-	data, err := g.baseApp.GfSpClient().GetObject(reqCtx.Context(), task)
+
+	contentDisposition := ContentDispositionInlineValue
+	if isDownload {
+		contentDisposition = ContentDispositionAttachmentValue + "; filename=\"" + escapedObjectName + "\""
+	}
+	if !privateObject && !isRange {
+		var redirected bool
+		if redirected, err = g.redirectToPresignedURL(w, r, task, contentDisposition); err != nil || redirected {
+			return
+		}
+	}
+	reader, err := g.baseApp.GfSpClient().GetObject(reqCtx.Context(), task)
 	if err != nil {
 		log.CtxErrorw(reqCtx.Context(), "failed to download object", "error", err)
 		return
 	}
+	defer reader.Close()
 
-	if isDownload {
-		w.Header().Set(ContentDispositionHeader, ContentDispositionAttachmentValue+"; filename=\""+escapedObjectName+"\"")
-	} else {
-		w.Header().Set(ContentDispositionHeader, ContentDispositionInlineValue)
-	}
+	w.Header().Set(ContentDispositionHeader, contentDisposition)
 	w.Header().Set(ContentTypeHeader, getObjectInfoRes.GetObjectInfo().GetContentType())
 	if isRange {
 		w.Header().Set(ContentRangeHeader, "bytes "+util.Uint64ToString(uint64(low))+
@@ -523,7 +545,11 @@ func (g *GateModular) getObjectByUniversalEndpointHandler(w http.ResponseWriter,
 	} else {
 		w.Header().Set(ContentLengthHeader, util.Uint64ToString(getObjectInfoRes.GetObjectInfo().GetPayloadSize()))
 	}
-	w.Write(data)
+	// the response is started, the error of the stream can not be returned to the client.
+	if _, copyErr := io.Copy(w, reader); copyErr != nil {
+		log.CtxErrorw(reqCtx.Context(), "failed to stream object data", "error", copyErr)
+		return
+	}
 	log.CtxDebugw(reqCtx.Context(), "succeed to download object for universal endpoint")
 }
 
@@ -542,3 +568,24 @@ func (g *GateModular) downloadObjectByUniversalEndpointHandler(w http.ResponseWr
 func (g *GateModular) viewObjectByUniversalEndpointHandler(w http.ResponseWriter, r *http.Request) {
 	g.getObjectByUniversalEndpointHandler(w, r, false)
 }
+
+// redirectToPresignedURL redirects the request to the presigned url of the backend storage if
+// the presign redirect is enabled and the object can be read from the backend storage as is,
+// the read quota is charged by the downloader before the url is returned.
+func (g *GateModular) redirectToPresignedURL(w http.ResponseWriter, r *http.Request,
+	task *gfsptask.GfSpDownloadObjectTask, contentDisposition string) (bool, error) {
+	if !g.presignRedirect {
+		return false, nil
+	}
+	presignedURL, err := g.baseApp.GfSpClient().PresignObject(r.Context(), task,
+		time.Duration(g.presignExpireSeconds)*time.Second, contentDisposition)
+	if err != nil {
+		log.CtxErrorw(r.Context(), "failed to presign object", "error", err)
+		return false, err
+	}
+	if presignedURL == "" {
+		return false, nil
+	}
+	http.Redirect(w, r, presignedURL, http.StatusFound)
+	return true, nil
+}
@@ -16,6 +16,7 @@ import (
 
 	commonhttp "github.com/bnb-chain/greenfield-common/go/http"
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/tracing"
 )
 
 // RequestContext generates from http request, it records the common info
@@ -48,7 +49,9 @@ func NewRequestContext(r *http.Request, g *GateModular) (*RequestContext, error)
 	if mux.CurrentRoute(r) != nil {
 		routerName = mux.CurrentRoute(r).GetName()
 	}
-	ctx, cancel := context.WithCancel(context.Background())
+	// detach from the http request context but keep the span started by the
+	// tracing handler, the backend calls will be traced as its children.
+	ctx, cancel := context.WithCancel(tracing.Detach(r.Context()))
 	reqCtx := &RequestContext{
 		g:          g,
 		ctx:        ctx,
@@ -0,0 +1,336 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/bnb-chain/greenfield-storage-provider/core/consensus"
+	"github.com/bnb-chain/greenfield-storage-provider/core/spdb"
+	metadatatypes "github.com/bnb-chain/greenfield-storage-provider/modular/metadata/types"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/metrics"
+	paymenttypes "github.com/bnb-chain/greenfield/x/payment/types"
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
+)
+
+const (
+	// DiscontinueRuleKeepAlive discontinues the buckets created before the keep alive days, it is
+	// used for the test net cleanup.
+	DiscontinueRuleKeepAlive = "keep_alive"
+	// DiscontinueRuleFrozenPayment discontinues the buckets whose payment stream is frozen.
+	DiscontinueRuleFrozenPayment = "frozen_payment"
+	// DiscontinueRulePolicyViolation discontinues the buckets flagged by the operator for violating
+	// the service policy.
+	DiscontinueRulePolicyViolation = "policy_violation"
+	// DiscontinueRuleAdminRequest discontinues the buckets flagged by the operator on the explicit
+	// admin request.
+	DiscontinueRuleAdminRequest = "admin_request"
+
+	// discontinueNotifyTimeout defines the timeout of posting the report to the notify url
+	discontinueNotifyTimeout = 10 * time.Second
+)
+
+// discontinueCandidate is the bucket that the discontinue rules are evaluated on, the flag is
+// set if the bucket is flagged by the operator.
+type discontinueCandidate struct {
+	bucketInfo *storagetypes.BucketInfo
+	flag       *spdb.DiscontinueBucketFlag
+}
+
+// DiscontinueRule decides whether the bucket should be discontinued and the reason on chain.
+type DiscontinueRule interface {
+	Name() string
+	Evaluate(ctx context.Context, candidate *discontinueCandidate) (reason string, matched bool, err error)
+}
+
+// NewDiscontinueRule returns the discontinue rule by name, the payment stream of the bucket is
+// queried from chain by the frozen payment rule.
+func NewDiscontinueRule(name string, chain consensus.Consensus, keepAliveDays int) (DiscontinueRule, error) {
+	switch name {
+	case DiscontinueRuleKeepAlive:
+		return &keepAliveRule{keepAliveDays: keepAliveDays}, nil
+	case DiscontinueRuleFrozenPayment:
+		return &frozenPaymentRule{chain: chain}, nil
+	case DiscontinueRulePolicyViolation, DiscontinueRuleAdminRequest:
+		return &flaggedRule{name: name}, nil
+	default:
+		return nil, fmt.Errorf("unknown discontinue bucket rule %s", name)
+	}
+}
+
+type keepAliveRule struct {
+	keepAliveDays int
+}
+
+func (r *keepAliveRule) Name() string { return DiscontinueRuleKeepAlive }
+
+func (r *keepAliveRule) Evaluate(_ context.Context, candidate *discontinueCandidate) (string, bool, error) {
+	createAt := time.Now().AddDate(0, 0, -r.keepAliveDays)
+	if candidate.bucketInfo.GetCreateAt() >= createAt.Unix() {
+		return "", false, nil
+	}
+	return DiscontinueBucketReason, true, nil
+}
+
+type frozenPaymentRule struct {
+	chain consensus.Consensus
+}
+
+func (r *frozenPaymentRule) Name() string { return DiscontinueRuleFrozenPayment }
+
+func (r *frozenPaymentRule) Evaluate(ctx context.Context, candidate *discontinueCandidate) (string, bool, error) {
+	paymentAddress := candidate.bucketInfo.GetPaymentAddress()
+	streamRecord, err := r.chain.QueryPaymentStreamRecord(ctx, paymentAddress)
+	if err != nil {
+		return "", false, err
+	}
+	if streamRecord.Status != paymenttypes.STREAM_ACCOUNT_STATUS_FROZEN {
+		return "", false, nil
+	}
+	return "payment stream of " + paymentAddress + " is frozen", true, nil
+}
+
+// flaggedRule matches the buckets flagged by the operator with the rule name.
+type flaggedRule struct {
+	name string
+}
+
+func (r *flaggedRule) Name() string { return r.name }
+
+func (r *flaggedRule) Evaluate(_ context.Context, candidate *discontinueCandidate) (string, bool, error) {
+	if candidate.flag == nil || candidate.flag.Rule != r.name {
+		return "", false, nil
+	}
+	if candidate.flag.Reason == "" {
+		return r.name, true, nil
+	}
+	return candidate.flag.Reason, true, nil
+}
+
+// DiscontinueDecision is the bucket that matches a discontinue rule, and the result of sending
+// the discontinue tx.
+type DiscontinueDecision struct {
+	BucketName string `json:"bucket_name"`
+	Rule       string `json:"rule"`
+	Reason     string `json:"reason"`
+	Error      string `json:"error,omitempty"`
+}
+
+// DiscontinueReport is the decisions of one round of discontinuing buckets, the discontinue txs
+// are not sent in the dry run.
+type DiscontinueReport struct {
+	DryRun    bool                   `json:"dry_run"`
+	Timestamp int64                  `json:"timestamp"`
+	Decisions []*DiscontinueDecision `json:"decisions"`
+}
+
+// DiscontinueNotifier is notified with the report of every round of discontinuing buckets that
+// has decisions.
+type DiscontinueNotifier interface {
+	Notify(ctx context.Context, report *DiscontinueReport) error
+}
+
+// webhookNotifier posts the report in json to the url.
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier returns the notifier that posts the report in json to the url.
+func NewWebhookNotifier(url string) DiscontinueNotifier {
+	return &webhookNotifier{url: url, client: &http.Client{Timeout: discontinueNotifyTimeout}}
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, report *DiscontinueReport) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to notify %s, status code %d", n.url, resp.StatusCode)
+	}
+	return nil
+}
+
+// discontinueClient is the part of the gfsp client that discontinuing buckets depends on.
+type discontinueClient interface {
+	ListExpiredBucketsBySp(ctx context.Context, createAt int64, primarySpAddress string, limit int64,
+		startAfterCreateAt int64, startAfterBucketID int64, opts ...grpc.DialOption) ([]*metadatatypes.Bucket, error)
+	DiscontinueBucket(ctx context.Context, bucket *storagetypes.MsgDiscontinueBucket) error
+}
+
+// bucketDiscontinuer discontinues the buckets of the SP by the discontinue rules.
+type bucketDiscontinuer struct {
+	chain          consensus.Consensus
+	db             spdb.DiscontinueBucketFlagDB
+	client         discontinueClient
+	operateAddress string
+	keepAliveDays  int
+	dryRun         bool
+	rules          []DiscontinueRule
+	notifiers      []DiscontinueNotifier
+}
+
+// discontinueBuckets evaluates the discontinue rules on the buckets of this SP, and discontinues
+// the matched buckets with the reason of the first matched rule. The buckets are listed from the
+// oldest, and the flagged buckets are always evaluated. The discontinue txs are rate limited by
+// the tx manager of the gc account in signer.
+func (d *bucketDiscontinuer) discontinueBuckets(ctx context.Context) {
+	candidates := d.listCandidates(ctx)
+	report := &DiscontinueReport{DryRun: d.dryRun, Timestamp: time.Now().Unix()}
+	for _, candidate := range candidates {
+		decision := d.evaluateRules(ctx, candidate)
+		if decision == nil {
+			continue
+		}
+		report.Decisions = append(report.Decisions, decision)
+		if d.dryRun {
+			log.CtxInfow(ctx, "dry run to discontinue bucket", "bucket_name", decision.BucketName,
+				"rule", decision.Rule, "reason", decision.Reason)
+			metrics.DiscontinueBucketDecisionCounter.WithLabelValues(decision.Rule, "dry_run").Inc()
+			continue
+		}
+		err := d.client.DiscontinueBucket(ctx, &storagetypes.MsgDiscontinueBucket{
+			BucketName: decision.BucketName,
+			Reason:     decision.Reason,
+		})
+		if err != nil {
+			decision.Error = err.Error()
+			log.CtxErrorw(ctx, "failed to discontinue bucket on chain", "bucket_name", decision.BucketName,
+				"rule", decision.Rule, "error", err)
+			metrics.DiscontinueBucketDecisionCounter.WithLabelValues(decision.Rule, "failure").Inc()
+			continue
+		}
+		log.CtxInfow(ctx, "succeed to discontinue bucket", "bucket_name", decision.BucketName,
+			"rule", decision.Rule, "reason", decision.Reason)
+		metrics.DiscontinueBucketDecisionCounter.WithLabelValues(decision.Rule, "success").Inc()
+		if candidate.flag != nil {
+			d.deleteFlag(ctx, decision.BucketName)
+		}
+	}
+	if len(report.Decisions) == 0 {
+		return
+	}
+	for _, notifier := range d.notifiers {
+		if err := notifier.Notify(ctx, report); err != nil {
+			log.CtxErrorw(ctx, "failed to notify the discontinue bucket report", "error", err)
+		}
+	}
+}
+
+// listCandidates returns the flagged buckets and the next page of the buckets of this SP, the
+// pages are listed in the order of the create time round by round.
+func (d *bucketDiscontinuer) listCandidates(ctx context.Context) []*discontinueCandidate {
+	var candidates []*discontinueCandidate
+	listed := make(map[string]bool)
+	flags, err := d.db.ListDiscontinueBucketFlags(int(DiscontinueBucketLimit))
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to list the flagged buckets", "error", err)
+	}
+	for _, flag := range flags {
+		bucketInfo, err := d.chain.QueryBucketInfo(ctx, flag.BucketName)
+		if err != nil {
+			log.CtxErrorw(ctx, "failed to query the flagged bucket", "bucket_name", flag.BucketName, "error", err)
+			continue
+		}
+		if bucketInfo.GetPrimarySpAddress() != d.operateAddress ||
+			bucketInfo.GetBucketStatus() != storagetypes.BUCKET_STATUS_CREATED {
+			log.CtxInfow(ctx, "flagged bucket is not served by this sp", "bucket_name", flag.BucketName,
+				"bucket_status", bucketInfo.GetBucketStatus().String())
+			d.deleteFlag(ctx, flag.BucketName)
+			continue
+		}
+		listed[flag.BucketName] = true
+		candidates = append(candidates, &discontinueCandidate{bucketInfo: bucketInfo, flag: flag})
+	}
+
+	if !d.ruleEnabled(DiscontinueRuleKeepAlive) && !d.ruleEnabled(DiscontinueRuleFrozenPayment) {
+		return candidates
+	}
+	cursor, err := d.db.GetDiscontinueBucketCursor()
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to get the discontinue bucket cursor", "error", err)
+		return candidates
+	}
+	// only the buckets that the keep alive rule matches are listed if the frozen payment rule,
+	// which matches the buckets of any age, is not enabled.
+	createAt := time.Now()
+	if !d.ruleEnabled(DiscontinueRuleFrozenPayment) {
+		createAt = createAt.AddDate(0, 0, -d.keepAliveDays)
+	}
+	buckets, err := d.client.ListExpiredBucketsBySp(ctx, createAt.Unix(), d.operateAddress,
+		DiscontinueBucketLimit, cursor.CreateAt, cursor.BucketID)
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to list the buckets of sp", "error", err)
+		return candidates
+	}
+	// the next round continues after the last listed bucket, and starts over from the oldest
+	// bucket once all the buckets are listed.
+	next := &spdb.DiscontinueBucketCursor{}
+	if int64(len(buckets)) >= DiscontinueBucketLimit {
+		last := buckets[len(buckets)-1].GetBucketInfo()
+		next.CreateAt, next.BucketID = last.GetCreateAt(), int64(last.Id.Uint64())
+	}
+	if err = d.db.UpdateDiscontinueBucketCursor(next); err != nil {
+		log.CtxErrorw(ctx, "failed to update the discontinue bucket cursor", "error", err)
+	}
+	for _, bucket := range buckets {
+		if bucket.GetBucketInfo() == nil || listed[bucket.GetBucketInfo().GetBucketName()] {
+			continue
+		}
+		candidates = append(candidates, &discontinueCandidate{bucketInfo: bucket.GetBucketInfo()})
+	}
+	return candidates
+}
+
+// evaluateRules returns the decision of the first matched rule, or nil if no rule matches the
+// bucket.
+func (d *bucketDiscontinuer) evaluateRules(ctx context.Context, candidate *discontinueCandidate) *DiscontinueDecision {
+	for _, rule := range d.rules {
+		reason, matched, err := rule.Evaluate(ctx, candidate)
+		if err != nil {
+			log.CtxErrorw(ctx, "failed to evaluate discontinue rule", "bucket_name",
+				candidate.bucketInfo.GetBucketName(), "rule", rule.Name(), "error", err)
+			continue
+		}
+		if matched {
+			return &DiscontinueDecision{
+				BucketName: candidate.bucketInfo.GetBucketName(),
+				Rule:       rule.Name(),
+				Reason:     reason,
+			}
+		}
+	}
+	return nil
+}
+
+func (d *bucketDiscontinuer) ruleEnabled(name string) bool {
+	for _, rule := range d.rules {
+		if rule.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *bucketDiscontinuer) deleteFlag(ctx context.Context, bucketName string) {
+	if err := d.db.DeleteDiscontinueBucketFlag(bucketName); err != nil {
+		log.CtxErrorw(ctx, "failed to delete the discontinue bucket flag", "bucket_name", bucketName, "error", err)
+	}
+}
@@ -0,0 +1,305 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	sdkmath "cosmossdk.io/math"
+	paymenttypes "github.com/bnb-chain/greenfield/x/payment/types"
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+
+	"github.com/bnb-chain/greenfield-storage-provider/core/consensus"
+	"github.com/bnb-chain/greenfield-storage-provider/core/spdb"
+	metadatatypes "github.com/bnb-chain/greenfield-storage-provider/modular/metadata/types"
+)
+
+const testOperateAddress = "sp"
+
+// mockDiscontinueDB keeps the flags and the cursor in memory.
+type mockDiscontinueDB struct {
+	flags  map[string]*spdb.DiscontinueBucketFlag
+	cursor spdb.DiscontinueBucketCursor
+}
+
+func (db *mockDiscontinueDB) InsertDiscontinueBucketFlag(flag *spdb.DiscontinueBucketFlag) error {
+	db.flags[flag.BucketName] = flag
+	return nil
+}
+
+func (db *mockDiscontinueDB) ListDiscontinueBucketFlags(limit int) ([]*spdb.DiscontinueBucketFlag, error) {
+	var flags []*spdb.DiscontinueBucketFlag
+	for _, flag := range db.flags {
+		flags = append(flags, flag)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].BucketName < flags[j].BucketName })
+	if len(flags) > limit {
+		flags = flags[:limit]
+	}
+	return flags, nil
+}
+
+func (db *mockDiscontinueDB) DeleteDiscontinueBucketFlag(bucketName string) error {
+	delete(db.flags, bucketName)
+	return nil
+}
+
+func (db *mockDiscontinueDB) GetDiscontinueBucketCursor() (*spdb.DiscontinueBucketCursor, error) {
+	cursor := db.cursor
+	return &cursor, nil
+}
+
+func (db *mockDiscontinueDB) UpdateDiscontinueBucketCursor(cursor *spdb.DiscontinueBucketCursor) error {
+	db.cursor = *cursor
+	return nil
+}
+
+// mockDiscontinueClient lists the buckets in the order of the create time and the id, and records
+// the discontinued buckets.
+type mockDiscontinueClient struct {
+	buckets      []*storagetypes.BucketInfo
+	createAt     []int64
+	discontinued []string
+	err          error
+}
+
+func (c *mockDiscontinueClient) ListExpiredBucketsBySp(_ context.Context, createAt int64, primarySpAddress string,
+	limit int64, startAfterCreateAt int64, startAfterBucketID int64, _ ...grpc.DialOption) ([]*metadatatypes.Bucket, error) {
+	c.createAt = append(c.createAt, createAt)
+	var buckets []*metadatatypes.Bucket
+	for _, bucket := range c.buckets {
+		if bucket.GetPrimarySpAddress() != primarySpAddress || bucket.GetCreateAt() >= createAt {
+			continue
+		}
+		id := int64(bucket.Id.Uint64())
+		if bucket.GetCreateAt() < startAfterCreateAt || bucket.GetCreateAt() == startAfterCreateAt && id <= startAfterBucketID {
+			continue
+		}
+		if int64(len(buckets)) >= limit {
+			break
+		}
+		buckets = append(buckets, &metadatatypes.Bucket{BucketInfo: bucket})
+	}
+	return buckets, nil
+}
+
+func (c *mockDiscontinueClient) DiscontinueBucket(_ context.Context, bucket *storagetypes.MsgDiscontinueBucket) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.discontinued = append(c.discontinued, bucket.GetBucketName())
+	return nil
+}
+
+// mockNotifier records the notified reports.
+type mockNotifier struct {
+	reports []*DiscontinueReport
+}
+
+func (n *mockNotifier) Notify(_ context.Context, report *DiscontinueReport) error {
+	n.reports = append(n.reports, report)
+	return nil
+}
+
+func newTestBucket(id uint64, name string, createAt int64) *storagetypes.BucketInfo {
+	return &storagetypes.BucketInfo{
+		Id:               sdkmath.NewUint(id),
+		BucketName:       name,
+		Owner:            "owner",
+		PaymentAddress:   "payment_" + name,
+		PrimarySpAddress: testOperateAddress,
+		BucketStatus:     storagetypes.BUCKET_STATUS_CREATED,
+		CreateAt:         createAt,
+	}
+}
+
+func setupDiscontinueTest(t *testing.T, rules ...string) (*bucketDiscontinuer, *consensus.MemoryConsensus,
+	*mockDiscontinueDB, *mockDiscontinueClient, *mockNotifier) {
+	chain := consensus.NewMemoryConsensus()
+	db := &mockDiscontinueDB{flags: make(map[string]*spdb.DiscontinueBucketFlag)}
+	client := &mockDiscontinueClient{}
+	notifier := &mockNotifier{}
+	d := &bucketDiscontinuer{
+		chain:          chain,
+		db:             db,
+		client:         client,
+		operateAddress: testOperateAddress,
+		keepAliveDays:  7,
+		notifiers:      []DiscontinueNotifier{notifier},
+	}
+	for _, name := range rules {
+		rule, err := NewDiscontinueRule(name, chain, d.keepAliveDays)
+		assert.Nil(t, err)
+		d.rules = append(d.rules, rule)
+	}
+	return d, chain, db, client, notifier
+}
+
+func TestDiscontinueRules(t *testing.T) {
+	chain := consensus.NewMemoryConsensus()
+	ctx := context.TODO()
+	old := &discontinueCandidate{bucketInfo: newTestBucket(1, "old", time.Now().AddDate(0, 0, -8).Unix())}
+	recent := &discontinueCandidate{bucketInfo: newTestBucket(2, "recent", time.Now().AddDate(0, 0, -6).Unix())}
+
+	keepAlive, err := NewDiscontinueRule(DiscontinueRuleKeepAlive, chain, 7)
+	assert.Nil(t, err)
+	reason, matched, err := keepAlive.Evaluate(ctx, old)
+	assert.Nil(t, err)
+	assert.True(t, matched)
+	assert.Equal(t, DiscontinueBucketReason, reason)
+	_, matched, _ = keepAlive.Evaluate(ctx, recent)
+	assert.False(t, matched)
+
+	frozen, err := NewDiscontinueRule(DiscontinueRuleFrozenPayment, chain, 7)
+	assert.Nil(t, err)
+	// the payment stream is not found
+	_, _, err = frozen.Evaluate(ctx, old)
+	assert.NotNil(t, err)
+	chain.SetStreamRecord("payment_old", &paymenttypes.StreamRecord{Status: paymenttypes.STREAM_ACCOUNT_STATUS_FROZEN})
+	chain.SetStreamRecord("payment_recent", &paymenttypes.StreamRecord{Status: paymenttypes.STREAM_ACCOUNT_STATUS_ACTIVE})
+	reason, matched, err = frozen.Evaluate(ctx, old)
+	assert.Nil(t, err)
+	assert.True(t, matched)
+	assert.Equal(t, "payment stream of payment_old is frozen", reason)
+	_, matched, err = frozen.Evaluate(ctx, recent)
+	assert.Nil(t, err)
+	assert.False(t, matched)
+
+	violation, err := NewDiscontinueRule(DiscontinueRulePolicyViolation, chain, 7)
+	assert.Nil(t, err)
+	_, matched, _ = violation.Evaluate(ctx, old)
+	assert.False(t, matched)
+	// the flag of the other rule is not matched
+	old.flag = &spdb.DiscontinueBucketFlag{BucketName: "old", Rule: DiscontinueRuleAdminRequest}
+	_, matched, _ = violation.Evaluate(ctx, old)
+	assert.False(t, matched)
+	// the rule name is the reason if the flag has no reason
+	old.flag.Rule = DiscontinueRulePolicyViolation
+	reason, matched, _ = violation.Evaluate(ctx, old)
+	assert.True(t, matched)
+	assert.Equal(t, DiscontinueRulePolicyViolation, reason)
+	old.flag.Reason = "phishing"
+	reason, _, _ = violation.Evaluate(ctx, old)
+	assert.Equal(t, "phishing", reason)
+
+	_, err = NewDiscontinueRule("unknown", chain, 7)
+	assert.NotNil(t, err)
+}
+
+func TestBucketDiscontinuer_ListCandidatesPaging(t *testing.T) {
+	d, chain, db, client, _ := setupDiscontinueTest(t, DiscontinueRuleKeepAlive, DiscontinueRuleAdminRequest)
+	createAt := time.Now().AddDate(0, 0, -30).Unix()
+	count := int(DiscontinueBucketLimit) + 10
+	for i := 0; i < count; i++ {
+		bucket := newTestBucket(uint64(i+1), fmt.Sprintf("bucket-%04d", i), createAt+int64(i/2))
+		client.buckets = append(client.buckets, bucket)
+		chain.PutBucket(bucket)
+	}
+	// the flagged bucket is listed once, the flag of the bucket served by other sp is deleted
+	other := newTestBucket(uint64(count+1), "other", createAt)
+	other.PrimarySpAddress = "other_sp"
+	chain.PutBucket(other)
+	db.flags["bucket-0000"] = &spdb.DiscontinueBucketFlag{BucketName: "bucket-0000", Rule: DiscontinueRuleAdminRequest}
+	db.flags["other"] = &spdb.DiscontinueBucketFlag{BucketName: "other", Rule: DiscontinueRuleAdminRequest}
+
+	candidates := d.listCandidates(context.TODO())
+	assert.Equal(t, int(DiscontinueBucketLimit), len(candidates))
+	assert.NotNil(t, candidates[0].flag)
+	assert.Equal(t, "bucket-0000", candidates[0].bucketInfo.GetBucketName())
+	assert.NotContains(t, db.flags, "other")
+	last := client.buckets[DiscontinueBucketLimit-1]
+	assert.Equal(t, spdb.DiscontinueBucketCursor{CreateAt: last.GetCreateAt(), BucketID: int64(last.Id.Uint64())}, db.cursor)
+
+	// the next round continues after the cursor and starts over once all the buckets are listed
+	candidates = d.listCandidates(context.TODO())
+	assert.Equal(t, 11, len(candidates))
+	assert.Equal(t, fmt.Sprintf("bucket-%04d", DiscontinueBucketLimit), candidates[1].bucketInfo.GetBucketName())
+	assert.Equal(t, spdb.DiscontinueBucketCursor{}, db.cursor)
+}
+
+func TestBucketDiscontinuer_ListCreateAt(t *testing.T) {
+	d, _, _, client, _ := setupDiscontinueTest(t, DiscontinueRuleKeepAlive)
+	d.listCandidates(context.TODO())
+	// only the buckets past the keep alive days are listed
+	assert.InDelta(t, time.Now().AddDate(0, 0, -7).Unix(), client.createAt[0], 5)
+
+	d, _, _, client, _ = setupDiscontinueTest(t, DiscontinueRuleKeepAlive, DiscontinueRuleFrozenPayment)
+	d.listCandidates(context.TODO())
+	assert.InDelta(t, time.Now().Unix(), client.createAt[0], 5)
+
+	// the buckets are not listed if only the flagged rules are enabled
+	d, _, _, client, _ = setupDiscontinueTest(t, DiscontinueRulePolicyViolation)
+	d.listCandidates(context.TODO())
+	assert.Empty(t, client.createAt)
+}
+
+func TestBucketDiscontinuer_Discontinue(t *testing.T) {
+	d, chain, db, client, notifier := setupDiscontinueTest(t, DiscontinueRuleKeepAlive, DiscontinueRuleAdminRequest)
+	old := newTestBucket(1, "old", time.Now().AddDate(0, 0, -8).Unix())
+	flagged := newTestBucket(2, "flagged", time.Now().Unix())
+	chain.PutBucket(flagged)
+	client.buckets = []*storagetypes.BucketInfo{old, newTestBucket(3, "recent", time.Now().Unix())}
+	db.flags["flagged"] = &spdb.DiscontinueBucketFlag{BucketName: "flagged", Rule: DiscontinueRuleAdminRequest,
+		Reason: "requested"}
+
+	// the decisions are reported but not sent in the dry run
+	d.dryRun = true
+	d.discontinueBuckets(context.TODO())
+	assert.Empty(t, client.discontinued)
+	assert.Equal(t, 1, len(notifier.reports))
+	assert.True(t, notifier.reports[0].DryRun)
+	assert.Equal(t, []*DiscontinueDecision{
+		{BucketName: "flagged", Rule: DiscontinueRuleAdminRequest, Reason: "requested"},
+		{BucketName: "old", Rule: DiscontinueRuleKeepAlive, Reason: DiscontinueBucketReason},
+	}, notifier.reports[0].Decisions)
+	assert.Contains(t, db.flags, "flagged")
+
+	// the failure is reported and the flag is kept
+	d.dryRun = false
+	client.err = errors.New("signer unavailable")
+	d.discontinueBuckets(context.TODO())
+	assert.Equal(t, 2, len(notifier.reports))
+	assert.Equal(t, "signer unavailable", notifier.reports[1].Decisions[0].Error)
+	assert.Contains(t, db.flags, "flagged")
+
+	// the flag is deleted once the bucket is discontinued
+	client.err = nil
+	d.discontinueBuckets(context.TODO())
+	assert.Equal(t, []string{"flagged", "old"}, client.discontinued)
+	assert.NotContains(t, db.flags, "flagged")
+
+	// no report is notified if no bucket is matched
+	client.buckets = client.buckets[1:]
+	d.discontinueBuckets(context.TODO())
+	assert.Equal(t, 3, len(notifier.reports))
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var received *DiscontinueReport
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		received = &DiscontinueReport{}
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(received))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	report := &DiscontinueReport{DryRun: true, Timestamp: 100, Decisions: []*DiscontinueDecision{
+		{BucketName: "old", Rule: DiscontinueRuleKeepAlive, Reason: DiscontinueBucketReason}}}
+	notifier := NewWebhookNotifier(server.URL)
+	assert.Nil(t, notifier.Notify(context.TODO(), report))
+	assert.Equal(t, report, received)
+
+	status = http.StatusInternalServerError
+	assert.NotNil(t, notifier.Notify(context.TODO(), report))
+}
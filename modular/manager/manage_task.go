@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/bnb-chain/greenfield-storage-provider/base/gfspapp"
 	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfsperrors"
 	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfsptask"
 	"github.com/bnb-chain/greenfield-storage-provider/core/module"
@@ -14,16 +15,19 @@ import (
 	"github.com/bnb-chain/greenfield-storage-provider/core/taskqueue"
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/metrics"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/tracing"
 	"github.com/bnb-chain/greenfield-storage-provider/store/types"
+	"github.com/bnb-chain/greenfield-storage-provider/util"
 )
 
 var (
-	ErrDanglingTask  = gfsperrors.Register(module.ManageModularName, http.StatusBadRequest, 60001, "OoooH... request lost")
-	ErrRepeatedTask  = gfsperrors.Register(module.ManageModularName, http.StatusNotAcceptable, 60002, "request repeated")
-	ErrExceedTask    = gfsperrors.Register(module.ManageModularName, http.StatusNotAcceptable, 60003, "OoooH... request exceed, try again later")
-	ErrCanceledTask  = gfsperrors.Register(module.ManageModularName, http.StatusBadRequest, 60004, "task canceled")
-	ErrFutureSupport = gfsperrors.Register(module.ManageModularName, http.StatusNotFound, 60005, "future support")
-	ErrGfSpDB        = gfsperrors.Register(module.DownloadModularName, http.StatusInternalServerError, 65201, "server slipped away, try again later")
+	ErrDanglingTask    = gfsperrors.Register(module.ManageModularName, http.StatusBadRequest, 60001, "OoooH... request lost")
+	ErrRepeatedTask    = gfsperrors.Register(module.ManageModularName, http.StatusNotAcceptable, 60002, "request repeated")
+	ErrExceedTask      = gfsperrors.Register(module.ManageModularName, http.StatusNotAcceptable, 60003, "OoooH... request exceed, try again later")
+	ErrCanceledTask    = gfsperrors.Register(module.ManageModularName, http.StatusBadRequest, 60004, "task canceled")
+	ErrFutureSupport   = gfsperrors.Register(module.ManageModularName, http.StatusNotFound, 60005, "future support")
+	ErrInvalidObjectID = gfsperrors.Register(module.ManageModularName, http.StatusBadRequest, 60006, "invalid object id")
+	ErrGfSpDB          = gfsperrors.Register(module.DownloadModularName, http.StatusInternalServerError, 65201, "server slipped away, try again later")
 )
 
 func (m *ManageModular) DispatchTask(ctx context.Context, limit rcmgr.Limit) (task.Task, error) {
@@ -32,7 +36,6 @@ func (m *ManageModular) DispatchTask(ctx context.Context, limit rcmgr.Limit) (ta
 		task        task.Task
 	)
 	m.mux.Lock()
-	defer m.mux.Unlock()
 	task = m.replicateQueue.TopByLimit(limit)
 	if task != nil {
 		log.CtxDebugw(ctx, "add replicate piece task to backup set", "task_key", task.Key().String(),
@@ -69,10 +72,22 @@ func (m *ManageModular) DispatchTask(ctx context.Context, limit rcmgr.Limit) (ta
 			"task_limit", task.EstimateLimit().String())
 		backupTasks = append(backupTasks, task)
 	}
+	task = m.tierObjectQueue.TopByLimit(limit)
+	if task != nil {
+		log.CtxDebugw(ctx, "add tier object task to backup set", "task_key", task.Key().String(),
+			"task_limit", task.EstimateLimit().String())
+		backupTasks = append(backupTasks, task)
+	}
 	task = m.PickUpTask(ctx, backupTasks)
+	m.mux.Unlock()
 	if task == nil {
 		return nil, nil
 	}
+	dispatched := newTaskEvent(task, spdb.TaskEventDispatched)
+	// the base app increases the retry and sets the executor address after dispatching.
+	dispatched.Address = gfspapp.RpcRemoteAddress(ctx)
+	dispatched.Retry++
+	m.taskEvents.Record(dispatched)
 	return task, nil
 }
 
@@ -98,6 +113,7 @@ func (m *ManageModular) HandleCreateUploadObjectTask(ctx context.Context, task t
 		log.CtxErrorw(ctx, "failed to create upload object progress", "task_info", task.Info(), "error", err)
 		return ErrGfSpDB
 	}
+	m.recordTaskEvent(task, spdb.TaskEventCreated)
 	return nil
 }
 
@@ -117,6 +133,7 @@ func (m *ManageModular) HandleDoneUploadObjectTask(ctx context.Context, task tas
 		return ErrRepeatedTask
 	}
 	if task.Error() != nil {
+		m.recordTaskEvent(task, spdb.TaskEventErrored)
 		startUpdateSPDBTime := time.Now()
 		err := m.baseApp.GfSpDB().UpdateUploadProgress(&spdb.UploadObjectMeta{
 			ObjectID:         task.GetObjectInfo().Id.Uint64(),
@@ -140,11 +157,15 @@ func (m *ManageModular) HandleDoneUploadObjectTask(ctx context.Context, task tas
 			"task_info", task.Info(), "error", task.Error(), "reject_unseal_error", err)
 		return nil
 	}
+	m.recordTaskEvent(task, spdb.TaskEventSucceeded)
 	replicateTask := &gfsptask.GfSpReplicatePieceTask{}
 	replicateTask.InitReplicatePieceTask(task.GetObjectInfo(), task.GetStorageParams(),
 		m.baseApp.TaskPriority(replicateTask),
 		m.baseApp.TaskTimeout(replicateTask, task.GetObjectInfo().GetPayloadSize()),
 		m.baseApp.TaskMaxRetry(replicateTask))
+	replicateTask.SetBucketInfo(task.GetBucketInfo())
+	// the executor continues the trace of upload object by the replicate task.
+	replicateTask.GetTask().SetTraceCarrier(tracing.InjectMap(ctx))
 
 	startPushReplicateQueueTime := time.Now()
 	err := m.replicateQueue.Push(replicateTask)
@@ -155,6 +176,7 @@ func (m *ManageModular) HandleDoneUploadObjectTask(ctx context.Context, task tas
 		log.CtxErrorw(ctx, "failed to push replicate piece task to queue", "error", err)
 		return err
 	}
+	m.recordTaskEvent(replicateTask, spdb.TaskEventCreated)
 	startUpdateSPDBTime := time.Now()
 	err = m.baseApp.GfSpDB().UpdateUploadProgress(&spdb.UploadObjectMeta{
 		ObjectID:  task.GetObjectInfo().Id.Uint64(),
@@ -184,6 +206,7 @@ func (m *ManageModular) HandleReplicatePieceTask(ctx context.Context, task task.
 		log.CtxErrorw(ctx, "replicate piece object task repeated")
 		return ErrRepeatedTask
 	}
+	m.recordTaskEvent(task, spdb.TaskEventSucceeded)
 	if task.GetSealed() {
 		metrics.SealObjectSucceedCounter.WithLabelValues(m.Name()).Inc()
 		log.CtxDebugw(ctx, "replicate piece object task has combined seal object task")
@@ -203,11 +226,13 @@ func (m *ManageModular) HandleReplicatePieceTask(ctx context.Context, task task.
 	sealObject.InitSealObjectTask(task.GetObjectInfo(), task.GetStorageParams(),
 		m.baseApp.TaskPriority(sealObject), task.GetSecondaryAddresses(), task.GetSecondarySignatures(),
 		m.baseApp.TaskTimeout(sealObject, 0), m.baseApp.TaskMaxRetry(sealObject))
+	sealObject.GetTask().SetTraceCarrier(tracing.InjectMap(ctx))
 	err := m.sealQueue.Push(sealObject)
 	if err != nil {
 		log.CtxErrorw(ctx, "failed to push seal object task to queue", "task_info", task.Info(), "error", err)
 		return ErrExceedTask
 	}
+	m.recordTaskEvent(sealObject, spdb.TaskEventCreated)
 	if err = m.baseApp.GfSpDB().UpdateUploadProgress(&spdb.UploadObjectMeta{
 		ObjectID:            task.GetObjectInfo().Id.Uint64(),
 		TaskState:           types.TaskState_TASK_STATE_SEAL_OBJECT_DOING,
@@ -223,6 +248,7 @@ func (m *ManageModular) HandleReplicatePieceTask(ctx context.Context, task task.
 }
 
 func (m *ManageModular) handleFailedReplicatePieceTask(ctx context.Context, handleTask task.ReplicatePieceTask) error {
+	m.recordTaskEvent(handleTask, spdb.TaskEventErrored)
 	oldTask := m.replicateQueue.PopByKey(handleTask.Key())
 	if m.TaskUploading(ctx, handleTask) {
 		log.CtxErrorw(ctx, "replicate piece task repeated", "task_info", handleTask.Info())
@@ -237,7 +263,9 @@ func (m *ManageModular) handleFailedReplicatePieceTask(ctx context.Context, hand
 		handleTask.SetUpdateTime(time.Now().Unix())
 		err := m.replicateQueue.Push(handleTask)
 		log.CtxDebugw(ctx, "push task again to retry", "task_info", handleTask.Info(), "error", err)
+		m.recordTaskEvent(handleTask, spdb.TaskEventRetried)
 	} else {
+		m.recordTaskEvent(handleTask, spdb.TaskEventRetired)
 		if err := m.baseApp.GfSpDB().UpdateUploadProgress(&spdb.UploadObjectMeta{
 			ObjectID:         handleTask.GetObjectInfo().Id.Uint64(),
 			TaskState:        types.TaskState_TASK_STATE_REPLICATE_OBJECT_ERROR,
@@ -264,6 +292,7 @@ func (m *ManageModular) HandleSealObjectTask(ctx context.Context, task task.Seal
 	}
 	metrics.SealObjectSucceedCounter.WithLabelValues(m.Name()).Inc()
 	m.sealQueue.PopByKey(task.Key())
+	m.recordTaskEvent(task, spdb.TaskEventSucceeded)
 	if err := m.baseApp.GfSpDB().UpdateUploadProgress(&spdb.UploadObjectMeta{
 		ObjectID:  task.GetObjectInfo().Id.Uint64(),
 		TaskState: types.TaskState_TASK_STATE_SEAL_OBJECT_DONE,
@@ -278,6 +307,7 @@ func (m *ManageModular) HandleSealObjectTask(ctx context.Context, task task.Seal
 }
 
 func (m *ManageModular) handleFailedSealObjectTask(ctx context.Context, handleTask task.SealObjectTask) error {
+	m.recordTaskEvent(handleTask, spdb.TaskEventErrored)
 	oldTask := m.sealQueue.PopByKey(handleTask.Key())
 	if m.TaskUploading(ctx, handleTask) {
 		log.CtxErrorw(ctx, "seal object task repeated", "task_info", handleTask.Info())
@@ -292,9 +322,11 @@ func (m *ManageModular) handleFailedSealObjectTask(ctx context.Context, handleTa
 		handleTask.SetUpdateTime(time.Now().Unix())
 		err := m.sealQueue.Push(handleTask)
 		log.CtxDebugw(ctx, "push task again to retry", "task_info", handleTask.Info(), "error", err)
+		m.recordTaskEvent(handleTask, spdb.TaskEventRetried)
 		return nil
 	} else {
 		metrics.SealObjectFailedCounter.WithLabelValues(m.Name()).Inc()
+		m.recordTaskEvent(handleTask, spdb.TaskEventRetired)
 		if err := m.baseApp.GfSpDB().UpdateUploadProgress(&spdb.UploadObjectMeta{
 			ObjectID:         handleTask.GetObjectInfo().Id.Uint64(),
 			TaskState:        types.TaskState_TASK_STATE_SEAL_OBJECT_ERROR,
@@ -310,6 +342,7 @@ func (m *ManageModular) handleFailedSealObjectTask(ctx context.Context, handleTa
 func (m *ManageModular) HandleReceivePieceTask(ctx context.Context, task task.ReceivePieceTask) error {
 	if task.GetSealed() {
 		m.receiveQueue.PopByKey(task.Key())
+		m.recordTaskEvent(task, spdb.TaskEventSucceeded)
 		log.CtxDebugw(ctx, "succeed to confirm receive piece seal on chain")
 	} else if task.Error() != nil {
 		return m.handleFailedReceivePieceTask(ctx, task)
@@ -321,11 +354,15 @@ func (m *ManageModular) HandleReceivePieceTask(ctx context.Context, task task.Re
 		task.SetUpdateTime(time.Now().Unix())
 		err := m.receiveQueue.Push(task)
 		log.CtxErrorw(ctx, "push receive task to queue", "error", err)
+		if err == nil {
+			m.recordTaskEvent(task, spdb.TaskEventCreated)
+		}
 	}
 	return nil
 }
 
 func (m *ManageModular) handleFailedReceivePieceTask(ctx context.Context, handleTask task.ReceivePieceTask) error {
+	m.recordTaskEvent(handleTask, spdb.TaskEventErrored)
 	oldTask := m.receiveQueue.PopByKey(handleTask.Key())
 	if oldTask == nil {
 		log.CtxErrorw(ctx, "task has been canceled", "task_info", handleTask.Info())
@@ -336,7 +373,9 @@ func (m *ManageModular) handleFailedReceivePieceTask(ctx context.Context, handle
 		handleTask.SetUpdateTime(time.Now().Unix())
 		err := m.receiveQueue.Push(handleTask)
 		log.CtxDebugw(ctx, "push task again to retry", "task_info", handleTask.Info(), "error", err)
+		m.recordTaskEvent(handleTask, spdb.TaskEventRetried)
 	} else {
+		m.recordTaskEvent(handleTask, spdb.TaskEventRetired)
 		log.CtxErrorw(ctx, "delete expired confirm receive piece task", "task_info", handleTask.Info())
 		// TODO: confirm it
 
@@ -357,6 +396,7 @@ func (m *ManageModular) HandleGCObjectTask(ctx context.Context, gcTask task.GCOb
 		log.CtxInfow(ctx, "succeed to finish the gc object task", "task_info", gcTask.Info())
 		m.gcObjectQueue.PopByKey(gcTask.Key())
 		m.baseApp.GfSpDB().DeleteGCObjectProgress(gcTask.Key().String())
+		m.recordTaskEvent(gcTask, spdb.TaskEventSucceeded)
 		return nil
 	}
 	gcTask.SetUpdateTime(time.Now().Unix())
@@ -393,6 +433,41 @@ func (m *ManageModular) HandleGCMetaTask(ctx context.Context, task task.GCMetaTa
 	return ErrFutureSupport
 }
 
+func (m *ManageModular) HandleTierObjectTask(ctx context.Context, task task.TierObjectTask) error {
+	if task == nil {
+		log.CtxErrorw(ctx, "failed to handle tier object due to task pointer dangling")
+		return ErrDanglingTask
+	}
+	if task.Error() != nil {
+		log.CtxErrorw(ctx, "handler error tier object task", "task_info", task.Info(), "error", task.Error())
+		return m.handleFailedTierObjectTask(ctx, task)
+	}
+	m.tierObjectQueue.PopByKey(task.Key())
+	m.recordTaskEvent(task, spdb.TaskEventSucceeded)
+	log.CtxDebugw(ctx, "succeed to tier object", "task_info", task.Info())
+	return nil
+}
+
+func (m *ManageModular) handleFailedTierObjectTask(ctx context.Context, handleTask task.TierObjectTask) error {
+	m.recordTaskEvent(handleTask, spdb.TaskEventErrored)
+	oldTask := m.tierObjectQueue.PopByKey(handleTask.Key())
+	if oldTask == nil {
+		log.CtxErrorw(ctx, "task has been canceled", "task_info", handleTask.Info())
+		return ErrCanceledTask
+	}
+	handleTask = oldTask.(task.TierObjectTask)
+	if !handleTask.ExceedRetry() {
+		handleTask.SetUpdateTime(time.Now().Unix())
+		err := m.tierObjectQueue.Push(handleTask)
+		log.CtxDebugw(ctx, "push task again to retry", "task_info", handleTask.Info(), "error", err)
+		m.recordTaskEvent(handleTask, spdb.TaskEventRetried)
+	} else {
+		m.recordTaskEvent(handleTask, spdb.TaskEventRetired)
+		log.CtxWarnw(ctx, "delete expired tier object task", "task_info", handleTask.Info())
+	}
+	return nil
+}
+
 func (m *ManageModular) HandleDownloadObjectTask(ctx context.Context, task task.DownloadObjectTask) error {
 	m.downloadQueue.Push(task)
 	log.CtxDebugw(ctx, "add download object task to queue")
@@ -413,6 +488,7 @@ func (m *ManageModular) QueryTasks(ctx context.Context, subKey task.TKey) ([]tas
 	gcObjectTasks, _ := taskqueue.ScanTQueueWithLimitBySubKey(m.gcObjectQueue, subKey)
 	gcZombieTasks, _ := taskqueue.ScanTQueueWithLimitBySubKey(m.gcZombieQueue, subKey)
 	gcMetaTasks, _ := taskqueue.ScanTQueueWithLimitBySubKey(m.gcMetaQueue, subKey)
+	tierObjectTasks, _ := taskqueue.ScanTQueueWithLimitBySubKey(m.tierObjectQueue, subKey)
 	downloadTasks, _ := taskqueue.ScanTQueueBySubKey(m.downloadQueue, subKey)
 	challengeTasks, _ := taskqueue.ScanTQueueBySubKey(m.challengeQueue, subKey)
 
@@ -424,7 +500,56 @@ func (m *ManageModular) QueryTasks(ctx context.Context, subKey task.TKey) ([]tas
 	tasks = append(tasks, gcObjectTasks...)
 	tasks = append(tasks, gcZombieTasks...)
 	tasks = append(tasks, gcMetaTasks...)
+	tasks = append(tasks, tierObjectTasks...)
 	tasks = append(tasks, downloadTasks...)
 	tasks = append(tasks, challengeTasks...)
 	return tasks, nil
 }
+
+func (m *ManageModular) QueryTaskEvents(ctx context.Context, objectID string, timeRange *spdb.TaskEventTimeRange) (
+	[]*spdb.TaskEvent, error) {
+	var (
+		events []*spdb.TaskEvent
+		err    error
+	)
+	if len(objectID) == 0 {
+		events, err = m.baseApp.GfSpDB().GetTaskEventsByTimeRange(timeRange)
+	} else {
+		id, parseErr := util.StringToUint64(objectID)
+		if parseErr != nil {
+			log.CtxErrorw(ctx, "failed to parse object id", "object_id", objectID, "error", parseErr)
+			return nil, ErrInvalidObjectID
+		}
+		events, err = m.baseApp.GfSpDB().GetTaskEventsByObjectID(id, timeRange)
+	}
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to query task events", "object_id", objectID, "error", err)
+		return nil, ErrGfSpDB
+	}
+	return events, nil
+}
+
+// newTaskEvent returns the task state transition event of the task.
+func newTaskEvent(t task.Task, event spdb.TaskEventType) *spdb.TaskEvent {
+	taskEvent := &spdb.TaskEvent{
+		TaskKey:         t.Key().String(),
+		TaskType:        task.TaskTypeName(t.Type()),
+		Event:           event,
+		Address:         t.GetAddress(),
+		Retry:           t.GetRetry(),
+		TimestampSecond: time.Now().Unix(),
+	}
+	if objectTask, ok := t.(task.ObjectTask); ok && objectTask.GetObjectInfo() != nil {
+		taskEvent.ObjectID = objectTask.GetObjectInfo().Id.Uint64()
+	}
+	if t.Error() != nil {
+		taskEvent.ErrorDescription = t.Error().Error()
+	}
+	return taskEvent
+}
+
+// recordTaskEvent records the task state transition to db asynchronously, the failure does
+// not affect the task handling.
+func (m *ManageModular) recordTaskEvent(t task.Task, event spdb.TaskEventType) {
+	m.taskEvents.Record(newTaskEvent(t, event))
+}
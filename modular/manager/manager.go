@@ -7,11 +7,13 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bnb-chain/greenfield-storage-provider/base/gfspapp"
 	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfsptask"
 	"github.com/bnb-chain/greenfield-storage-provider/core/module"
+	"github.com/bnb-chain/greenfield-storage-provider/core/piecestore"
 	"github.com/bnb-chain/greenfield-storage-provider/core/rcmgr"
 	"github.com/bnb-chain/greenfield-storage-provider/core/spdb"
 	"github.com/bnb-chain/greenfield-storage-provider/core/task"
@@ -50,15 +52,16 @@ type ManageModular struct {
 	loadTaskLimitToSeal      int
 	loadTaskLimitToGC        int
 
-	uploadQueue    taskqueue.TQueueOnStrategy
-	replicateQueue taskqueue.TQueueOnStrategyWithLimit
-	sealQueue      taskqueue.TQueueOnStrategyWithLimit
-	receiveQueue   taskqueue.TQueueOnStrategyWithLimit
-	gcObjectQueue  taskqueue.TQueueOnStrategyWithLimit
-	gcZombieQueue  taskqueue.TQueueOnStrategyWithLimit
-	gcMetaQueue    taskqueue.TQueueOnStrategyWithLimit
-	downloadQueue  taskqueue.TQueueOnStrategy
-	challengeQueue taskqueue.TQueueOnStrategy
+	uploadQueue     taskqueue.TQueueOnStrategy
+	replicateQueue  taskqueue.TQueueOnStrategyWithLimit
+	sealQueue       taskqueue.TQueueOnStrategyWithLimit
+	receiveQueue    taskqueue.TQueueOnStrategyWithLimit
+	gcObjectQueue   taskqueue.TQueueOnStrategyWithLimit
+	gcZombieQueue   taskqueue.TQueueOnStrategyWithLimit
+	gcMetaQueue     taskqueue.TQueueOnStrategyWithLimit
+	tierObjectQueue taskqueue.TQueueOnStrategyWithLimit
+	downloadQueue   taskqueue.TQueueOnStrategy
+	challengeQueue  taskqueue.TQueueOnStrategy
 
 	maxUploadObjectNumber int
 
@@ -70,9 +73,30 @@ type ManageModular struct {
 	syncConsensusInfoInterval uint64
 	statisticsOutputInterval  int
 
-	discontinueBucketEnabled       bool
-	discontinueBucketTimeInterval  int
-	discontinueBucketKeepAliveDays int
+	discontinueBucketEnabled      bool
+	discontinueBucketTimeInterval int
+	discontinuer                  *bucketDiscontinuer
+
+	tierObjectEnabled      bool
+	tierObjectTimeInterval int64
+	tierColdAfterDays      int
+	tierPromoteReadCount   int
+	tierPromoteWindowDays  int
+	tierBatchSize          int
+
+	rejectUnsealEnabled  bool
+	rejectUnsealInterval int64
+	rejecter             *unsealRejecter
+
+	packCompactEnabled  bool
+	packCompactInterval int64
+	packCompacting      atomic.Bool
+
+	orphanRemoveInterval int64
+	orphanRemoving       atomic.Bool
+
+	taskEvents             *taskEventWriter
+	taskEventPurgeInterval int64
 }
 
 func (m *ManageModular) Name() string {
@@ -89,6 +113,8 @@ func (m *ManageModular) Start(ctx context.Context) error {
 	m.receiveQueue.SetFilterTaskStrategy(m.FilterUploadingTask)
 	m.gcObjectQueue.SetRetireTaskStrategy(m.ResetGCObjectTask)
 	m.gcObjectQueue.SetFilterTaskStrategy(m.FilterGCTask)
+	m.tierObjectQueue.SetRetireTaskStrategy(m.GCTierObjectQueue)
+	m.tierObjectQueue.SetFilterTaskStrategy(m.FilterUploadingTask)
 	m.downloadQueue.SetRetireTaskStrategy(m.GCCacheQueue)
 	m.challengeQueue.SetRetireTaskStrategy(m.GCCacheQueue)
 
@@ -102,6 +128,7 @@ func (m *ManageModular) Start(ctx context.Context) error {
 		return err
 	}
 
+	m.taskEvents.Start()
 	go m.eventLoop(ctx)
 	return nil
 }
@@ -112,6 +139,12 @@ func (m *ManageModular) eventLoop(ctx context.Context) {
 	syncConsensusInfoTicker := time.NewTicker(time.Duration(m.syncConsensusInfoInterval) * time.Second)
 	statisticsTicker := time.NewTicker(time.Duration(m.statisticsOutputInterval) * time.Second)
 	discontinueBucketTicker := time.NewTicker(time.Duration(m.discontinueBucketTimeInterval) * time.Second)
+	tierObjectTicker := time.NewTicker(time.Duration(m.tierObjectTimeInterval) * time.Second)
+	rejectUnsealTicker := time.NewTicker(time.Duration(m.rejectUnsealInterval) * time.Second)
+	purgeTaskEventTicker := time.NewTicker(time.Duration(m.taskEventPurgeInterval) * time.Second)
+	compactPackTicker := time.NewTicker(time.Duration(m.packCompactInterval) * time.Second)
+	removeOrphanTicker := time.NewTicker(time.Duration(m.orphanRemoveInterval) * time.Second)
+	m.removeOrphanFiles(ctx)
 	for {
 		select {
 		case <-ctx.Done():
@@ -140,6 +173,7 @@ func (m *ManageModular) eventLoop(ctx context.Context) {
 			task.InitGCObjectTask(m.baseApp.TaskPriority(task), start, end, m.baseApp.TaskTimeout(task, 0))
 			err = m.gcObjectQueue.Push(task)
 			if err == nil {
+				m.recordTaskEvent(task, spdb.TaskEventCreated)
 				metrics.GCBlockNumberGauge.WithLabelValues(m.Name()).Set(float64(m.gcBlockHeight))
 				m.gcBlockHeight = end + 1
 
@@ -156,41 +190,111 @@ func (m *ManageModular) eventLoop(ctx context.Context) {
 			if !m.discontinueBucketEnabled {
 				continue
 			}
-			m.discontinueBuckets(ctx)
+			m.discontinuer.discontinueBuckets(ctx)
 			log.Infof("finish to discontinue buckets", "time", time.Now())
+		case <-tierObjectTicker.C:
+			if !m.tierObjectEnabled {
+				continue
+			}
+			m.generateTierObjectTasks(ctx)
+		case <-rejectUnsealTicker.C:
+			if !m.rejectUnsealEnabled {
+				continue
+			}
+			m.rejecter.sweepUnsealedObjects(ctx)
+		case <-purgeTaskEventTicker.C:
+			m.taskEvents.Purge(ctx)
+		case <-compactPackTicker.C:
+			if !m.packCompactEnabled {
+				continue
+			}
+			m.compactPacks(ctx)
+		case <-removeOrphanTicker.C:
+			m.removeOrphanFiles(ctx)
 		}
 	}
 }
 
-func (m *ManageModular) discontinueBuckets(ctx context.Context) {
-	createAt := time.Now().AddDate(0, 0, -m.discontinueBucketKeepAliveDays)
-	buckets, err := m.baseApp.GfSpClient().ListExpiredBucketsBySp(context.Background(),
-		createAt.Unix(), m.baseApp.OperateAddress(), DiscontinueBucketLimit)
+// compactPacks compacts the pack files of the piece store in the background, the manager is
+// the only module that compacts the packs, and the next round is skipped if the previous one
+// is not finished.
+func (m *ManageModular) compactPacks(ctx context.Context) {
+	if m.baseApp.PieceStore() == nil || !m.packCompacting.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer m.packCompacting.Store(false)
+		m.baseApp.PieceStore().CompactPacks(ctx)
+	}()
+}
+
+// removeOrphanFiles removes the orphan files of the piece store in the background, the manager
+// is the only module that removes them, and the next round is skipped if the previous one is
+// not finished.
+func (m *ManageModular) removeOrphanFiles(ctx context.Context) {
+	if m.baseApp.PieceStore() == nil || !m.orphanRemoving.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer m.orphanRemoving.Store(false)
+		m.baseApp.PieceStore().RemoveOrphanFiles(ctx)
+	}()
+}
+
+// generateTierObjectTasks generates the tasks that demote the objects which are not read
+// for a long time to the cold tier, and promote the cold objects which are read repeatedly
+// back to the hot tier.
+func (m *ManageModular) generateTierObjectTasks(ctx context.Context) {
+	now := time.Now()
+	demoteIDs, err := m.baseApp.GfSpDB().GetObjectsToDemote(piecestore.ColdTier,
+		now.AddDate(0, 0, -m.tierColdAfterDays).Unix(), m.tierBatchSize)
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to query the objects to demote", "error", err)
+		return
+	}
+	promoteIDs, err := m.baseApp.GfSpDB().GetObjectsToPromote(piecestore.ColdTier,
+		now.AddDate(0, 0, -m.tierPromoteWindowDays).Unix(), m.tierPromoteReadCount, m.tierBatchSize)
 	if err != nil {
-		log.Errorw("failed to query expired buckets", "error", err)
+		log.CtxErrorw(ctx, "failed to query the objects to promote", "error", err)
 		return
 	}
+	for _, objectID := range promoteIDs {
+		m.pushTierObjectTask(ctx, objectID, piecestore.HotTier)
+	}
+	for _, objectID := range demoteIDs {
+		m.pushTierObjectTask(ctx, objectID, piecestore.ColdTier)
+	}
+	log.CtxInfow(ctx, "finish to generate tier object tasks", "demote_object_number", len(demoteIDs),
+		"promote_object_number", len(promoteIDs))
+}
 
-	for _, bucket := range buckets {
-		time.Sleep(1 * time.Second)
-		log.Infow("start to discontinue bucket", "bucket_name", bucket.BucketInfo.BucketName)
-		discontinueBucket := &storagetypes.MsgDiscontinueBucket{
-			BucketName: bucket.BucketInfo.BucketName,
-			Reason:     DiscontinueBucketReason,
-		}
-		err = m.baseApp.GfSpClient().DiscontinueBucket(ctx, discontinueBucket)
-		if err != nil {
-			log.Errorw("failed to discontinue bucket on chain", "bucket_name",
-				discontinueBucket.BucketName, "error", err)
-			continue
-		} else {
-			log.Infow("succeed to discontinue bucket", "bucket_name",
-				discontinueBucket.BucketName)
-		}
+func (m *ManageModular) pushTierObjectTask(ctx context.Context, objectID uint64, tier string) {
+	objectInfo, err := m.baseApp.Consensus().QueryObjectInfoByID(ctx, util.Uint64ToString(objectID))
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to query object info", "object_id", objectID, "error", err)
+		return
+	}
+	if objectInfo.GetObjectStatus() != storagetypes.OBJECT_STATUS_SEALED {
+		log.CtxDebugw(ctx, "object is not sealed, skip to tier", "object_id", objectID)
+		return
 	}
+	storageParams, err := m.baseApp.Consensus().QueryStorageParamsByTimestamp(ctx, objectInfo.GetCreateAt())
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to query storage params", "object_id", objectID, "error", err)
+		return
+	}
+	tierTask := &gfsptask.GfSpTierObjectTask{}
+	tierTask.InitTierObjectTask(objectInfo, storageParams, tier, m.baseApp.TaskPriority(tierTask),
+		m.baseApp.TaskTimeout(tierTask, objectInfo.GetPayloadSize()), m.baseApp.TaskMaxRetry(tierTask))
+	if err = m.tierObjectQueue.Push(tierTask); err != nil {
+		log.CtxDebugw(ctx, "failed to push tier object task to queue", "task_info", tierTask.Info(), "error", err)
+		return
+	}
+	m.recordTaskEvent(tierTask, spdb.TaskEventCreated)
 }
 
 func (m *ManageModular) Stop(ctx context.Context) error {
+	m.taskEvents.Stop()
 	m.scope.Release()
 	return nil
 }
@@ -260,6 +364,7 @@ func (m *ManageModular) LoadTaskFromDB() error {
 			log.Errorw("failed to push replicate piece task to queue", "object_info", objectInfo, "error", pushErr)
 			continue
 		}
+		m.recordTaskEvent(replicateTask, spdb.TaskEventCreated)
 		generateReplicateTaskCounter++
 	}
 
@@ -291,6 +396,7 @@ func (m *ManageModular) LoadTaskFromDB() error {
 			log.Errorw("failed to push seal object task to queue", "object_info", objectInfo, "error", pushErr)
 			continue
 		}
+		m.recordTaskEvent(sealTask, spdb.TaskEventCreated)
 		generateSealTaskCounter++
 	}
 
@@ -342,6 +448,7 @@ func (m *ManageModular) UploadingObjectNumber() int {
 func (m *ManageModular) GCUploadObjectQueue(qTask task.Task) bool {
 	task := qTask.(task.UploadObjectTask)
 	if task.Expired() {
+		m.recordTaskEvent(task, spdb.TaskEventRetired)
 		if err := m.baseApp.GfSpDB().UpdateUploadProgress(&spdb.UploadObjectMeta{
 			ObjectID:         task.GetObjectInfo().Id.Uint64(),
 			TaskState:        types.TaskState_TASK_STATE_UPLOAD_OBJECT_ERROR,
@@ -357,6 +464,7 @@ func (m *ManageModular) GCUploadObjectQueue(qTask task.Task) bool {
 func (m *ManageModular) GCReplicatePieceQueue(qTask task.Task) bool {
 	task := qTask.(task.ReplicatePieceTask)
 	if task.Expired() {
+		m.recordTaskEvent(task, spdb.TaskEventRetired)
 		if err := m.baseApp.GfSpDB().UpdateUploadProgress(&spdb.UploadObjectMeta{
 			ObjectID:         task.GetObjectInfo().Id.Uint64(),
 			TaskState:        types.TaskState_TASK_STATE_REPLICATE_OBJECT_ERROR,
@@ -372,6 +480,7 @@ func (m *ManageModular) GCReplicatePieceQueue(qTask task.Task) bool {
 func (m *ManageModular) GCSealObjectQueue(qTask task.Task) bool {
 	task := qTask.(task.SealObjectTask)
 	if task.Expired() {
+		m.recordTaskEvent(task, spdb.TaskEventRetired)
 		if err := m.baseApp.GfSpDB().UpdateUploadProgress(&spdb.UploadObjectMeta{
 			ObjectID:         task.GetObjectInfo().Id.Uint64(),
 			TaskState:        types.TaskState_TASK_STATE_SEAL_OBJECT_ERROR,
@@ -385,7 +494,11 @@ func (m *ManageModular) GCSealObjectQueue(qTask task.Task) bool {
 }
 
 func (m *ManageModular) GCReceiveQueue(qTask task.Task) bool {
-	return qTask.Expired()
+	if qTask.Expired() {
+		m.recordTaskEvent(qTask, spdb.TaskEventRetired)
+		return true
+	}
+	return false
 }
 
 func (m *ManageModular) ResetGCObjectTask(qTask task.Task) bool {
@@ -398,6 +511,14 @@ func (m *ManageModular) ResetGCObjectTask(qTask task.Task) bool {
 	return false
 }
 
+func (m *ManageModular) GCTierObjectQueue(qTask task.Task) bool {
+	if qTask.Expired() {
+		m.recordTaskEvent(qTask, spdb.TaskEventRetired)
+		return true
+	}
+	return false
+}
+
 func (m *ManageModular) GCCacheQueue(qTask task.Task) bool {
 	return true
 }
@@ -479,9 +600,9 @@ func (m *ManageModular) RejectUnSealObject(ctx context.Context, object *storaget
 
 func (m *ManageModular) Statistics() string {
 	return fmt.Sprintf(
-		"upload[%d], replicate[%d], seal[%d], receive[%d], gcObject[%d], gcZombie[%d], gcMeta[%d], download[%d], challenge[%d], gcBlockHeight[%d], gcSafeDistance[%d]",
+		"upload[%d], replicate[%d], seal[%d], receive[%d], gcObject[%d], gcZombie[%d], gcMeta[%d], tierObject[%d], download[%d], challenge[%d], gcBlockHeight[%d], gcSafeDistance[%d]",
 		m.uploadQueue.Len(), m.replicateQueue.Len(), m.sealQueue.Len(),
 		m.receiveQueue.Len(), m.gcObjectQueue.Len(), m.gcZombieQueue.Len(),
-		m.gcMetaQueue.Len(), m.downloadQueue.Len(), m.challengeQueue.Len(),
+		m.gcMetaQueue.Len(), m.tierObjectQueue.Len(), m.downloadQueue.Len(), m.challengeQueue.Len(),
 		m.gcBlockHeight, m.gcSafeBlockDistance)
 }
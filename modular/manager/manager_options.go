@@ -1,9 +1,13 @@
 package manager
 
 import (
+	"time"
+
 	"github.com/bnb-chain/greenfield-storage-provider/base/gfspapp"
 	"github.com/bnb-chain/greenfield-storage-provider/base/gfspconfig"
+	"github.com/bnb-chain/greenfield-storage-provider/base/gnfd"
 	coremodule "github.com/bnb-chain/greenfield-storage-provider/core/module"
+	"github.com/bnb-chain/greenfield-storage-provider/store/piecestore/storage"
 )
 
 const (
@@ -32,6 +36,9 @@ const (
 	// DefaultGlobalGCMetaParallel defines the default max parallel gc meta db in SP
 	// system.
 	DefaultGlobalGCMetaParallel int = 1
+	// DefaultGlobalTierObjectParallel defines the default max parallel migrating objects
+	// between the piece store tiers in SP system.
+	DefaultGlobalTierObjectParallel int = 4
 	// DefaultGlobalDownloadObjectTaskCacheSize defines the default max cache the download
 	// object tasks in manager.
 	DefaultGlobalDownloadObjectTaskCacheSize int = 4096
@@ -60,6 +67,24 @@ const (
 	// DefaultDiscontinueBucketKeepAliveDays defines the default bucket keep alive days, after
 	// the interval, buckets will be discontinued, used for test net.
 	DefaultDiscontinueBucketKeepAliveDays = 7
+	// DefaultDiscontinueBucketRule defines the default discontinue bucket rule, used for test net.
+	DefaultDiscontinueBucketRule = DiscontinueRuleKeepAlive
+
+	// DefaultRejectUnsealInterval defines the default interval for sweeping the objects that
+	// are stuck in the upload progress.
+	DefaultRejectUnsealInterval int64 = 10 * 60
+	// DefaultRejectUnsealDeadline defines the default seconds that the upload progress is not
+	// updated before the object is rejected.
+	DefaultRejectUnsealDeadline int64 = 24 * 60 * 60
+	// DefaultRejectUnsealBatchSize defines the default max number of the objects rejected
+	// in one sweep.
+	DefaultRejectUnsealBatchSize = 20
+
+	// DefaultTaskEventRetentionDays defines the default days that the task events are kept.
+	DefaultTaskEventRetentionDays = 7
+	// DefaultTaskEventPurgeInterval defines the default interval for purging the expired
+	// task events.
+	DefaultTaskEventPurgeInterval int64 = 60 * 60
 )
 
 func NewManageModular(app *gfspapp.GfSpBaseApp, cfg *gfspconfig.GfSpConfig) (coremodule.Modular, error) {
@@ -95,6 +120,24 @@ func DefaultManagerOptions(manager *ManageModular, cfg *gfspconfig.GfSpConfig) e
 	if cfg.Parallel.GlobalGCMetaParallel == 0 {
 		cfg.Parallel.GlobalGCMetaParallel = DefaultGlobalGCMetaParallel
 	}
+	if cfg.Parallel.GlobalTierObjectParallel == 0 {
+		cfg.Parallel.GlobalTierObjectParallel = DefaultGlobalTierObjectParallel
+	}
+	if cfg.PieceStore.Tiering.ColdAfterDays == 0 {
+		cfg.PieceStore.Tiering.ColdAfterDays = storage.DefaultTierColdAfterDays
+	}
+	if cfg.PieceStore.Tiering.PromoteReadCount == 0 {
+		cfg.PieceStore.Tiering.PromoteReadCount = storage.DefaultTierPromoteReadCount
+	}
+	if cfg.PieceStore.Tiering.PromoteWindowDays == 0 {
+		cfg.PieceStore.Tiering.PromoteWindowDays = storage.DefaultTierPromoteWindowDays
+	}
+	if cfg.PieceStore.Tiering.Interval == 0 {
+		cfg.PieceStore.Tiering.Interval = storage.DefaultTierInterval
+	}
+	if cfg.PieceStore.Tiering.BatchSize == 0 {
+		cfg.PieceStore.Tiering.BatchSize = storage.DefaultTierBatchSize
+	}
 	if cfg.Parallel.GlobalDownloadObjectTaskCacheSize == 0 {
 		cfg.Parallel.GlobalDownloadObjectTaskCacheSize = DefaultGlobalDownloadObjectTaskCacheSize
 	}
@@ -119,6 +162,24 @@ func DefaultManagerOptions(manager *ManageModular, cfg *gfspconfig.GfSpConfig) e
 	if cfg.Parallel.DiscontinueBucketKeepAliveDays == 0 {
 		cfg.Parallel.DiscontinueBucketKeepAliveDays = DefaultDiscontinueBucketKeepAliveDays
 	}
+	if len(cfg.Parallel.DiscontinueBucketRules) == 0 {
+		cfg.Parallel.DiscontinueBucketRules = []string{DefaultDiscontinueBucketRule}
+	}
+	if cfg.Manager.RejectUnsealInterval == 0 {
+		cfg.Manager.RejectUnsealInterval = DefaultRejectUnsealInterval
+	}
+	if cfg.Manager.RejectUnsealDeadline == 0 {
+		cfg.Manager.RejectUnsealDeadline = DefaultRejectUnsealDeadline
+	}
+	if cfg.Manager.RejectUnsealBatchSize == 0 {
+		cfg.Manager.RejectUnsealBatchSize = DefaultRejectUnsealBatchSize
+	}
+	if cfg.Manager.TaskEventRetentionDays == 0 {
+		cfg.Manager.TaskEventRetentionDays = DefaultTaskEventRetentionDays
+	}
+	if cfg.Manager.TaskEventPurgeInterval == 0 {
+		cfg.Manager.TaskEventPurgeInterval = DefaultTaskEventPurgeInterval
+	}
 
 	manager.enableLoadTask = cfg.Manager.EnableLoadTask
 	manager.loadTaskLimitToReplicate = cfg.Parallel.GlobalReplicatePieceParallel
@@ -133,7 +194,52 @@ func DefaultManagerOptions(manager *ManageModular, cfg *gfspconfig.GfSpConfig) e
 	manager.syncConsensusInfoInterval = cfg.Parallel.GlobalSyncConsensusInfoInterval
 	manager.discontinueBucketEnabled = cfg.Parallel.DiscontinueBucketEnabled
 	manager.discontinueBucketTimeInterval = cfg.Parallel.DiscontinueBucketTimeInterval
-	manager.discontinueBucketKeepAliveDays = cfg.Parallel.DiscontinueBucketKeepAliveDays
+	manager.discontinuer = &bucketDiscontinuer{
+		chain:          manager.baseApp.Consensus(),
+		db:             manager.baseApp.GfSpDB(),
+		client:         manager.baseApp.GfSpClient(),
+		operateAddress: manager.baseApp.OperateAddress(),
+		keepAliveDays:  cfg.Parallel.DiscontinueBucketKeepAliveDays,
+		dryRun:         cfg.Parallel.DiscontinueBucketDryRun,
+	}
+	for _, name := range cfg.Parallel.DiscontinueBucketRules {
+		rule, err := NewDiscontinueRule(name, manager.baseApp.Consensus(), cfg.Parallel.DiscontinueBucketKeepAliveDays)
+		if err != nil {
+			return err
+		}
+		manager.discontinuer.rules = append(manager.discontinuer.rules, rule)
+	}
+	for _, url := range cfg.Parallel.DiscontinueBucketNotifyURLs {
+		manager.discontinuer.notifiers = append(manager.discontinuer.notifiers, NewWebhookNotifier(url))
+	}
+	manager.packCompactEnabled = cfg.PieceStore.Pack.Enable
+	manager.packCompactInterval = cfg.PieceStore.Pack.CompactInterval
+	if manager.packCompactInterval <= 0 {
+		manager.packCompactInterval = storage.DefaultPackCompactInterval
+	}
+	manager.orphanRemoveInterval = storage.DefaultOrphanRemoveInterval
+	manager.tierObjectEnabled = cfg.PieceStore.Tiering.Enable
+	manager.tierObjectTimeInterval = cfg.PieceStore.Tiering.Interval
+	manager.tierColdAfterDays = cfg.PieceStore.Tiering.ColdAfterDays
+	manager.tierPromoteReadCount = cfg.PieceStore.Tiering.PromoteReadCount
+	manager.tierPromoteWindowDays = cfg.PieceStore.Tiering.PromoteWindowDays
+	manager.tierBatchSize = cfg.PieceStore.Tiering.BatchSize
+	manager.rejectUnsealEnabled = cfg.Manager.RejectUnsealEnabled
+	manager.rejectUnsealInterval = cfg.Manager.RejectUnsealInterval
+	manager.rejecter = &unsealRejecter{
+		chain:          manager.baseApp.Consensus(),
+		db:             manager.baseApp.GfSpDB(),
+		client:         manager.baseApp.GfSpClient(),
+		pieceStore:     manager.baseApp.PieceStore(),
+		pieceOp:        manager.baseApp.PieceOp(),
+		operateAddress: manager.baseApp.OperateAddress(),
+		uploading:      manager.objectUploading,
+		deadline:       cfg.Manager.RejectUnsealDeadline,
+		batchSize:      cfg.Manager.RejectUnsealBatchSize,
+		blockInterval:  gnfd.ExpectedOutputBlockInternal * time.Second,
+	}
+	manager.taskEventPurgeInterval = cfg.Manager.TaskEventPurgeInterval
+	manager.taskEvents = newTaskEventWriter(manager.baseApp.GfSpDB(), cfg.Manager.TaskEventRetentionDays)
 	manager.uploadQueue = cfg.Customize.NewStrategyTQueueFunc(
 		manager.Name()+"-upload-object", cfg.Parallel.GlobalUploadObjectParallel)
 	manager.replicateQueue = cfg.Customize.NewStrategyTQueueWithLimitFunc(
@@ -148,6 +254,8 @@ func DefaultManagerOptions(manager *ManageModular, cfg *gfspconfig.GfSpConfig) e
 		manager.Name()+"-gc-zombie", cfg.Parallel.GlobalGCZombieParallel)
 	manager.gcMetaQueue = cfg.Customize.NewStrategyTQueueWithLimitFunc(
 		manager.Name()+"-gc-meta", cfg.Parallel.GlobalGCMetaParallel)
+	manager.tierObjectQueue = cfg.Customize.NewStrategyTQueueWithLimitFunc(
+		manager.Name()+"-tier-object", cfg.Parallel.GlobalTierObjectParallel)
 	manager.downloadQueue = cfg.Customize.NewStrategyTQueueFunc(
 		manager.Name()+"-cache-download-object", cfg.Parallel.GlobalDownloadObjectTaskCacheSize)
 	manager.challengeQueue = cfg.Customize.NewStrategyTQueueFunc(
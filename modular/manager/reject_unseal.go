@@ -0,0 +1,297 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bnb-chain/greenfield-storage-provider/base/gfspapp"
+	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfsptask"
+	"github.com/bnb-chain/greenfield-storage-provider/core/consensus"
+	"github.com/bnb-chain/greenfield-storage-provider/core/piecestore"
+	"github.com/bnb-chain/greenfield-storage-provider/core/spdb"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/metrics"
+	"github.com/bnb-chain/greenfield-storage-provider/store/types"
+	"github.com/bnb-chain/greenfield-storage-provider/util"
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
+)
+
+const (
+	rejectUnsealResultRejected = "rejected"
+	rejectUnsealResultFailed   = "failed"
+	rejectUnsealResultSealed   = "sealed"
+	rejectUnsealResultSkipped  = "skipped"
+
+	// rejectUnsealConfirmBlocks defines the max blocks waiting for the reject txs to be included,
+	// the reject tx is broadcast in async mode.
+	rejectUnsealConfirmBlocks = 5
+)
+
+// rejectUnsealDB is the part of the sp db that rejecting the unsealed objects depends on.
+type rejectUnsealDB interface {
+	GetUploadMetasToReject(updatedBefore int64, limit int) ([]*spdb.UploadObjectMeta, error)
+	UpdateUploadProgress(uploadMeta *spdb.UploadObjectMeta) error
+	InsertGCZombiePieces(pieces []*spdb.GCZombiePiece) error
+	DeletePieceUsage(objectID uint64, replicateIdx int32) error
+	DeleteObjectIntegrity(objectID uint64) error
+	DeleteObjectTier(objectID uint64) error
+}
+
+// rejectUnsealClient is the part of the gfsp client that rejecting the unsealed objects depends on.
+type rejectUnsealClient interface {
+	RejectUnSealObject(ctx context.Context, object *storagetypes.MsgRejectSealObject) error
+}
+
+// pieceDeleter deletes the pieces from the piece store in batch.
+type pieceDeleter interface {
+	DeletePieces(ctx context.Context, keys []string) map[string]error
+}
+
+// unsealRejecter rejects the objects that are stuck in the upload progress of the SP.
+type unsealRejecter struct {
+	chain          consensus.Consensus
+	db             rejectUnsealDB
+	client         rejectUnsealClient
+	pieceStore     pieceDeleter
+	pieceOp        piecestore.PieceOp
+	operateAddress string
+	// uploading returns whether the object is still in the upload, replicate or seal queue
+	uploading     func(objectInfo *storagetypes.ObjectInfo) bool
+	deadline      int64
+	batchSize     int
+	blockInterval time.Duration
+	sweeping      atomic.Bool
+}
+
+// sweepUnsealedObjects rejects the objects that are created on chain but stuck in the upload
+// progress of this SP, whose upload never completed or whose replicate/seal exhausted the
+// retries, and the progress is not updated before the deadline. The pieces of the objects are
+// deleted once the reject is confirmed on chain, the objects that are sealed in the meantime
+// keep their pieces. The sweep runs in the background, and the next round is skipped if the
+// previous one is not finished.
+//
+// The failed objects are recorded as reject error, it refreshes the update time of the
+// progress, so they are retried after the next deadline instead of blocking the sweep.
+func (r *unsealRejecter) sweepUnsealedObjects(ctx context.Context) {
+	if !r.sweeping.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer r.sweeping.Store(false)
+		r.doSweepUnsealedObjects(ctx)
+	}()
+}
+
+func (r *unsealRejecter) doSweepUnsealedObjects(ctx context.Context) {
+	updatedBefore := time.Now().Unix() - r.deadline
+	metas, err := r.db.GetUploadMetasToReject(updatedBefore, r.batchSize)
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to query the stuck objects to reject", "error", err)
+		return
+	}
+	rejecting := make(map[*spdb.UploadObjectMeta]*storagetypes.ObjectInfo)
+	for _, meta := range metas {
+		objectInfo, result := r.rejectStuckObject(ctx, meta)
+		if objectInfo != nil {
+			rejecting[meta] = objectInfo
+			continue
+		}
+		metrics.RejectUnsealSweepCounter.WithLabelValues(result).Inc()
+	}
+	r.confirmRejectedObjects(ctx, rejecting)
+	log.CtxInfow(ctx, "finish to sweep the stuck objects", "object_number", len(metas))
+}
+
+// rejectStuckObject broadcasts the reject tx of the stuck object, it returns the object info
+// if the tx is broadcast, otherwise the sweep result.
+func (r *unsealRejecter) rejectStuckObject(ctx context.Context, meta *spdb.UploadObjectMeta) (
+	*storagetypes.ObjectInfo, string) {
+	objectInfo, err := r.chain.QueryObjectInfoByID(ctx, util.Uint64ToString(meta.ObjectID))
+	if err != nil {
+		if isNoSuchObjectErr(err) {
+			// the object is deleted or rejected by a previous sweep whose confirmation timed out,
+			// its size is unknown any more, the pieces are left to the orphan cleanup.
+			r.recordRejectUnseal(ctx, meta.ObjectID, types.TaskState_TASK_STATE_REJECT_UNSEAL_OBJECT_DONE,
+				"object is not found on chain")
+			return nil, rejectUnsealResultSkipped
+		}
+		r.recordRejectUnseal(ctx, meta.ObjectID, types.TaskState_TASK_STATE_REJECT_UNSEAL_OBJECT_ERROR,
+			fmt.Sprintf("failed to query object info: %v", err))
+		return nil, rejectUnsealResultFailed
+	}
+	switch objectInfo.GetObjectStatus() {
+	case storagetypes.OBJECT_STATUS_SEALED:
+		r.recordRejectUnseal(ctx, meta.ObjectID, types.TaskState_TASK_STATE_SEAL_OBJECT_DONE, "")
+		return nil, rejectUnsealResultSealed
+	case storagetypes.OBJECT_STATUS_CREATED:
+	default:
+		r.recordRejectUnseal(ctx, meta.ObjectID, types.TaskState_TASK_STATE_REJECT_UNSEAL_OBJECT_DONE,
+			"object status is "+objectInfo.GetObjectStatus().String())
+		return nil, rejectUnsealResultSkipped
+	}
+	bucketInfo, err := r.chain.QueryBucketInfo(ctx, objectInfo.GetBucketName())
+	if err != nil {
+		r.recordRejectUnseal(ctx, meta.ObjectID, types.TaskState_TASK_STATE_REJECT_UNSEAL_OBJECT_ERROR,
+			fmt.Sprintf("failed to query bucket info: %v", err))
+		return nil, rejectUnsealResultFailed
+	}
+	if bucketInfo.GetPrimarySpAddress() != r.operateAddress {
+		r.recordRejectUnseal(ctx, meta.ObjectID, types.TaskState_TASK_STATE_REJECT_UNSEAL_OBJECT_DONE,
+			"the sp is not the primary sp of the object")
+		return nil, rejectUnsealResultSkipped
+	}
+	if r.uploading(objectInfo) {
+		log.CtxDebugw(ctx, "object is still uploading, skip to reject", "object_id", meta.ObjectID)
+		return nil, rejectUnsealResultSkipped
+	}
+
+	// the signer also succeeds if the object is sealed before the reject, the object status is
+	// confirmed on chain before deleting the pieces.
+	err = r.client.RejectUnSealObject(ctx, &storagetypes.MsgRejectSealObject{
+		BucketName: objectInfo.GetBucketName(),
+		ObjectName: objectInfo.GetObjectName(),
+	})
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to reject the stuck object", "object_id", meta.ObjectID,
+			"task_state", meta.TaskState.String(), "error", err)
+		r.recordRejectUnseal(ctx, meta.ObjectID, types.TaskState_TASK_STATE_REJECT_UNSEAL_OBJECT_ERROR,
+			fmt.Sprintf("failed to reject unseal object: %v", err))
+		return nil, rejectUnsealResultFailed
+	}
+	return objectInfo, ""
+}
+
+// confirmRejectedObjects re-queries the objects whose reject txs are broadcast once a block,
+// the object that is not found on chain any more is rejected and its pieces are deleted, the
+// object that is sealed keeps its pieces. The objects that are still created after the max
+// blocks are recorded as reject error and retried by the later sweep.
+func (r *unsealRejecter) confirmRejectedObjects(ctx context.Context,
+	rejecting map[*spdb.UploadObjectMeta]*storagetypes.ObjectInfo) {
+	for i := 0; i < rejectUnsealConfirmBlocks && len(rejecting) > 0; i++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.blockInterval):
+		}
+		for meta, objectInfo := range rejecting {
+			result := r.confirmRejectedObject(ctx, meta, objectInfo)
+			if result == "" {
+				continue
+			}
+			metrics.RejectUnsealSweepCounter.WithLabelValues(result).Inc()
+			delete(rejecting, meta)
+		}
+	}
+	for meta := range rejecting {
+		log.CtxErrorw(ctx, "reject of the stuck object is not confirmed", "object_id", meta.ObjectID)
+		r.recordRejectUnseal(ctx, meta.ObjectID, types.TaskState_TASK_STATE_REJECT_UNSEAL_OBJECT_ERROR,
+			"reject unseal object is not confirmed on chain")
+		metrics.RejectUnsealSweepCounter.WithLabelValues(rejectUnsealResultFailed).Inc()
+	}
+}
+
+// confirmRejectedObject returns the sweep result of the object whose reject tx is broadcast,
+// or empty if the reject is not included yet.
+func (r *unsealRejecter) confirmRejectedObject(ctx context.Context, meta *spdb.UploadObjectMeta,
+	objectInfo *storagetypes.ObjectInfo) string {
+	current, err := r.chain.QueryObjectInfoByID(ctx, util.Uint64ToString(meta.ObjectID))
+	if err != nil {
+		if !isNoSuchObjectErr(err) {
+			return ""
+		}
+		log.CtxInfow(ctx, "succeed to reject the stuck object", "object_id", meta.ObjectID,
+			"task_state", meta.TaskState.String(), "error_description", meta.ErrorDescription)
+		r.recordRejectUnseal(ctx, meta.ObjectID, types.TaskState_TASK_STATE_REJECT_UNSEAL_OBJECT_DONE,
+			"rejected from "+meta.TaskState.String())
+		r.gcRejectedObject(ctx, objectInfo)
+		return rejectUnsealResultRejected
+	}
+	if current.GetObjectStatus() == storagetypes.OBJECT_STATUS_SEALED {
+		log.CtxInfow(ctx, "stuck object is sealed before the reject", "object_id", meta.ObjectID)
+		r.recordRejectUnseal(ctx, meta.ObjectID, types.TaskState_TASK_STATE_SEAL_OBJECT_DONE, "")
+		return rejectUnsealResultSealed
+	}
+	return ""
+}
+
+func isNoSuchObjectErr(err error) bool {
+	return strings.Contains(err.Error(), storagetypes.ErrNoSuchObject.Error())
+}
+
+// objectUploading returns whether the object is still in the upload, replicate or seal queue.
+func (m *ManageModular) objectUploading(objectInfo *storagetypes.ObjectInfo) bool {
+	bucket, object, id := objectInfo.GetBucketName(), objectInfo.GetObjectName(), objectInfo.Id.String()
+	return m.uploadQueue.Has(gfsptask.GfSpUploadObjectTaskKey(bucket, object, id)) ||
+		m.replicateQueue.Has(gfsptask.GfSpReplicatePieceTaskKey(bucket, object, id)) ||
+		m.sealQueue.Has(gfsptask.GfSpSealObjectTaskKey(bucket, object, id))
+}
+
+func (r *unsealRejecter) recordRejectUnseal(ctx context.Context, objectID uint64, state types.TaskState,
+	description string) {
+	if err := r.db.UpdateUploadProgress(&spdb.UploadObjectMeta{
+		ObjectID:         objectID,
+		TaskState:        state,
+		ErrorDescription: description,
+	}); err != nil {
+		log.CtxErrorw(ctx, "failed to update upload progress", "object_id", objectID,
+			"task_state", state.String(), "error", err)
+	}
+}
+
+// gcRejectedObject deletes the segment pieces, the piece usage and the meta of the rejected
+// object as the gc of the deleted objects, the pieces that fail to be deleted are recorded as
+// the zombie pieces.
+func (r *unsealRejecter) gcRejectedObject(ctx context.Context, objectInfo *storagetypes.ObjectInfo) {
+	storageParams, err := r.chain.QueryStorageParamsByTimestamp(ctx, objectInfo.GetCreateAt())
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to query storage params to gc the rejected object",
+			"object_id", objectInfo.Id.Uint64(), "error", err)
+		return
+	}
+	objectID := objectInfo.Id.Uint64()
+	segmentCount := r.pieceOp.SegmentPieceCount(objectInfo.GetPayloadSize(),
+		storageParams.VersionedParams.GetMaxSegmentSize())
+	pieceKeys := make([]string, 0, segmentCount)
+	for segIdx := uint32(0); segIdx < segmentCount; segIdx++ {
+		pieceKeys = append(pieceKeys, r.pieceOp.SegmentPieceKey(objectID, segIdx))
+	}
+	r.gcRejectedPieces(ctx, objectID, pieceKeys)
+	// the rejected object is only stored by the primary sp
+	if err = r.db.DeletePieceUsage(objectID, gfspapp.PrimaryReplicateIdx); err != nil {
+		log.CtxErrorw(ctx, "failed to delete the piece usage of the rejected object", "object_id", objectID,
+			"error", err)
+	}
+	// ignore the delete errors of the meta as the gc of the deleted objects
+	deleteErr := r.db.DeleteObjectIntegrity(objectID)
+	log.CtxDebugw(ctx, "delete the integrity meta of the rejected object", "object_id", objectID, "error", deleteErr)
+	deleteErr = r.db.DeleteObjectTier(objectID)
+	log.CtxDebugw(ctx, "delete the tier record of the rejected object", "object_id", objectID, "error", deleteErr)
+}
+
+// gcRejectedPieces deletes the pieces of the rejected object, the pieces that fail to be deleted
+// are recorded as the zombie pieces.
+func (r *unsealRejecter) gcRejectedPieces(ctx context.Context, objectID uint64, pieceKeys []string) {
+	failed := r.pieceStore.DeletePieces(ctx, pieceKeys)
+	if len(failed) == 0 {
+		log.CtxDebugw(ctx, "succeed to delete the pieces of the rejected object", "object_id", objectID,
+			"piece_number", len(pieceKeys))
+		return
+	}
+	zombies := make([]*spdb.GCZombiePiece, 0, len(failed))
+	for key, deleteErr := range failed {
+		zombies = append(zombies, &spdb.GCZombiePiece{
+			PieceKey:         key,
+			ObjectID:         objectID,
+			ErrorDescription: deleteErr.Error(),
+		})
+	}
+	log.CtxErrorw(ctx, "failed to delete the pieces of the rejected object, record them as zombie pieces",
+		"object_id", objectID, "failed_number", len(failed))
+	if err := r.db.InsertGCZombiePieces(zombies); err != nil {
+		log.CtxErrorw(ctx, "failed to insert the zombie pieces", "object_id", objectID,
+			"failed_number", len(failed), "error", err)
+	}
+}
@@ -0,0 +1,354 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkmath "cosmossdk.io/math"
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/greenfield-storage-provider/base/gfsppieceop"
+	"github.com/bnb-chain/greenfield-storage-provider/core/consensus"
+	"github.com/bnb-chain/greenfield-storage-provider/core/spdb"
+	"github.com/bnb-chain/greenfield-storage-provider/store/types"
+)
+
+const (
+	testRejectBucket  = "bucket"
+	testRejectObject  = "object"
+	testRejectID      = uint64(1)
+	testSegmentSize   = uint64(10)
+	testPayloadSize   = uint64(25)
+	testSegmentNumber = 3
+)
+
+// mockRejectUnsealDB records the progress and the deleted meta of the objects.
+type mockRejectUnsealDB struct {
+	mu             sync.Mutex
+	metas          []*spdb.UploadObjectMeta
+	listCalls      int
+	block          chan struct{}
+	progress       map[uint64]*spdb.UploadObjectMeta
+	zombies        []*spdb.GCZombiePiece
+	pieceUsage     []uint64
+	integrity      []uint64
+	tier           []uint64
+	lastListedSize int
+}
+
+func newMockRejectUnsealDB(metas ...*spdb.UploadObjectMeta) *mockRejectUnsealDB {
+	return &mockRejectUnsealDB{metas: metas, progress: make(map[uint64]*spdb.UploadObjectMeta)}
+}
+
+func (db *mockRejectUnsealDB) GetUploadMetasToReject(_ int64, limit int) ([]*spdb.UploadObjectMeta, error) {
+	db.mu.Lock()
+	db.listCalls++
+	db.lastListedSize = limit
+	block := db.block
+	db.mu.Unlock()
+	if block != nil {
+		<-block
+	}
+	return db.metas, nil
+}
+
+func (db *mockRejectUnsealDB) UpdateUploadProgress(meta *spdb.UploadObjectMeta) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.progress[meta.ObjectID] = meta
+	return nil
+}
+
+func (db *mockRejectUnsealDB) InsertGCZombiePieces(pieces []*spdb.GCZombiePiece) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.zombies = append(db.zombies, pieces...)
+	return nil
+}
+
+func (db *mockRejectUnsealDB) DeletePieceUsage(objectID uint64, _ int32) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.pieceUsage = append(db.pieceUsage, objectID)
+	return nil
+}
+
+func (db *mockRejectUnsealDB) DeleteObjectIntegrity(objectID uint64) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.integrity = append(db.integrity, objectID)
+	return nil
+}
+
+func (db *mockRejectUnsealDB) DeleteObjectTier(objectID uint64) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.tier = append(db.tier, objectID)
+	return nil
+}
+
+// mockRejectUnsealClient applies the reject tx to the chain by the given func.
+type mockRejectUnsealClient struct {
+	rejected []string
+	apply    func(object *storagetypes.MsgRejectSealObject)
+}
+
+func (c *mockRejectUnsealClient) RejectUnSealObject(_ context.Context, object *storagetypes.MsgRejectSealObject) error {
+	c.rejected = append(c.rejected, object.GetObjectName())
+	if c.apply != nil {
+		c.apply(object)
+	}
+	return nil
+}
+
+// mockPieceDeleter fails to delete the pieces in the failed keys.
+type mockPieceDeleter struct {
+	deleted []string
+	failed  map[string]bool
+}
+
+func (p *mockPieceDeleter) DeletePieces(_ context.Context, keys []string) map[string]error {
+	failed := make(map[string]error)
+	for _, key := range keys {
+		if p.failed[key] {
+			failed[key] = errors.New("mock delete error")
+			continue
+		}
+		p.deleted = append(p.deleted, key)
+	}
+	return failed
+}
+
+// rejectUnsealChain returns the no such object error for the missing object as the chain does,
+// and counts the object queries.
+type rejectUnsealChain struct {
+	*consensus.MemoryConsensus
+	queries atomic.Int32
+}
+
+func (c *rejectUnsealChain) QueryObjectInfoByID(ctx context.Context, objectID string) (*storagetypes.ObjectInfo, error) {
+	c.queries.Add(1)
+	objectInfo, err := c.MemoryConsensus.QueryObjectInfoByID(ctx, objectID)
+	if errors.Is(err, consensus.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", storagetypes.ErrNoSuchObject, objectID)
+	}
+	return objectInfo, err
+}
+
+func setupRejectUnsealTest(t *testing.T) (*unsealRejecter, *rejectUnsealChain, *mockRejectUnsealDB,
+	*mockRejectUnsealClient, *mockPieceDeleter) {
+	t.Helper()
+	chain := &rejectUnsealChain{MemoryConsensus: consensus.NewMemoryConsensus()}
+	params := storagetypes.DefaultParams()
+	params.VersionedParams.MaxSegmentSize = testSegmentSize
+	chain.SetStorageParams(&params)
+	chain.PutBucket(&storagetypes.BucketInfo{BucketName: testRejectBucket, PrimarySpAddress: testOperateAddress})
+	chain.PutObject(&storagetypes.ObjectInfo{
+		Id:           sdkmath.NewUint(testRejectID),
+		BucketName:   testRejectBucket,
+		ObjectName:   testRejectObject,
+		PayloadSize:  testPayloadSize,
+		ObjectStatus: storagetypes.OBJECT_STATUS_CREATED,
+	})
+	db := newMockRejectUnsealDB(&spdb.UploadObjectMeta{
+		ObjectID:  testRejectID,
+		TaskState: types.TaskState_TASK_STATE_REPLICATE_OBJECT_ERROR,
+	})
+	client := &mockRejectUnsealClient{}
+	pieceStore := &mockPieceDeleter{failed: make(map[string]bool)}
+	r := &unsealRejecter{
+		chain:          chain,
+		db:             db,
+		client:         client,
+		pieceStore:     pieceStore,
+		pieceOp:        &gfsppieceop.GfSpPieceOp{},
+		operateAddress: testOperateAddress,
+		uploading:      func(*storagetypes.ObjectInfo) bool { return false },
+		deadline:       3600,
+		batchSize:      10,
+		blockInterval:  time.Millisecond,
+	}
+	return r, chain, db, client, pieceStore
+}
+
+func testSegmentPieceKeys() []string {
+	op := &gfsppieceop.GfSpPieceOp{}
+	keys := make([]string, 0, testSegmentNumber)
+	for segIdx := uint32(0); segIdx < testSegmentNumber; segIdx++ {
+		keys = append(keys, op.SegmentPieceKey(testRejectID, segIdx))
+	}
+	return keys
+}
+
+func TestUnsealRejecter_SweepReentry(t *testing.T) {
+	r, _, db, _, _ := setupRejectUnsealTest(t)
+	db.block = make(chan struct{})
+
+	r.sweepUnsealedObjects(context.Background())
+	assert.Eventually(t, func() bool {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+		return db.listCalls == 1
+	}, time.Second, time.Millisecond)
+	// the previous sweep is not finished, the next round is skipped
+	r.sweepUnsealedObjects(context.Background())
+	r.sweepUnsealedObjects(context.Background())
+	close(db.block)
+	assert.Eventually(t, func() bool { return !r.sweeping.Load() }, time.Second, time.Millisecond)
+
+	db.mu.Lock()
+	assert.Equal(t, 1, db.listCalls)
+	assert.Equal(t, 10, db.lastListedSize)
+	db.mu.Unlock()
+
+	// the next round runs once the previous one is finished
+	db.block = nil
+	r.sweepUnsealedObjects(context.Background())
+	assert.Eventually(t, func() bool { return !r.sweeping.Load() }, time.Second, time.Millisecond)
+	db.mu.Lock()
+	assert.Equal(t, 2, db.listCalls)
+	db.mu.Unlock()
+}
+
+func TestUnsealRejecter_Confirm(t *testing.T) {
+	cases := []struct {
+		name         string
+		apply        func(chain *rejectUnsealChain) func(*storagetypes.MsgRejectSealObject)
+		state        types.TaskState
+		description  string
+		queries      int32
+		pieceDeleted bool
+	}{
+		{
+			name: "rejected",
+			apply: func(chain *rejectUnsealChain) func(*storagetypes.MsgRejectSealObject) {
+				return func(object *storagetypes.MsgRejectSealObject) {
+					chain.DeleteObject(object.GetBucketName(), object.GetObjectName())
+				}
+			},
+			state:        types.TaskState_TASK_STATE_REJECT_UNSEAL_OBJECT_DONE,
+			description:  "rejected from " + types.TaskState_TASK_STATE_REPLICATE_OBJECT_ERROR.String(),
+			queries:      2,
+			pieceDeleted: true,
+		},
+		{
+			name: "sealed before the reject",
+			apply: func(chain *rejectUnsealChain) func(*storagetypes.MsgRejectSealObject) {
+				return func(*storagetypes.MsgRejectSealObject) {
+					_ = chain.SetObjectStatus(testRejectID, storagetypes.OBJECT_STATUS_SEALED)
+				}
+			},
+			state:   types.TaskState_TASK_STATE_SEAL_OBJECT_DONE,
+			queries: 2,
+		},
+		{
+			name: "not confirmed",
+			apply: func(*rejectUnsealChain) func(*storagetypes.MsgRejectSealObject) {
+				return nil
+			},
+			state:       types.TaskState_TASK_STATE_REJECT_UNSEAL_OBJECT_ERROR,
+			description: "reject unseal object is not confirmed on chain",
+			queries:     1 + rejectUnsealConfirmBlocks,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r, chain, db, client, pieceStore := setupRejectUnsealTest(t)
+			client.apply = c.apply(chain)
+
+			r.doSweepUnsealedObjects(context.Background())
+
+			assert.Equal(t, []string{testRejectObject}, client.rejected)
+			assert.Equal(t, c.queries, chain.queries.Load())
+			progress := db.progress[testRejectID]
+			assert.NotNil(t, progress)
+			assert.Equal(t, c.state, progress.TaskState)
+			assert.Equal(t, c.description, progress.ErrorDescription)
+			if !c.pieceDeleted {
+				assert.Empty(t, pieceStore.deleted)
+				assert.Empty(t, db.pieceUsage)
+				assert.Empty(t, db.integrity)
+				assert.Empty(t, db.tier)
+				return
+			}
+			assert.Equal(t, testSegmentPieceKeys(), pieceStore.deleted)
+			assert.Empty(t, db.zombies)
+			assert.Equal(t, []uint64{testRejectID}, db.pieceUsage)
+			assert.Equal(t, []uint64{testRejectID}, db.integrity)
+			assert.Equal(t, []uint64{testRejectID}, db.tier)
+		})
+	}
+}
+
+func TestUnsealRejecter_ConfirmCanceled(t *testing.T) {
+	r, chain, db, client, pieceStore := setupRejectUnsealTest(t)
+	r.blockInterval = time.Hour
+	client.apply = func(object *storagetypes.MsgRejectSealObject) {
+		chain.DeleteObject(object.GetBucketName(), object.GetObjectName())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r.doSweepUnsealedObjects(ctx)
+
+	// the reject is broadcast but not confirmed, the progress is left to the next sweep
+	assert.Equal(t, []string{testRejectObject}, client.rejected)
+	assert.Empty(t, db.progress)
+	assert.Empty(t, pieceStore.deleted)
+}
+
+func TestUnsealRejecter_GCZombiePieces(t *testing.T) {
+	r, chain, db, _, pieceStore := setupRejectUnsealTest(t)
+	keys := testSegmentPieceKeys()
+	pieceStore.failed[keys[0]] = true
+	pieceStore.failed[keys[2]] = true
+	objectInfo, err := chain.QueryObjectInfoByID(context.Background(), "1")
+	assert.NoError(t, err)
+
+	r.gcRejectedObject(context.Background(), objectInfo)
+
+	assert.Equal(t, []string{keys[1]}, pieceStore.deleted)
+	var zombieKeys []string
+	for _, zombie := range db.zombies {
+		assert.Equal(t, testRejectID, zombie.ObjectID)
+		assert.Equal(t, "mock delete error", zombie.ErrorDescription)
+		zombieKeys = append(zombieKeys, zombie.PieceKey)
+	}
+	sort.Strings(zombieKeys)
+	assert.Equal(t, []string{keys[0], keys[2]}, zombieKeys)
+	assert.Equal(t, []uint64{testRejectID}, db.pieceUsage)
+	assert.Equal(t, []uint64{testRejectID}, db.integrity)
+	assert.Equal(t, []uint64{testRejectID}, db.tier)
+}
+
+func TestUnsealRejecter_Skip(t *testing.T) {
+	t.Run("uploading", func(t *testing.T) {
+		r, _, db, client, _ := setupRejectUnsealTest(t)
+		r.uploading = func(*storagetypes.ObjectInfo) bool { return true }
+		r.doSweepUnsealedObjects(context.Background())
+		assert.Empty(t, client.rejected)
+		assert.Empty(t, db.progress)
+	})
+	t.Run("not the primary sp", func(t *testing.T) {
+		r, _, db, client, _ := setupRejectUnsealTest(t)
+		r.operateAddress = "other"
+		r.doSweepUnsealedObjects(context.Background())
+		assert.Empty(t, client.rejected)
+		assert.Equal(t, types.TaskState_TASK_STATE_REJECT_UNSEAL_OBJECT_DONE, db.progress[testRejectID].TaskState)
+	})
+	t.Run("not found", func(t *testing.T) {
+		r, chain, db, client, pieceStore := setupRejectUnsealTest(t)
+		chain.DeleteObject(testRejectBucket, testRejectObject)
+		r.doSweepUnsealedObjects(context.Background())
+		assert.Empty(t, client.rejected)
+		assert.Empty(t, pieceStore.deleted)
+		assert.Equal(t, types.TaskState_TASK_STATE_REJECT_UNSEAL_OBJECT_DONE, db.progress[testRejectID].TaskState)
+	})
+}
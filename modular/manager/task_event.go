@@ -0,0 +1,118 @@
+package manager
+
+import (
+	"context"
+	"time"
+
+	"github.com/bnb-chain/greenfield-storage-provider/core/spdb"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/metrics"
+)
+
+const (
+	// taskEventBufferSize defines the max number of the task events waiting to be written, the
+	// new events are dropped if the buffer is full
+	taskEventBufferSize = 10000
+	// taskEventBatchSize defines the max number of the task events written in one batch
+	taskEventBatchSize = 100
+	// taskEventFlushInterval defines the max time that a task event waits to be written
+	taskEventFlushInterval = time.Second
+	// taskEventPurgeBatchSize defines the number of the expired task events deleted in one batch
+	taskEventPurgeBatchSize = 1000
+)
+
+// taskEventWriter writes the task events to db in batch in the background, so the task
+// dispatching and reporting are not blocked by the db.
+type taskEventWriter struct {
+	db            spdb.TaskEventDB
+	retentionDays int
+	events        chan *spdb.TaskEvent
+	stopCh        chan struct{}
+	stopped       chan struct{}
+}
+
+func newTaskEventWriter(db spdb.TaskEventDB, retentionDays int) *taskEventWriter {
+	return &taskEventWriter{
+		db:            db,
+		retentionDays: retentionDays,
+		events:        make(chan *spdb.TaskEvent, taskEventBufferSize),
+		stopCh:        make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+}
+
+// Record queues the task event to be written, the event is dropped if the buffer is full.
+func (w *taskEventWriter) Record(event *spdb.TaskEvent) {
+	select {
+	case w.events <- event:
+	default:
+		metrics.TaskEventDroppedCounter.WithLabelValues("buffer_full").Inc()
+	}
+}
+
+// Start starts to write the queued task events.
+func (w *taskEventWriter) Start() {
+	go func() {
+		defer close(w.stopped)
+		ticker := time.NewTicker(taskEventFlushInterval)
+		defer ticker.Stop()
+		batch := make([]*spdb.TaskEvent, 0, taskEventBatchSize)
+		for {
+			select {
+			case event := <-w.events:
+				if batch = append(batch, event); len(batch) >= taskEventBatchSize {
+					batch = w.flush(batch)
+				}
+			case <-ticker.C:
+				batch = w.flush(batch)
+			case <-w.stopCh:
+				for {
+					select {
+					case event := <-w.events:
+						if batch = append(batch, event); len(batch) >= taskEventBatchSize {
+							batch = w.flush(batch)
+						}
+					default:
+						w.flush(batch)
+						return
+					}
+				}
+			}
+		}
+	}()
+}
+
+// Stop writes the queued task events and stops the writer.
+func (w *taskEventWriter) Stop() {
+	close(w.stopCh)
+	<-w.stopped
+}
+
+func (w *taskEventWriter) flush(batch []*spdb.TaskEvent) []*spdb.TaskEvent {
+	if len(batch) == 0 {
+		return batch
+	}
+	if err := w.db.InsertTaskEvents(batch); err != nil {
+		log.Errorw("failed to write task events", "event_number", len(batch), "error", err)
+		metrics.TaskEventDroppedCounter.WithLabelValues("write_error").Add(float64(len(batch)))
+	}
+	return batch[:0]
+}
+
+// Purge deletes the task events older than the retention days in batches.
+func (w *taskEventWriter) Purge(ctx context.Context) {
+	before := time.Now().AddDate(0, 0, -w.retentionDays).Unix()
+	var total int64
+	for {
+		deleted, err := w.db.DeleteTaskEventsBefore(before, taskEventPurgeBatchSize)
+		if err != nil {
+			log.CtxErrorw(ctx, "failed to purge expired task events", "error", err)
+			break
+		}
+		total += deleted
+		if deleted < taskEventPurgeBatchSize {
+			break
+		}
+	}
+	log.CtxInfow(ctx, "finish to purge expired task events", "event_number", total)
+}
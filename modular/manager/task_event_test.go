@@ -0,0 +1,158 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/greenfield-storage-provider/core/spdb"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/metrics"
+)
+
+// mockTaskEventDB records the written batches and returns the deleted numbers in order.
+type mockTaskEventDB struct {
+	spdb.TaskEventDB
+	mu        sync.Mutex
+	batches   [][]*spdb.TaskEvent
+	insertErr error
+	deleted   []int64
+	deleteErr error
+	before    []int64
+	limits    []int
+}
+
+func (db *mockTaskEventDB) InsertTaskEvents(events []*spdb.TaskEvent) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.insertErr != nil {
+		return db.insertErr
+	}
+	db.batches = append(db.batches, append([]*spdb.TaskEvent(nil), events...))
+	return nil
+}
+
+func (db *mockTaskEventDB) DeleteTaskEventsBefore(timestampSecond int64, limit int) (int64, error) {
+	db.before = append(db.before, timestampSecond)
+	db.limits = append(db.limits, limit)
+	if db.deleteErr != nil {
+		return 0, db.deleteErr
+	}
+	if len(db.deleted) == 0 {
+		return 0, nil
+	}
+	deleted := db.deleted[0]
+	db.deleted = db.deleted[1:]
+	return deleted, nil
+}
+
+func (db *mockTaskEventDB) batchSizes() []int {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	sizes := make([]int, 0, len(db.batches))
+	for _, batch := range db.batches {
+		sizes = append(sizes, len(batch))
+	}
+	return sizes
+}
+
+func recordTaskEvents(w *taskEventWriter, number int) {
+	for i := 0; i < number; i++ {
+		w.Record(&spdb.TaskEvent{ObjectID: uint64(i)})
+	}
+}
+
+func TestTaskEventWriter_Batch(t *testing.T) {
+	db := &mockTaskEventDB{}
+	w := newTaskEventWriter(db, 7)
+	w.Start()
+	defer w.Stop()
+
+	// the full batch is written without waiting for the flush interval
+	recordTaskEvents(w, taskEventBatchSize)
+	assert.Eventually(t, func() bool {
+		sizes := db.batchSizes()
+		return len(sizes) == 1 && sizes[0] == taskEventBatchSize
+	}, taskEventFlushInterval/2, time.Millisecond)
+
+	// the partial batch is written after the flush interval
+	recordTaskEvents(w, 3)
+	assert.Eventually(t, func() bool {
+		sizes := db.batchSizes()
+		return len(sizes) == 2 && sizes[1] == 3
+	}, 3*taskEventFlushInterval, 10*time.Millisecond)
+}
+
+func TestTaskEventWriter_FlushOnStop(t *testing.T) {
+	db := &mockTaskEventDB{}
+	w := newTaskEventWriter(db, 7)
+	recordTaskEvents(w, taskEventBatchSize+50)
+	w.Start()
+	w.Stop()
+
+	assert.Equal(t, []int{taskEventBatchSize, 50}, db.batchSizes())
+	var objectIDs []uint64
+	for _, batch := range db.batches {
+		for _, event := range batch {
+			objectIDs = append(objectIDs, event.ObjectID)
+		}
+	}
+	for i, objectID := range objectIDs {
+		assert.Equal(t, uint64(i), objectID)
+	}
+}
+
+func TestTaskEventWriter_Dropped(t *testing.T) {
+	t.Run("buffer full", func(t *testing.T) {
+		counter := metrics.TaskEventDroppedCounter.WithLabelValues("buffer_full")
+		dropped := testutil.ToFloat64(counter)
+		db := &mockTaskEventDB{}
+		w := newTaskEventWriter(db, 7)
+		recordTaskEvents(w, taskEventBufferSize+2)
+		assert.Equal(t, dropped+2, testutil.ToFloat64(counter))
+
+		w.Start()
+		w.Stop()
+		var written int
+		for _, size := range db.batchSizes() {
+			written += size
+		}
+		assert.Equal(t, taskEventBufferSize, written)
+	})
+	t.Run("write error", func(t *testing.T) {
+		counter := metrics.TaskEventDroppedCounter.WithLabelValues("write_error")
+		dropped := testutil.ToFloat64(counter)
+		db := &mockTaskEventDB{insertErr: errors.New("mock insert error")}
+		w := newTaskEventWriter(db, 7)
+		recordTaskEvents(w, 5)
+		w.Start()
+		w.Stop()
+		assert.Equal(t, dropped+5, testutil.ToFloat64(counter))
+	})
+}
+
+func TestTaskEventWriter_Purge(t *testing.T) {
+	t.Run("batches", func(t *testing.T) {
+		db := &mockTaskEventDB{deleted: []int64{taskEventPurgeBatchSize, taskEventPurgeBatchSize, 10}}
+		w := newTaskEventWriter(db, 7)
+		expected := time.Now().AddDate(0, 0, -7).Unix()
+		w.Purge(context.Background())
+
+		assert.Len(t, db.before, 3)
+		for i := range db.before {
+			assert.InDelta(t, expected, db.before[i], 1)
+			assert.Equal(t, taskEventPurgeBatchSize, db.limits[i])
+		}
+		assert.Empty(t, db.deleted)
+	})
+	t.Run("error", func(t *testing.T) {
+		db := &mockTaskEventDB{deleteErr: errors.New("mock delete error")}
+		w := newTaskEventWriter(db, 7)
+		w.Purge(context.Background())
+		assert.Len(t, db.before, 1)
+	})
+}
@@ -0,0 +1,23 @@
+package metadata
+
+import (
+	"context"
+
+	"github.com/bnb-chain/greenfield-storage-provider/modular/metadata/types"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+)
+
+// GfSpGetLatestBlockNumber get the latest block number indexed by the block syncer
+func (r *MetadataModular) GfSpGetLatestBlockNumber(ctx context.Context, req *types.GfSpGetLatestBlockNumberRequest) (resp *types.GfSpGetLatestBlockNumberResponse, err error) {
+	ctx = log.Context(ctx, req)
+
+	blockNumber, err := r.baseApp.GfBsDB().GetLatestBlockNumber()
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to get latest block number", "error", err)
+		return
+	}
+
+	resp = &types.GfSpGetLatestBlockNumberResponse{BlockNumber: blockNumber}
+	log.CtxDebugw(ctx, "succeed to get latest block number", "block_number", blockNumber)
+	return resp, nil
+}
@@ -195,7 +195,8 @@ func (r *MetadataModular) GfSpGetUserBucketsCount(ctx context.Context, req *type
 // GfSpListExpiredBucketsBySp list expired bucket by sp
 func (r *MetadataModular) GfSpListExpiredBucketsBySp(ctx context.Context, req *types.GfSpListExpiredBucketsBySpRequest) (resp *types.GfSpListExpiredBucketsBySpResponse, err error) {
 	ctx = log.Context(ctx, req)
-	buckets, err := r.baseApp.GfBsDB().ListExpiredBucketsBySp(req.GetCreateAt(), req.GetPrimarySpAddress(), req.GetLimit())
+	buckets, err := r.baseApp.GfBsDB().ListExpiredBucketsBySp(req.GetCreateAt(), req.GetPrimarySpAddress(), req.GetLimit(),
+		req.GetStartAfterCreateAt(), req.GetStartAfterBucketId())
 	if err != nil {
 		log.CtxErrorw(ctx, "failed to get user buckets", "error", err)
 		return
@@ -10,10 +10,12 @@ import (
 
 	"github.com/cosmos/gogoproto/proto"
 	"github.com/libp2p/go-libp2p/core/network"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfsptask"
 	coretask "github.com/bnb-chain/greenfield-storage-provider/core/task"
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/tracing"
 )
 
 // pattern: /protocol-name/request-or-response-message/version
@@ -112,7 +114,10 @@ func (a *ApprovalProtocol) onGetApprovalRequest(s network.Stream) {
 		log.Errorw("failed to unmarshal replicate piece approval request msg", "error", err)
 		return
 	}
-	ctx := log.WithValue(context.Background(), log.CtxKeyTask, req.Key().String())
+	ctx := log.WithValue(tracing.ExtractMap(context.Background(), req.GetTask().GetTraceCarrier()),
+		log.CtxKeyTask, req.Key().String())
+	ctx, span := tracing.StartSpanWithKind(ctx, GetApprovalRequest, trace.SpanKindServer)
+	defer span.End()
 	log.Debugf("%s received replicate piece approval request from %s, object_id: %d",
 		s.Conn().LocalPeer(), s.Conn().RemotePeer(), req.GetObjectInfo().Id.Uint64())
 	if !a.node.peers.checkSP(req.GetAskSpOperatorAddress()) {
@@ -152,6 +157,7 @@ func (a *ApprovalProtocol) onGetApprovalRequest(s network.Stream) {
 	}
 	req.SetApprovedSignature(signature)
 	req.SetApprovedSpOperatorAddress(a.node.baseApp.OperateAddress())
+	req.GetTask().SetTraceCarrier(tracing.InjectMap(ctx))
 	err = a.node.sendToPeer(ctx, s.Conn().RemotePeer(), GetApprovalResponse, req)
 	log.Infof("%s response to %s approval request, task_key: %s, error: %v",
 		s.Conn().LocalPeer(), s.Conn().RemotePeer(), req.Key().String(), err)
@@ -172,7 +178,10 @@ func (a *ApprovalProtocol) onGetApprovalResponse(s network.Stream) {
 		log.Errorw("failed to unmarshal replicate piece approval response msg", "error", err)
 		return
 	}
-	ctx := log.WithValue(context.Background(), log.CtxKeyTask, resp.Key().String())
+	ctx := log.WithValue(tracing.ExtractMap(context.Background(), resp.GetTask().GetTraceCarrier()),
+		log.CtxKeyTask, resp.Key().String())
+	_, span := tracing.StartSpanWithKind(ctx, GetApprovalResponse, trace.SpanKindServer)
+	defer span.End()
 	log.Debugf("%s received approval response from %s, object_id: %d",
 		s.Conn().LocalPeer(), s.Conn().RemotePeer(), resp.GetObjectInfo().Id.Uint64())
 
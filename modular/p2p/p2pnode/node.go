@@ -19,12 +19,14 @@ import (
 	"github.com/libp2p/go-libp2p/p2p/host/peerstore/pstoreds"
 	"github.com/libp2p/go-libp2p/p2p/security/noise"
 	ma "github.com/multiformats/go-multiaddr"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/bnb-chain/greenfield-storage-provider/base/gfspapp"
 	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfspp2p"
 	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfsptask"
 	coretask "github.com/bnb-chain/greenfield-storage-provider/core/task"
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/tracing"
 )
 
 // Node defines the p2p protocol node, encapsulates the go-lib.p2p
@@ -181,6 +183,11 @@ func (n *Node) GetSecondaryReplicatePieceApproval(
 	expectedAccept int, timeout int64) (
 	accept []coretask.ApprovalReplicatePieceTask,
 	err error) {
+	ctx, span := tracing.StartSpan(ctx, "GetSecondaryReplicatePieceApproval")
+	defer func() {
+		span.SetAttributes(attribute.Int("accepted", len(accept)))
+		tracing.EndSpan(span, err)
+	}()
 	approvalCh, err := n.approval.hangApprovalRequest(task.GetObjectInfo().Id.Uint64())
 	if err != nil {
 		log.CtxErrorw(ctx, "failed to hang replicate piece approval request")
@@ -194,7 +201,9 @@ func (n *Node) GetSecondaryReplicatePieceApproval(
 		return
 	}
 	task.SetAskSignature(signature)
-	n.broadcast(ctx, GetApprovalRequest, task.(*gfsptask.GfSpReplicatePieceApprovalTask))
+	approvalTask := task.(*gfsptask.GfSpReplicatePieceApprovalTask)
+	approvalTask.GetTask().SetTraceCarrier(tracing.InjectMap(ctx))
+	n.broadcast(ctx, GetApprovalRequest, approvalTask)
 	approvalCtx, cancelFunc := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
 	defer cancelFunc()
 	for {
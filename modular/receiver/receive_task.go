@@ -12,6 +12,7 @@ import (
 	"github.com/bnb-chain/greenfield-storage-provider/core/task"
 	"github.com/bnb-chain/greenfield-storage-provider/core/taskqueue"
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/tracing"
 	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
 )
 
@@ -64,8 +65,11 @@ func (r *ReceiveModular) HandleReceivePieceTask(ctx context.Context, task task.R
 		pieceKey = r.baseApp.PieceOp().SegmentPieceKey(task.GetObjectInfo().Id.Uint64(),
 			uint32(task.GetPieceIdx()))
 	}
-	if err = r.baseApp.GfSpDB().SetReplicatePieceChecksum(task.GetObjectInfo().Id.Uint64(),
-		task.GetReplicateIdx(), uint32(task.GetPieceIdx()), task.GetPieceChecksum()); err != nil {
+	_, dbSpan := tracing.StartSpan(ctx, "SpDB.SetReplicatePieceChecksum")
+	err = r.baseApp.GfSpDB().SetReplicatePieceChecksum(task.GetObjectInfo().Id.Uint64(),
+		task.GetReplicateIdx(), uint32(task.GetPieceIdx()), task.GetPieceChecksum())
+	tracing.EndSpan(dbSpan, err)
+	if err != nil {
 		log.CtxErrorw(ctx, "failed to set checksum to db", "error", err)
 		err = ErrGfSpDB
 		return ErrGfSpDB
@@ -104,8 +108,10 @@ func (r *ReceiveModular) HandleDoneReceivePieceTask(ctx context.Context, task ta
 	}
 	segmentCount := r.baseApp.PieceOp().SegmentPieceCount(task.GetObjectInfo().GetPayloadSize(),
 		task.GetStorageParams().VersionedParams.GetMaxSegmentSize())
+	_, dbSpan := tracing.StartSpan(ctx, "SpDB.GetAllReplicatePieceChecksum")
 	checksums, err := r.baseApp.GfSpDB().GetAllReplicatePieceChecksum(
 		task.GetObjectInfo().Id.Uint64(), task.GetReplicateIdx(), segmentCount)
+	tracing.EndSpan(dbSpan, err)
 	if err != nil {
 		log.CtxErrorw(ctx, "failed to get checksum from db", "error", err)
 		err = ErrGfSpDB
@@ -128,12 +134,15 @@ func (r *ReceiveModular) HandleDoneReceivePieceTask(ctx context.Context, task ta
 		PieceChecksumList: checksums,
 		Signature:         signature,
 	}
+	_, dbSpan = tracing.StartSpan(ctx, "SpDB.SetObjectIntegrity")
 	err = r.baseApp.GfSpDB().SetObjectIntegrity(integrityMeta)
+	tracing.EndSpan(dbSpan, err)
 	if err != nil {
 		log.CtxErrorw(ctx, "failed to write integrity meta to db", "error", err)
 		err = ErrGfSpDB
 		return nil, nil, ErrGfSpDB
 	}
+	r.recordPieceUsage(ctx, task, segmentCount)
 	if err = r.baseApp.GfSpDB().DeleteAllReplicatePieceChecksum(
 		task.GetObjectInfo().Id.Uint64(), task.GetReplicateIdx(), segmentCount); err != nil {
 		log.CtxErrorw(ctx, "failed to delete all replicate piece checksum", "error", err)
@@ -149,6 +158,25 @@ func (r *ReceiveModular) HandleDoneReceivePieceTask(ctx context.Context, task ta
 	return integrity, signature, nil
 }
 
+// recordPieceUsage records the bytes of the received pieces, the piece sizes are computed by
+// the payload size and the storage params in the same way as the primary SP splits them.
+func (r *ReceiveModular) recordPieceUsage(ctx context.Context, task task.ReceivePieceTask, segmentCount uint32) {
+	var (
+		objectInfo     = task.GetObjectInfo()
+		maxSegmentSize = task.GetStorageParams().VersionedParams.GetMaxSegmentSize()
+		size           int64
+	)
+	for segIdx := uint32(0); segIdx < segmentCount; segIdx++ {
+		if objectInfo.GetRedundancyType() == storagetypes.REDUNDANCY_EC_TYPE {
+			size += r.baseApp.PieceOp().ECPieceSize(objectInfo.GetPayloadSize(), segIdx, maxSegmentSize,
+				task.GetStorageParams().VersionedParams.GetRedundantDataChunkNum())
+		} else {
+			size += r.baseApp.PieceOp().SegmentPieceSize(objectInfo.GetPayloadSize(), segIdx, maxSegmentSize)
+		}
+	}
+	r.baseApp.RecordPieceUsage(ctx, task.GetBucketInfo(), objectInfo, int32(task.GetReplicateIdx()), size)
+}
+
 func (r *ReceiveModular) QueryTasks(
 	ctx context.Context,
 	subKey task.TKey) (
@@ -0,0 +1,339 @@
+package signer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+	"github.com/bnb-chain/greenfield/sdk/keys"
+)
+
+const (
+	// ApprovalKeyStaged is the status of the approval key that is ready to be promoted
+	ApprovalKeyStaged = "staged"
+	// ApprovalKeyActive is the status of the approval key that signs the approvals
+	ApprovalKeyActive = "active"
+	// ApprovalKeyRetired is the status of the approval key that was active, its signatures are
+	// still accepted in the grace period
+	ApprovalKeyRetired = "retired"
+
+	// approvalKeyringReloadInterval defines the min interval of checking the keyring file changes
+	approvalKeyringReloadInterval = 5 * time.Second
+)
+
+// ApprovalKey is one approval key of the keyring, the private key is held by the key provider
+// and is got by the key name, e.g. the keystore file ${dir}/${key_name}.json or the remote key
+// ${endpoint}/v1/keys/${key_name}.
+type ApprovalKey struct {
+	Address string `json:"address"`
+	KeyName string `json:"key_name"`
+	Status  string `json:"status"`
+	// UpdatedAt is the unix seconds of the last status change
+	UpdatedAt int64 `json:"updated_at"`
+}
+
+// ApprovalKeyring is the content of the approval keyring file, it holds at most one active key
+// for signing, the staged keys to be promoted and the retired keys.
+type ApprovalKeyring struct {
+	Keys []*ApprovalKey `json:"keys"`
+}
+
+// LoadApprovalKeyring loads the approval keyring from the file, an empty keyring is returned
+// if the file does not exist.
+func LoadApprovalKeyring(file string) (*ApprovalKeyring, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return &ApprovalKeyring{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	keyring := &ApprovalKeyring{}
+	if err = json.Unmarshal(data, keyring); err != nil {
+		return nil, fmt.Errorf("invalid approval keyring: %w", err)
+	}
+	return keyring, nil
+}
+
+// Save writes the approval keyring to the file atomically, the file is only readable by the owner.
+func (k *ApprovalKeyring) Save(file string) error {
+	data, err := json.MarshalIndent(k, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), "."+filepath.Base(file)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// Generate generates a new approval key of the name by the key provider and stages it, the
+// key provider must support generating keys.
+func (k *ApprovalKeyring) Generate(provider KeyProvider, keyName string) (*ApprovalKey, error) {
+	generator, ok := provider.(KeyGenerator)
+	if !ok {
+		return nil, fmt.Errorf("key provider does not support generating keys, create the key in it and stage the key")
+	}
+	if key := k.getByName(keyName); key != nil {
+		return nil, fmt.Errorf("approval key name %s already exists", keyName)
+	}
+	if _, err := generator.GenerateKey(SignType(keyName)); err != nil {
+		return nil, err
+	}
+	return k.Stage(provider, keyName)
+}
+
+// Stage adds the key of the name held by the key provider to the keyring as the staged key.
+func (k *ApprovalKeyring) Stage(provider KeyProvider, keyName string) (*ApprovalKey, error) {
+	if len(keyName) == 0 {
+		return nil, fmt.Errorf("approval key name missing")
+	}
+	if key := k.getByName(keyName); key != nil {
+		return nil, fmt.Errorf("approval key name %s already exists", keyName)
+	}
+	km, err := provider.GetKeyManager(SignType(keyName))
+	if err != nil {
+		return nil, err
+	}
+	address := km.GetAddr().String()
+	if k.get(address) != nil {
+		return nil, fmt.Errorf("approval key %s already exists", address)
+	}
+	key := &ApprovalKey{
+		Address:   address,
+		KeyName:   keyName,
+		Status:    ApprovalKeyStaged,
+		UpdatedAt: time.Now().Unix(),
+	}
+	k.Keys = append(k.Keys, key)
+	return key, nil
+}
+
+// Promote makes the staged key active, and retires the current active key. The chain verifies
+// the approvals by the approval address of the SP, so the staged key is only promoted after the
+// on-chain approval address is updated to it.
+func (k *ApprovalKeyring) Promote(address, onChainApprovalAddress string) error {
+	key := k.get(address)
+	if key == nil {
+		return fmt.Errorf("approval key %s not found", address)
+	}
+	if key.Status != ApprovalKeyStaged {
+		return fmt.Errorf("approval key %s is %s, only the staged key can be promoted", address, key.Status)
+	}
+	if onChainApprovalAddress != address {
+		return fmt.Errorf("the on-chain approval address of the SP is %s, update it to %s before promoting",
+			onChainApprovalAddress, address)
+	}
+	now := time.Now().Unix()
+	for _, old := range k.Keys {
+		if old.Status == ApprovalKeyActive {
+			old.Status = ApprovalKeyRetired
+			old.UpdatedAt = now
+		}
+	}
+	key.Status = ApprovalKeyActive
+	key.UpdatedAt = now
+	return nil
+}
+
+func (k *ApprovalKeyring) get(address string) *ApprovalKey {
+	for _, key := range k.Keys {
+		if key.Address == address {
+			return key
+		}
+	}
+	return nil
+}
+
+func (k *ApprovalKeyring) getByName(keyName string) *ApprovalKey {
+	for _, key := range k.Keys {
+		if key.KeyName == keyName {
+			return key
+		}
+	}
+	return nil
+}
+
+var _ keys.KeyManager = &approvalKeyManager{}
+
+// approvalKeyManager signs by the active key of the approval keyring file, and reloads the file
+// once it changes, so the promoted key takes effect without restarting the signer. The retired
+// keys are still accepted by the signature verification in the grace period.
+type approvalKeyManager struct {
+	provider KeyProvider
+	file     string
+	grace    time.Duration
+
+	mu        sync.RWMutex
+	modTime   time.Time
+	checkedAt time.Time
+	active    keys.KeyManager
+	retired   map[string]time.Time // the address to the retired time
+}
+
+func newApprovalKeyManager(provider KeyProvider, file string, grace time.Duration) (*approvalKeyManager, error) {
+	km := &approvalKeyManager{provider: provider, file: file, grace: grace}
+	if err := km.load(); err != nil {
+		return nil, err
+	}
+	return km, nil
+}
+
+func (km *approvalKeyManager) load() error {
+	info, err := os.Stat(km.file)
+	if err != nil {
+		return err
+	}
+	keyring, err := LoadApprovalKeyring(km.file)
+	if err != nil {
+		return err
+	}
+	var active keys.KeyManager
+	retired := make(map[string]time.Time)
+	for _, key := range keyring.Keys {
+		switch key.Status {
+		case ApprovalKeyActive:
+			if active != nil {
+				return fmt.Errorf("multiple active approval keys")
+			}
+			if active, err = km.provider.GetKeyManager(SignType(key.KeyName)); err != nil {
+				return err
+			}
+			if active.GetAddr().String() != key.Address {
+				return fmt.Errorf("approval key %s mismatches the address %s of the key provider", key.Address,
+					active.GetAddr().String())
+			}
+		case ApprovalKeyRetired:
+			retired[key.Address] = time.Unix(key.UpdatedAt, 0)
+		}
+	}
+	if active == nil {
+		return fmt.Errorf("no active approval key")
+	}
+	km.mu.Lock()
+	defer km.mu.Unlock()
+	if km.active != nil && !km.active.GetAddr().Equals(active.GetAddr()) {
+		log.Infow("approval key is rotated", "old_address", km.active.GetAddr().String(),
+			"new_address", active.GetAddr().String())
+	}
+	km.modTime = info.ModTime()
+	km.active = active
+	km.retired = retired
+	return nil
+}
+
+// current returns the active key, the keyring file is reloaded if it changes.
+func (km *approvalKeyManager) current() keys.KeyManager {
+	km.mu.Lock()
+	if time.Since(km.checkedAt) < approvalKeyringReloadInterval {
+		defer km.mu.Unlock()
+		return km.active
+	}
+	km.checkedAt = time.Now()
+	modTime := km.modTime
+	km.mu.Unlock()
+
+	if info, err := os.Stat(km.file); err == nil && !info.ModTime().Equal(modTime) {
+		if err = km.load(); err != nil {
+			log.Errorw("failed to reload approval keyring, keep the current key", "file", km.file, "error", err)
+		}
+	}
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+	return km.active
+}
+
+// AcceptedAddrs returns the addresses whose signatures are accepted, the active key and the
+// retired keys in the grace period.
+func (km *approvalKeyManager) AcceptedAddrs() []sdk.AccAddress {
+	active := km.current()
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+	addrs := []sdk.AccAddress{active.GetAddr()}
+	for address, retiredAt := range km.retired {
+		if time.Since(retiredAt) > km.grace {
+			continue
+		}
+		if addr, err := sdk.AccAddressFromHexUnsafe(address); err == nil {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+func (km *approvalKeyManager) Bytes() []byte {
+	panic("Not allow to get privKey bytes from KeyManager")
+}
+
+func (km *approvalKeyManager) Sign(msg []byte) ([]byte, error) {
+	return km.current().Sign(msg)
+}
+
+func (km *approvalKeyManager) PubKey() cryptotypes.PubKey {
+	return km.current().PubKey()
+}
+
+func (km *approvalKeyManager) Equals(key cryptotypes.LedgerPrivKey) bool {
+	return km.current().Equals(key)
+}
+
+func (km *approvalKeyManager) Type() string {
+	return km.current().Type()
+}
+
+func (km *approvalKeyManager) GetAddr() sdk.AccAddress {
+	return km.current().GetAddr()
+}
+
+func (km *approvalKeyManager) String() string { return km.file }
+func (km *approvalKeyManager) ProtoMessage()  {}
+func (km *approvalKeyManager) Reset()         {}
+
+var _ KeyProvider = &approvalKeyringProvider{}
+
+// approvalKeyringProvider provides the approval key manager of the keyring file, and the key
+// managers of the other accounts by the underlying key provider.
+type approvalKeyringProvider struct {
+	KeyProvider
+	approval *approvalKeyManager
+}
+
+// NewApprovalKeyringProvider returns the key provider that signs approvals by the active key of
+// the keyring file, the key is got from the underlying key provider by its key name. The retired
+// keys are accepted by the verification in the grace period.
+func NewApprovalKeyringProvider(provider KeyProvider, file string, grace time.Duration) (KeyProvider, error) {
+	approval, err := newApprovalKeyManager(provider, file, grace)
+	if err != nil {
+		return nil, err
+	}
+	return &approvalKeyringProvider{KeyProvider: provider, approval: approval}, nil
+}
+
+// GetKeyManager returns the approval key manager of the keyring for the approval account.
+func (p *approvalKeyringProvider) GetKeyManager(scope SignType) (keys.KeyManager, error) {
+	if scope == SignApproval {
+		return p.approval, nil
+	}
+	return p.KeyProvider.GetKeyManager(scope)
+}
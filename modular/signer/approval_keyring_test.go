@@ -0,0 +1,102 @@
+package signer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
+)
+
+func newTestKeyProvider(t *testing.T) KeyProvider {
+	return &keystoreKeyProvider{dir: t.TempDir(), passphrase: "passphrase", scryptN: keystore.LightScryptN,
+		scryptP: keystore.LightScryptP}
+}
+
+func TestApprovalKeyring(t *testing.T) {
+	provider := newTestKeyProvider(t)
+	file := filepath.Join(t.TempDir(), "approval_keyring.json")
+	keyring, err := LoadApprovalKeyring(file)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(keyring.Keys))
+
+	first, err := keyring.Generate(provider, "approval_1")
+	assert.Nil(t, err)
+	assert.Equal(t, ApprovalKeyStaged, first.Status)
+	_, err = keyring.Stage(provider, "approval_1")
+	assert.NotNil(t, err)
+	_, err = keyring.Stage(provider, "approval_missing")
+	assert.NotNil(t, err)
+	_, err = keyring.Generate(NewPlaintextKeyProvider(nil), "approval_2")
+	assert.NotNil(t, err)
+	// the key is not promoted until the on-chain approval address is updated to it
+	assert.NotNil(t, keyring.Promote(first.Address, ""))
+	assert.Nil(t, keyring.Promote(first.Address, first.Address))
+	assert.NotNil(t, keyring.Promote(first.Address, first.Address))
+
+	second, err := keyring.Generate(provider, "approval_2")
+	assert.Nil(t, err)
+	assert.Nil(t, keyring.Promote(second.Address, second.Address))
+	assert.Nil(t, keyring.Save(file))
+
+	info, err := os.Stat(file)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	keyring, err = LoadApprovalKeyring(file)
+	assert.Nil(t, err)
+	assert.Equal(t, ApprovalKeyRetired, keyring.get(first.Address).Status)
+	assert.Equal(t, ApprovalKeyActive, keyring.get(second.Address).Status)
+}
+
+func verifyApproval(km *approvalKeyManager, msg, sig []byte) bool {
+	for _, addr := range km.AcceptedAddrs() {
+		if storagetypes.VerifySignature(addr, crypto.Keccak256(msg), sig) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func TestApprovalKeyManager_Rotate(t *testing.T) {
+	provider := newTestKeyProvider(t)
+	file := filepath.Join(t.TempDir(), "approval_keyring.json")
+	_, err := newApprovalKeyManager(provider, file, time.Hour)
+	assert.NotNil(t, err)
+
+	keyring := &ApprovalKeyring{}
+	first, err := keyring.Generate(provider, "approval_1")
+	assert.Nil(t, err)
+	assert.Nil(t, keyring.Promote(first.Address, first.Address))
+	assert.Nil(t, keyring.Save(file))
+	km, err := newApprovalKeyManager(provider, file, time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, first.Address, km.GetAddr().String())
+	msg := []byte("approval")
+	oldSig, err := km.Sign(msg)
+	assert.Nil(t, err)
+
+	// promote the new key, the running key manager picks it up after reloading
+	second, err := keyring.Generate(provider, "approval_2")
+	assert.Nil(t, err)
+	assert.Nil(t, keyring.Promote(second.Address, second.Address))
+	assert.Nil(t, keyring.Save(file))
+	future := time.Now().Add(time.Minute)
+	assert.Nil(t, os.Chtimes(file, future, future))
+	km.checkedAt = time.Time{}
+	assert.Equal(t, second.Address, km.GetAddr().String())
+
+	newSig, err := km.Sign(msg)
+	assert.Nil(t, err)
+	assert.Nil(t, storagetypes.VerifySignature(sdk.MustAccAddressFromHex(second.Address), crypto.Keccak256(msg), newSig))
+	assert.True(t, verifyApproval(km, msg, newSig))
+	// the signature of the retired key is accepted in the grace period
+	assert.True(t, verifyApproval(km, msg, oldSig))
+	km.grace = 0
+	assert.False(t, verifyApproval(km, msg, oldSig))
+}
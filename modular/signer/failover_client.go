@@ -0,0 +1,190 @@
+package signer
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cometbft/cometbft/crypto/tmhash"
+	chttp "github.com/cometbft/cometbft/rpc/client/http"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	"google.golang.org/grpc"
+
+	"github.com/bnb-chain/greenfield-storage-provider/base/gnfd"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+	"github.com/bnb-chain/greenfield/sdk/client"
+	ctypes "github.com/bnb-chain/greenfield/sdk/types"
+)
+
+// BroadcastEndpointPool is the name of the endpoint pool of the signer txs
+const BroadcastEndpointPool = "broadcast"
+
+// txQueryTimeout defines the timeout of querying the nonce and looking up the tx whose
+// broadcast is ambiguous
+const txQueryTimeout = 10 * time.Second
+
+var _ txChainClient = &failoverTxClient{}
+
+// endpointTxClient is the client of one chain address, the tx is signed once and the same tx
+// bytes are broadcast by the clients of the other addresses on fail over.
+type endpointTxClient interface {
+	txChainClient
+	SignTx(ctx context.Context, msgs []sdk.Msg, txOpt *ctypes.TxOption) ([]byte, error)
+	BroadcastTxBytes(ctx context.Context, txBytes []byte, mode tx.BroadcastMode, opts ...grpc.CallOption) (
+		*tx.BroadcastTxResponse, error)
+}
+
+// failoverTxClient fails over the calls of the tx manager across the clients of the chain
+// addresses, the clients are indexed in the same order as the endpoints of the pool. The
+// broadcast only fails over if the tx is not sent, if it is unknown whether the tx is sent,
+// the tx is looked up by its hash instead.
+type failoverTxClient struct {
+	pool    *gnfd.EndpointPool
+	clients []endpointTxClient
+}
+
+func (c *failoverTxClient) GetNonce(ctx context.Context) (nonce uint64, err error) {
+	ctx, cancel := context.WithTimeout(ctx, txQueryTimeout)
+	defer cancel()
+	err = c.pool.Do(ctx, "GetNonce", func(idx int) (err error) {
+		nonce, err = c.clients[idx].GetNonce(ctx)
+		return err
+	})
+	return nonce, err
+}
+
+func (c *failoverTxClient) BroadcastTx(ctx context.Context, msgs []sdk.Msg, txOpt *ctypes.TxOption,
+	opts ...grpc.CallOption) (resp *tx.BroadcastTxResponse, err error) {
+	var txBytes []byte
+	if err = c.pool.Do(ctx, "SignTx", func(idx int) (err error) {
+		txBytes, err = c.clients[idx].SignTx(ctx, msgs, txOpt)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	mode := tx.BroadcastMode_BROADCAST_MODE_SYNC
+	if txOpt != nil && txOpt.Mode != nil {
+		mode = *txOpt.Mode
+	}
+	sentIdx := -1
+	err = c.pool.DoIf(ctx, "BroadcastTx", gnfd.IsPreSendError, func(idx int) (err error) {
+		sentIdx = idx
+		resp, err = c.clients[idx].BroadcastTxBytes(ctx, txBytes, mode, opts...)
+		return err
+	})
+	if err == nil || !gnfd.IsFailoverError(err) || gnfd.IsPreSendError(err) {
+		return resp, err
+	}
+	// the tx may have been accepted by the endpoint, it is not broadcast again by another
+	// endpoint but looked up by its hash.
+	hash := txHash(txBytes)
+	if !c.txExists(c.clients[sentIdx], hash) && !c.txExists(c, hash) {
+		log.CtxErrorw(ctx, "failed to broadcast tx and the tx is not found", "tx_hash", hash,
+			"endpoint", c.pool.Address(sentIdx), "error", err)
+		return nil, err
+	}
+	log.CtxWarnw(ctx, "the broadcast tx is found after the ambiguous error", "tx_hash", hash,
+		"endpoint", c.pool.Address(sentIdx), "error", err)
+	return &tx.BroadcastTxResponse{TxResponse: &sdk.TxResponse{TxHash: hash}}, nil
+}
+
+// txExists returns an indicator whether the tx is included on chain or in the mempool.
+func (c *failoverTxClient) txExists(client txChainClient, hash string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), txQueryTimeout)
+	defer cancel()
+	if resp, err := client.GetTx(ctx, &tx.GetTxRequest{Hash: hash}); err == nil && resp.GetTxResponse() != nil {
+		return true
+	}
+	// the truncated mempool is still searched, the tx is regarded as not sent if it is not listed
+	hashes, err := client.UnconfirmedTxHashes(ctx)
+	return (err == nil || errors.Is(err, errMempoolTruncated)) && hashes[hash]
+}
+
+func (c *failoverTxClient) GetTx(ctx context.Context, in *tx.GetTxRequest, opts ...grpc.CallOption) (
+	resp *tx.GetTxResponse, err error) {
+	err = c.pool.Do(ctx, "GetTx", func(idx int) (err error) {
+		resp, err = c.clients[idx].GetTx(ctx, in, opts...)
+		return err
+	})
+	return resp, err
+}
+
+func (c *failoverTxClient) UnconfirmedTxHashes(ctx context.Context) (hashes map[string]bool, err error) {
+	err = c.pool.Do(ctx, "UnconfirmedTxHashes", func(idx int) (err error) {
+		hashes, err = c.clients[idx].UnconfirmedTxHashes(ctx)
+		return err
+	})
+	return hashes, err
+}
+
+func (c *failoverTxClient) SimulateTx(ctx context.Context, msgs []sdk.Msg, txOpt *ctypes.TxOption,
+	opts ...grpc.CallOption) (resp *tx.SimulateResponse, err error) {
+	err = c.pool.Do(ctx, "SimulateTx", func(idx int) (err error) {
+		resp, err = c.clients[idx].SimulateTx(ctx, msgs, txOpt, opts...)
+		return err
+	})
+	return resp, err
+}
+
+// txHash returns the hash of the tx bytes in upper case hex as the tx hashes of the broadcast
+// responses.
+func txHash(txBytes []byte) string {
+	return strings.ToUpper(hex.EncodeToString(tmhash.Sum(txBytes)))
+}
+
+var _ endpointTxClient = &chainTxClient{}
+
+// chainTxClient is the greenfield client of one chain address, the mempool is queried by the
+// rpc client of the same address.
+type chainTxClient struct {
+	*client.GreenfieldClient
+	rpc *chttp.HTTP
+}
+
+// GetNonce returns the sequence of the account of the client, the query is canceled with ctx.
+func (c *chainTxClient) GetNonce(ctx context.Context) (uint64, error) {
+	km, err := c.GetKeyManager()
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.Account(ctx, &authtypes.QueryAccountRequest{Address: km.GetAddr().String()})
+	if err != nil {
+		return 0, err
+	}
+	var account authtypes.AccountI
+	if err = c.GetCodec().InterfaceRegistry().UnpackAny(resp.GetAccount(), &account); err != nil {
+		return 0, err
+	}
+	return account.GetSequence(), nil
+}
+
+// BroadcastTxBytes broadcasts the signed tx bytes.
+func (c *chainTxClient) BroadcastTxBytes(ctx context.Context, txBytes []byte, mode tx.BroadcastMode,
+	opts ...grpc.CallOption) (*tx.BroadcastTxResponse, error) {
+	return c.GreenfieldClient.TxClient.BroadcastTx(ctx, &tx.BroadcastTxRequest{Mode: mode, TxBytes: txBytes}, opts...)
+}
+
+// UnconfirmedTxHashes returns the hashes of at most txMempoolQueryLimit txs in the mempool, the
+// hashes are upper case hex as the tx hashes of the broadcast responses. The listed hashes are
+// returned with errMempoolTruncated if the mempool has more txs.
+func (c *chainTxClient) UnconfirmedTxHashes(ctx context.Context) (map[string]bool, error) {
+	limit := txMempoolQueryLimit
+	res, err := c.rpc.UnconfirmedTxs(ctx, &limit)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make(map[string]bool, len(res.Txs))
+	for _, t := range res.Txs {
+		hashes[txHash(t)] = true
+	}
+	if res.Total > len(res.Txs) {
+		return hashes, fmt.Errorf("%w: listed %d of %d txs", errMempoolTruncated, len(res.Txs), res.Total)
+	}
+	return hashes, nil
+}
@@ -0,0 +1,62 @@
+package signer
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/bnb-chain/greenfield-storage-provider/base/gnfd"
+)
+
+func TestFailoverTxClient_Broadcast(t *testing.T) {
+	down, up := newMockChainClient(0), newMockChainClient(0)
+	down.reject = status.Error(codes.Unavailable, "connection refused")
+	client := &failoverTxClient{
+		pool:    gnfd.NewEndpointPool("test", []string{"down", "up"}, gnfd.FailoverConfig{}),
+		clients: []endpointTxClient{down, up},
+	}
+	m, err := newTxManager(SignSeal, client, testGasConfig)
+	assert.Nil(t, err)
+
+	hash, err := m.Broadcast(context.TODO(), nil, 1200, tx.BroadcastMode_BROADCAST_MODE_SYNC)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(down.nonces))
+	assert.Equal(t, []uint64{0}, up.nonces)
+
+	// the rejected tx is returned without fail over
+	up.reject = status.Error(codes.Unknown, "insufficient fee")
+	_, err = m.Broadcast(context.TODO(), nil, 1200, tx.BroadcastMode_BROADCAST_MODE_SYNC)
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, len(up.nonces))
+	assert.NotEmpty(t, hash)
+}
+
+func TestFailoverTxClient_AmbiguousBroadcast(t *testing.T) {
+	first, second := newMockChainClient(0), newMockChainClient(0)
+	client := &failoverTxClient{
+		pool:    gnfd.NewEndpointPool("test", []string{"first", "second"}, gnfd.FailoverConfig{}),
+		clients: []endpointTxClient{first, second},
+	}
+	m, err := newTxManager(SignSeal, client, testGasConfig)
+	assert.Nil(t, err)
+
+	// the tx accepted by the endpoint is found by its hash and not broadcast again
+	first.ambiguous = status.Error(codes.DeadlineExceeded, "context deadline exceeded")
+	hash, err := m.Broadcast(context.TODO(), nil, 1200, tx.BroadcastMode_BROADCAST_MODE_SYNC)
+	assert.Nil(t, err)
+	assert.True(t, first.mempool[hash])
+	assert.Equal(t, []uint64{0}, first.nonces)
+	assert.Equal(t, 0, len(second.nonces))
+
+	// the tx that is not found is failed without fail over
+	first.ambiguous = nil
+	first.reject = io.EOF
+	_, err = m.Broadcast(context.TODO(), nil, 1200, tx.BroadcastMode_BROADCAST_MODE_SYNC)
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, 0, len(second.nonces))
+}
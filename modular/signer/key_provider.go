@@ -0,0 +1,275 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/eth/ethsecp256k1"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+
+	"github.com/bnb-chain/greenfield/sdk/keys"
+)
+
+const (
+	// KeyProviderPlaintext reads the hex private keys from the config or the env variables
+	KeyProviderPlaintext = "plaintext"
+	// KeyProviderKeystore decrypts the private keys from the encrypted keystore files
+	KeyProviderKeystore = "keystore"
+	// KeyProviderRemote signs by the remote signing service, the private keys never leave it
+	KeyProviderRemote = "remote"
+)
+
+// KeyProvider provides the key managers of the SP accounts to the signer, the key manager
+// signs the tx and the msg for the account.
+type KeyProvider interface {
+	// GetKeyManager returns the key manager of the account.
+	GetKeyManager(scope SignType) (keys.KeyManager, error)
+}
+
+// KeyGenerator is the optional interface of the key provider that can generate new keys, the
+// generated key is held by the provider and is got by GetKeyManager of the same name.
+type KeyGenerator interface {
+	// GenerateKey generates a new key of the name, it fails if the name already exists.
+	GenerateKey(name SignType) (keys.KeyManager, error)
+}
+
+var _ KeyProvider = &plaintextKeyProvider{}
+
+// plaintextKeyProvider holds the key managers recovered from the hex private keys.
+type plaintextKeyProvider struct {
+	privateKeys map[SignType]string
+}
+
+// NewPlaintextKeyProvider returns the key provider of the hex private keys.
+func NewPlaintextKeyProvider(privateKeys map[SignType]string) KeyProvider {
+	return &plaintextKeyProvider{privateKeys: privateKeys}
+}
+
+// GetKeyManager returns the key manager of the hex private key of the account.
+func (p *plaintextKeyProvider) GetKeyManager(scope SignType) (keys.KeyManager, error) {
+	return keys.NewPrivateKeyManager(p.privateKeys[scope])
+}
+
+var _ KeyProvider = &keystoreKeyProvider{}
+
+// keystoreKeyProvider decrypts the private keys from the keystore files in the web3 secret
+// storage format, the file of every account is named as ${dir}/${scope}.json.
+type keystoreKeyProvider struct {
+	dir        string
+	passphrase string
+	// scryptN and scryptP are the scrypt parameters of encrypting the generated keys
+	scryptN int
+	scryptP int
+}
+
+// NewKeystoreKeyProvider returns the key provider of the keystore files in the dir.
+func NewKeystoreKeyProvider(dir, passphrase string) KeyProvider {
+	return &keystoreKeyProvider{
+		dir:        dir,
+		passphrase: passphrase,
+		scryptN:    keystore.StandardScryptN,
+		scryptP:    keystore.StandardScryptP,
+	}
+}
+
+// GetKeyManager decrypts the keystore file of the account by the passphrase.
+func (p *keystoreKeyProvider) GetKeyManager(scope SignType) (keys.KeyManager, error) {
+	keyJSON, err := os.ReadFile(filepath.Join(p.dir, string(scope)+".json"))
+	if err != nil {
+		return nil, err
+	}
+	key, err := keystore.DecryptKey(keyJSON, p.passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s keystore: %w", scope, err)
+	}
+	return keys.NewPrivateKeyManager(hex.EncodeToString(crypto.FromECDSA(key.PrivateKey)))
+}
+
+var _ KeyGenerator = &keystoreKeyProvider{}
+
+// GenerateKey generates a new private key and writes it to the keystore file of the name,
+// encrypted by the passphrase.
+func (p *keystoreKeyProvider) GenerateKey(name SignType) (keys.KeyManager, error) {
+	privKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+	keyJSON, err := keystore.EncryptKey(&keystore.Key{
+		Id:         id,
+		Address:    crypto.PubkeyToAddress(privKey.PublicKey),
+		PrivateKey: privKey,
+	}, p.passphrase, p.scryptN, p.scryptP)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(p.dir, string(name)+".json"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = f.Write(keyJSON); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err = f.Close(); err != nil {
+		return nil, err
+	}
+	return keys.NewPrivateKeyManager(hex.EncodeToString(crypto.FromECDSA(privKey)))
+}
+
+var _ KeyProvider = &remoteKeyProvider{}
+
+// remoteKeyProvider signs by the remote signing service over http. The service serves:
+//
+//	GET  ${endpoint}/v1/keys/${scope}/pubkey returns {"pub_key": hex compressed secp256k1 public key}
+//	POST ${endpoint}/v1/keys/${scope}/sign with {"msg": hex msg} returns {"signature": hex signature}
+//
+// The signature is the same as the eth_secp256k1 private key signs, the 65 bytes [R || S || V]
+// of the msg if it is a 32 bytes digest, otherwise of the keccak256 hash of the msg.
+type remoteKeyProvider struct {
+	endpoint  string
+	authToken string
+	client    *http.Client
+}
+
+// NewRemoteKeyProvider returns the key provider of the remote signing service, the auth
+// token is sent as the bearer token if it is not empty.
+func NewRemoteKeyProvider(endpoint, authToken string, timeout time.Duration) KeyProvider {
+	return &remoteKeyProvider{
+		endpoint:  strings.TrimSuffix(endpoint, "/"),
+		authToken: authToken,
+		client:    &http.Client{Timeout: timeout},
+	}
+}
+
+type remotePubKeyResponse struct {
+	PubKey string `json:"pub_key"`
+}
+
+type remoteSignRequest struct {
+	Msg string `json:"msg"`
+}
+
+type remoteSignResponse struct {
+	Signature string `json:"signature"`
+}
+
+// GetKeyManager fetches the public key of the account, and returns the key manager that
+// signs by the remote signing service.
+func (p *remoteKeyProvider) GetKeyManager(scope SignType) (keys.KeyManager, error) {
+	var resp remotePubKeyResponse
+	if err := p.call(http.MethodGet, scope, "pubkey", nil, &resp); err != nil {
+		return nil, err
+	}
+	pubKey, err := hex.DecodeString(resp.PubKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s public key: %w", scope, err)
+	}
+	if len(pubKey) != ethsecp256k1.PubKeySize {
+		return nil, fmt.Errorf("invalid %s public key size %d", scope, len(pubKey))
+	}
+	return &remoteKeyManager{provider: p, scope: scope, pubKey: &ethsecp256k1.PubKey{Key: pubKey}}, nil
+}
+
+func (p *remoteKeyProvider) call(method string, scope SignType, action string, body interface{},
+	result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(context.Background(), method,
+		fmt.Sprintf("%s/v1/keys/%s/%s", p.endpoint, scope, action), reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+p.authToken)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("remote signer %s %s failed, status: %d, message: %s", scope, action,
+			resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+var _ keys.KeyManager = &remoteKeyManager{}
+
+// remoteKeyManager is the key manager whose private key is held by the remote signing service.
+type remoteKeyManager struct {
+	provider *remoteKeyProvider
+	scope    SignType
+	pubKey   *ethsecp256k1.PubKey
+}
+
+// Bytes is not allowed, the private key never leaves the remote signing service.
+func (km *remoteKeyManager) Bytes() []byte {
+	panic("Not allow to get privKey bytes from remote KeyManager")
+}
+
+// Sign signs the msg by the remote signing service.
+func (km *remoteKeyManager) Sign(msg []byte) ([]byte, error) {
+	var resp remoteSignResponse
+	if err := km.provider.call(http.MethodPost, km.scope, "sign", &remoteSignRequest{Msg: hex.EncodeToString(msg)},
+		&resp); err != nil {
+		return nil, err
+	}
+	sig, err := hex.DecodeString(resp.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s signature: %w", km.scope, err)
+	}
+	digest := msg
+	if len(digest) != crypto.DigestLength {
+		digest = crypto.Keccak256(msg)
+	}
+	if len(sig) != crypto.SignatureLength || !crypto.VerifySignature(km.pubKey.Key, digest, sig[:crypto.RecoveryIDOffset]) {
+		return nil, fmt.Errorf("mismatched %s signature", km.scope)
+	}
+	return sig, nil
+}
+
+func (km *remoteKeyManager) PubKey() cryptotypes.PubKey {
+	return km.pubKey
+}
+
+func (km *remoteKeyManager) Equals(key cryptotypes.LedgerPrivKey) bool {
+	return key != nil && km.pubKey.Equals(key.PubKey())
+}
+
+func (km *remoteKeyManager) Type() string {
+	return ethsecp256k1.KeyType
+}
+
+func (km *remoteKeyManager) GetAddr() sdk.AccAddress {
+	return sdk.AccAddress(km.pubKey.Address())
+}
+
+func (km *remoteKeyManager) String() string { return string(km.scope) }
+func (km *remoteKeyManager) ProtoMessage()  {}
+func (km *remoteKeyManager) Reset()         { *km = remoteKeyManager{} }
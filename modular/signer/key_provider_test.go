@@ -0,0 +1,103 @@
+package signer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/eth/ethsecp256k1"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeystoreKeyProvider(t *testing.T) {
+	dir := t.TempDir()
+	privKey, err := crypto.GenerateKey()
+	assert.Nil(t, err)
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.ImportECDSA(privKey, "passphrase")
+	assert.Nil(t, err)
+	assert.Nil(t, os.Rename(account.URL.Path, filepath.Join(dir, string(SignSeal)+".json")))
+
+	km, err := NewKeystoreKeyProvider(dir, "passphrase").GetKeyManager(SignSeal)
+	assert.Nil(t, err)
+	assert.Equal(t, account.Address.Bytes(), km.GetAddr().Bytes())
+
+	_, err = NewKeystoreKeyProvider(dir, "wrong").GetKeyManager(SignSeal)
+	assert.NotNil(t, err)
+	_, err = NewKeystoreKeyProvider(dir, "passphrase").GetKeyManager(SignGc)
+	assert.NotNil(t, err)
+}
+
+func TestKeystoreKeyProvider_GenerateKey(t *testing.T) {
+	dir := t.TempDir()
+	provider := &keystoreKeyProvider{dir: dir, passphrase: "passphrase", scryptN: keystore.LightScryptN,
+		scryptP: keystore.LightScryptP}
+	generated, err := provider.GenerateKey("approval_1")
+	assert.Nil(t, err)
+	km, err := provider.GetKeyManager("approval_1")
+	assert.Nil(t, err)
+	assert.Equal(t, generated.GetAddr(), km.GetAddr())
+	info, err := os.Stat(filepath.Join(dir, "approval_1.json"))
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// the existing key is never overwritten
+	_, err = provider.GenerateKey("approval_1")
+	assert.NotNil(t, err)
+}
+
+// newRemoteSignerStub serves the remote signing api with the private key of the seal account.
+func newRemoteSignerStub(t *testing.T, privKey *ethsecp256k1.PrivKey, authToken string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+authToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/keys/seal/pubkey":
+			_ = json.NewEncoder(w).Encode(&remotePubKeyResponse{
+				PubKey: hex.EncodeToString(privKey.PubKey().Bytes()),
+			})
+		case "/v1/keys/seal/sign":
+			var req remoteSignRequest
+			assert.Nil(t, json.NewDecoder(r.Body).Decode(&req))
+			msg, err := hex.DecodeString(req.Msg)
+			assert.Nil(t, err)
+			sig, err := privKey.Sign(msg)
+			assert.Nil(t, err)
+			_ = json.NewEncoder(w).Encode(&remoteSignResponse{Signature: hex.EncodeToString(sig)})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestRemoteKeyProvider(t *testing.T) {
+	privKey, err := ethsecp256k1.GenPrivKey()
+	assert.Nil(t, err)
+	server := newRemoteSignerStub(t, privKey, "token")
+	defer server.Close()
+
+	km, err := NewRemoteKeyProvider(server.URL, "token", 0).GetKeyManager(SignSeal)
+	assert.Nil(t, err)
+	assert.Equal(t, sdk.AccAddress(privKey.PubKey().Address()), km.GetAddr())
+	for _, msg := range [][]byte{[]byte("msg"), crypto.Keccak256([]byte("digest"))} {
+		sig, err := km.Sign(msg)
+		assert.Nil(t, err)
+		expected, err := privKey.Sign(msg)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, sig)
+	}
+
+	_, err = NewRemoteKeyProvider(server.URL, "wrong", 0).GetKeyManager(SignSeal)
+	assert.NotNil(t, err)
+	_, err = NewRemoteKeyProvider(server.URL, "token", 0).GetKeyManager(SignGc)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,182 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
+)
+
+const (
+	// sealBatchBroadcastTimeout defines the timeout of broadcasting one seal batch
+	sealBatchBroadcastTimeout = time.Minute
+	// sealBatchStopTimeout defines the time that Stop waits for the in-flight batch before canceling it
+	sealBatchStopTimeout = 10 * time.Second
+)
+
+// sealBroadcastFunc broadcasts the seal messages in one tx and returns the tx hash, the error
+// tells whether the tx is rejected by its messages.
+type sealBroadcastFunc func(ctx context.Context, msgs []*storagetypes.MsgSealObject) ([]byte, error)
+
+// sealBatcher accumulates the seal messages for a short window or up to the max batch size,
+// and broadcasts them in one multi-message tx. If the tx is rejected by its messages, the batch
+// is split into halves and broadcast again until the failed message is isolated, so one bad
+// message only fails its own caller. The batch is not split if the tx fails due to the transport,
+// and the messages are broadcast one by one if the batch can not be simulated.
+type sealBatcher struct {
+	broadcast        sealBroadcastFunc
+	maxBatch         int
+	window           time.Duration
+	broadcastTimeout time.Duration
+	stopTimeout      time.Duration
+
+	// ctx is canceled if the in-flight batch is not finished in the stop timeout, the broadcast
+	// of every batch is derived from it.
+	ctx      context.Context
+	cancel   context.CancelFunc
+	requests chan *sealRequest
+	stopCh   chan struct{}
+	stopped  chan struct{}
+}
+
+type sealRequest struct {
+	ctx  context.Context
+	msg  *storagetypes.MsgSealObject
+	done chan sealResult
+}
+
+type sealResult struct {
+	txHash []byte
+	err    error
+}
+
+func newSealBatcher(broadcast sealBroadcastFunc, maxBatch int, window time.Duration) *sealBatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &sealBatcher{
+		broadcast:        broadcast,
+		maxBatch:         maxBatch,
+		window:           window,
+		broadcastTimeout: sealBatchBroadcastTimeout,
+		stopTimeout:      sealBatchStopTimeout,
+		ctx:              ctx,
+		cancel:           cancel,
+		requests:         make(chan *sealRequest),
+		stopCh:           make(chan struct{}),
+		stopped:          make(chan struct{}),
+	}
+}
+
+// Start starts the loop of batching the seal messages.
+func (b *sealBatcher) Start() {
+	go b.run()
+}
+
+// Stop stops accepting the seal messages and waits for the in-flight batch to finish, the
+// in-flight batch is canceled if it is not finished in the stop timeout.
+func (b *sealBatcher) Stop() {
+	close(b.stopCh)
+	defer b.cancel()
+	select {
+	case <-b.stopped:
+	case <-time.After(b.stopTimeout):
+		log.Warnw("cancel the in-flight seal batch due to seal batcher stopped")
+		b.cancel()
+		<-b.stopped
+	}
+}
+
+// Seal adds the seal message to the pending batch, and waits for the tx that includes it.
+func (b *sealBatcher) Seal(ctx context.Context, msg *storagetypes.MsgSealObject) ([]byte, error) {
+	req := &sealRequest{ctx: ctx, msg: msg, done: make(chan sealResult, 1)}
+	select {
+	case b.requests <- req:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-b.stopCh:
+		log.CtxErrorw(ctx, "failed to seal object due to seal batcher stopped")
+		return nil, ErrSealObjectOnChain
+	}
+	select {
+	case result := <-req.done:
+		return result.txHash, result.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (b *sealBatcher) run() {
+	defer close(b.stopped)
+	for {
+		var batch []*sealRequest
+		select {
+		case req := <-b.requests:
+			batch = append(batch, req)
+		case <-b.stopCh:
+			return
+		}
+		timer := time.NewTimer(b.window)
+	collect:
+		for len(batch) < b.maxBatch {
+			select {
+			case req := <-b.requests:
+				batch = append(batch, req)
+			case <-timer.C:
+				break collect
+			case <-b.stopCh:
+				break collect
+			}
+		}
+		timer.Stop()
+		b.flush(batch)
+	}
+}
+
+// flush broadcasts the batch except the requests whose callers have given up.
+func (b *sealBatcher) flush(batch []*sealRequest) {
+	pending := batch[:0]
+	for _, req := range batch {
+		if err := req.ctx.Err(); err != nil {
+			req.done <- sealResult{err: err}
+			continue
+		}
+		pending = append(pending, req)
+	}
+	if len(pending) > 0 {
+		b.broadcastAndSplit(pending)
+	}
+}
+
+func (b *sealBatcher) broadcastAndSplit(batch []*sealRequest) {
+	msgs := make([]*storagetypes.MsgSealObject, 0, len(batch))
+	for _, req := range batch {
+		msgs = append(msgs, req.msg)
+	}
+	ctx, cancel := context.WithTimeout(b.ctx, b.broadcastTimeout)
+	txHash, err := b.broadcast(ctx, msgs)
+	cancel()
+	switch {
+	case err == nil:
+		for _, req := range batch {
+			req.done <- sealResult{txHash: txHash}
+		}
+	case len(batch) > 1 && isTxRejected(err):
+		log.Warnw("seal batch is rejected, split it to isolate the failed message",
+			"batch_size", len(batch), "error", err)
+		mid := len(batch) / 2
+		b.broadcastAndSplit(batch[:mid])
+		b.broadcastAndSplit(batch[mid:])
+	case len(batch) > 1 && errors.Is(err, errSimulationRequired):
+		log.Warnw("seal batch can not be simulated, broadcast the messages one by one",
+			"batch_size", len(batch), "error", err)
+		for _, req := range batch {
+			b.broadcastAndSplit([]*sealRequest{req})
+		}
+	default:
+		log.Errorw("failed to broadcast seal batch", "batch_size", len(batch), "error", err)
+		for _, req := range batch {
+			req.done <- sealResult{err: ErrSealObjectOnChain}
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSealBroadcaster struct {
+	mu      sync.Mutex
+	batches [][]string
+	bad     string
+	// err fails the batches of multiple messages
+	err      error
+	attempts int
+}
+
+func (m *mockSealBroadcaster) broadcast(_ context.Context, msgs []*storagetypes.MsgSealObject) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attempts++
+	if m.err != nil && len(msgs) > 1 {
+		return nil, m.err
+	}
+	var names []string
+	for _, msg := range msgs {
+		if msg.ObjectName == m.bad {
+			return nil, &txRejectedError{err: errors.New("invalid seal message")}
+		}
+		names = append(names, msg.ObjectName)
+	}
+	m.batches = append(m.batches, names)
+	return []byte(fmt.Sprintf("tx%d", len(m.batches))), nil
+}
+
+func sealConcurrently(b *sealBatcher, count int) ([][]byte, []error) {
+	var (
+		wg      sync.WaitGroup
+		txHashs = make([][]byte, count)
+		errs    = make([]error, count)
+	)
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			txHashs[i], errs[i] = b.Seal(context.TODO(), &storagetypes.MsgSealObject{
+				BucketName: "bucket",
+				ObjectName: fmt.Sprintf("object%d", i),
+			})
+		}(i)
+	}
+	wg.Wait()
+	return txHashs, errs
+}
+
+func TestSealBatcher_Batch(t *testing.T) {
+	broadcaster := &mockSealBroadcaster{}
+	b := newSealBatcher(broadcaster.broadcast, 4, time.Second)
+	b.Start()
+	defer b.Stop()
+
+	txHashs, errs := sealConcurrently(b, 8)
+	for i := range errs {
+		assert.Nil(t, errs[i])
+		assert.NotNil(t, txHashs[i])
+	}
+	// the batch is broadcast once it is full without waiting for the window
+	assert.Equal(t, 2, len(broadcaster.batches))
+	for _, batch := range broadcaster.batches {
+		assert.Equal(t, 4, len(batch))
+	}
+}
+
+func TestSealBatcher_Window(t *testing.T) {
+	broadcaster := &mockSealBroadcaster{}
+	b := newSealBatcher(broadcaster.broadcast, 100, 20*time.Millisecond)
+	b.Start()
+	defer b.Stop()
+
+	start := time.Now()
+	txHash, err := b.Seal(context.TODO(), &storagetypes.MsgSealObject{ObjectName: "object"})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("tx1"), txHash)
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+}
+
+func TestSealBatcher_Split(t *testing.T) {
+	broadcaster := &mockSealBroadcaster{bad: "object5"}
+	b := newSealBatcher(broadcaster.broadcast, 8, time.Second)
+	b.Start()
+	defer b.Stop()
+
+	_, errs := sealConcurrently(b, 8)
+	for i, err := range errs {
+		if i == 5 {
+			assert.NotNil(t, err)
+		} else {
+			assert.Nil(t, err)
+		}
+	}
+	var sealed int
+	for _, batch := range broadcaster.batches {
+		sealed += len(batch)
+	}
+	assert.Equal(t, 7, sealed)
+	// the halves without the bad message are broadcast without splitting further
+	assert.Equal(t, 3, len(broadcaster.batches))
+}
+
+func TestSealBatcher_NoSplit(t *testing.T) {
+	// the batch failed due to the transport is not split
+	broadcaster := &mockSealBroadcaster{err: errors.New("connection reset")}
+	b := newSealBatcher(broadcaster.broadcast, 8, time.Second)
+	b.Start()
+	_, errs := sealConcurrently(b, 8)
+	b.Stop()
+	for _, err := range errs {
+		assert.Equal(t, ErrSealObjectOnChain, err)
+	}
+	assert.Equal(t, 1, broadcaster.attempts)
+
+	// the batch that can not be simulated is broadcast one by one
+	broadcaster = &mockSealBroadcaster{err: fmt.Errorf("%w: unavailable", errSimulationRequired)}
+	b = newSealBatcher(broadcaster.broadcast, 8, time.Second)
+	b.Start()
+	_, errs = sealConcurrently(b, 8)
+	b.Stop()
+	for _, err := range errs {
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 8, len(broadcaster.batches))
+}
+
+func TestSealBatcher_Stop(t *testing.T) {
+	broadcaster := &mockSealBroadcaster{}
+	b := newSealBatcher(broadcaster.broadcast, 4, time.Second)
+	b.Start()
+	b.Stop()
+	_, err := b.Seal(context.TODO(), &storagetypes.MsgSealObject{ObjectName: "object"})
+	assert.Equal(t, ErrSealObjectOnChain, err)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	_, err = b.Seal(ctx, &storagetypes.MsgSealObject{ObjectName: "object"})
+	assert.NotNil(t, err)
+}
+
+func TestSealBatcher_BroadcastTimeout(t *testing.T) {
+	// the broadcast blocks until it is canceled
+	var canceled error
+	b := newSealBatcher(func(ctx context.Context, _ []*storagetypes.MsgSealObject) ([]byte, error) {
+		<-ctx.Done()
+		canceled = ctx.Err()
+		return nil, ctx.Err()
+	}, 4, time.Millisecond)
+	b.broadcastTimeout = 10 * time.Millisecond
+	b.Start()
+	_, err := b.Seal(context.TODO(), &storagetypes.MsgSealObject{ObjectName: "object"})
+	assert.Equal(t, ErrSealObjectOnChain, err)
+	assert.Equal(t, context.DeadlineExceeded, canceled)
+
+	// the in-flight batch is canceled if it is not finished in the stop timeout
+	b.broadcastTimeout = time.Hour
+	b.stopTimeout = 10 * time.Millisecond
+	done := make(chan error, 1)
+	go func() {
+		_, err := b.Seal(context.TODO(), &storagetypes.MsgSealObject{ObjectName: "object"})
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	b.Stop()
+	assert.Equal(t, ErrSealObjectOnChain, <-done)
+	assert.Equal(t, context.Canceled, canceled)
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/bnb-chain/greenfield-storage-provider/core/rcmgr"
 	"github.com/bnb-chain/greenfield-storage-provider/core/task"
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/metrics"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/tracing"
 	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
 )
 
@@ -30,6 +31,8 @@ var _ module.Signer = &SignModular{}
 type SignModular struct {
 	baseApp *gfspapp.GfSpBaseApp
 	client  *GreenfieldChainSignClient
+	// sealBatcher broadcasts the seal messages in batch, it is nil if batching is disabled.
+	sealBatcher *sealBatcher
 }
 
 func (s *SignModular) Name() string {
@@ -37,10 +40,18 @@ func (s *SignModular) Name() string {
 }
 
 func (s *SignModular) Start(ctx context.Context) error {
+	s.client.Start()
+	if s.sealBatcher != nil {
+		s.sealBatcher.Start()
+	}
 	return nil
 }
 
 func (s *SignModular) Stop(ctx context.Context) error {
+	if s.sealBatcher != nil {
+		s.sealBatcher.Stop()
+	}
+	s.client.Stop()
 	return nil
 }
 
@@ -129,9 +140,15 @@ func (s *SignModular) SealObject(ctx context.Context, object *storagetypes.MsgSe
 		err       error
 		startTime = time.Now()
 	)
+	ctx, span := tracing.StartSpan(ctx, "Signer.SealObject")
 	defer func() {
+		tracing.EndSpan(span, err)
 		metrics.SealObjectTimeHistogram.WithLabelValues(s.Name()).Observe(time.Since(startTime).Seconds())
 	}()
+	if s.sealBatcher != nil {
+		_, err = s.sealBatcher.Seal(ctx, object)
+		return err
+	}
 	_, err = s.client.SealObject(ctx, SignSeal, object)
 	return err
 }
@@ -143,7 +160,9 @@ func (s *SignModular) RejectUnSealObject(
 		err       error
 		startTime = time.Now()
 	)
+	ctx, span := tracing.StartSpan(ctx, "Signer.RejectUnSealObject")
 	defer func() {
+		tracing.EndSpan(span, err)
 		metrics.RejectUnSealObjectTimeHistogram.WithLabelValues(s.Name()).Observe(time.Since(startTime).Seconds())
 		if err != nil {
 			metrics.RejectUnSealObjectSucceedCounter.WithLabelValues(s.Name()).Inc()
@@ -160,7 +179,9 @@ func (s *SignModular) DiscontinueBucket(ctx context.Context, bucket *storagetype
 		err       error
 		startTime = time.Now()
 	)
+	ctx, span := tracing.StartSpan(ctx, "Signer.DiscontinueBucket")
 	defer func() {
+		tracing.EndSpan(span, err)
 		metrics.DiscontinueBucketTimeHistogram.WithLabelValues(s.Name()).Observe(time.Since(startTime).Seconds())
 		if err != nil {
 			metrics.DiscontinueBucketSucceedCounter.WithLabelValues(s.Name()).Inc()
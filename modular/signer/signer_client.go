@@ -3,17 +3,17 @@ package signer
 import (
 	"context"
 	"encoding/hex"
+	"fmt"
 	"strings"
-	"sync"
 
+	chttp "github.com/cometbft/cometbft/rpc/client/http"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/types/tx"
 	"github.com/ethereum/go-ethereum/crypto"
 
+	"github.com/bnb-chain/greenfield-storage-provider/base/gnfd"
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
 	"github.com/bnb-chain/greenfield/sdk/client"
-	"github.com/bnb-chain/greenfield/sdk/keys"
-	ctypes "github.com/bnb-chain/greenfield/sdk/types"
 	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
 )
 
@@ -37,97 +37,128 @@ const (
 	SignGc SignType = "gc"
 )
 
+// GasConfig defines the gas of the txs broadcast by the signer.
+type GasConfig struct {
+	// GasLimit is the static gas limit of one msg used if the simulation is unavailable
+	GasLimit uint64
+	// GasAdjustment multiplies the simulated gas used as the gas limit of the tx
+	GasAdjustment float64
+	// MaxGasLimit caps the estimated gas limit of the tx
+	MaxGasLimit uint64
+	// GasPrice is the gas price used before the simulation returns the min gas price
+	GasPrice sdk.Coin
+}
+
 // GreenfieldChainSignClient the greenfield chain client
 type GreenfieldChainSignClient struct {
-	mu sync.Mutex
-
 	gasLimit          uint64
 	greenfieldClients map[SignType]*client.GreenfieldClient
-	sealAccNonce      uint64
-	gcAccNonce        uint64
+	// txManagers assign the nonces and track the pending txs of the accounts that send txs
+	txManagers map[SignType]*txManager
+	// pool fails over the txs of the tx managers across the chain addresses
+	pool      *gnfd.EndpointPool
+	wsClients []*chttp.HTTP
 }
 
-// NewGreenfieldChainSignClient return the GreenfieldChainSignClient instance
-func NewGreenfieldChainSignClient(rpcAddr, chainID string, gasLimit uint64, operatorPrivateKey, fundingPrivateKey,
-	sealPrivateKey, approvalPrivateKey, gcPrivateKey string) (*GreenfieldChainSignClient, error) {
-	// init clients
-	// TODO: Get private key from KMS(AWS, GCP, Azure, Aliyun)
-	operatorKM, err := keys.NewPrivateKeyManager(operatorPrivateKey)
-	if err != nil {
-		log.Errorw("failed to new operator private key manager", "error", err)
-		return nil, err
+// NewGreenfieldChainSignClient return the GreenfieldChainSignClient instance, the key managers
+// of the accounts are provided by the key provider. The txs fail over across the rpc addresses.
+func NewGreenfieldChainSignClient(rpcAddrs []string, chainID string, gasConfig GasConfig,
+	failoverConfig gnfd.FailoverConfig, keyProvider KeyProvider) (*GreenfieldChainSignClient, error) {
+	if len(rpcAddrs) == 0 {
+		return nil, fmt.Errorf("chain address missing")
 	}
-
-	operatorClient, err := client.NewGreenfieldClient(rpcAddr, chainID, client.WithKeyManager(operatorKM))
-	if err != nil {
-		log.Errorw("failed to new operator greenfield client", "error", err)
-		return nil, err
-	}
-	fundingKM, err := keys.NewPrivateKeyManager(fundingPrivateKey)
-	if err != nil {
-		log.Errorw("failed to new funding private key manager", "error", err)
-		return nil, err
-	}
-	fundingClient, err := client.NewGreenfieldClient(rpcAddr, chainID, client.WithKeyManager(fundingKM))
-	if err != nil {
-		log.Errorw("failed to new funding greenfield client", "error", err)
-		return nil, err
+	var wsClients []*chttp.HTTP
+	for _, rpcAddr := range rpcAddrs {
+		wsClient, err := chttp.New(rpcAddr, "/websocket")
+		if err != nil {
+			log.Errorw("failed to new websocket client", "rpc_addr", rpcAddr, "error", err)
+			return nil, err
+		}
+		wsClients = append(wsClients, wsClient)
 	}
+	pool := gnfd.NewEndpointPool(BroadcastEndpointPool, rpcAddrs, failoverConfig)
 
-	sealKM, err := keys.NewPrivateKeyManager(sealPrivateKey)
-	if err != nil {
-		log.Errorw("failed to new seal private key manager", "error", err)
-		return nil, err
-	}
-	sealClient, err := client.NewGreenfieldClient(rpcAddr, chainID, client.WithKeyManager(sealKM))
-	if err != nil {
-		log.Errorw("failed to new seal greenfield client", "error", err)
-		return nil, err
-	}
-	sealAccNonce, err := sealClient.GetNonce()
-	if err != nil {
-		log.Errorw("failed to get nonce", "error", err)
-		return nil, err
+	// init clients, the key manager is held by the client of the first address
+	greenfieldClients := make(map[SignType]*client.GreenfieldClient)
+	txClients := make(map[SignType]*failoverTxClient)
+	for _, scope := range []SignType{SignOperator, SignFunding, SignSeal, SignApproval, SignGc} {
+		km, err := keyProvider.GetKeyManager(scope)
+		if err != nil {
+			log.Errorw("failed to new private key manager", "scope", scope, "error", err)
+			return nil, err
+		}
+		txClient := &failoverTxClient{pool: pool}
+		for i, rpcAddr := range rpcAddrs {
+			greenfieldClient, err := client.NewGreenfieldClient(rpcAddr, chainID, client.WithKeyManager(km))
+			if err != nil {
+				log.Errorw("failed to new greenfield client", "scope", scope, "rpc_addr", rpcAddr, "error", err)
+				return nil, err
+			}
+			if _, ok := greenfieldClients[scope]; !ok {
+				greenfieldClients[scope] = greenfieldClient
+			}
+			txClient.clients = append(txClient.clients, &chainTxClient{GreenfieldClient: greenfieldClient, rpc: wsClients[i]})
+		}
+		txClients[scope] = txClient
 	}
 
-	approvalKM, err := keys.NewPrivateKeyManager(approvalPrivateKey)
+	sealTxManager, err := newTxManager(SignSeal, txClients[SignSeal], gasConfig)
 	if err != nil {
-		log.Errorw("failed to new approval private key manager", "error", err)
 		return nil, err
 	}
-	approvalClient, err := client.NewGreenfieldClient(rpcAddr, chainID, client.WithKeyManager(approvalKM))
+	gcTxManager, err := newTxManager(SignGc, txClients[SignGc], gasConfig)
 	if err != nil {
-		log.Errorw("failed to new approval greenfield client", "error", err)
 		return nil, err
 	}
 
-	gcKM, err := keys.NewPrivateKeyManager(gcPrivateKey)
-	if err != nil {
-		return nil, err
-	}
-	gcClient, err := client.NewGreenfieldClient(rpcAddr, chainID, client.WithKeyManager(gcKM))
-	if err != nil {
-		return nil, err
+	return &GreenfieldChainSignClient{
+		gasLimit:          gasConfig.GasLimit,
+		greenfieldClients: greenfieldClients,
+		txManagers: map[SignType]*txManager{
+			SignSeal: sealTxManager,
+			SignGc:   gcTxManager,
+		},
+		pool:      pool,
+		wsClients: wsClients,
+	}, nil
+}
+
+// SetTxRateLimit limits the txs of the account to txsPerSecond, it is unlimited if txsPerSecond
+// is not positive.
+func (client *GreenfieldChainSignClient) SetTxRateLimit(scope SignType, txsPerSecond float64) error {
+	m, ok := client.txManagers[scope]
+	if !ok {
+		return fmt.Errorf("no tx manager for %s account", scope)
 	}
-	gcAccNonce, err := gcClient.GetNonce()
-	if err != nil {
-		return nil, err
+	m.SetRateLimit(txsPerSecond)
+	return nil
+}
+
+// Start starts confirming the pending txs of the accounts and checking the health of the chain
+// addresses.
+func (client *GreenfieldChainSignClient) Start() {
+	client.pool.StartHealthCheck(client.latestHeight)
+	for _, m := range client.txManagers {
+		m.Start()
 	}
+}
 
-	greenfieldClients := map[SignType]*client.GreenfieldClient{
-		SignOperator: operatorClient,
-		SignFunding:  fundingClient,
-		SignSeal:     sealClient,
-		SignApproval: approvalClient,
-		SignGc:       gcClient,
+// Stop stops confirming the pending txs of the accounts and checking the health of the chain
+// addresses.
+func (client *GreenfieldChainSignClient) Stop() {
+	for _, m := range client.txManagers {
+		m.Stop()
 	}
+	client.pool.Stop()
+}
 
-	return &GreenfieldChainSignClient{
-		gasLimit:          gasLimit,
-		greenfieldClients: greenfieldClients,
-		sealAccNonce:      sealAccNonce,
-		gcAccNonce:        gcAccNonce,
-	}, nil
+// latestHeight returns the latest block height of the chain address by index.
+func (client *GreenfieldChainSignClient) latestHeight(ctx context.Context, idx int) (uint64, error) {
+	info, err := client.wsClients[idx].ABCIInfo(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(info.Response.LastBlockHeight), nil
 }
 
 // GetAddr returns the public address of the private key.
@@ -148,14 +179,24 @@ func (client *GreenfieldChainSignClient) Sign(scope SignType, msg []byte) ([]byt
 	return km.Sign(msg)
 }
 
-// VerifySignature verifies the signature.
+// VerifySignature verifies the signature, the signatures of the rotated keys that are still
+// accepted by the key manager pass the verification too.
 func (client *GreenfieldChainSignClient) VerifySignature(scope SignType, msg, sig []byte) bool {
 	km, err := client.greenfieldClients[scope].GetKeyManager()
 	if err != nil {
 		return false
 	}
 
-	return storagetypes.VerifySignature(km.GetAddr(), crypto.Keccak256(msg), sig) == nil
+	addrs := []sdk.AccAddress{km.GetAddr()}
+	if rotated, ok := km.(interface{ AcceptedAddrs() []sdk.AccAddress }); ok {
+		addrs = rotated.AcceptedAddrs()
+	}
+	for _, addr := range addrs {
+		if storagetypes.VerifySignature(addr, crypto.Keccak256(msg), sig) == nil {
+			return true
+		}
+	}
+	return false
 }
 
 // SealObject seal the object on the greenfield chain.
@@ -180,45 +221,56 @@ func (client *GreenfieldChainSignClient) SealObject(
 		secondarySPAccs = append(secondarySPAccs, opAddr)
 	}
 
-	client.mu.Lock()
-	defer client.mu.Unlock()
-	nonce := client.sealAccNonce
-
 	msgSealObject := storagetypes.NewMsgSealObject(km.GetAddr(),
 		sealObject.BucketName, sealObject.ObjectName, secondarySPAccs, sealObject.SecondarySpSignatures)
-	mode := tx.BroadcastMode_BROADCAST_MODE_ASYNC
-	txOpt := &ctypes.TxOption{
-		Mode:     &mode,
-		GasLimit: client.gasLimit,
-		Nonce:    nonce,
+	txHash, err := client.broadcastTx(ctx, scope, []sdk.Msg{msgSealObject}, client.gasLimit,
+		tx.BroadcastMode_BROADCAST_MODE_ASYNC)
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to broadcast tx", "err", err, "seal_info", msgSealObject.String())
+		return nil, ErrSealObjectOnChain
 	}
+	return txHash, nil
+}
 
-	resp, err := client.greenfieldClients[scope].BroadcastTx(ctx, []sdk.Msg{msgSealObject}, txOpt)
+// SealObjects seals the objects by one multi-message tx on the greenfield chain. The check of
+// the sync mode does not execute the messages, so the tx of multiple messages is broadcast only
+// if it passes the simulation, otherwise one failed message fails the whole tx in the block.
+// The error is returned as is for the caller to tell whether the tx is rejected by its messages.
+func (client *GreenfieldChainSignClient) SealObjects(
+	ctx context.Context,
+	scope SignType,
+	sealObjects []*storagetypes.MsgSealObject) (
+	[]byte, error) {
+	km, err := client.greenfieldClients[scope].GetKeyManager()
 	if err != nil {
-		log.CtxErrorw(ctx, "failed to broadcast tx", "err", err, "seal_info", msgSealObject.String())
-		if strings.Contains(err.Error(), "account sequence mismatch") {
-			// if nonce mismatch, reset nonce by querying the nonce on chain
-			nonce, err = client.greenfieldClients[scope].GetNonce()
+		log.CtxErrorw(ctx, "failed to get private key", "err", err)
+		return nil, ErrSignMsg
+	}
+
+	msgs := make([]sdk.Msg, 0, len(sealObjects))
+	for _, sealObject := range sealObjects {
+		var secondarySPAccs []sdk.AccAddress
+		for _, sp := range sealObject.SecondarySpAddresses {
+			opAddr, err := sdk.AccAddressFromHexUnsafe(sp) // should be 0x...
 			if err != nil {
-				log.CtxErrorw(ctx, "failed to get seal account nonce", "err", err, "seal_info", msgSealObject.String())
-				return nil, ErrSealObjectOnChain
+				log.CtxErrorw(ctx, "failed to parse address", "error", err, "address", sp)
+				return nil, err
 			}
-			client.sealAccNonce = nonce
+			secondarySPAccs = append(secondarySPAccs, opAddr)
 		}
-		return nil, ErrSealObjectOnChain
+		msgs = append(msgs, storagetypes.NewMsgSealObject(km.GetAddr(),
+			sealObject.BucketName, sealObject.ObjectName, secondarySPAccs, sealObject.SecondarySpSignatures))
 	}
 
-	if resp.TxResponse.Code != 0 {
-		log.CtxErrorf(ctx, "failed to broadcast tx, resp code: %d", resp.TxResponse.Code, "seal_info", msgSealObject.String())
-		return nil, ErrSealObjectOnChain
+	gasLimit := client.gasLimit
+	if len(msgs) > 1 {
+		gasLimit = 0
 	}
-	txHash, err := hex.DecodeString(resp.TxResponse.TxHash)
+	txHash, err := client.broadcastTx(ctx, scope, msgs, gasLimit, tx.BroadcastMode_BROADCAST_MODE_SYNC)
 	if err != nil {
-		log.CtxErrorw(ctx, "failed to marshal tx hash", "err", err, "seal_info", msgSealObject.String())
-		return nil, ErrSealObjectOnChain
+		log.CtxErrorw(ctx, "failed to broadcast seal batch tx", "err", err, "batch_size", len(msgs))
+		return nil, err
 	}
-	client.sealAccNonce = nonce + 1
-
 	return txHash, nil
 }
 
@@ -234,47 +286,16 @@ func (client *GreenfieldChainSignClient) RejectUnSealObject(
 		return nil, ErrSignMsg
 	}
 
-	client.mu.Lock()
-	defer client.mu.Unlock()
-	nonce := client.sealAccNonce
-
 	msgRejectUnSealObject := storagetypes.NewMsgRejectUnsealedObject(km.GetAddr(), rejectObject.GetBucketName(), rejectObject.GetObjectName())
-	mode := tx.BroadcastMode_BROADCAST_MODE_ASYNC
-	txOpt := &ctypes.TxOption{
-		Mode:     &mode,
-		GasLimit: client.gasLimit,
-		Nonce:    nonce,
-	}
-	resp, err := client.greenfieldClients[scope].BroadcastTx(ctx, []sdk.Msg{msgRejectUnSealObject}, txOpt)
-
+	txHash, err := client.broadcastTx(ctx, scope, []sdk.Msg{msgRejectUnSealObject}, client.gasLimit,
+		tx.BroadcastMode_BROADCAST_MODE_ASYNC)
 	if err != nil {
 		log.CtxErrorw(ctx, "failed to broadcast tx", "err", err, "seal_info", msgRejectUnSealObject.String())
-		if strings.Contains(err.Error(), "account sequence mismatch") {
-			// if nonce mismatch, reset nonce by querying the nonce on chain
-			nonce, err = client.greenfieldClients[scope].GetNonce()
-			if err != nil {
-				log.CtxErrorw(ctx, "failed to get seal account nonce", "err", err, "seal_info", msgRejectUnSealObject.String())
-				return nil, ErrRejectUnSealObjectOnChain
-			}
-			client.sealAccNonce = nonce
-		}
 		if strings.Contains(err.Error(), "Object already sealed") {
 			return nil, nil
 		}
 		return nil, ErrRejectUnSealObjectOnChain
 	}
-
-	if resp.TxResponse.Code != 0 {
-		log.CtxErrorf(ctx, "failed to broadcast tx, resp code: %d", resp.TxResponse.Code, "seal_info", msgRejectUnSealObject.String())
-		return nil, ErrSealObjectOnChain
-	}
-	txHash, err := hex.DecodeString(resp.TxResponse.TxHash)
-	if err != nil {
-		log.CtxErrorw(ctx, "failed to marshal tx hash", "err", err, "seal_info", msgRejectUnSealObject.String())
-		return nil, ErrSealObjectOnChain
-	}
-	client.sealAccNonce = nonce + 1
-
 	return txHash, nil
 }
 
@@ -287,45 +308,28 @@ func (client *GreenfieldChainSignClient) DiscontinueBucket(ctx context.Context,
 		return nil, ErrSignMsg
 	}
 
-	client.mu.Lock()
-	defer client.mu.Unlock()
-	nonce := client.gcAccNonce
-
 	msgDiscontinueBucket := storagetypes.NewMsgDiscontinueBucket(km.GetAddr(),
 		discontinueBucket.BucketName, discontinueBucket.Reason)
-	mode := tx.BroadcastMode_BROADCAST_MODE_SYNC
-	txOpt := &ctypes.TxOption{
-		Mode:     &mode,
-		GasLimit: client.gasLimit,
-		Nonce:    nonce,
-	}
-
-	resp, err := client.greenfieldClients[scope].BroadcastTx(ctx, []sdk.Msg{msgDiscontinueBucket}, txOpt)
+	txHash, err := client.broadcastTx(ctx, scope, []sdk.Msg{msgDiscontinueBucket}, client.gasLimit,
+		tx.BroadcastMode_BROADCAST_MODE_SYNC)
 	if err != nil {
 		log.CtxErrorw(ctx, "failed to broadcast tx", "err", err, "discontinue_bucket", msgDiscontinueBucket.String())
-		if strings.Contains(err.Error(), "account sequence mismatch") {
-			// if nonce mismatch, reset nonce by querying the nonce on chain
-			nonce, err := client.greenfieldClients[scope].GetNonce()
-			if err != nil {
-				log.CtxErrorw(ctx, "failed to get gc account nonce", "err", err)
-				return nil, ErrDiscontinueBucketOnChain
-			}
-			client.gcAccNonce = nonce
-		}
 		return nil, ErrDiscontinueBucketOnChain
 	}
+	return txHash, nil
+}
 
-	if resp.TxResponse.Code != 0 {
-		log.CtxErrorf(ctx, "failed to broadcast tx, resp code: %d", resp.TxResponse.Code, "discontinue_bucket", msgDiscontinueBucket.String())
-		return nil, ErrDiscontinueBucketOnChain
+// broadcastTx broadcasts the msgs by the tx manager of the account, the nonce is assigned
+// and the tx is tracked until it is included by the tx manager.
+func (client *GreenfieldChainSignClient) broadcastTx(ctx context.Context, scope SignType, msgs []sdk.Msg,
+	gasLimit uint64, mode tx.BroadcastMode) ([]byte, error) {
+	m, ok := client.txManagers[scope]
+	if !ok {
+		return nil, fmt.Errorf("no tx manager for %s account", scope)
 	}
-	txHash, err := hex.DecodeString(resp.TxResponse.TxHash)
+	hash, err := m.Broadcast(ctx, msgs, gasLimit, mode)
 	if err != nil {
-		log.CtxErrorw(ctx, "failed to marshal tx hash", "err", err, "discontinue_bucket", msgDiscontinueBucket.String())
-		return nil, ErrDiscontinueBucketOnChain
+		return nil, err
 	}
-
-	// update nonce when tx is successful submitted
-	client.gcAccNonce = nonce + 1
-	return txHash, nil
+	return hex.DecodeString(hash)
 }
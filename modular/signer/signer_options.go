@@ -1,17 +1,39 @@
 package signer
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"strings"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
 
 	"github.com/bnb-chain/greenfield-storage-provider/base/gfspapp"
 	"github.com/bnb-chain/greenfield-storage-provider/base/gfspconfig"
 	coremodule "github.com/bnb-chain/greenfield-storage-provider/core/module"
+	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
 )
 
 const (
 	// DefaultGasLimit defines the default gas limit
 	DefaultGasLimit = 210000
+	// DefaultSealBatchSize defines the default max number of the seal messages in one tx
+	DefaultSealBatchSize = 10
+	// DefaultSealBatchWindow defines the default max milliseconds that a seal message waits for the batch
+	DefaultSealBatchWindow = 200
+	// DefaultGasAdjustment defines the default multiplier of the simulated gas used
+	DefaultGasAdjustment = 1.2
+	// DefaultMaxGasLimit defines the default max gas limit of the tx
+	DefaultMaxGasLimit = 5000000
+	// DefaultGasPrice defines the default gas price used if the simulation is unavailable
+	DefaultGasPrice = "5000000000BNB"
+	// DefaultApprovalKeyGracePeriod defines the default seconds that the retired approval key is accepted
+	DefaultApprovalKeyGracePeriod = 24 * 60 * 60
+	// DefaultGcTxRateLimit defines the default max txs per second of the gc account
+	DefaultGcTxRateLimit = 1.0
+	// DefaultRemoteSignerTimeoutSeconds defines the default timeout of the requests to the remote signing service
+	DefaultRemoteSignerTimeoutSeconds = 10
 	// SpOperatorPrivKey defines env variable name for sp operator private key
 	SpOperatorPrivKey = "SIGNER_OPERATOR_PRIV_KEY"
 	// SpFundingPrivKey defines env variable name for sp funding private key
@@ -22,6 +44,10 @@ const (
 	SpSealPrivKey = "SIGNER_SEAL_PRIV_KEY"
 	// SpGcPrivKey defines env variable name for sp gc private key
 	SpGcPrivKey = "SIGNER_GC_PRIV_KEY"
+	// SpKeystorePassphrase defines env variable name for the passphrase of sp keystore files
+	SpKeystorePassphrase = "SIGNER_KEYSTORE_PASSPHRASE"
+	// SpRemoteSignerAuthToken defines env variable name for the auth token of remote signing service
+	SpRemoteSignerAuthToken = "SIGNER_REMOTE_AUTH_TOKEN"
 )
 
 func NewSignModular(app *gfspapp.GfSpBaseApp, cfg *gfspconfig.GfSpConfig) (coremodule.Modular, error) {
@@ -39,27 +65,112 @@ func DefaultSignerOptions(signer *SignModular, cfg *gfspconfig.GfSpConfig) error
 	if cfg.Chain.GasLimit == 0 {
 		cfg.Chain.GasLimit = DefaultGasLimit
 	}
-	if val, ok := os.LookupEnv(SpOperatorPrivKey); ok {
-		cfg.SpAccount.OperatorPrivateKey = val
+	keyProvider, err := NewKeyProvider(cfg)
+	if err != nil {
+		return err
 	}
-	if val, ok := os.LookupEnv(SpFundingPrivKey); ok {
-		cfg.SpAccount.FundingPrivateKey = val
+	if len(cfg.Signer.ApprovalKeyring) != 0 {
+		if cfg.Signer.ApprovalKeyGracePeriod == 0 {
+			cfg.Signer.ApprovalKeyGracePeriod = DefaultApprovalKeyGracePeriod
+		}
+		keyProvider, err = NewApprovalKeyringProvider(keyProvider, cfg.Signer.ApprovalKeyring,
+			time.Duration(cfg.Signer.ApprovalKeyGracePeriod)*time.Second)
+		if err != nil {
+			return err
+		}
 	}
-	if val, ok := os.LookupEnv(SpSealPrivKey); ok {
-		cfg.SpAccount.SealPrivateKey = val
+	if cfg.Signer.GasAdjustment == 0 {
+		cfg.Signer.GasAdjustment = DefaultGasAdjustment
 	}
-	if val, ok := os.LookupEnv(SpApprovalPrivKey); ok {
-		cfg.SpAccount.ApprovalPrivateKey = val
+	if cfg.Signer.MaxGasLimit == 0 {
+		cfg.Signer.MaxGasLimit = DefaultMaxGasLimit
 	}
-	if val, ok := os.LookupEnv(SpGcPrivKey); ok {
-		cfg.SpAccount.GcPrivateKey = val
+	if len(cfg.Signer.GasPrice) == 0 {
+		cfg.Signer.GasPrice = DefaultGasPrice
 	}
-	client, err := NewGreenfieldChainSignClient(cfg.Chain.ChainAddress[0], cfg.Chain.ChainID,
-		cfg.Chain.GasLimit, cfg.SpAccount.OperatorPrivateKey, cfg.SpAccount.FundingPrivateKey,
-		cfg.SpAccount.SealPrivateKey, cfg.SpAccount.ApprovalPrivateKey, cfg.SpAccount.GcPrivateKey)
+	gasPrice, err := sdk.ParseCoinNormalized(cfg.Signer.GasPrice)
 	if err != nil {
+		return fmt.Errorf("invalid gas price %s: %w", cfg.Signer.GasPrice, err)
+	}
+	client, err := NewGreenfieldChainSignClient(cfg.Chain.ChainAddress, cfg.Chain.ChainID, GasConfig{
+		GasLimit:      cfg.Chain.GasLimit,
+		GasAdjustment: cfg.Signer.GasAdjustment,
+		MaxGasLimit:   cfg.Signer.MaxGasLimit,
+		GasPrice:      gasPrice,
+	}, gfspapp.MakeFailoverConfig(cfg), keyProvider)
+	if err != nil {
+		return err
+	}
+	if cfg.Signer.GcTxRateLimit == 0 {
+		cfg.Signer.GcTxRateLimit = DefaultGcTxRateLimit
+	}
+	if err = client.SetTxRateLimit(SignGc, cfg.Signer.GcTxRateLimit); err != nil {
 		return err
 	}
 	signer.client = client
+	if cfg.Signer.SealBatchSize == 0 {
+		cfg.Signer.SealBatchSize = DefaultSealBatchSize
+	}
+	if cfg.Signer.SealBatchWindow == 0 {
+		cfg.Signer.SealBatchWindow = DefaultSealBatchWindow
+	}
+	if cfg.Signer.SealBatchSize > 1 {
+		signer.sealBatcher = newSealBatcher(func(ctx context.Context, msgs []*storagetypes.MsgSealObject) ([]byte, error) {
+			return client.SealObjects(ctx, SignSeal, msgs)
+		}, cfg.Signer.SealBatchSize, time.Duration(cfg.Signer.SealBatchWindow)*time.Millisecond)
+	}
 	return nil
 }
+
+// NewKeyProvider returns the key provider of the SpAccount.KeyProvider config.
+func NewKeyProvider(cfg *gfspconfig.GfSpConfig) (KeyProvider, error) {
+	switch cfg.SpAccount.KeyProvider {
+	case "", KeyProviderPlaintext:
+		if val, ok := os.LookupEnv(SpOperatorPrivKey); ok {
+			cfg.SpAccount.OperatorPrivateKey = val
+		}
+		if val, ok := os.LookupEnv(SpFundingPrivKey); ok {
+			cfg.SpAccount.FundingPrivateKey = val
+		}
+		if val, ok := os.LookupEnv(SpSealPrivKey); ok {
+			cfg.SpAccount.SealPrivateKey = val
+		}
+		if val, ok := os.LookupEnv(SpApprovalPrivKey); ok {
+			cfg.SpAccount.ApprovalPrivateKey = val
+		}
+		if val, ok := os.LookupEnv(SpGcPrivKey); ok {
+			cfg.SpAccount.GcPrivateKey = val
+		}
+		return NewPlaintextKeyProvider(map[SignType]string{
+			SignOperator: cfg.SpAccount.OperatorPrivateKey,
+			SignFunding:  cfg.SpAccount.FundingPrivateKey,
+			SignSeal:     cfg.SpAccount.SealPrivateKey,
+			SignApproval: cfg.SpAccount.ApprovalPrivateKey,
+			SignGc:       cfg.SpAccount.GcPrivateKey,
+		}), nil
+	case KeyProviderKeystore:
+		if len(cfg.SpAccount.Keystore.Dir) == 0 {
+			return nil, fmt.Errorf("keystore dir missing")
+		}
+		passphrase := os.Getenv(SpKeystorePassphrase)
+		if len(cfg.SpAccount.Keystore.PassphraseFile) != 0 {
+			data, err := os.ReadFile(cfg.SpAccount.Keystore.PassphraseFile)
+			if err != nil {
+				return nil, err
+			}
+			passphrase = strings.TrimRight(string(data), "\r\n")
+		}
+		return NewKeystoreKeyProvider(cfg.SpAccount.Keystore.Dir, passphrase), nil
+	case KeyProviderRemote:
+		if len(cfg.SpAccount.RemoteSigner.Endpoint) == 0 {
+			return nil, fmt.Errorf("remote signer endpoint missing")
+		}
+		if cfg.SpAccount.RemoteSigner.TimeoutSeconds == 0 {
+			cfg.SpAccount.RemoteSigner.TimeoutSeconds = DefaultRemoteSignerTimeoutSeconds
+		}
+		return NewRemoteKeyProvider(cfg.SpAccount.RemoteSigner.Endpoint, os.Getenv(SpRemoteSignerAuthToken),
+			time.Duration(cfg.SpAccount.RemoteSigner.TimeoutSeconds)*time.Second), nil
+	default:
+		return nil, fmt.Errorf("unknown key provider %s", cfg.SpAccount.KeyProvider)
+	}
+}
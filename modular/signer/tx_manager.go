@@ -0,0 +1,490 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/cosmos/cosmos-sdk/types/tx"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/metrics"
+	ctypes "github.com/bnb-chain/greenfield/sdk/types"
+)
+
+const (
+	// txConfirmInterval defines the interval of checking the inclusion of the pending txs
+	txConfirmInterval = 3 * time.Second
+	// txRebroadcastTimeout defines the time that the pending tx is regarded as dropped from the
+	// mempool if it is not included
+	txRebroadcastTimeout = time.Minute
+	// txMaxRebroadcast defines the max times that a dropped tx is re-broadcast before giving up
+	txMaxRebroadcast = 3
+	// txGasBumpPercent defines the percent that the gas limit is increased by every re-broadcast,
+	// the fee of the re-broadcast tx is higher to be picked by the mempool
+	txGasBumpPercent = 10
+	// txMempoolQueryLimit defines the max number of the mempool txs queried to recover the nonce gap,
+	// the gap is not recovered if the mempool has more txs
+	txMempoolQueryLimit = 100
+)
+
+// errSimulationRequired is returned if the simulation of the tx without the static gas limit is
+// unavailable, the messages of the tx are not verified before the tx is broadcast.
+var errSimulationRequired = errors.New("tx simulation is required but unavailable")
+
+// errMempoolTruncated is returned with the listed hashes if the mempool has more txs than the
+// query limit, the txs that are not listed may be in the mempool.
+var errMempoolTruncated = errors.New("mempool txs are truncated")
+
+// txRejectedError is the error that the tx is rejected by the simulation or the check of the
+// node due to its messages, the same tx fails again if it is broadcast by another node.
+type txRejectedError struct {
+	err error
+}
+
+func (e *txRejectedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *txRejectedError) Unwrap() error {
+	return e.err
+}
+
+// isTxRejected returns an indicator whether the tx is rejected due to its messages.
+func isTxRejected(err error) bool {
+	var rejected *txRejectedError
+	return errors.As(err, &rejected)
+}
+
+// txChainClient is the part of the greenfield client that the tx manager depends on.
+type txChainClient interface {
+	GetNonce(ctx context.Context) (uint64, error)
+	BroadcastTx(ctx context.Context, msgs []sdk.Msg, txOpt *ctypes.TxOption, opts ...grpc.CallOption) (
+		*tx.BroadcastTxResponse, error)
+	GetTx(ctx context.Context, in *tx.GetTxRequest, opts ...grpc.CallOption) (*tx.GetTxResponse, error)
+	SimulateTx(ctx context.Context, msgs []sdk.Msg, txOpt *ctypes.TxOption, opts ...grpc.CallOption) (
+		*tx.SimulateResponse, error)
+	// UnconfirmedTxHashes returns the hashes of the txs in the mempool of the node, errMempoolTruncated
+	// is returned if not all the txs are listed.
+	UnconfirmedTxHashes(ctx context.Context) (map[string]bool, error)
+}
+
+// pendingTx is the tx that has been accepted by the node but not included in a block.
+type pendingTx struct {
+	hash  string
+	nonce uint64
+	msgs  []sdk.Msg
+	// gasLimit is the static gas limit used if the simulation is unavailable, the tx is not
+	// broadcast without the simulation if it is zero
+	gasLimit    uint64
+	mode        tx.BroadcastMode
+	broadcastAt time.Time
+	rebroadcast int
+}
+
+// txManager assigns the nonces of one signer account and tracks its pending txs until they
+// are included. The dropped txs are re-broadcast with the same nonce and bumped gas, and the
+// nonce gap left by the abandoned txs is recovered by re-broadcasting the later pending txs
+// with the nonces that the chain expects. The gas limit of every tx is estimated by the
+// simulation.
+type txManager struct {
+	scope  SignType
+	client txChainClient
+
+	gasAdjustment float64
+	maxGasLimit   uint64
+
+	confirmInterval    time.Duration
+	rebroadcastTimeout time.Duration
+	maxRebroadcast     int
+
+	// sendMu serializes the broadcasts that assign the nonces, the next nonce is guarded by it
+	sendMu sync.Mutex
+	nonce  uint64
+
+	// mu guards the pending txs, the gas price and the limiter, it is not held during the requests
+	// to the chain
+	mu      sync.Mutex
+	pending map[string]*pendingTx
+	// gasPrice is updated by the min gas price of the simulation
+	gasPrice sdk.Coin
+	// limiter limits the rate of broadcasting the new txs, it is unlimited if nil
+	limiter *rate.Limiter
+
+	stopCh  chan struct{}
+	stopped chan struct{}
+}
+
+func newTxManager(scope SignType, client txChainClient, gasConfig GasConfig) (*txManager, error) {
+	nonce, err := client.GetNonce(context.Background())
+	if err != nil {
+		log.Errorw("failed to get nonce", "scope", scope, "error", err)
+		return nil, err
+	}
+	return &txManager{
+		scope:              scope,
+		client:             client,
+		gasAdjustment:      gasConfig.GasAdjustment,
+		maxGasLimit:        gasConfig.MaxGasLimit,
+		gasPrice:           gasConfig.GasPrice,
+		confirmInterval:    txConfirmInterval,
+		rebroadcastTimeout: txRebroadcastTimeout,
+		maxRebroadcast:     txMaxRebroadcast,
+		nonce:              nonce,
+		pending:            make(map[string]*pendingTx),
+		stopCh:             make(chan struct{}),
+		stopped:            make(chan struct{}),
+	}, nil
+}
+
+// Start starts the loop of confirming the pending txs.
+func (m *txManager) Start() {
+	go func() {
+		defer close(m.stopped)
+		ticker := time.NewTicker(m.confirmInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.checkPending(context.Background())
+			case <-m.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the loop of confirming the pending txs.
+func (m *txManager) Stop() {
+	close(m.stopCh)
+	<-m.stopped
+}
+
+// SetRateLimit limits the new txs to txsPerSecond, it is unlimited if txsPerSecond is not positive.
+func (m *txManager) SetRateLimit(txsPerSecond float64) {
+	var limiter *rate.Limiter
+	if txsPerSecond > 0 {
+		limiter = rate.NewLimiter(rate.Limit(txsPerSecond), 1)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.limiter = limiter
+}
+
+// PendingCount returns the number of the pending txs.
+func (m *txManager) PendingCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.pending)
+}
+
+// Broadcast signs the msgs with the next nonce and broadcasts them in one tx, the tx is
+// tracked until it is included if the node accepts it. It waits for the rate limit if it is set.
+func (m *txManager) Broadcast(ctx context.Context, msgs []sdk.Msg, gasLimit uint64, mode tx.BroadcastMode) (
+	string, error) {
+	m.mu.Lock()
+	limiter := m.limiter
+	m.mu.Unlock()
+	if limiter != nil {
+		if err := limiter.Wait(ctx); err != nil {
+			return "", err
+		}
+	}
+	m.sendMu.Lock()
+	defer m.sendMu.Unlock()
+	ptx := &pendingTx{nonce: m.nonce, msgs: msgs, gasLimit: gasLimit, mode: mode}
+	if err := m.broadcast(ctx, ptx); err != nil {
+		if isNonceMismatch(err) {
+			m.recoverNonce(ctx)
+		}
+		return "", err
+	}
+	m.track("", ptx)
+	m.nonce++
+	return ptx.hash, nil
+}
+
+// broadcast broadcasts the pending tx with its nonce and sets the new tx hash, the gas limit is
+// bumped by every re-broadcast.
+func (m *txManager) broadcast(ctx context.Context, ptx *pendingTx) error {
+	gasLimit, err := m.estimateGas(ctx, ptx)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < ptx.rebroadcast; i++ {
+		gasLimit = gasLimit * (100 + txGasBumpPercent) / 100
+	}
+	m.mu.Lock()
+	gasPrice := m.gasPrice
+	m.mu.Unlock()
+	mode := ptx.mode
+	txOpt := &ctypes.TxOption{
+		Mode:       &mode,
+		NoSimulate: true,
+		GasLimit:   gasLimit,
+		FeeAmount:  sdk.NewCoins(sdk.NewCoin(gasPrice.Denom, gasPrice.Amount.MulRaw(int64(gasLimit)))),
+		Nonce:      ptx.nonce,
+	}
+	resp, err := m.client.BroadcastTx(ctx, ptx.msgs, txOpt)
+	if err != nil {
+		return err
+	}
+	if resp.GetTxResponse() == nil {
+		return fmt.Errorf("empty broadcast tx response")
+	}
+	if resp.TxResponse.Code != 0 {
+		err = fmt.Errorf("tx is rejected with code %d: %s", resp.TxResponse.Code, resp.TxResponse.RawLog)
+		if isNonceMismatch(err) {
+			return err
+		}
+		return &txRejectedError{err: err}
+	}
+	ptx.hash = resp.TxResponse.TxHash
+	ptx.broadcastAt = time.Now()
+	metrics.SignerTxGasEstimatedHistogram.WithLabelValues(string(m.scope)).Observe(float64(gasLimit))
+	return nil
+}
+
+// track tracks the broadcast tx by its hash instead of the old hash.
+func (m *txManager) track(oldHash string, ptx *pendingTx) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.pending, oldHash)
+	m.pending[ptx.hash] = ptx
+	m.updateMetrics()
+}
+
+// untrack stops tracking the txs.
+func (m *txManager) untrack(hashes ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, hash := range hashes {
+		delete(m.pending, hash)
+	}
+	m.updateMetrics()
+}
+
+// snapshotPending returns the copies of the pending txs whose nonces are not less than minNonce
+// sorted by the nonce, the copies are checked and re-broadcast without holding the lock.
+func (m *txManager) snapshotPending(minNonce uint64) []*pendingTx {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	txs := make([]*pendingTx, 0, len(m.pending))
+	for _, ptx := range m.pending {
+		if ptx.nonce >= minNonce {
+			snapshot := *ptx
+			txs = append(txs, &snapshot)
+		}
+	}
+	sort.Slice(txs, func(i, j int) bool { return txs[i].nonce < txs[j].nonce })
+	return txs
+}
+
+// estimateGas returns the simulated gas used multiplied by the gas adjustment and capped by the
+// max gas limit, the static gas limit is used if the simulation is unavailable.
+func (m *txManager) estimateGas(ctx context.Context, ptx *pendingTx) (uint64, error) {
+	resp, err := m.client.SimulateTx(ctx, ptx.msgs, &ctypes.TxOption{Nonce: ptx.nonce})
+	if err != nil {
+		if !isSimulationUnavailable(err) {
+			// the tx fails in the simulation, it is not worth broadcasting
+			return 0, &txRejectedError{err: err}
+		}
+		if ptx.gasLimit == 0 {
+			return 0, fmt.Errorf("%w: %v", errSimulationRequired, err)
+		}
+		log.Warnw("failed to simulate tx, use the static gas limit", "scope", m.scope,
+			"gas_limit", ptx.gasLimit, "error", err)
+		metrics.SignerGasEstimateFallbackCounter.WithLabelValues(string(m.scope)).Inc()
+		return ptx.gasLimit, nil
+	}
+	if gasPrice, err := sdk.ParseCoinNormalized(resp.GetGasInfo().GetMinGasPrice()); err == nil &&
+		gasPrice.IsPositive() {
+		m.mu.Lock()
+		m.gasPrice = gasPrice
+		m.mu.Unlock()
+	}
+	gasLimit := uint64(math.Ceil(float64(resp.GetGasInfo().GetGasUsed()) * m.gasAdjustment))
+	if gasLimit > m.maxGasLimit {
+		log.Warnw("estimated gas exceeds the max gas limit", "scope", m.scope, "estimated_gas", gasLimit,
+			"max_gas_limit", m.maxGasLimit)
+		gasLimit = m.maxGasLimit
+	}
+	return gasLimit, nil
+}
+
+// recoverNonce resets the next nonce by the nonce on chain, the nonces of the pending txs
+// that may be in the mempool are skipped. It is called with sendMu held.
+func (m *txManager) recoverNonce(ctx context.Context) {
+	nonce, err := m.client.GetNonce(ctx)
+	if err != nil {
+		log.Errorw("failed to get nonce to recover", "scope", m.scope, "error", err)
+		return
+	}
+	for _, ptx := range m.snapshotPending(nonce) {
+		nonce = ptx.nonce + 1
+	}
+	log.Infow("recover nonce", "scope", m.scope, "old_nonce", m.nonce, "new_nonce", nonce)
+	m.nonce = nonce
+}
+
+// checkPending removes the included txs, re-broadcasts the dropped txs and recovers the
+// nonce gap. The pending txs are snapshot under the lock and the requests to the chain are
+// sent without holding it.
+func (m *txManager) checkPending(ctx context.Context) {
+	snapshot := m.snapshotPending(0)
+	if len(snapshot) == 0 {
+		return
+	}
+
+	var included []string
+	for _, ptx := range snapshot {
+		resp, err := m.client.GetTx(ctx, &tx.GetTxRequest{Hash: ptx.hash})
+		if err != nil || resp.GetTxResponse() == nil {
+			continue
+		}
+		txResp := resp.GetTxResponse()
+		metrics.SignerTxGasUsedHistogram.WithLabelValues(string(m.scope)).Observe(float64(txResp.GasUsed))
+		if txResp.Code != 0 {
+			log.Errorw("tx is included but failed", "scope", m.scope, "tx_hash", ptx.hash,
+				"code", txResp.Code, "raw_log", txResp.RawLog)
+			metrics.SignerTxFailedCounter.WithLabelValues(string(m.scope), "included_failed").Inc()
+		}
+		included = append(included, ptx.hash)
+	}
+	m.untrack(included...)
+	chainNonce, err := m.client.GetNonce(ctx)
+	if err != nil {
+		log.Errorw("failed to get nonce to check pending txs", "scope", m.scope, "error", err)
+		return
+	}
+
+	for _, ptx := range snapshot {
+		if ptx.nonce < chainNonce {
+			// the nonce has been used by an included tx, e.g. the re-broadcast one
+			log.Warnw("pending tx is replaced", "scope", m.scope, "tx_hash", ptx.hash, "nonce", ptx.nonce)
+			m.untrack(ptx.hash)
+			continue
+		}
+		if time.Since(ptx.broadcastAt) < m.rebroadcastTimeout {
+			continue
+		}
+		if ptx.rebroadcast >= m.maxRebroadcast {
+			log.Errorw("abandon the dropped tx", "scope", m.scope, "tx_hash", ptx.hash, "nonce", ptx.nonce)
+			metrics.SignerTxFailedCounter.WithLabelValues(string(m.scope), "abandoned").Inc()
+			m.untrack(ptx.hash)
+			continue
+		}
+		// the dropped tx keeps its nonce, the new txs are broadcast with the later nonces meanwhile
+		m.rebroadcast(ctx, ptx, ptx.nonce)
+	}
+	m.recoverNonceGap(ctx, chainNonce)
+}
+
+// recoverNonceGap re-broadcasts the pending txs with the nonces from the expected one if the chain
+// waits for the nonce that no pending tx holds, the later txs can never be included otherwise. The
+// new txs are not broadcast until the gap is recovered, the pending txs are not locked meanwhile.
+func (m *txManager) recoverNonceGap(ctx context.Context, chainNonce uint64) {
+	m.sendMu.Lock()
+	defer m.sendMu.Unlock()
+	alive := m.snapshotPending(chainNonce)
+	if m.nonce <= chainNonce || (len(alive) > 0 && alive[0].nonce == chainNonce) {
+		return
+	}
+	log.Warnw("recover nonce gap", "scope", m.scope, "chain_nonce", chainNonce, "next_nonce", m.nonce,
+		"pending", len(alive))
+	// the txs in the mempool keep their nonces, they would be included twice if they were
+	// re-broadcast with the other nonces, so the gap is not recovered if the mempool is not
+	// fully listed.
+	mempool, err := m.client.UnconfirmedTxHashes(ctx)
+	if err != nil {
+		log.Errorw("failed to get unconfirmed txs to recover nonce gap", "scope", m.scope, "error", err)
+		return
+	}
+	used := make(map[uint64]bool)
+	for _, ptx := range alive {
+		if mempool[ptx.hash] {
+			used[ptx.nonce] = true
+		}
+	}
+	nonce := chainNonce
+	for _, ptx := range alive {
+		if mempool[ptx.hash] {
+			continue
+		}
+		// the tx included after the chain nonce is queried keeps its nonce, the gap is checked again
+		// by the next round.
+		if resp, err := m.client.GetTx(ctx, &tx.GetTxRequest{Hash: ptx.hash}); err == nil && resp.GetTxResponse() != nil {
+			log.Infow("pending tx is included while recovering nonce gap", "scope", m.scope, "tx_hash", ptx.hash)
+			return
+		}
+		for used[nonce] {
+			nonce++
+		}
+		if !m.rebroadcast(ctx, ptx, nonce) {
+			// the nonce is not used by the failed tx, the next nonce is recovered from the chain
+			// and the pending txs.
+			m.recoverNonce(ctx)
+			return
+		}
+		used[nonce] = true
+	}
+	for used[nonce] {
+		nonce++
+	}
+	m.nonce = nonce
+}
+
+// rebroadcast re-broadcasts the pending tx with the nonce and the bumped gas limit, returns
+// whether the tx is pending.
+func (m *txManager) rebroadcast(ctx context.Context, ptx *pendingTx, nonce uint64) bool {
+	hash := ptx.hash
+	retry := *ptx
+	retry.nonce = nonce
+	retry.rebroadcast++
+	if err := m.broadcast(ctx, &retry); err != nil {
+		log.Errorw("failed to re-broadcast tx", "scope", m.scope, "tx_hash", hash, "nonce", nonce, "error", err)
+		// the old tx is kept to be checked next time, it may be included
+		return false
+	}
+	m.track(hash, &retry)
+	metrics.SignerRebroadcastTxCounter.WithLabelValues(string(m.scope)).Inc()
+	log.Infow("succeed to re-broadcast tx", "scope", m.scope, "old_tx_hash", hash, "tx_hash", retry.hash,
+		"nonce", nonce, "rebroadcast", retry.rebroadcast)
+	return true
+}
+
+func (m *txManager) updateMetrics() {
+	metrics.SignerPendingTxGauge.WithLabelValues(string(m.scope)).Set(float64(len(m.pending)))
+}
+
+// isSimulationUnavailable returns an indicator whether the simulation fails due to the node
+// rather than the tx.
+func isSimulationUnavailable(err error) bool {
+	st, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	switch st.Code() {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Unimplemented, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
+
+// isNonceMismatch returns an indicator whether the tx is rejected due to the wrong nonce.
+func isNonceMismatch(err error) bool {
+	return strings.Contains(err.Error(), "account sequence mismatch") ||
+		strings.Contains(err.Error(), fmt.Sprintf("code %d:", sdkerrors.ErrWrongSequence.ABCICode()))
+}
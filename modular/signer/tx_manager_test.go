@@ -0,0 +1,391 @@
+package signer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	ctypes "github.com/bnb-chain/greenfield/sdk/types"
+)
+
+// mockChainClient accepts the tx whose nonce is not used, and includes it on chain by include.
+type mockChainClient struct {
+	mu         sync.Mutex
+	chainNonce uint64
+	txs        map[string]uint64
+	included   map[string]bool
+	nonces     []uint64
+	gasLimits  []uint64
+	fees       []sdk.Coins
+	reject     error
+	// ambiguous is returned after the tx is accepted into the mempool
+	ambiguous error
+	// mempool holds the hashes of the txs in the mempool
+	mempool map[string]bool
+	// truncated indicates the mempool has more txs than the listed ones
+	truncated bool
+	// simulate returns the simulation result, the simulation is unavailable if it is nil
+	simulate func() (*tx.SimulateResponse, error)
+}
+
+func newMockChainClient(nonce uint64) *mockChainClient {
+	return &mockChainClient{chainNonce: nonce, txs: make(map[string]uint64), included: make(map[string]bool),
+		mempool: make(map[string]bool)}
+}
+
+func (c *mockChainClient) GetNonce(_ context.Context) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.chainNonce, nil
+}
+
+func (c *mockChainClient) BroadcastTx(_ context.Context, _ []sdk.Msg, txOpt *ctypes.TxOption, _ ...grpc.CallOption) (
+	*tx.BroadcastTxResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.accept(txOpt, fmt.Sprintf("%064x", len(c.nonces)+1))
+}
+
+// mockTx is the content of the tx bytes signed by the mock client.
+type mockTx struct {
+	Nonce     uint64
+	GasLimit  uint64
+	FeeAmount sdk.Coins
+}
+
+func (c *mockChainClient) SignTx(_ context.Context, _ []sdk.Msg, txOpt *ctypes.TxOption) ([]byte, error) {
+	return json.Marshal(&mockTx{Nonce: txOpt.Nonce, GasLimit: txOpt.GasLimit, FeeAmount: txOpt.FeeAmount})
+}
+
+func (c *mockChainClient) BroadcastTxBytes(_ context.Context, txBytes []byte, _ tx.BroadcastMode,
+	_ ...grpc.CallOption) (*tx.BroadcastTxResponse, error) {
+	var t mockTx
+	if err := json.Unmarshal(txBytes, &t); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.accept(&ctypes.TxOption{Nonce: t.Nonce, GasLimit: t.GasLimit, FeeAmount: t.FeeAmount}, txHash(txBytes))
+}
+
+// accept accepts the tx into the mempool if its nonce is not used, the tx is still accepted if
+// the ambiguous error is returned.
+func (c *mockChainClient) accept(txOpt *ctypes.TxOption, hash string) (*tx.BroadcastTxResponse, error) {
+	if c.reject != nil {
+		return nil, c.reject
+	}
+	if txOpt.Nonce < c.chainNonce {
+		return nil, fmt.Errorf("account sequence mismatch, expected %d, got %d", c.chainNonce, txOpt.Nonce)
+	}
+	c.nonces = append(c.nonces, txOpt.Nonce)
+	c.gasLimits = append(c.gasLimits, txOpt.GasLimit)
+	c.fees = append(c.fees, txOpt.FeeAmount)
+	c.txs[hash] = txOpt.Nonce
+	if c.ambiguous != nil {
+		c.mempool[hash] = true
+		return nil, c.ambiguous
+	}
+	return &tx.BroadcastTxResponse{TxResponse: &sdk.TxResponse{TxHash: hash}}, nil
+}
+
+func (c *mockChainClient) GetTx(_ context.Context, in *tx.GetTxRequest, _ ...grpc.CallOption) (*tx.GetTxResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.included[in.GetHash()] {
+		return nil, errors.New("tx not found")
+	}
+	return &tx.GetTxResponse{TxResponse: &sdk.TxResponse{TxHash: in.GetHash()}}, nil
+}
+
+func (c *mockChainClient) SimulateTx(_ context.Context, _ []sdk.Msg, _ *ctypes.TxOption, _ ...grpc.CallOption) (
+	*tx.SimulateResponse, error) {
+	if c.simulate == nil {
+		return nil, status.Error(codes.Unavailable, "connection refused")
+	}
+	return c.simulate()
+}
+
+func (c *mockChainClient) UnconfirmedTxHashes(_ context.Context) (map[string]bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hashes := make(map[string]bool, len(c.mempool))
+	for hash := range c.mempool {
+		hashes[hash] = true
+	}
+	if c.truncated {
+		return hashes, errMempoolTruncated
+	}
+	return hashes, nil
+}
+
+// include includes the tx on chain and advances the chain nonce.
+func (c *mockChainClient) include(hash string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.included[hash] = true
+	if c.txs[hash] >= c.chainNonce {
+		c.chainNonce = c.txs[hash] + 1
+	}
+}
+
+var testGasConfig = GasConfig{
+	GasAdjustment: 1.2,
+	MaxGasLimit:   5000,
+	GasPrice:      sdk.NewInt64Coin("BNB", 10),
+}
+
+func broadcastTxs(t *testing.T, m *txManager, count int) []string {
+	var hashes []string
+	for i := 0; i < count; i++ {
+		hash, err := m.Broadcast(context.TODO(), nil, 1000, tx.BroadcastMode_BROADCAST_MODE_ASYNC)
+		assert.Nil(t, err)
+		hashes = append(hashes, hash)
+	}
+	return hashes
+}
+
+func TestTxManager_Confirm(t *testing.T) {
+	c := newMockChainClient(5)
+	m, err := newTxManager(SignSeal, c, testGasConfig)
+	assert.Nil(t, err)
+
+	hashes := broadcastTxs(t, m, 3)
+	assert.Equal(t, []uint64{5, 6, 7}, c.nonces)
+	assert.Equal(t, 3, m.PendingCount())
+
+	c.include(hashes[0])
+	c.include(hashes[1])
+	m.checkPending(context.TODO())
+	assert.Equal(t, 1, m.PendingCount())
+	c.include(hashes[2])
+	m.checkPending(context.TODO())
+	assert.Equal(t, 0, m.PendingCount())
+}
+
+func TestTxManager_NonceMismatch(t *testing.T) {
+	c := newMockChainClient(5)
+	m, err := newTxManager(SignSeal, c, testGasConfig)
+	assert.Nil(t, err)
+
+	// the nonce is used by another client of the same account
+	c.chainNonce = 8
+	_, err = m.Broadcast(context.TODO(), nil, 1000, tx.BroadcastMode_BROADCAST_MODE_ASYNC)
+	assert.NotNil(t, err)
+	broadcastTxs(t, m, 1)
+	assert.Equal(t, []uint64{8}, c.nonces)
+
+	c.reject = errors.New("Object already sealed")
+	_, err = m.Broadcast(context.TODO(), nil, 1000, tx.BroadcastMode_BROADCAST_MODE_ASYNC)
+	assert.Equal(t, c.reject, err)
+	c.reject = nil
+	broadcastTxs(t, m, 1)
+	assert.Equal(t, []uint64{8, 9}, c.nonces)
+}
+
+func TestTxManager_Rebroadcast(t *testing.T) {
+	c := newMockChainClient(0)
+	m, err := newTxManager(SignGc, c, testGasConfig)
+	assert.Nil(t, err)
+	m.rebroadcastTimeout = 10 * time.Millisecond
+	m.maxRebroadcast = 1
+
+	broadcastTxs(t, m, 1)
+	time.Sleep(20 * time.Millisecond)
+	// the dropped tx is re-broadcast with the same nonce and more gas
+	m.checkPending(context.TODO())
+	assert.Equal(t, []uint64{0, 0}, c.nonces)
+	assert.Equal(t, []uint64{1000, 1100}, c.gasLimits)
+	assert.Equal(t, 1, m.PendingCount())
+
+	time.Sleep(20 * time.Millisecond)
+	// the tx is abandoned after the max re-broadcast
+	m.checkPending(context.TODO())
+	assert.Equal(t, 2, len(c.nonces))
+	assert.Equal(t, 0, m.PendingCount())
+}
+
+func TestTxManager_NonceGap(t *testing.T) {
+	c := newMockChainClient(0)
+	m, err := newTxManager(SignSeal, c, testGasConfig)
+	assert.Nil(t, err)
+	m.rebroadcastTimeout = 10 * time.Millisecond
+	m.maxRebroadcast = 0
+
+	broadcastTxs(t, m, 1)
+	time.Sleep(20 * time.Millisecond)
+	hashes := broadcastTxs(t, m, 2)
+	// the first tx is abandoned, the later txs are re-broadcast to fill the gap
+	m.checkPending(context.TODO())
+	assert.Equal(t, []uint64{0, 1, 2, 0, 1}, c.nonces)
+	assert.Equal(t, 2, m.PendingCount())
+	for _, hash := range hashes {
+		m.mu.Lock()
+		_, ok := m.pending[hash]
+		m.mu.Unlock()
+		assert.False(t, ok)
+	}
+
+	hash, err := m.Broadcast(context.TODO(), nil, 1000, tx.BroadcastMode_BROADCAST_MODE_ASYNC)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), c.txs[hash])
+}
+
+func TestTxManager_NonceGapMempool(t *testing.T) {
+	c := newMockChainClient(0)
+	m, err := newTxManager(SignSeal, c, testGasConfig)
+	assert.Nil(t, err)
+	m.rebroadcastTimeout = 10 * time.Millisecond
+	m.maxRebroadcast = 0
+
+	broadcastTxs(t, m, 1)
+	time.Sleep(20 * time.Millisecond)
+	hashes := broadcastTxs(t, m, 2)
+	// the tx in the mempool keeps its nonce, the other tx fills the gap
+	c.mempool[hashes[0]] = true
+	m.checkPending(context.TODO())
+	assert.Equal(t, []uint64{0, 1, 2, 0}, c.nonces)
+	assert.Equal(t, 2, m.PendingCount())
+	m.mu.Lock()
+	_, ok := m.pending[hashes[0]]
+	m.mu.Unlock()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(2), m.nonce)
+}
+
+func TestTxManager_NonceGapRebroadcastFailed(t *testing.T) {
+	c := newMockChainClient(0)
+	m, err := newTxManager(SignSeal, c, testGasConfig)
+	assert.Nil(t, err)
+	m.rebroadcastTimeout = 10 * time.Millisecond
+	m.maxRebroadcast = 0
+
+	broadcastTxs(t, m, 1)
+	time.Sleep(20 * time.Millisecond)
+	broadcastTxs(t, m, 2)
+	// the nonce is recovered from the pending txs if the gap is not filled
+	c.reject = errors.New("connection reset")
+	m.checkPending(context.TODO())
+	assert.Equal(t, 2, m.PendingCount())
+	assert.Equal(t, uint64(3), m.nonce)
+}
+
+func TestTxManager_NonceGapMempoolTruncated(t *testing.T) {
+	c := newMockChainClient(0)
+	m, err := newTxManager(SignSeal, c, testGasConfig)
+	assert.Nil(t, err)
+	m.rebroadcastTimeout = 10 * time.Millisecond
+	m.maxRebroadcast = 0
+
+	broadcastTxs(t, m, 1)
+	time.Sleep(20 * time.Millisecond)
+	broadcastTxs(t, m, 2)
+	// the txs that are not listed may be in the mempool, they are not re-broadcast with other nonces
+	c.truncated = true
+	m.checkPending(context.TODO())
+	assert.Equal(t, []uint64{0, 1, 2}, c.nonces)
+	assert.Equal(t, 2, m.PendingCount())
+	assert.Equal(t, uint64(3), m.nonce)
+
+	c.truncated = false
+	m.checkPending(context.TODO())
+	assert.Equal(t, []uint64{0, 1, 2, 0, 1}, c.nonces)
+}
+
+func TestTxManager_CheckPendingUnlocked(t *testing.T) {
+	c := newMockChainClient(0)
+	m, err := newTxManager(SignSeal, c, testGasConfig)
+	assert.Nil(t, err)
+	m.rebroadcastTimeout = 10 * time.Millisecond
+
+	broadcastTxs(t, m, 1)
+	time.Sleep(20 * time.Millisecond)
+	simulating, release := make(chan struct{}), make(chan struct{})
+	c.simulate = func() (*tx.SimulateResponse, error) {
+		close(simulating)
+		<-release
+		return &tx.SimulateResponse{GasInfo: &sdk.GasInfo{GasUsed: 1000}}, nil
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.checkPending(context.TODO())
+	}()
+	// the pending txs are not locked while the dropped tx is re-broadcast
+	<-simulating
+	assert.Equal(t, 1, m.PendingCount())
+	close(release)
+	<-done
+	assert.Equal(t, []uint64{0, 0}, c.nonces)
+	assert.Equal(t, 1, m.PendingCount())
+}
+
+func TestTxManager_EstimateGas(t *testing.T) {
+	c := newMockChainClient(0)
+	m, err := newTxManager(SignSeal, c, testGasConfig)
+	assert.Nil(t, err)
+
+	// the static gas limit is used if the simulation is unavailable
+	broadcastTxs(t, m, 1)
+	assert.Equal(t, uint64(1000), c.gasLimits[0])
+	assert.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("BNB", 10000)), c.fees[0])
+
+	gasUsed := uint64(2000)
+	c.simulate = func() (*tx.SimulateResponse, error) {
+		return &tx.SimulateResponse{GasInfo: &sdk.GasInfo{GasUsed: gasUsed, MinGasPrice: "5BNB"}}, nil
+	}
+	broadcastTxs(t, m, 1)
+	assert.Equal(t, uint64(2400), c.gasLimits[1])
+	assert.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("BNB", 12000)), c.fees[1])
+	// the estimated gas is capped by the max gas limit
+	gasUsed = 10000
+	broadcastTxs(t, m, 1)
+	assert.Equal(t, uint64(5000), c.gasLimits[2])
+
+	// the tx that fails the simulation is not broadcast
+	c.simulate = func() (*tx.SimulateResponse, error) {
+		return nil, status.Error(codes.Unknown, "Object already sealed")
+	}
+	_, err = m.Broadcast(context.TODO(), nil, 1000, tx.BroadcastMode_BROADCAST_MODE_ASYNC)
+	assert.NotNil(t, err)
+	assert.Equal(t, 3, len(c.nonces))
+}
+
+func TestTxManager_RateLimit(t *testing.T) {
+	c := newMockChainClient(0)
+	m, err := newTxManager(SignGc, c, testGasConfig)
+	assert.Nil(t, err)
+	m.SetRateLimit(20)
+
+	start := time.Now()
+	broadcastTxs(t, m, 3)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+	// the tx waiting for the rate limit is canceled with the context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = m.Broadcast(ctx, nil, 1000, tx.BroadcastMode_BROADCAST_MODE_SYNC)
+	assert.NotNil(t, err)
+	assert.Equal(t, 3, len(c.nonces))
+
+	// the rate limit is changed while the txs are broadcast
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		broadcastTxs(t, m, 3)
+	}()
+	m.SetRateLimit(0)
+	wg.Wait()
+	assert.Equal(t, 6, len(c.nonces))
+}
@@ -1,22 +1,26 @@
 package uploader
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/hex"
-	"fmt"
+	"hash"
 	"io"
 	"net/http"
 	"time"
 
-	"github.com/bnb-chain/greenfield-common/go/hash"
+	"github.com/bnb-chain/greenfield-storage-provider/base/gfspapp"
 	"github.com/bnb-chain/greenfield-storage-provider/base/types/gfsperrors"
 	"github.com/bnb-chain/greenfield-storage-provider/core/module"
+	"github.com/bnb-chain/greenfield-storage-provider/core/piecestore"
 	corespdb "github.com/bnb-chain/greenfield-storage-provider/core/spdb"
 	coretask "github.com/bnb-chain/greenfield-storage-provider/core/task"
 	"github.com/bnb-chain/greenfield-storage-provider/core/taskqueue"
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/metrics"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/tracing"
 	storagetypes "github.com/bnb-chain/greenfield/x/storage/types"
 )
 
@@ -67,9 +71,8 @@ func (u *UploadModular) HandleUploadObjectTask(ctx context.Context, uploadObject
 		signature []byte
 		integrity []byte
 		checksums [][]byte
-		readN     int
 		readSize  int
-		data      = make([]byte, segmentSize)
+		reader    = bufio.NewReaderSize(stream, piecestore.StreamBufferSize)
 	)
 	defer func() {
 		if err != nil {
@@ -83,97 +86,94 @@ func (u *UploadModular) HandleUploadObjectTask(ctx context.Context, uploadObject
 	}()
 
 	for {
-		if err != nil {
-			return err
-		}
-		data = data[0:segmentSize]
 		startReadFromGateway := time.Now()
-		readN, err = StreamReadAt(stream, data)
+		_, err = reader.Peek(1)
 		metrics.PerfUploadTimeHistogram.WithLabelValues("consumer_read_from_gateway").Observe(time.Since(startReadFromGateway).Seconds())
-		readSize += readN
-		data = data[0:readN]
-
 		if err == io.EOF {
 			err = nil
-			if readN != 0 { // the last segment piece
-				pieceKey = u.baseApp.PieceOp().SegmentPieceKey(uploadObjectTask.GetObjectInfo().Id.Uint64(), segIdx)
-				checksums = append(checksums, hash.GenerateChecksum(data))
-				startPutPiece := time.Now()
-				if err = u.baseApp.PieceStore().PutPiece(ctx, pieceKey, data); err != nil {
-					metrics.PerfUploadTimeHistogram.WithLabelValues("put_to_piecestore").Observe(time.Since(startPutPiece).Seconds())
-					log.CtxErrorw(ctx, "failed to put segment piece to piece store",
-						"piece_key", pieceKey, "error", err)
-					return ErrPieceStore
-				}
-				metrics.PerfUploadTimeHistogram.WithLabelValues("put_to_piecestore").Observe(time.Since(startPutPiece).Seconds())
-			}
-			startSignSignature := time.Now()
-			if signature, integrity, err = u.baseApp.GfSpClient().SignIntegrityHash(ctx,
-				uploadObjectTask.GetObjectInfo().Id.Uint64(), checksums); err != nil {
-				metrics.PerfUploadTimeHistogram.WithLabelValues("sign_from_signer").Observe(time.Since(startSignSignature).Seconds())
-				log.CtxErrorw(ctx, "failed to sign the integrity hash", "error", err)
-				return err
-			}
-			metrics.PerfUploadTimeHistogram.WithLabelValues("sign_from_signer").Observe(time.Since(startSignSignature).Seconds())
-			if !bytes.Equal(integrity, uploadObjectTask.GetObjectInfo().GetChecksums()[0]) {
-				log.CtxErrorw(ctx, "failed to put object due to check integrity hash not consistent",
-					"actual_integrity", hex.EncodeToString(integrity),
-					"expected_integrity", hex.EncodeToString(uploadObjectTask.GetObjectInfo().GetChecksums()[0]))
-				err = ErrInvalidIntegrity
-				return ErrInvalidIntegrity
-			}
-			integrityMeta := &corespdb.IntegrityMeta{
-				ObjectID:          uploadObjectTask.GetObjectInfo().Id.Uint64(),
-				PieceChecksumList: checksums,
-				IntegrityChecksum: integrity,
-				Signature:         signature,
-			}
-			startUpdateSignature := time.Now()
-			if err = u.baseApp.GfSpDB().SetObjectIntegrity(integrityMeta); err != nil {
-				metrics.PerfUploadTimeHistogram.WithLabelValues("update_to_sqldb").Observe(time.Since(startUpdateSignature).Seconds())
-				log.CtxErrorw(ctx, "failed to write integrity hash to db", "error", err)
-				return ErrGfSpDB
-			}
-			metrics.PerfUploadTimeHistogram.WithLabelValues("update_to_sqldb").Observe(time.Since(startUpdateSignature).Seconds())
-			log.CtxDebugw(ctx, "succeed to upload payload to piece store")
-			return nil
+			break
 		}
 		if err != nil {
 			log.CtxErrorw(ctx, "stream closed abnormally", "piece_key", pieceKey, "error", err)
+			err = ErrClosedStream
 			return ErrClosedStream
 		}
 		pieceKey = u.baseApp.PieceOp().SegmentPieceKey(uploadObjectTask.GetObjectInfo().Id.Uint64(), segIdx)
-		checksums = append(checksums, hash.GenerateChecksum(data))
+		// the segment is streamed to piece store, and the checksum is computed while reading
+		// in the same way as hash.GenerateChecksum.
+		segment := &segmentReader{reader: io.LimitReader(reader, segmentSize), hash: sha256.New()}
 		startPutPiece := time.Now()
-		err = u.baseApp.PieceStore().PutPiece(ctx, pieceKey, data)
+		err = u.baseApp.PieceStore().PutPieceReader(ctx, pieceKey, segment)
+		metrics.PerfUploadTimeHistogram.WithLabelValues("put_to_piecestore").Observe(time.Since(startPutPiece).Seconds())
+		if segment.err != nil {
+			log.CtxErrorw(ctx, "stream closed abnormally", "piece_key", pieceKey, "error", segment.err)
+			err = ErrClosedStream
+			return ErrClosedStream
+		}
 		if err != nil {
-			metrics.PerfUploadTimeHistogram.WithLabelValues("put_to_piecestore").Observe(time.Since(startPutPiece).Seconds())
-			log.CtxErrorw(ctx, "failed to put segment piece to piece store", "error", err)
+			log.CtxErrorw(ctx, "failed to put segment piece to piece store", "piece_key", pieceKey, "error", err)
+			err = ErrPieceStore
 			return ErrPieceStore
 		}
-		metrics.PerfUploadTimeHistogram.WithLabelValues("put_to_piecestore").Observe(time.Since(startPutPiece).Seconds())
+		checksums = append(checksums, segment.hash.Sum(nil))
+		readSize += int(segment.size)
 		segIdx++
 	}
-}
 
-func StreamReadAt(stream io.Reader, b []byte) (int, error) {
-	if len(b) == 0 {
-		return 0, fmt.Errorf("failed to read due to invalid args")
+	startSignSignature := time.Now()
+	if signature, integrity, err = u.baseApp.GfSpClient().SignIntegrityHash(ctx,
+		uploadObjectTask.GetObjectInfo().Id.Uint64(), checksums); err != nil {
+		metrics.PerfUploadTimeHistogram.WithLabelValues("sign_from_signer").Observe(time.Since(startSignSignature).Seconds())
+		log.CtxErrorw(ctx, "failed to sign the integrity hash", "error", err)
+		return err
+	}
+	metrics.PerfUploadTimeHistogram.WithLabelValues("sign_from_signer").Observe(time.Since(startSignSignature).Seconds())
+	if !bytes.Equal(integrity, uploadObjectTask.GetObjectInfo().GetChecksums()[0]) {
+		log.CtxErrorw(ctx, "failed to put object due to check integrity hash not consistent",
+			"actual_integrity", hex.EncodeToString(integrity),
+			"expected_integrity", hex.EncodeToString(uploadObjectTask.GetObjectInfo().GetChecksums()[0]))
+		err = ErrInvalidIntegrity
+		return ErrInvalidIntegrity
 	}
+	integrityMeta := &corespdb.IntegrityMeta{
+		ObjectID:          uploadObjectTask.GetObjectInfo().Id.Uint64(),
+		PieceChecksumList: checksums,
+		IntegrityChecksum: integrity,
+		Signature:         signature,
+	}
+	startUpdateSignature := time.Now()
+	_, dbSpan := tracing.StartSpan(ctx, "SpDB.SetObjectIntegrity")
+	err = u.baseApp.GfSpDB().SetObjectIntegrity(integrityMeta)
+	tracing.EndSpan(dbSpan, err)
+	if err != nil {
+		metrics.PerfUploadTimeHistogram.WithLabelValues("update_to_sqldb").Observe(time.Since(startUpdateSignature).Seconds())
+		log.CtxErrorw(ctx, "failed to write integrity hash to db", "error", err)
+		return ErrGfSpDB
+	}
+	metrics.PerfUploadTimeHistogram.WithLabelValues("update_to_sqldb").Observe(time.Since(startUpdateSignature).Seconds())
+	u.baseApp.RecordPieceUsage(ctx, uploadObjectTask.GetBucketInfo(), uploadObjectTask.GetObjectInfo(),
+		gfspapp.PrimaryReplicateIdx, int64(readSize))
+	log.CtxDebugw(ctx, "succeed to upload payload to piece store")
+	return nil
+}
 
-	var (
-		totalReadLen int
-		curReadLen   int
-		err          error
-	)
+// segmentReader reads the segment from the upload stream, it computes the checksum of the
+// segment and records the stream error to tell it from the piece store error.
+type segmentReader struct {
+	reader io.Reader
+	hash   hash.Hash
+	size   int64
+	err    error
+}
 
-	for {
-		curReadLen, err = stream.Read(b[totalReadLen:])
-		totalReadLen += curReadLen
-		if err != nil || totalReadLen == len(b) {
-			return totalReadLen, err
-		}
+func (r *segmentReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.hash.Write(p[:n])
+	r.size += int64(n)
+	if err != nil && err != io.EOF {
+		r.err = err
 	}
+	return n, err
 }
 
 func (u *UploadModular) PostUploadObject(ctx context.Context, uploadObjectTask coretask.UploadObjectTask) {
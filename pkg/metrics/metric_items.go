@@ -29,6 +29,9 @@ var MetricsItems = []prometheus.Collector{
 	DeletePieceTimeHistogram,
 	DeletePieceTotalNumberCounter,
 	PieceUsageAmountGauge,
+	PieceStoreCapacityGauge,
+	PieceStoreCompressionBytesCounter,
+	PieceStoreCompressionRatioHistogram,
 	// Front module metrics category
 	UploadObjectSizeHistogram,
 	DownloadObjectSizeHistogram,
@@ -53,6 +56,7 @@ var MetricsItems = []prometheus.Collector{
 	ExecutorGCObjectTaskCounter,
 	ExecutorGCZombieTaskCounter,
 	ExecutorGCMetaTaskCounter,
+	ExecutorTierObjectTaskCounter,
 	// Manager metrics category
 	UploadObjectTaskTimeHistogram,
 	ReplicateAndSealTaskTimeHistogram,
@@ -68,6 +72,9 @@ var MetricsItems = []prometheus.Collector{
 	DispatchSealObjectTaskCounter,
 	DispatchReceivePieceTaskCounter,
 	DispatchGcObjectTaskCounter,
+	RejectUnsealSweepCounter,
+	TaskEventDroppedCounter,
+	DiscontinueBucketDecisionCounter,
 	// Signer metrics category
 	SealObjectTimeHistogram,
 	SealObjectSucceedCounter,
@@ -78,6 +85,21 @@ var MetricsItems = []prometheus.Collector{
 	DiscontinueBucketTimeHistogram,
 	DiscontinueBucketSucceedCounter,
 	DiscontinueBucketFailedCounter,
+	SignerPendingTxGauge,
+	SignerRebroadcastTxCounter,
+	SignerTxGasEstimatedHistogram,
+	SignerTxGasUsedHistogram,
+	SignerGasEstimateFallbackCounter,
+	SignerTxFailedCounter,
+	// Consensus metrics category
+	ConsensusCacheCounter,
+	ChainEndpointRequestCounter,
+	ChainEndpointCircuitGauge,
+	ChainEndpointHeightLagGauge,
+	// Authorizer metrics category
+	AuthorizerPermissionCounter,
+	AuthorizerPermissionConsistencyCounter,
+	AuthorizerMetadataBlockLagGauge,
 	// SPDB metrics category
 	SPDBTimeHistogram,
 	// BlockSyncer metrics category
@@ -147,6 +169,19 @@ var (
 		Name: "usage_amount_piece_store",
 		Help: "Track usage amount of piece store.",
 	}, []string{"usage_amount_piece_store"})
+	PieceStoreCapacityGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "capacity_piece_store",
+		Help: "Track the total and available bytes of piece store.",
+	}, []string{"storage", "type"})
+	PieceStoreCompressionBytesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "compression_bytes_piece_store",
+		Help: "Track the original and stored bytes of compressing piece data.",
+	}, []string{"type"})
+	PieceStoreCompressionRatioHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "compression_ratio_piece_store",
+		Help:    "Track the ratio of original size to stored size of compressing piece data.",
+		Buckets: []float64{0.9, 1, 1.5, 2, 3, 5, 10, 20},
+	}, []string{"codec"})
 
 	// front module metrics
 	UploadObjectSizeHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
@@ -249,6 +284,10 @@ var (
 		Name: "gc_meta_task_count",
 		Help: "Track gc meta task number.",
 	}, []string{"gc_meta_task_count"})
+	ExecutorTierObjectTaskCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tier_object_task_count",
+		Help: "Track tier object task number.",
+	}, []string{"tier_object_task_count"})
 
 	// manager mertics
 	UploadObjectTaskTimeHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
@@ -311,6 +350,14 @@ var (
 		Name: "dispatch_gc_object_task",
 		Help: "Track gc object task total number",
 	}, []string{"dispatch_gc_object_task"})
+	RejectUnsealSweepCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "reject_unseal_sweep",
+		Help: "Track the stuck objects handled by the reject unseal sweeper.",
+	}, []string{"result"})
+	TaskEventDroppedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "task_event_dropped",
+		Help: "Track the task events that are dropped before being written to db.",
+	}, []string{"reason"})
 
 	// signer metrics
 	SealObjectTimeHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
@@ -352,6 +399,64 @@ var (
 		Name: "discontinue_bucket_failure",
 		Help: "Track discontinue bucket failure total number.",
 	}, []string{"discontinue_bucket_failure"})
+	DiscontinueBucketDecisionCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "discontinue_bucket_decision",
+		Help: "Track the buckets matched by the discontinue rules.",
+	}, []string{"rule", "result"})
+	ConsensusCacheCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "consensus_cache",
+		Help: "Track the hit and miss of the consensus cache.",
+	}, []string{"method", "result"})
+	ChainEndpointRequestCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "chain_endpoint_request",
+		Help: "Track the success and failure requests of the chain endpoints.",
+	}, []string{"pool", "endpoint", "result"})
+	ChainEndpointCircuitGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "chain_endpoint_circuit_open",
+		Help: "Track whether the circuit of the chain endpoint is open.",
+	}, []string{"pool", "endpoint"})
+	ChainEndpointHeightLagGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "chain_endpoint_height_lag",
+		Help: "Track the blocks that the chain endpoint lags behind the highest endpoint.",
+	}, []string{"pool", "endpoint"})
+	AuthorizerPermissionCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "authorizer_permission",
+		Help: "Track the permission verifications of the authorizer by source.",
+	}, []string{"source", "result"})
+	AuthorizerPermissionConsistencyCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "authorizer_permission_consistency",
+		Help: "Track the sampled metadata permission verifications compared with the chain.",
+	}, []string{"result"})
+	AuthorizerMetadataBlockLagGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "authorizer_metadata_block_lag",
+		Help: "Track the blocks that the metadata index lags behind the chain.",
+	})
+	SignerPendingTxGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "signer_pending_tx",
+		Help: "Track the number of the pending txs of the signer accounts.",
+	}, []string{"scope"})
+	SignerRebroadcastTxCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "signer_rebroadcast_tx",
+		Help: "Track the re-broadcast tx total number of the signer accounts.",
+	}, []string{"scope"})
+	SignerTxGasEstimatedHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "signer_tx_gas_estimated",
+		Help:    "Track the estimated gas limit of the txs broadcast by the signer.",
+		Buckets: prometheus.ExponentialBuckets(50000, 2, 10),
+	}, []string{"scope"})
+	SignerTxGasUsedHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "signer_tx_gas_used",
+		Help:    "Track the gas used of the txs included on chain.",
+		Buckets: prometheus.ExponentialBuckets(50000, 2, 10),
+	}, []string{"scope"})
+	SignerGasEstimateFallbackCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "signer_gas_estimate_fallback",
+		Help: "Track the number of the txs that use the static gas limit due to the simulation unavailable.",
+	}, []string{"scope"})
+	SignerTxFailedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "signer_tx_failed",
+		Help: "Track the number of the txs that are included but failed or abandoned after re-broadcast.",
+	}, []string{"scope", "reason"})
 
 	// spdb metrics
 	SPDBTimeHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
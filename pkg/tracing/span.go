@@ -0,0 +1,95 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+)
+
+// TracerName defines the instrumentation name of the sp tracer.
+const TracerName = "github.com/bnb-chain/greenfield-storage-provider"
+
+// TaskKeyAttribute defines the span attribute key of the task key, it is used to
+// correlate the spans with the logs that carry the log.CtxKeyTask value.
+const TaskKeyAttribute = attribute.Key("gfsp.task_key")
+
+// StartSpan starts a span as the child of the span in ctx. It is a no-op span if
+// the tracing service is not started.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if taskKey, ok := metainfo.GetValue(ctx, log.CtxKeyTask); ok {
+		attrs = append(attrs, TaskKeyAttribute.String(taskKey))
+	}
+	return otel.Tracer(TracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartSpanWithKind starts a span with the special span kind, e.g. client or server.
+func StartSpanWithKind(ctx context.Context, name string, kind trace.SpanKind,
+	attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if taskKey, ok := metainfo.GetValue(ctx, log.CtxKeyTask); ok {
+		attrs = append(attrs, TaskKeyAttribute.String(taskKey))
+	}
+	return otel.Tracer(TracerName).Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
+
+// EndSpan records the err to span if err is not nil, and ends the span.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Detach returns a background context that carries the span of ctx, it is used
+// to decouple the lifecycle of ctx but keep the trace.
+func Detach(ctx context.Context) context.Context {
+	return trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx))
+}
+
+// Inject injects the trace context of ctx into the carrier.
+func Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+}
+
+// Extract extracts the trace context from the carrier and returns a copy of ctx
+// that carries the remote span context.
+func Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+// InjectHTTPHeader injects the trace context of ctx into the http header.
+func InjectHTTPHeader(ctx context.Context, header http.Header) {
+	Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// ExtractHTTPHeader extracts the trace context from the http header.
+func ExtractHTTPHeader(ctx context.Context, header http.Header) context.Context {
+	return Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// InjectMap injects the trace context of ctx into a map, it is used by the
+// messages that do not have header, e.g. the p2p protocol messages.
+func InjectMap(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// ExtractMap extracts the trace context from the map.
+func ExtractMap(ctx context.Context, carrier map[string]string) context.Context {
+	if len(carrier) == 0 {
+		return ctx
+	}
+	return Extract(ctx, propagation.MapCarrier(carrier))
+}
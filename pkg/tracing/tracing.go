@@ -0,0 +1,139 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+
+	coremodule "github.com/bnb-chain/greenfield-storage-provider/core/module"
+	corercmgr "github.com/bnb-chain/greenfield-storage-provider/core/rcmgr"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+)
+
+var (
+	TracingModularName = strings.ToLower("Tracing")
+)
+
+const (
+	// StdoutExporter exports the finished spans to the stdout, it works offline.
+	StdoutExporter = "stdout"
+	// FileExporter exports the finished spans to the local file, it works offline.
+	FileExporter = "file"
+	// OTLPExporter exports the finished spans to the OTLP collector by gRPC.
+	OTLPExporter = "otlp"
+)
+
+var _ coremodule.Modular = &Tracing{}
+
+// Tracing is used to export the distributed tracing spans of sp services.
+type Tracing struct {
+	serviceName string
+	exporter    string
+	endpoint    string
+	filePath    string
+	sampleRatio float64
+	provider    *sdktrace.TracerProvider
+	file        *os.File
+}
+
+// NewTracing returns an instance of tracing, the exporter is one of stdout, file
+// and otlp, the endpoint is the OTLP collector address, the filePath is the file
+// to write spans for file exporter.
+func NewTracing(serviceName, exporter, endpoint, filePath string, sampleRatio float64) *Tracing {
+	return &Tracing{
+		serviceName: serviceName,
+		exporter:    exporter,
+		endpoint:    endpoint,
+		filePath:    filePath,
+		sampleRatio: sampleRatio,
+	}
+}
+
+// Name describes tracing service name
+func (t *Tracing) Name() string {
+	return TracingModularName
+}
+
+// Start installs the global tracer provider and the trace context propagator.
+func (t *Tracing) Start(ctx context.Context) error {
+	exporter, err := t.newExporter(ctx)
+	if err != nil {
+		log.Errorw("failed to new tracing exporter", "exporter", t.exporter, "error", err)
+		return err
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL, semconv.ServiceNameKey.String(t.serviceName)))
+	if err != nil {
+		log.Errorw("failed to new tracing resource", "error", err)
+		return err
+	}
+	t.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(t.sampleRatio))))
+	otel.SetTracerProvider(t.provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+	return nil
+}
+
+// Stop flushes the remaining spans and shuts down the exporter.
+func (t *Tracing) Stop(ctx context.Context) error {
+	var errs []error
+	if t.provider != nil {
+		if err := t.provider.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if t.file != nil {
+		if err := t.file.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if errs != nil {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
+
+func (t *Tracing) newExporter(ctx context.Context) (sdktrace.SpanExporter, error) {
+	switch t.exporter {
+	case StdoutExporter:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case FileExporter:
+		file, err := os.OpenFile(t.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, err
+		}
+		t.file = file
+		return stdouttrace.New(stdouttrace.WithWriter(file))
+	case OTLPExporter:
+		return otlptracegrpc.New(ctx,
+			otlptracegrpc.WithEndpoint(t.endpoint),
+			otlptracegrpc.WithInsecure())
+	default:
+		return nil, fmt.Errorf("unsupported tracing exporter: %s", t.exporter)
+	}
+}
+
+func (t *Tracing) ReserveResource(
+	ctx context.Context,
+	state *corercmgr.ScopeStat) (
+	corercmgr.ResourceScopeSpan, error) {
+	return &corercmgr.NullScope{}, nil
+}
+
+func (t *Tracing) ReleaseResource(
+	ctx context.Context,
+	scope corercmgr.ResourceScopeSpan) {
+	scope.Done()
+}
@@ -2,6 +2,7 @@ package bsdb
 
 import (
 	"errors"
+	"math/big"
 	"strconv"
 
 	"github.com/forbole/juno/v4/common"
@@ -96,8 +97,9 @@ func (b *BsDBImpl) GetUserBucketsCount(accountID common.Address, includeRemoved
 	return count, err
 }
 
-// ListExpiredBucketsBySp lists expired buckets
-func (b *BsDBImpl) ListExpiredBucketsBySp(createAt int64, primarySpAddress string, limit int64) ([]*Bucket, error) {
+// ListExpiredBucketsBySp lists expired buckets after the cursor of the create time and the bucket id
+func (b *BsDBImpl) ListExpiredBucketsBySp(createAt int64, primarySpAddress string, limit int64,
+	startAfterCreateAt int64, startAfterBucketID int64) ([]*Bucket, error) {
 	var (
 		buckets []*Bucket
 		err     error
@@ -110,8 +112,10 @@ func (b *BsDBImpl) ListExpiredBucketsBySp(createAt int64, primarySpAddress strin
 	err = b.db.Table((&Bucket{}).TableName()).
 		Select("*").
 		Where("primary_sp_address = ? and status = 'BUCKET_STATUS_CREATED' and create_time < ? and removed = false", common.HexToAddress(primarySpAddress), createAt).
+		Where("create_time > ? or (create_time = ? and bucket_id > ?)", startAfterCreateAt, startAfterCreateAt,
+			common.BigToHash(big.NewInt(startAfterBucketID))).
 		Limit(int(limit)).
-		Order("create_at").
+		Order("create_time, bucket_id").
 		Find(&buckets).Error
 
 	return buckets, err
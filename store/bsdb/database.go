@@ -34,8 +34,10 @@ type Metadata interface {
 	ListObjectsByBucketName(bucketName, continuationToken, prefix, delimiter string, maxKeys int, includeRemoved bool) ([]*ListObjectsResult, error)
 	// ListDeletedObjectsByBlockNumberRange list deleted objects info by a block number range
 	ListDeletedObjectsByBlockNumberRange(startBlockNumber int64, endBlockNumber int64, includePrivate bool) ([]*Object, error)
-	// ListExpiredBucketsBySp list expired buckets by sp
-	ListExpiredBucketsBySp(createAt int64, primarySpAddress string, limit int64) ([]*Bucket, error)
+	// ListExpiredBucketsBySp list expired buckets by sp, the buckets are ordered by the create time and the
+	// bucket id, and only the buckets after the cursor of startAfterCreateAt and startAfterBucketID are listed
+	ListExpiredBucketsBySp(createAt int64, primarySpAddress string, limit int64, startAfterCreateAt int64,
+		startAfterBucketID int64) ([]*Bucket, error)
 	// GetObjectByName get object info by an object name
 	GetObjectByName(objectName string, bucketName string, includePrivate bool) (*Object, error)
 	// GetSwitchDBSignal check if there is a signal to switch the database
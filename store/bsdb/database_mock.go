@@ -275,18 +275,18 @@ func (mr *MockMetadataMockRecorder) ListDeletedObjectsByBlockNumberRange(startBl
 }
 
 // ListExpiredBucketsBySp mocks base method.
-func (m *MockMetadata) ListExpiredBucketsBySp(createAt int64, primarySpAddress string, limit int64) ([]*Bucket, error) {
+func (m *MockMetadata) ListExpiredBucketsBySp(createAt int64, primarySpAddress string, limit, startAfterCreateAt, startAfterBucketID int64) ([]*Bucket, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListExpiredBucketsBySp", createAt, primarySpAddress, limit)
+	ret := m.ctrl.Call(m, "ListExpiredBucketsBySp", createAt, primarySpAddress, limit, startAfterCreateAt, startAfterBucketID)
 	ret0, _ := ret[0].([]*Bucket)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListExpiredBucketsBySp indicates an expected call of ListExpiredBucketsBySp.
-func (mr *MockMetadataMockRecorder) ListExpiredBucketsBySp(createAt, primarySpAddress, limit interface{}) *gomock.Call {
+func (mr *MockMetadataMockRecorder) ListExpiredBucketsBySp(createAt, primarySpAddress, limit, startAfterCreateAt, startAfterBucketID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpiredBucketsBySp", reflect.TypeOf((*MockMetadata)(nil).ListExpiredBucketsBySp), createAt, primarySpAddress, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpiredBucketsBySp", reflect.TypeOf((*MockMetadata)(nil).ListExpiredBucketsBySp), createAt, primarySpAddress, limit, startAfterCreateAt, startAfterBucketID)
 }
 
 // ListGroupsByNameAndSourceType mocks base method.
@@ -584,18 +584,18 @@ func (mr *MockBSDBMockRecorder) ListDeletedObjectsByBlockNumberRange(startBlockN
 }
 
 // ListExpiredBucketsBySp mocks base method.
-func (m *MockBSDB) ListExpiredBucketsBySp(createAt int64, primarySpAddress string, limit int64) ([]*Bucket, error) {
+func (m *MockBSDB) ListExpiredBucketsBySp(createAt int64, primarySpAddress string, limit, startAfterCreateAt, startAfterBucketID int64) ([]*Bucket, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListExpiredBucketsBySp", createAt, primarySpAddress, limit)
+	ret := m.ctrl.Call(m, "ListExpiredBucketsBySp", createAt, primarySpAddress, limit, startAfterCreateAt, startAfterBucketID)
 	ret0, _ := ret[0].([]*Bucket)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListExpiredBucketsBySp indicates an expected call of ListExpiredBucketsBySp.
-func (mr *MockBSDBMockRecorder) ListExpiredBucketsBySp(createAt, primarySpAddress, limit interface{}) *gomock.Call {
+func (mr *MockBSDBMockRecorder) ListExpiredBucketsBySp(createAt, primarySpAddress, limit, startAfterCreateAt, startAfterBucketID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpiredBucketsBySp", reflect.TypeOf((*MockBSDB)(nil).ListExpiredBucketsBySp), createAt, primarySpAddress, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpiredBucketsBySp", reflect.TypeOf((*MockBSDB)(nil).ListExpiredBucketsBySp), createAt, primarySpAddress, limit, startAfterCreateAt, startAfterBucketID)
 }
 
 // ListGroupsByNameAndSourceType mocks base method.
@@ -3,12 +3,19 @@ package client
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
+	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/bnb-chain/greenfield-storage-provider/base/gfsppieceop"
 	corepiecestore "github.com/bnb-chain/greenfield-storage-provider/core/piecestore"
+	"github.com/bnb-chain/greenfield-storage-provider/core/spdb"
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/metrics"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/tracing"
 	"github.com/bnb-chain/greenfield-storage-provider/store/piecestore/piece"
 	"github.com/bnb-chain/greenfield-storage-provider/store/piecestore/storage"
 )
@@ -24,33 +31,95 @@ type PieceStoreAPI interface {
 
 var _ corepiecestore.PieceStore = &StoreClient{}
 
+const (
+	// pieceKeyAttribute defines the span attribute key of the piece key.
+	pieceKeyAttribute = attribute.Key("piece_key")
+	// storageAttribute defines the span attribute key of the storage type.
+	storageAttribute = attribute.Key("storage")
+
+	// tierCacheSize defines the max number of the cached object tiers, the cache is reset
+	// if it is full.
+	tierCacheSize = 100000
+	// tierCacheTTL defines the duration that the cached object tier is used, the tier updated
+	// by the other processes is seen after it, and the reads fall back to the other tier before.
+	tierCacheTTL = time.Minute
+)
+
+type tierCacheEntry struct {
+	tier     string
+	expireAt time.Time
+}
+
+// StoreClient stores the pieces in the hot tier piece store, and migrates the pieces of
+// the cold objects to the cold tier piece store if tiering is enabled. The tier of each
+// object is recorded in the tier index, the reads are routed to the recorded tier and
+// fall back to the other tier, because the object may be migrating between the tiers.
 type StoreClient struct {
-	name string
-	ps   *piece.PieceStore
+	name      string
+	ps        *piece.PieceStore
+	coldName  string
+	cold      *piece.PieceStore
+	tierIndex spdb.ObjectTierDB
+
+	tierMux   sync.Mutex
+	tierCache map[uint64]tierCacheEntry
 }
 
-func NewStoreClient(pieceConfig *storage.PieceStoreConfig) (*StoreClient, error) {
-	ps, err := piece.NewPieceStore(pieceConfig)
+// NewStoreClient returns an instance of StoreClient, the packIndex is required if
+// packing the small pieces is enabled, the dedupIndex is required if deduplicating the
+// segment pieces is enabled, and the tierIndex is required if tiering the cold objects
+// is enabled.
+func NewStoreClient(pieceConfig *storage.PieceStoreConfig, packIndex spdb.PackDB, dedupIndex spdb.DedupDB,
+	tierIndex spdb.ObjectTierDB) (*StoreClient, error) {
+	ps, err := piece.NewPieceStoreWithIndex(pieceConfig, packIndex, dedupIndex)
 	if err != nil {
 		return nil, err
 	}
-	return &StoreClient{ps: ps, name: pieceConfig.Store.Storage}, nil
+	client := &StoreClient{ps: ps, name: pieceConfig.Store.Storage}
+	if pieceConfig.Tiering.Enable {
+		if tierIndex == nil {
+			return nil, fmt.Errorf("tier index is required if tiering is enabled")
+		}
+		if client.cold, err = piece.NewColdPieceStore(pieceConfig); err != nil {
+			return nil, err
+		}
+		client.coldName = pieceConfig.Tiering.ColdStore.Storage
+		client.tierIndex = tierIndex
+		client.tierCache = make(map[uint64]tierCacheEntry)
+	}
+	return client, nil
 }
 
 // GetPiece gets piece data from piece store.
-func (client *StoreClient) GetPiece(ctx context.Context, key string, offset, limit int64) ([]byte, error) {
+func (client *StoreClient) GetPiece(ctx context.Context, key string, offset, limit int64) (data []byte, err error) {
 	startTime := time.Now()
+	tier := client.pieceTier(key)
+	name := client.tierName(tier)
+	ctx, span := tracing.StartSpan(ctx, "PieceStore.GetPiece", pieceKeyAttribute.String(key),
+		storageAttribute.String(name))
 	defer func() {
-		metrics.GetPieceTimeHistogram.WithLabelValues(client.name).Observe(
+		metrics.GetPieceTimeHistogram.WithLabelValues(name).Observe(
 			time.Since(startTime).Seconds())
-		metrics.GetPieceTotalNumberCounter.WithLabelValues(client.name).Inc()
+		metrics.GetPieceTotalNumberCounter.WithLabelValues(name).Inc()
+		tracing.EndSpan(span, err)
 	}()
 
-	rc, err := client.ps.Get(ctx, key, offset, limit)
+	data, err = readPiece(ctx, client.tierStore(tier), key, offset, limit)
+	if err != nil && client.cold != nil {
+		log.CtxWarnw(ctx, "failed to get piece data from the tier, try the other tier", "piece_key", key,
+			"tier", tier, "error", err)
+		data, err = readPiece(ctx, client.tierStore(otherTier(tier)), key, offset, limit)
+	}
+	return data, err
+}
+
+func readPiece(ctx context.Context, ps *piece.PieceStore, key string, offset, limit int64) ([]byte, error) {
+	rc, err := ps.Get(ctx, key, offset, limit)
 	if err != nil {
 		log.Errorw("failed to get piece data from piece store", "error", err)
 		return nil, err
 	}
+	defer rc.Close()
 	buf := &bytes.Buffer{}
 	_, err = io.Copy(buf, rc)
 	if err != nil {
@@ -60,44 +129,316 @@ func (client *StoreClient) GetPiece(ctx context.Context, key string, offset, lim
 	return buf.Bytes(), nil
 }
 
-// PutPiece puts piece to piece store.
+// GetPieceReader returns the reader of the piece data from piece store, the caller should
+// close the reader after reading.
+func (client *StoreClient) GetPieceReader(ctx context.Context, key string, offset, limit int64) (
+	rc io.ReadCloser, err error) {
+	startTime := time.Now()
+	tier := client.pieceTier(key)
+	name := client.tierName(tier)
+	ctx, span := tracing.StartSpan(ctx, "PieceStore.GetPieceReader", pieceKeyAttribute.String(key),
+		storageAttribute.String(name))
+	defer func() {
+		metrics.GetPieceTimeHistogram.WithLabelValues(name).Observe(
+			time.Since(startTime).Seconds())
+		metrics.GetPieceTotalNumberCounter.WithLabelValues(name).Inc()
+		tracing.EndSpan(span, err)
+	}()
+
+	rc, err = client.tierStore(tier).Get(ctx, key, offset, limit)
+	if err != nil && client.cold != nil {
+		log.CtxWarnw(ctx, "failed to get piece reader from the tier, try the other tier", "piece_key", key,
+			"tier", tier, "error", err)
+		rc, err = client.tierStore(otherTier(tier)).Get(ctx, key, offset, limit)
+	}
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to get piece reader from piece store", "piece_key", key, "error", err)
+		return nil, err
+	}
+	return rc, nil
+}
+
+// PutPiece puts piece to piece store, the piece is put to the tier of the object.
 func (client *StoreClient) PutPiece(ctx context.Context, key string, value []byte) error {
+	return client.putPiece(ctx, key, bytes.NewReader(value), func() int64 { return int64(len(value)) })
+}
+
+// PutPieceReader puts the piece data read from the reader to piece store, the piece is
+// put to the tier of the object.
+func (client *StoreClient) PutPieceReader(ctx context.Context, key string, reader io.Reader) error {
+	counter := &countReader{reader: reader}
+	return client.putPiece(ctx, key, counter, func() int64 { return counter.size })
+}
+
+// putPiece puts the piece to the tier of the object, the size is called after the piece
+// is put to account the piece usage.
+func (client *StoreClient) putPiece(ctx context.Context, key string, reader io.Reader, size func() int64) error {
 	var (
 		startTime = time.Now()
+		tier      = client.pieceTier(key)
+		name      = client.tierName(tier)
 		err       error
 	)
+	ctx, span := tracing.StartSpan(ctx, "PieceStore.PutPiece", pieceKeyAttribute.String(key),
+		storageAttribute.String(name))
 	defer func() {
-		metrics.PutPieceTimeHistogram.WithLabelValues(client.name).Observe(
+		metrics.PutPieceTimeHistogram.WithLabelValues(name).Observe(
 			time.Since(startTime).Seconds())
-		metrics.PutPieceTotalNumberCounter.WithLabelValues(client.name).Inc()
+		metrics.PutPieceTotalNumberCounter.WithLabelValues(name).Inc()
 		if err == nil {
-			metrics.PieceUsageAmountGauge.WithLabelValues(client.name).Add(float64(len(value)))
+			metrics.PieceUsageAmountGauge.WithLabelValues(name).Add(float64(size()))
 		}
+		tracing.EndSpan(span, err)
 	}()
-	err = client.ps.Put(ctx, key, bytes.NewReader(value))
+	err = client.tierStore(tier).Put(ctx, key, reader)
 	return err
 }
 
-// DeletePiece deletes piece from piece store.
+// countReader counts the bytes read from the reader.
+type countReader struct {
+	reader io.Reader
+	size   int64
+}
+
+func (r *countReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// DeletePiece deletes piece from piece store, the piece is deleted from all the tiers.
 func (client *StoreClient) DeletePiece(ctx context.Context, key string) error {
 	var (
 		startTime = time.Now()
+		tier      = client.pieceTier(key)
+		name      = client.tierName(tier)
 		err       error
 		valSize   int
 	)
+	ctx, span := tracing.StartSpan(ctx, "PieceStore.DeletePiece", pieceKeyAttribute.String(key),
+		storageAttribute.String(name))
 	defer func() {
-		metrics.DeletePieceTimeHistogram.WithLabelValues(client.name).Observe(
+		metrics.DeletePieceTimeHistogram.WithLabelValues(name).Observe(
 			time.Since(startTime).Seconds())
-		metrics.DeletePieceTotalNumberCounter.WithLabelValues(client.name).Inc()
+		metrics.DeletePieceTotalNumberCounter.WithLabelValues(name).Inc()
 		if err == nil {
-			metrics.PieceUsageAmountGauge.WithLabelValues(client.name).Add(0 - float64(valSize))
+			metrics.PieceUsageAmountGauge.WithLabelValues(name).Add(0 - float64(valSize))
 		}
+		tracing.EndSpan(span, err)
 	}()
 	val, err := client.GetPiece(ctx, key, 0, -1)
 	if err != nil {
 		return err
 	}
 	valSize = len(val)
-	err = client.ps.Delete(ctx, key)
+	if err = client.tierStore(tier).Delete(ctx, key); err != nil {
+		return err
+	}
+	if client.cold != nil {
+		// the piece may be left in the other tier by the interrupted migration
+		deleteErr := client.tierStore(otherTier(tier)).Delete(ctx, key)
+		log.CtxDebugw(ctx, "delete the piece from the other tier", "piece_key", key, "error", deleteErr)
+	}
+	return nil
+}
+
+// DeletePieces deletes the pieces from piece store in batch, the pieces are deleted from
+// all the tiers. The sizes of the pieces are probed by head to decrease the piece usage.
+func (client *StoreClient) DeletePieces(ctx context.Context, keys []string) map[string]error {
+	var (
+		startTime = time.Now()
+		tiers     = make(map[string][]string)
+		failed    = make(map[string]error)
+	)
+	ctx, span := tracing.StartSpan(ctx, "PieceStore.DeletePieces", storageAttribute.String(client.name))
+	defer func() {
+		metrics.DeletePieceTimeHistogram.WithLabelValues(client.name).Observe(
+			time.Since(startTime).Seconds())
+		metrics.DeletePieceTotalNumberCounter.WithLabelValues(client.name).Add(float64(len(keys)))
+		tracing.EndSpan(span, nil)
+	}()
+	for _, key := range keys {
+		tier := client.pieceTier(key)
+		tiers[tier] = append(tiers[tier], key)
+	}
+	for tier, tierKeys := range tiers {
+		sizes := make(map[string]int64, len(tierKeys))
+		for _, key := range tierKeys {
+			if info, err := client.tierStore(tier).GetPieceInfo(ctx, key); err == nil {
+				sizes[key] = info.Size()
+			}
+		}
+		for key, err := range client.tierStore(tier).DeleteBatch(ctx, tierKeys) {
+			failed[key] = err
+		}
+		var deletedSize int64
+		for key, size := range sizes {
+			if _, ok := failed[key]; !ok {
+				deletedSize += size
+			}
+		}
+		metrics.PieceUsageAmountGauge.WithLabelValues(client.tierName(tier)).Add(0 - float64(deletedSize))
+		if client.cold != nil {
+			// the pieces may be left in the other tier by the interrupted migration
+			otherFailed := client.tierStore(otherTier(tier)).DeleteBatch(ctx, tierKeys)
+			log.CtxDebugw(ctx, "delete the pieces from the other tier", "piece_number", len(tierKeys),
+				"failed_number", len(otherFailed))
+		}
+	}
+	return failed
+}
+
+// PresignGetPiece returns the presigned url to read the piece from the tier of the object.
+func (client *StoreClient) PresignGetPiece(ctx context.Context, key string, expire time.Duration,
+	contentType, contentDisposition string) (string, error) {
+	return client.tierStore(client.pieceTier(key)).PresignGet(ctx, key, expire, storage.PresignHeader{
+		ContentType:        contentType,
+		ContentDisposition: contentDisposition,
+	})
+}
+
+// HeadPiece returns the size of piece in piece store.
+func (client *StoreClient) HeadPiece(ctx context.Context, key string) (int64, error) {
+	tier := client.pieceTier(key)
+	info, err := client.tierStore(tier).GetPieceInfo(ctx, key)
+	if err != nil && client.cold != nil {
+		info, err = client.tierStore(otherTier(tier)).GetPieceInfo(ctx, key)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// CopyPieceToTier copies the piece from the other tier to the tier, it succeeds if the
+// piece has been copied to the tier before, so that the interrupted migration can retry.
+func (client *StoreClient) CopyPieceToTier(ctx context.Context, key string, tier string) (err error) {
+	if err = client.checkTier(tier); err != nil {
+		return err
+	}
+	ctx, span := tracing.StartSpan(ctx, "PieceStore.CopyPieceToTier", pieceKeyAttribute.String(key),
+		storageAttribute.String(client.tierName(tier)))
+	defer func() {
+		tracing.EndSpan(span, err)
+	}()
+	rc, err := client.tierStore(otherTier(tier)).Get(ctx, key, 0, -1)
+	if err != nil {
+		if _, headErr := client.tierStore(tier).GetPieceInfo(ctx, key); headErr == nil {
+			return nil
+		}
+		return err
+	}
+	defer rc.Close()
+	return client.tierStore(tier).Put(ctx, key, rc)
+}
+
+// DeletePieceFromTier deletes the piece from the tier.
+func (client *StoreClient) DeletePieceFromTier(ctx context.Context, key string, tier string) error {
+	if err := client.checkTier(tier); err != nil {
+		return err
+	}
+	return client.tierStore(tier).Delete(ctx, key)
+}
+
+// SetObjectTier records the tier of the object after its pieces are copied to the tier, and
+// invalidates the cached tier of the object.
+func (client *StoreClient) SetObjectTier(objectID uint64, tier string) error {
+	if err := client.checkTier(tier); err != nil {
+		return err
+	}
+	err := client.tierIndex.SetObjectTier(objectID, tier)
+	client.tierMux.Lock()
+	delete(client.tierCache, objectID)
+	client.tierMux.Unlock()
 	return err
 }
+
+// pieceTier returns the tier that the piece is stored in, the piece is in the hot tier if
+// tiering is disabled or the object has no tier record. The tier is cached for tierCacheTTL
+// to not query the tier index by every piece.
+func (client *StoreClient) pieceTier(key string) string {
+	if client.cold == nil {
+		return corepiecestore.HotTier
+	}
+	objectID, _, _, err := gfsppieceop.ParsePieceKey(key)
+	if err != nil {
+		return corepiecestore.HotTier
+	}
+	now := time.Now()
+	client.tierMux.Lock()
+	entry, ok := client.tierCache[objectID]
+	client.tierMux.Unlock()
+	if ok && now.Before(entry.expireAt) {
+		return entry.tier
+	}
+	tier, err := client.tierIndex.GetObjectTier(objectID)
+	if err != nil {
+		log.Errorw("failed to get object tier, read from the hot tier", "object_id", objectID, "error", err)
+		return corepiecestore.HotTier
+	}
+	if tier != corepiecestore.ColdTier {
+		tier = corepiecestore.HotTier
+	}
+	client.tierMux.Lock()
+	if len(client.tierCache) >= tierCacheSize {
+		client.tierCache = make(map[uint64]tierCacheEntry)
+	}
+	client.tierCache[objectID] = tierCacheEntry{tier: tier, expireAt: now.Add(tierCacheTTL)}
+	client.tierMux.Unlock()
+	return tier
+}
+
+func (client *StoreClient) checkTier(tier string) error {
+	if client.cold == nil {
+		return fmt.Errorf("tiering is not enabled")
+	}
+	if tier != corepiecestore.HotTier && tier != corepiecestore.ColdTier {
+		return fmt.Errorf("invalid piece store tier: %s", tier)
+	}
+	return nil
+}
+
+func (client *StoreClient) tierStore(tier string) *piece.PieceStore {
+	if tier == corepiecestore.ColdTier {
+		return client.cold
+	}
+	return client.ps
+}
+
+func (client *StoreClient) tierName(tier string) string {
+	if tier == corepiecestore.ColdTier {
+		return client.coldName
+	}
+	return client.name
+}
+
+func otherTier(tier string) string {
+	if tier == corepiecestore.ColdTier {
+		return corepiecestore.HotTier
+	}
+	return corepiecestore.ColdTier
+}
+
+// CompactPacks compacts the pack files of the hot tier, the cold tier does not pack pieces.
+func (client *StoreClient) CompactPacks(ctx context.Context) {
+	client.ps.CompactPacks(ctx)
+}
+
+// RemoveOrphanFiles removes the orphan files of all the tiers.
+func (client *StoreClient) RemoveOrphanFiles(ctx context.Context) {
+	client.ps.RemoveOrphanFiles(ctx)
+	if client.cold != nil {
+		client.cold.RemoveOrphanFiles(ctx)
+	}
+}
+
+// Capacity returns the total and available bytes of piece store.
+func (client *StoreClient) Capacity(ctx context.Context) (uint64, uint64, error) {
+	total, available, err := client.ps.Capacity(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	metrics.PieceStoreCapacityGauge.WithLabelValues(client.name, "total").Set(float64(total))
+	metrics.PieceStoreCapacityGauge.WithLabelValues(client.name, "available").Set(float64(available))
+	return total, available, nil
+}
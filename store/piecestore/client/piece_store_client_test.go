@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	corepiecestore "github.com/bnb-chain/greenfield-storage-provider/core/piecestore"
+	"github.com/bnb-chain/greenfield-storage-provider/store/piecestore/storage"
+)
+
+type mockTierIndex struct {
+	tiers map[uint64]string
+}
+
+func (m *mockTierIndex) GetObjectTier(objectID uint64) (string, error) {
+	return m.tiers[objectID], nil
+}
+
+func (m *mockTierIndex) SetObjectTier(objectID uint64, tier string) error {
+	m.tiers[objectID] = tier
+	return nil
+}
+
+func (m *mockTierIndex) DeleteObjectTier(objectID uint64) error {
+	delete(m.tiers, objectID)
+	return nil
+}
+
+func (m *mockTierIndex) GetObjectsToDemote(string, int64, int) ([]uint64, error) {
+	return nil, nil
+}
+
+func (m *mockTierIndex) GetObjectsToPromote(string, int64, int, int) ([]uint64, error) {
+	return nil, nil
+}
+
+func setupTieringTest(t *testing.T) (*StoreClient, *mockTierIndex) {
+	index := &mockTierIndex{tiers: make(map[uint64]string)}
+	client, err := NewStoreClient(&storage.PieceStoreConfig{
+		Store: storage.ObjectStorageConfig{Storage: storage.MemoryStore, BucketURL: "hot"},
+		Tiering: storage.TieringConfig{
+			Enable:    true,
+			ColdStore: storage.ObjectStorageConfig{Storage: storage.MemoryStore, BucketURL: "cold"},
+		},
+	}, nil, nil, index)
+	assert.Nil(t, err)
+	return client, index
+}
+
+func TestStoreClient_Tiering(t *testing.T) {
+	client, index := setupTieringTest(t)
+	ctx := context.TODO()
+	assert.Nil(t, client.PutPiece(ctx, "1_s0", []byte("segment")))
+	assert.Nil(t, client.PutPiece(ctx, "1_s0_p2", []byte("ec piece")))
+
+	// demote the object to the cold tier
+	for _, key := range []string{"1_s0", "1_s0_p2"} {
+		assert.Nil(t, client.CopyPieceToTier(ctx, key, corepiecestore.ColdTier))
+	}
+	// the cached tier is invalidated by setting the tier
+	assert.Equal(t, corepiecestore.HotTier, client.pieceTier("1_s0"))
+	assert.Nil(t, client.SetObjectTier(1, corepiecestore.ColdTier))
+	assert.Equal(t, corepiecestore.ColdTier, client.pieceTier("1_s0"))
+	assert.Equal(t, corepiecestore.ColdTier, index.tiers[1])
+	for _, key := range []string{"1_s0", "1_s0_p2"} {
+		assert.Nil(t, client.DeletePieceFromTier(ctx, key, corepiecestore.HotTier))
+	}
+	_, err := client.ps.GetPieceInfo(ctx, "1_s0")
+	assert.NotNil(t, err)
+	data, err := client.GetPiece(ctx, "1_s0", 2, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, "gme", string(data))
+	size, err := client.HeadPiece(ctx, "1_s0_p2")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len("ec piece")), size)
+
+	// the retried copy succeeds if the piece has been copied to the tier
+	assert.Nil(t, client.CopyPieceToTier(ctx, "1_s0", corepiecestore.ColdTier))
+
+	// promote the object back to the hot tier, the read falls back to the hot tier
+	// before the tier record is updated
+	assert.Nil(t, client.CopyPieceToTier(ctx, "1_s0", corepiecestore.HotTier))
+	assert.Nil(t, client.DeletePieceFromTier(ctx, "1_s0", corepiecestore.ColdTier))
+	data, err = client.GetPiece(ctx, "1_s0", 0, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, "segment", string(data))
+
+	// the piece is deleted from all the tiers
+	assert.Nil(t, client.DeletePiece(ctx, "1_s0_p2"))
+	_, err = client.GetPiece(ctx, "1_s0_p2", 0, -1)
+	assert.NotNil(t, err)
+
+	assert.NotNil(t, client.CopyPieceToTier(ctx, "1_s0", "warm"))
+}
+
+func TestStoreClient_TieringDisabled(t *testing.T) {
+	client, err := NewStoreClient(&storage.PieceStoreConfig{
+		Store: storage.ObjectStorageConfig{Storage: storage.MemoryStore, BucketURL: "hot"},
+	}, nil, nil, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, client.PutPiece(context.TODO(), "1_s0", []byte("segment")))
+	data, err := client.GetPiece(context.TODO(), "1_s0", 0, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, "segment", string(data))
+	assert.NotNil(t, client.CopyPieceToTier(context.TODO(), "1_s0", corepiecestore.ColdTier))
+}
+
+func TestStoreClient_Stream(t *testing.T) {
+	client, err := NewStoreClient(&storage.PieceStoreConfig{
+		Store: storage.ObjectStorageConfig{Storage: storage.MemoryStore, BucketURL: "hot"},
+	}, nil, nil, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, client.PutPieceReader(context.TODO(), "1_s0", strings.NewReader("segment")))
+	rc, err := client.GetPieceReader(context.TODO(), "1_s0", 2, 3)
+	assert.Nil(t, err)
+	data, err := io.ReadAll(rc)
+	assert.Nil(t, err)
+	assert.Nil(t, rc.Close())
+	assert.Equal(t, "gme", string(data))
+
+	_, err = client.GetPieceReader(context.TODO(), "2_s0", 0, -1)
+	assert.NotNil(t, err)
+}
@@ -3,12 +3,17 @@ package piece
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/bnb-chain/greenfield-storage-provider/store/piecestore/storage"
 )
 
 type PieceStore struct {
 	storeAPI storage.ObjectStorage
+	// compactor is the pack store under the decorators, it is nil if packing is disabled
+	compactor storage.PackCompactor
+	// removers are the backend storage and the decorators that have orphan files
+	removers []storage.OrphanRemover
 }
 
 // Get one piece from PieceStore
@@ -26,7 +31,53 @@ func (p *PieceStore) Delete(ctx context.Context, key string) error {
 	return p.storeAPI.DeleteObject(ctx, key)
 }
 
+// DeleteBatch deletes the pieces in PieceStore in batch, returns the errors of the pieces
+// that fail to be deleted.
+func (p *PieceStore) DeleteBatch(ctx context.Context, keys []string) map[string]error {
+	return p.storeAPI.DeleteObjects(ctx, keys)
+}
+
+// PresignGet returns the presigned url to read the piece from the backend storage directly,
+// returns storage.ErrUnsupportedMethod if the piece can not be read as is, e.g. the piece is
+// encrypted, compressed, packed or deduplicated.
+func (p *PieceStore) PresignGet(ctx context.Context, key string, expire time.Duration, header storage.PresignHeader) (
+	string, error) {
+	presigner, ok := p.storeAPI.(storage.Presigner)
+	if !ok {
+		return "", storage.ErrUnsupportedMethod
+	}
+	return presigner.PresignGet(ctx, key, expire, header)
+}
+
 // GetPieceInfo returns piece info in PieceStore
 func (p *PieceStore) GetPieceInfo(ctx context.Context, key string) (storage.Object, error) {
 	return p.storeAPI.HeadObject(ctx, key)
 }
+
+// List returns all the pieces whose key is greater than marker in PieceStore
+func (p *PieceStore) List(ctx context.Context, prefix, marker string) (<-chan storage.Object, error) {
+	return p.storeAPI.ListAllObjects(ctx, prefix, marker)
+}
+
+// CompactPacks compacts the pack files of PieceStore, it is a no-op if packing is disabled
+func (p *PieceStore) CompactPacks(ctx context.Context) {
+	if p.compactor != nil {
+		p.compactor.CompactPacks(ctx)
+	}
+}
+
+// RemoveOrphanFiles removes the orphan files of the backend storage and the decorators, it is
+// a no-op if none of them has orphan files
+func (p *PieceStore) RemoveOrphanFiles(ctx context.Context) {
+	for _, remover := range p.removers {
+		if ctx.Err() != nil {
+			return
+		}
+		remover.RemoveOrphanFiles(ctx)
+	}
+}
+
+// Capacity returns the total and available bytes of PieceStore
+func (p *PieceStore) Capacity(ctx context.Context) (uint64, uint64, error) {
+	return p.storeAPI.Capacity(ctx)
+}
@@ -0,0 +1,334 @@
+package piece
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+)
+
+const (
+	// DefaultMigrateConcurrency defines the default number of pieces that are migrated concurrently.
+	DefaultMigrateConcurrency = 16
+	// DefaultMigrateReportInterval defines the default interval of reporting and saving the progress.
+	DefaultMigrateReportInterval = 10 * time.Second
+	// maxRecordFailedKeys defines the max number of failed keys recorded in the progress.
+	maxRecordFailedKeys = 1000
+)
+
+// ErrChecksumMismatch defines the piece checksum mismatch error.
+var ErrChecksumMismatch = errors.New("piece checksum mismatch")
+
+// MigrateConfig defines the options of migrating pieces between piece stores.
+type MigrateConfig struct {
+	// Prefix only migrates the pieces whose key has the prefix.
+	Prefix string
+	// Concurrency is the number of pieces that are migrated concurrently.
+	Concurrency int
+	// DryRun only lists the pieces that need to be migrated and does not write the destination.
+	DryRun bool
+	// CheckpointFile is used to save the progress, the migration resumes from the
+	// checkpoint if the file exists. The checkpoint is disabled if it is empty.
+	CheckpointFile string
+	// ReportInterval is the interval of reporting and saving the progress.
+	ReportInterval time.Duration
+	// ExpectedChecksum returns the expected checksum of the piece, returns nil if the
+	// piece can not be verified. It is used to verify the source pieces.
+	ExpectedChecksum func(key string) ([]byte, error)
+}
+
+// MigrateProgress defines the progress of migration, it is also the checkpoint content.
+type MigrateProgress struct {
+	// Marker is the max piece key that all the pieces before it have been handled, it relies on
+	// ListAllObjects listing the pieces in the lexical order of the keys.
+	Marker     string   `json:"marker"`
+	Scanned    int64    `json:"scanned"`
+	Migrated   int64    `json:"migrated"`
+	Skipped    int64    `json:"skipped"`
+	Failed     int64    `json:"failed"`
+	Bytes      int64    `json:"bytes"`
+	FailedKeys []string `json:"failed_keys,omitempty"`
+}
+
+// String returns the progress description.
+func (p *MigrateProgress) String() string {
+	return fmt.Sprintf("marker[%s], scanned[%d], migrated[%d], skipped[%d], failed[%d], bytes[%d]",
+		p.Marker, p.Scanned, p.Migrated, p.Skipped, p.Failed, p.Bytes)
+}
+
+// Migrator migrates the pieces from the source piece store to the destination piece store.
+type Migrator struct {
+	src *PieceStore
+	dst *PieceStore
+	cfg *MigrateConfig
+
+	mux      sync.Mutex
+	progress *MigrateProgress
+	// done records the finished pieces that are out of order by sequence.
+	done    map[int64]string
+	nextSeq int64
+	// failedSeq is the min sequence of the failed pieces, the marker does not advance
+	// beyond it, so that the failed pieces are retried when resuming from checkpoint.
+	failedSeq int64
+}
+
+// NewMigrator returns an instance of Migrator.
+func NewMigrator(src, dst *PieceStore, cfg *MigrateConfig) *Migrator {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = DefaultMigrateConcurrency
+	}
+	if cfg.ReportInterval <= 0 {
+		cfg.ReportInterval = DefaultMigrateReportInterval
+	}
+	return &Migrator{
+		src:       src,
+		dst:       dst,
+		cfg:       cfg,
+		progress:  &MigrateProgress{},
+		done:      make(map[int64]string),
+		failedSeq: -1,
+	}
+}
+
+type migrateItem struct {
+	seq  int64
+	key  string
+	size int64
+}
+
+// Migrate streams all the pieces from source to destination, and returns the final progress.
+func (m *Migrator) Migrate(ctx context.Context) (*MigrateProgress, error) {
+	if err := m.loadCheckpoint(); err != nil {
+		return nil, err
+	}
+	objects, err := m.src.List(ctx, m.cfg.Prefix, m.progress.Marker)
+	if err != nil {
+		log.CtxErrorw(ctx, "failed to list source pieces", "error", err)
+		return nil, err
+	}
+
+	var (
+		items   = make(chan *migrateItem, m.cfg.Concurrency)
+		wg      sync.WaitGroup
+		listErr error
+	)
+	for i := 0; i < m.cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range items {
+				skipped, err := m.migrate(ctx, item)
+				m.finish(item, skipped, err)
+			}
+		}()
+	}
+
+	reportCtx, cancelReport := context.WithCancel(ctx)
+	go m.report(reportCtx)
+
+	var seq int64
+	for obj := range objects {
+		if obj == nil {
+			listErr = fmt.Errorf("failed to list source pieces after marker %s", m.Progress().Marker)
+			break
+		}
+		if strings.HasSuffix(obj.Key(), "/") {
+			continue
+		}
+		items <- &migrateItem{seq: seq, key: obj.Key(), size: obj.Size()}
+		seq++
+	}
+	close(items)
+	wg.Wait()
+	cancelReport()
+
+	if err = m.saveCheckpoint(); err != nil {
+		log.CtxErrorw(ctx, "failed to save checkpoint", "error", err)
+	}
+	progress := m.Progress()
+	if listErr != nil {
+		return progress, listErr
+	}
+	return progress, ctx.Err()
+}
+
+// Progress returns the copy of current progress.
+func (m *Migrator) Progress() *MigrateProgress {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	progress := *m.progress
+	progress.FailedKeys = append([]string(nil), m.progress.FailedKeys...)
+	return &progress
+}
+
+// migrate migrates one piece, returns true if the piece is skipped. The piece is streamed
+// from source to destination, and the destination piece is verified by checksum.
+func (m *Migrator) migrate(ctx context.Context, item *migrateItem) (bool, error) {
+	expected, err := m.expectedChecksum(item.key)
+	if err != nil {
+		return false, err
+	}
+	if info, err := m.dst.GetPieceInfo(ctx, item.key); err == nil && info.Size() == item.size {
+		if expected == nil {
+			if expected, err = pieceChecksum(ctx, m.src, item.key); err != nil {
+				return false, err
+			}
+		}
+		existing, err := pieceChecksum(ctx, m.dst, item.key)
+		if err != nil {
+			return false, err
+		}
+		// the destination piece with the same size but different content is overwritten
+		if bytes.Equal(expected, existing) {
+			return true, nil
+		}
+	}
+	if m.cfg.DryRun {
+		return false, nil
+	}
+	rc, err := m.src.Get(ctx, item.key, 0, -1)
+	if err != nil {
+		return false, err
+	}
+	hasher := sha256.New()
+	err = m.dst.Put(ctx, item.key, io.TeeReader(rc, hasher))
+	_ = rc.Close()
+	if err != nil {
+		return false, err
+	}
+	checksum := hasher.Sum(nil)
+	if expected != nil && !bytes.Equal(expected, checksum) {
+		// the corrupted source piece has been written, do not leave it in the destination
+		if err = m.dst.Delete(ctx, item.key); err != nil {
+			log.CtxErrorw(ctx, "failed to delete corrupted piece", "piece_key", item.key, "error", err)
+		}
+		return false, fmt.Errorf("source %w", ErrChecksumMismatch)
+	}
+	written, err := pieceChecksum(ctx, m.dst, item.key)
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(checksum, written) {
+		return false, fmt.Errorf("destination %w", ErrChecksumMismatch)
+	}
+	return false, nil
+}
+
+// expectedChecksum returns the expected checksum of the piece, returns nil if the piece
+// can not be verified.
+func (m *Migrator) expectedChecksum(key string) ([]byte, error) {
+	if m.cfg.ExpectedChecksum == nil {
+		return nil, nil
+	}
+	return m.cfg.ExpectedChecksum(key)
+}
+
+// pieceChecksum streams the piece and returns its checksum, it is the same as
+// hash.GenerateChecksum of the piece data.
+func pieceChecksum(ctx context.Context, store *PieceStore, key string) ([]byte, error) {
+	rc, err := store.Get(ctx, key, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	hasher := sha256.New()
+	if _, err = io.Copy(hasher, rc); err != nil {
+		return nil, err
+	}
+	return hasher.Sum(nil), nil
+}
+
+func (m *Migrator) finish(item *migrateItem, skipped bool, err error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.progress.Scanned++
+	switch {
+	case err != nil:
+		log.Errorw("failed to migrate piece", "piece_key", item.key, "error", err)
+		m.progress.Failed++
+		if len(m.progress.FailedKeys) < maxRecordFailedKeys {
+			m.progress.FailedKeys = append(m.progress.FailedKeys, item.key)
+		}
+		if m.failedSeq < 0 || item.seq < m.failedSeq {
+			m.failedSeq = item.seq
+		}
+	case skipped:
+		m.progress.Skipped++
+	default:
+		m.progress.Migrated++
+		m.progress.Bytes += item.size
+	}
+	// advance the marker only if all the pieces before it have been handled.
+	if err == nil && (m.failedSeq < 0 || item.seq < m.failedSeq) {
+		m.done[item.seq] = item.key
+	}
+	for {
+		key, ok := m.done[m.nextSeq]
+		if !ok {
+			break
+		}
+		m.progress.Marker = key
+		delete(m.done, m.nextSeq)
+		m.nextSeq++
+	}
+}
+
+func (m *Migrator) report(ctx context.Context) {
+	ticker := time.NewTicker(m.cfg.ReportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			log.Infow("piece store migration progress", "progress", m.Progress().String())
+			if err := m.saveCheckpoint(); err != nil {
+				log.Errorw("failed to save checkpoint", "error", err)
+			}
+		}
+	}
+}
+
+func (m *Migrator) loadCheckpoint() error {
+	if m.cfg.CheckpointFile == "" {
+		return nil
+	}
+	bz, err := os.ReadFile(m.cfg.CheckpointFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	progress := &MigrateProgress{}
+	if err = json.Unmarshal(bz, progress); err != nil {
+		return fmt.Errorf("invalid checkpoint file %s: %v", m.cfg.CheckpointFile, err)
+	}
+	m.progress = progress
+	log.Infow("resume piece store migration from checkpoint", "progress", progress.String())
+	return nil
+}
+
+func (m *Migrator) saveCheckpoint() error {
+	if m.cfg.CheckpointFile == "" || m.cfg.DryRun {
+		return nil
+	}
+	bz, err := json.MarshalIndent(m.Progress(), "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := m.cfg.CheckpointFile + ".tmp"
+	if err = os.WriteFile(tmp, bz, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.cfg.CheckpointFile)
+}
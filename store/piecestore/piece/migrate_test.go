@@ -0,0 +1,101 @@
+package piece
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/bnb-chain/greenfield-common/go/hash"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/greenfield-storage-provider/store/piecestore/storage"
+)
+
+func setupMigrateTest(t *testing.T) (*PieceStore, *PieceStore) {
+	src, err := NewPieceStore(&storage.PieceStoreConfig{
+		Store: storage.ObjectStorageConfig{Storage: storage.MemoryStore, BucketURL: "src"}})
+	assert.Nil(t, err)
+	dst, err := NewPieceStore(&storage.PieceStoreConfig{
+		Store: storage.ObjectStorageConfig{Storage: storage.MemoryStore, BucketURL: "dst"}})
+	assert.Nil(t, err)
+	for i := 0; i < 20; i++ {
+		err = src.Put(context.TODO(), fmt.Sprintf("%d_s0", i), bytes.NewReader([]byte(fmt.Sprintf("piece-%d", i))))
+		assert.Nil(t, err)
+	}
+	return src, dst
+}
+
+func TestMigrator_Migrate(t *testing.T) {
+	src, dst := setupMigrateTest(t)
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint")
+	cfg := &MigrateConfig{Concurrency: 4, CheckpointFile: checkpoint}
+	progress, err := NewMigrator(src, dst, cfg).Migrate(context.TODO())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(20), progress.Scanned)
+	assert.Equal(t, int64(20), progress.Migrated)
+	assert.Equal(t, "9_s0", progress.Marker)
+	for i := 0; i < 20; i++ {
+		rc, err := dst.Get(context.TODO(), fmt.Sprintf("%d_s0", i), 0, -1)
+		assert.Nil(t, err)
+		data, _ := io.ReadAll(rc)
+		assert.Equal(t, fmt.Sprintf("piece-%d", i), string(data))
+	}
+
+	// resume from checkpoint, all the pieces have been handled
+	progress, err = NewMigrator(src, dst, cfg).Migrate(context.TODO())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(20), progress.Scanned)
+	assert.Equal(t, int64(20), progress.Migrated)
+
+	// without checkpoint, the existing pieces are skipped
+	progress, err = NewMigrator(src, dst, &MigrateConfig{}).Migrate(context.TODO())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(20), progress.Skipped)
+	assert.Equal(t, int64(0), progress.Migrated)
+
+	// the existing piece with the same size but different content is overwritten
+	assert.Nil(t, dst.Put(context.TODO(), "5_s0", bytes.NewReader([]byte("PIECE-5"))))
+	progress, err = NewMigrator(src, dst, &MigrateConfig{}).Migrate(context.TODO())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(19), progress.Skipped)
+	assert.Equal(t, int64(1), progress.Migrated)
+	rc, err := dst.Get(context.TODO(), "5_s0", 0, -1)
+	assert.Nil(t, err)
+	data, _ := io.ReadAll(rc)
+	assert.Equal(t, "piece-5", string(data))
+}
+
+func TestMigrator_DryRun(t *testing.T) {
+	src, dst := setupMigrateTest(t)
+	progress, err := NewMigrator(src, dst, &MigrateConfig{Prefix: "1", DryRun: true}).Migrate(context.TODO())
+	assert.Nil(t, err)
+	// 1_s0 and 10_s0 ~ 19_s0
+	assert.Equal(t, int64(11), progress.Migrated)
+	_, err = dst.GetPieceInfo(context.TODO(), "1_s0")
+	assert.NotNil(t, err)
+}
+
+func TestMigrator_VerifyChecksum(t *testing.T) {
+	src, dst := setupMigrateTest(t)
+	cfg := &MigrateConfig{
+		ExpectedChecksum: func(key string) ([]byte, error) {
+			if key == "3_s0" {
+				return hash.GenerateChecksum([]byte("corrupted")), nil
+			}
+			return nil, nil
+		},
+	}
+	progress, err := NewMigrator(src, dst, cfg).Migrate(context.TODO())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(19), progress.Migrated)
+	assert.Equal(t, int64(1), progress.Failed)
+	assert.Equal(t, []string{"3_s0"}, progress.FailedKeys)
+	// the marker stops before the failed piece, so that it is retried when resuming
+	assert.Equal(t, "2_s0", progress.Marker)
+	// the corrupted piece is not left in the destination
+	_, err = dst.GetPieceInfo(context.TODO(), "3_s0")
+	assert.NotNil(t, err)
+}
@@ -9,27 +9,97 @@ import (
 	"path/filepath"
 	"runtime"
 
+	"github.com/bnb-chain/greenfield-storage-provider/core/spdb"
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
 	"github.com/bnb-chain/greenfield-storage-provider/store/piecestore/storage"
 )
 
 // NewPieceStore returns an instance of PieceStore
 func NewPieceStore(pieceConfig *storage.PieceStoreConfig) (*PieceStore, error) {
+	return NewPieceStoreWithIndex(pieceConfig, nil, nil)
+}
+
+// NewPieceStoreWithIndex returns an instance of PieceStore, the packIndex is required if
+// packing the small pieces is enabled, and the dedupIndex is required if deduplicating
+// the segment pieces is enabled.
+func NewPieceStoreWithIndex(pieceConfig *storage.PieceStoreConfig, packIndex spdb.PackDB, dedupIndex spdb.DedupDB) (
+	*PieceStore, error) {
+	overrideConfigFromEnv(pieceConfig)
 	checkConfig(pieceConfig)
+	return newPieceStore(pieceConfig, packIndex, dedupIndex)
+}
+
+// NewColdPieceStore returns the PieceStore of the cold tier, it stores the pieces in the
+// cold object storage with the same encryption and compression as the hot tier. The small
+// pieces are not packed and the segment pieces are not deduplicated in the cold tier, because
+// the pack index and the dedup index are keyed by the piece key.
+func NewColdPieceStore(pieceConfig *storage.PieceStoreConfig) (*PieceStore, error) {
+	coldConfig := &storage.PieceStoreConfig{
+		Store:       pieceConfig.Tiering.ColdStore,
+		Encryption:  pieceConfig.Encryption,
+		Compression: pieceConfig.Compression,
+	}
+	checkConfig(coldConfig)
+	return newPieceStore(coldConfig, nil, nil)
+}
+
+func newPieceStore(pieceConfig *storage.PieceStoreConfig, packIndex spdb.PackDB, dedupIndex spdb.DedupDB) (
+	*PieceStore, error) {
 	blob, err := createStorage(*pieceConfig)
 	if err != nil {
 		log.Errorw("failed to create storage", "error", err)
 		return nil, err
 	}
+	var removers []storage.OrphanRemover
+	if remover, ok := blob.(storage.OrphanRemover); ok {
+		removers = append(removers, remover)
+	}
+	// the faults are injected into the backend storage, the decorators above handle them as
+	// the real backend errors.
+	if pieceConfig.Fault.Enable {
+		if blob, err = storage.NewFaultStore(blob, pieceConfig.Fault); err != nil {
+			log.Errorw("failed to create fault storage", "error", err)
+			return nil, err
+		}
+	}
+	if pieceConfig.Encryption.Enable {
+		if blob, err = storage.NewEncryptStore(blob, pieceConfig.Encryption); err != nil {
+			log.Errorw("failed to create encrypt storage", "error", err)
+			return nil, err
+		}
+	}
+	// compress the pieces before encryption, the encrypted data can not be compressed.
+	if pieceConfig.Compression.Enable {
+		if blob, err = storage.NewCompressStore(blob, pieceConfig.Compression); err != nil {
+			log.Errorw("failed to create compress storage", "error", err)
+			return nil, err
+		}
+	}
+	var compactor storage.PackCompactor
+	if pieceConfig.Pack.Enable {
+		if blob, err = storage.NewPackStore(blob, packIndex, pieceConfig.Pack); err != nil {
+			log.Errorw("failed to create pack storage", "error", err)
+			return nil, err
+		}
+		compactor = blob.(storage.PackCompactor)
+	}
+	// deduplicate the segment pieces before packing, the small contents are packed by content key.
+	if pieceConfig.Dedup.Enable {
+		if blob, err = storage.NewDedupStore(blob, dedupIndex, pieceConfig.Dedup); err != nil {
+			log.Errorw("failed to create dedup storage", "error", err)
+			return nil, err
+		}
+		removers = append(removers, blob.(storage.OrphanRemover))
+	}
 	log.Debugw("piece store is running", "storage type", pieceConfig.Store.Storage,
-		"shards", pieceConfig.Shards)
+		"shards", pieceConfig.Shards, "pack", pieceConfig.Pack.Enable, "dedup", pieceConfig.Dedup.Enable, "encryption", pieceConfig.Encryption.Enable,
+		"compression", pieceConfig.Compression.Enable)
 
-	return &PieceStore{blob}, nil
+	return &PieceStore{storeAPI: blob, compactor: compactor, removers: removers}, nil
 }
 
 // checkConfig checks config if right
 func checkConfig(cfg *storage.PieceStoreConfig) {
-	overrideConfigFromEnv(cfg)
 	if cfg.Shards > 256 {
 		log.Panicf("too many shards: %d", cfg.Shards)
 	}
@@ -195,8 +195,9 @@ func TestB2_ListSuccess(t *testing.T) {
 
 func TestB2_ListAll(t *testing.T) {
 	store := setupB2Test(t)
+	store.api = mockS3ClientError{}
 	_, err := store.ListAllObjects(context.TODO(), emptyString, emptyString)
-	assert.Equal(t, ErrUnsupportedMethod, err)
+	assert.Equal(t, errors.New("List objects error"), err)
 }
 
 func TestB2_CreateError(t *testing.T) {
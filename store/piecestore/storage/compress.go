@@ -0,0 +1,593 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/metrics"
+)
+
+var (
+	// compressMagic is the first bytes of the object written by compressStore.
+	compressMagic = []byte("GFSZ")
+	// errNotCompressed is returned if the object does not start with the compression header.
+	errNotCompressed = errors.New("object is not written by compression")
+	// crc32cTable is the crc32c table to checksum the compression header.
+	crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// compressStore compresses the objects of the underlying ObjectStorage by zstd. The object is
+// split into chunks of compressChunkSize and each chunk is compressed separately while it is read
+// from the writer, so the range reads only need to decompress the chunks covering the range. The
+// chunk is stored uncompressed if compression does not save MinSavingRatio of its size, the chunk
+// is always stored compressed if MinSavingRatio is 0, and the objects without the prefix are the
+// objects written before compression is enabled, so the compressed and uncompressed objects
+// coexist in the same storage.
+//
+// The layout of the object is:
+//
+//	prefix:  magic(4) | version(1) | codec(1) | reserved(2) | chunk size(4) | crc32c of the prefix(4)
+//	frames:  raw flag(1 bit) | chunk length(31 bits) | chunk 0 | ... | end frame 0(4)
+//	trailer: size(8) | chunk count(4) | frame headers(4 * chunk count) | crc32c of the trailer(4) |
+//	         trailer length(4)
+//
+// The objects of the version 1 layout keep the chunk lengths in the header and are still readable:
+//
+//	header: magic(4) | version(1) | codec(1) | reserved(2) | chunk size(4) | chunk count(4) | size(8) |
+//	        compressed chunk lengths(4 * chunk count) | crc32c of the header(4)
+//	chunks: compressed chunk 0 | compressed chunk 1 | ...
+//
+// ListObjects and ListAllObjects return the stored size of objects, HeadObject returns the
+// original size by reading the trailer.
+type compressStore struct {
+	ObjectStorage
+	encoder        *zstd.Encoder
+	decoder        *zstd.Decoder
+	minSavingRatio float64
+}
+
+// compressHeader is the decoded header, or the decoded prefix and trailer of the object written by compressStore.
+type compressHeader struct {
+	version   byte
+	codec     byte
+	chunkSize int64
+	size      int64    // the original size of the object, -1 if the trailer is not read
+	offsets   []int64  // the offsets of the chunks relative to the end of header, nil if the trailer is not read
+	frames    []uint32 // the frame headers of the trailer index layout
+	length    int64    // the length of the header or the prefix
+}
+
+// NewCompressStore returns an ObjectStorage that compresses the objects of the underlying ObjectStorage.
+func NewCompressStore(store ObjectStorage, cfg CompressionConfig) (ObjectStorage, error) {
+	if cfg.Level <= 0 {
+		cfg.Level = DefaultCompressLevel
+	}
+	minSavingRatio := DefaultCompressMinSavingRatio
+	if cfg.MinSavingRatio != nil {
+		minSavingRatio = *cfg.MinSavingRatio
+	}
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(cfg.Level)))
+	if err != nil {
+		return nil, err
+	}
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderMaxMemory(maxCompressChunkSize))
+	if err != nil {
+		return nil, err
+	}
+	return &compressStore{ObjectStorage: store, encoder: encoder, decoder: decoder,
+		minSavingRatio: minSavingRatio}, nil
+}
+
+func (s *compressStore) String() string {
+	return fmt.Sprintf("compress://%s", s.ObjectStorage)
+}
+
+func (s *compressStore) PutObject(ctx context.Context, key string, reader io.Reader) error {
+	prefix := make([]byte, 0, compressPrefixSize)
+	prefix = append(prefix, compressMagic...)
+	prefix = append(prefix, compressTrailerIndexVersion, compressCodecZstd, 0, 0)
+	prefix = binary.BigEndian.AppendUint32(prefix, compressChunkSize)
+	prefix = binary.BigEndian.AppendUint32(prefix, crc32.Checksum(prefix, crc32cTable))
+	r := &compressReader{
+		src:            reader,
+		encoder:        s.encoder,
+		minSavingRatio: s.minSavingRatio,
+		out:            prefix,
+		plain:          make([]byte, compressChunkSize),
+	}
+	if err := s.ObjectStorage.PutObject(ctx, key, r); err != nil {
+		return err
+	}
+	metrics.PieceStoreCompressionBytesCounter.WithLabelValues("original").Add(float64(r.size))
+	metrics.PieceStoreCompressionBytesCounter.WithLabelValues("stored").Add(float64(r.stored))
+	if r.size > 0 {
+		codec := byte(compressCodecNone)
+		if r.compressed > 0 {
+			codec = compressCodecZstd
+		}
+		metrics.PieceStoreCompressionRatioHistogram.WithLabelValues(compressCodecName(codec)).
+			Observe(float64(r.size) / float64(r.stored))
+	}
+	return nil
+}
+
+func (s *compressStore) GetObject(ctx context.Context, key string, offset, limit int64) (io.ReadCloser, error) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset < compressChunkSize && limit <= 0 {
+		// read the header and the chunks to the end by one request, the range reads only
+		// request the chunks covering the range after reading the header.
+		rc, err := s.ObjectStorage.GetObject(ctx, key, 0, -1)
+		if err != nil {
+			return nil, err
+		}
+		br := bufio.NewReader(rc)
+		raw, header, err := readCompressHeader(br)
+		if errors.Is(err, errNotCompressed) {
+			return newPlaintextReader(io.MultiReader(bytes.NewReader(raw), br), rc, offset, limit), nil
+		}
+		if err != nil {
+			_ = rc.Close()
+			log.Errorw("failed to read compression header", "key", key, "error", err)
+			return nil, err
+		}
+		if header.codec == compressCodecNone {
+			return newPlaintextReader(io.LimitReader(br, header.size), rc, offset, limit), nil
+		}
+		// the frames of the trailer index layout are read to the end frame and the trailer is verified
+		return s.newDecompressReader(header, br, rc, 0, offset, limit), nil
+	}
+
+	header, err := s.readHeader(ctx, key)
+	if errors.Is(err, errNotCompressed) {
+		return s.ObjectStorage.GetObject(ctx, key, offset, limit)
+	}
+	if err != nil {
+		log.Errorw("failed to read compression header", "key", key, "error", err)
+		return nil, err
+	}
+	if offset >= header.size {
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	}
+	if header.codec == compressCodecNone {
+		if limit <= 0 || offset+limit > header.size {
+			limit = header.size - offset
+		}
+		return s.ObjectStorage.GetObject(ctx, key, header.length+offset, limit)
+	}
+	first, last := offset/header.chunkSize, int64(len(header.offsets)-2)
+	if limit > 0 && (offset+limit-1)/header.chunkSize < last {
+		last = (offset + limit - 1) / header.chunkSize
+	}
+	start, end := header.offsets[first], header.offsets[last+1]
+	rc, err := s.ObjectStorage.GetObject(ctx, key, header.length+start, end-start)
+	if err != nil {
+		return nil, err
+	}
+	return s.newDecompressReader(header, bufio.NewReader(rc), rc, first, offset, limit), nil
+}
+
+func (s *compressStore) HeadObject(ctx context.Context, key string) (Object, error) {
+	obj, err := s.ObjectStorage.HeadObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	header, err := s.readHeader(ctx, key)
+	if errors.Is(err, errNotCompressed) {
+		return obj, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &object{key: obj.Key(), size: header.size, modTime: obj.ModTime()}, nil
+}
+
+// readHeader reads the header by range reads, the header of the most objects is read by one request.
+// The trailer of the trailer index layout is read from the end of the object.
+func (s *compressStore) readHeader(ctx context.Context, key string) (*compressHeader, error) {
+	rc, err := s.ObjectStorage.GetObject(ctx, key, 0, compressHeaderPrefetchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	rest := &lazyReader{open: func() (io.ReadCloser, error) {
+		return s.ObjectStorage.GetObject(ctx, key, compressHeaderPrefetchSize, -1)
+	}}
+	defer rest.Close()
+	_, header, err := readCompressHeader(io.MultiReader(io.LimitReader(rc, compressHeaderPrefetchSize), rest))
+	if err != nil || header.version == compressHeaderIndexVersion {
+		return header, err
+	}
+	return header, s.readTrailer(ctx, key, header)
+}
+
+// readTrailer reads the trailer from the end of the object and fills the size and the chunk offsets
+// of the header, the trailer of the most objects is read by one request.
+func (s *compressStore) readTrailer(ctx context.Context, key string, header *compressHeader) error {
+	obj, err := s.ObjectStorage.HeadObject(ctx, key)
+	if err != nil {
+		return err
+	}
+	stored := obj.Size()
+	if stored < header.length+4+compressTrailerFixedSize {
+		return fmt.Errorf("invalid compression trailer")
+	}
+	tail := stored - header.length
+	if tail > compressHeaderPrefetchSize {
+		tail = compressHeaderPrefetchSize
+	}
+	raw, err := s.readRange(ctx, key, stored-tail, tail)
+	if err != nil {
+		return err
+	}
+	length := int64(binary.BigEndian.Uint32(raw[len(raw)-4:]))
+	if length < compressTrailerFixedSize || header.length+4+length > stored {
+		return fmt.Errorf("invalid compression trailer")
+	}
+	if length > tail {
+		if raw, err = s.readRange(ctx, key, stored-length, length); err != nil {
+			return err
+		}
+	}
+	if header.size, header.frames, err = decodeCompressTrailer(raw[int64(len(raw))-length:], header.chunkSize); err != nil {
+		return err
+	}
+	header.offsets = make([]int64, len(header.frames)+1)
+	for i, frame := range header.frames {
+		header.offsets[i+1] = header.offsets[i] + 4 + int64(frame&^compressFrameRawFlag)
+	}
+	if header.length+header.offsets[len(header.frames)]+4+length != stored {
+		return fmt.Errorf("compression trailer size mismatch")
+	}
+	return nil
+}
+
+// readRange reads the range of the object and returns the read bytes.
+func (s *compressStore) readRange(ctx context.Context, key string, offset, limit int64) ([]byte, error) {
+	rc, err := s.ObjectStorage.GetObject(ctx, key, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	raw := make([]byte, limit)
+	if _, err = io.ReadFull(rc, raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// readCompressHeader reads the header or the prefix from r, returns the read bytes and errNotCompressed
+// if the object does not start with the header.
+func readCompressHeader(r io.Reader) ([]byte, *compressHeader, error) {
+	raw := make([]byte, 8, compressHeaderFixedSize)
+	n, err := io.ReadFull(r, raw)
+	if n < len(compressMagic)+1 || !bytes.Equal(raw[:len(compressMagic)], compressMagic) {
+		return raw[:n], nil, errNotCompressed
+	}
+	if err != nil {
+		return raw[:n], nil, err
+	}
+	switch raw[len(compressMagic)] {
+	case compressHeaderIndexVersion:
+		return readCompressIndexHeader(r, raw)
+	case compressTrailerIndexVersion:
+		raw = raw[:compressPrefixSize]
+		if _, err = io.ReadFull(r, raw[8:]); err != nil {
+			return raw, nil, err
+		}
+		if crc32.Checksum(raw[:compressPrefixSize-4], crc32cTable) != binary.BigEndian.Uint32(raw[compressPrefixSize-4:]) {
+			return raw, nil, fmt.Errorf("compression prefix checksum mismatch")
+		}
+		h := &compressHeader{
+			version:   raw[4],
+			codec:     raw[5],
+			chunkSize: int64(binary.BigEndian.Uint32(raw[8:])),
+			size:      -1,
+			length:    compressPrefixSize,
+		}
+		if h.codec != compressCodecZstd || h.chunkSize <= 0 || h.chunkSize > maxCompressChunkSize {
+			return raw, nil, fmt.Errorf("invalid compression prefix")
+		}
+		return raw, h, nil
+	}
+	return raw, nil, fmt.Errorf("unsupported compression version: %d", raw[len(compressMagic)])
+}
+
+// readCompressIndexHeader reads the rest of the header of the version 1 layout after the first 8 bytes.
+func readCompressIndexHeader(r io.Reader, raw []byte) ([]byte, *compressHeader, error) {
+	raw = raw[:compressHeaderFixedSize]
+	if _, err := io.ReadFull(r, raw[8:]); err != nil {
+		return raw, nil, err
+	}
+	h := &compressHeader{
+		version:   raw[4],
+		codec:     raw[5],
+		chunkSize: int64(binary.BigEndian.Uint32(raw[8:])),
+		size:      int64(binary.BigEndian.Uint64(raw[16:])),
+	}
+	count := int64(binary.BigEndian.Uint32(raw[12:]))
+	if h.codec == compressCodecZstd && (h.chunkSize <= 0 || h.chunkSize > maxCompressChunkSize ||
+		count != (h.size+h.chunkSize-1)/h.chunkSize) || h.codec == compressCodecNone && count != 0 ||
+		h.codec > compressCodecZstd {
+		return raw, nil, fmt.Errorf("invalid compression header")
+	}
+	rest := make([]byte, 4*count+4)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return raw, nil, err
+	}
+	raw = append(raw, rest...)
+	if crc32.Checksum(raw[:len(raw)-4], crc32cTable) != binary.BigEndian.Uint32(raw[len(raw)-4:]) {
+		return raw, nil, fmt.Errorf("compression header checksum mismatch")
+	}
+	h.length = int64(len(raw))
+	if h.codec == compressCodecZstd {
+		h.offsets = make([]int64, count+1)
+		for i := int64(0); i < count; i++ {
+			h.offsets[i+1] = h.offsets[i] + int64(binary.BigEndian.Uint32(rest[4*i:]))
+		}
+	}
+	return raw, h, nil
+}
+
+// decodeCompressTrailer decodes the trailer and returns the original size and the frame headers.
+func decodeCompressTrailer(raw []byte, chunkSize int64) (int64, []uint32, error) {
+	if len(raw) < compressTrailerFixedSize || binary.BigEndian.Uint32(raw[len(raw)-4:]) != uint32(len(raw)) {
+		return 0, nil, fmt.Errorf("invalid compression trailer")
+	}
+	size := int64(binary.BigEndian.Uint64(raw))
+	count := int64(binary.BigEndian.Uint32(raw[8:]))
+	if size < 0 || compressTrailerFixedSize+4*count != int64(len(raw)) || count != (size+chunkSize-1)/chunkSize {
+		return 0, nil, fmt.Errorf("invalid compression trailer")
+	}
+	if crc32.Checksum(raw[:len(raw)-8], crc32cTable) != binary.BigEndian.Uint32(raw[len(raw)-8:]) {
+		return 0, nil, fmt.Errorf("compression trailer checksum mismatch")
+	}
+	frames := make([]uint32, count)
+	for i := range frames {
+		frames[i] = binary.BigEndian.Uint32(raw[12+4*i:])
+		if length := int64(frames[i] &^ compressFrameRawFlag); length == 0 || length > 2*chunkSize {
+			return 0, nil, fmt.Errorf("invalid compression frame %d", i)
+		}
+	}
+	return size, frames, nil
+}
+
+func compressCodecName(codec byte) string {
+	if codec == compressCodecZstd {
+		return "zstd"
+	}
+	return "none"
+}
+
+// compressReader reads the plaintext from src and returns the compressed object chunk by chunk.
+type compressReader struct {
+	src            io.Reader
+	encoder        *zstd.Encoder
+	minSavingRatio float64
+	frames         []uint32 // the frame headers written to the trailer
+	size           int64    // the original size of the read bytes
+	stored         int64    // the size of the returned bytes
+	compressed     int      // the number of the chunks stored compressed
+	out            []byte   // the compressed bytes that are not read
+	plain          []byte
+	frame          []byte
+	done           bool
+}
+
+func (r *compressReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(r.src, r.plain)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return 0, err
+		}
+		if n == 0 {
+			r.out, r.done = r.trailer(), true
+			break
+		}
+		r.out = r.compress(r.plain[:n])
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	r.stored += int64(n)
+	return n, nil
+}
+
+// compress returns the frame of the chunk, the chunk is stored uncompressed if compression does not
+// save minSavingRatio of its size.
+func (r *compressReader) compress(plain []byte) []byte {
+	frame := r.encoder.EncodeAll(plain, append(r.frame[:0], 0, 0, 0, 0))
+	header := uint32(len(frame) - 4)
+	if r.minSavingRatio > 0 && float64(len(frame)-4) > float64(len(plain))*(1-r.minSavingRatio) {
+		frame = append(frame[:4], plain...)
+		header = uint32(len(plain)) | compressFrameRawFlag
+	} else {
+		r.compressed++
+	}
+	binary.BigEndian.PutUint32(frame, header)
+	r.frames = append(r.frames, header)
+	r.size += int64(len(plain))
+	r.frame = frame
+	return frame
+}
+
+// trailer returns the end frame and the trailer.
+func (r *compressReader) trailer() []byte {
+	raw := make([]byte, 4, 4+compressTrailerFixedSize+4*len(r.frames))
+	raw = binary.BigEndian.AppendUint64(raw, uint64(r.size))
+	raw = binary.BigEndian.AppendUint32(raw, uint32(len(r.frames)))
+	for _, frame := range r.frames {
+		raw = binary.BigEndian.AppendUint32(raw, frame)
+	}
+	raw = binary.BigEndian.AppendUint32(raw, crc32.Checksum(raw[4:], crc32cTable))
+	// the trailer length includes the length field and excludes the end frame
+	return binary.BigEndian.AppendUint32(raw, uint32(len(raw[4:])+4))
+}
+
+// decompressReader reads the compressed chunks from src and returns the decompressed bytes.
+type decompressReader struct {
+	io.Closer
+	src     io.Reader
+	decoder *zstd.Decoder
+	header  *compressHeader
+	index   int64  // the index of the next chunk
+	skip    int64  // the bytes to skip in the first chunk
+	remain  int64  // the bytes to read, -1 means reading to the end
+	size    int64  // the decompressed size of the read chunks to verify the trailer
+	short   bool   // whether the read chunk is shorter than the chunk size, only the last chunk can be short
+	done    bool   // whether all the chunks are read
+	buf     []byte // the decompressed bytes that are not read
+	chunk   []byte
+	plain   []byte
+}
+
+func (s *compressStore) newDecompressReader(header *compressHeader, src io.Reader, c io.Closer, first, offset,
+	limit int64) io.ReadCloser {
+	remain := int64(-1)
+	if limit > 0 {
+		remain = limit
+	}
+	return &decompressReader{Closer: c, src: src, decoder: s.decoder, header: header, index: first,
+		skip: offset - first*header.chunkSize, remain: remain}
+}
+
+func (r *decompressReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.remain == 0 || r.done {
+			return 0, io.EOF
+		}
+		if err := r.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+	if r.remain >= 0 && int64(len(r.buf)) > r.remain {
+		r.buf = r.buf[:r.remain]
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	if r.remain > 0 {
+		r.remain -= int64(n)
+	}
+	return n, nil
+}
+
+func (r *decompressReader) readChunk() error {
+	if r.header.offsets != nil && r.index >= int64(len(r.header.offsets)-1) {
+		r.done = true
+		return nil
+	}
+	length, raw := int64(0), false
+	if r.header.version == compressHeaderIndexVersion {
+		length = r.header.offsets[r.index+1] - r.header.offsets[r.index]
+	} else {
+		var buf [4]byte
+		if _, err := io.ReadFull(r.src, buf[:]); err != nil {
+			return err
+		}
+		frame := binary.BigEndian.Uint32(buf[:])
+		if frame == 0 && r.header.offsets == nil {
+			r.done = true
+			return r.readTrailer()
+		}
+		if r.header.offsets != nil && frame != r.header.frames[r.index] {
+			return fmt.Errorf("compression frame %d mismatch", r.index)
+		}
+		length, raw = int64(frame&^compressFrameRawFlag), frame&compressFrameRawFlag != 0
+		if length == 0 || length > 2*r.header.chunkSize {
+			return fmt.Errorf("invalid compression frame %d", r.index)
+		}
+	}
+	if int64(cap(r.chunk)) < length {
+		r.chunk = make([]byte, length)
+	}
+	if _, err := io.ReadFull(r.src, r.chunk[:length]); err != nil {
+		return err
+	}
+	plain := r.chunk[:length]
+	if !raw {
+		var err error
+		if plain, err = r.decoder.DecodeAll(r.chunk[:length], r.plain[:0]); err != nil {
+			return fmt.Errorf("failed to decompress chunk %d: %s", r.index, err)
+		}
+		r.plain = plain
+	}
+	if r.header.offsets != nil {
+		expected := r.header.size - r.index*r.header.chunkSize
+		if expected > r.header.chunkSize {
+			expected = r.header.chunkSize
+		}
+		if int64(len(plain)) != expected {
+			return fmt.Errorf("decompressed chunk %d size %d mismatch, expected %d", r.index, len(plain), expected)
+		}
+	} else if r.short || int64(len(plain)) > r.header.chunkSize {
+		// the size of the streamed chunks is verified by the trailer, only the last chunk can be short
+		return fmt.Errorf("decompressed chunk %d size %d mismatch", r.index, len(plain))
+	}
+	r.short = int64(len(plain)) < r.header.chunkSize
+	r.size += int64(len(plain))
+	if r.skip > int64(len(plain)) {
+		r.skip = int64(len(plain))
+	}
+	r.buf, r.skip = plain[r.skip:], 0
+	r.index++
+	return nil
+}
+
+// readTrailer reads the trailer after the end frame and verifies the size and the count of the read chunks.
+func (r *decompressReader) readTrailer() error {
+	raw := make([]byte, 12)
+	if _, err := io.ReadFull(r.src, raw); err != nil {
+		return err
+	}
+	if count := int64(binary.BigEndian.Uint32(raw[8:])); count != r.index {
+		return fmt.Errorf("compression trailer chunk count %d mismatch, read %d", count, r.index)
+	}
+	raw = append(raw, make([]byte, compressTrailerFixedSize-12+4*r.index)...)
+	if _, err := io.ReadFull(r.src, raw[12:]); err != nil {
+		return err
+	}
+	size, _, err := decodeCompressTrailer(raw, r.header.chunkSize)
+	if err != nil {
+		return err
+	}
+	if size != r.size {
+		return fmt.Errorf("compression trailer size %d mismatch, read %d", size, r.size)
+	}
+	return nil
+}
+
+// lazyReader opens the reader when it is read at the first time.
+type lazyReader struct {
+	open func() (io.ReadCloser, error)
+	rc   io.ReadCloser
+}
+
+func (r *lazyReader) Read(p []byte) (int, error) {
+	if r.rc == nil {
+		rc, err := r.open()
+		if err != nil {
+			return 0, err
+		}
+		r.rc = rc
+	}
+	return r.rc.Read(p)
+}
+
+func (r *lazyReader) Close() error {
+	if r.rc == nil {
+		return nil
+	}
+	return r.rc.Close()
+}
@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupCompressTest(t *testing.T) (*compressStore, *memoryStore) {
+	backend := &memoryStore{name: mockBucket, objects: make(map[string]*memoryObject)}
+	store, err := NewCompressStore(backend, CompressionConfig{})
+	assert.Nil(t, err)
+	return store.(*compressStore), backend
+}
+
+func readRange(t *testing.T, store ObjectStorage, key string, offset, limit int64) []byte {
+	rc, err := store.GetObject(context.TODO(), key, offset, limit)
+	assert.Nil(t, err)
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	assert.Nil(t, err)
+	return data
+}
+
+func TestCompress_PutAndGet(t *testing.T) {
+	store, backend := setupCompressTest(t)
+	random := make([]byte, 2*compressChunkSize+10)
+	_, _ = rand.Read(random)
+	cases := map[string][]byte{
+		"empty_s0":  {},
+		"small_s0":  []byte(`{"level":"info","msg":"small"}`),
+		"log_s0":    []byte(strings.Repeat(`{"level":"info","msg":"compress me"}`+"\n", 10000)),
+		"random_s0": random,
+	}
+	for key, data := range cases {
+		assert.Nil(t, store.PutObject(context.TODO(), key, bytes.NewReader(data)))
+		obj, err := store.HeadObject(context.TODO(), key)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(len(data)), obj.Size())
+
+		size := int64(len(data))
+		ranges := [][2]int64{{0, -1}, {0, 10}, {3, 100}, {compressChunkSize - 5, 10}, {compressChunkSize, -1},
+			{2*compressChunkSize + 3, compressChunkSize}, {size + 10, 10}}
+		for _, r := range ranges {
+			start, end := r[0], size
+			if start > end {
+				start = end
+			}
+			if r[1] > 0 && start+r[1] < end {
+				end = start + r[1]
+			}
+			assert.Equal(t, data[start:end], readRange(t, store, key, r[0], r[1]), "key %s range %v", key, r)
+		}
+	}
+	// the compressible piece is stored compressed and the random piece is stored uncompressed
+	assert.True(t, len(backend.objects["log_s0"].data) < len(cases["log_s0"])/10)
+	assert.True(t, len(backend.objects["random_s0"].data) > len(random))
+}
+
+// rangeRecorder records the bytes requested from the backend storage.
+type rangeRecorder struct {
+	ObjectStorage
+	requested int64
+}
+
+func (r *rangeRecorder) GetObject(ctx context.Context, key string, offset, limit int64) (io.ReadCloser, error) {
+	obj, err := r.ObjectStorage.HeadObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || offset+limit > obj.Size() {
+		limit = obj.Size() - offset
+	}
+	r.requested += limit
+	return r.ObjectStorage.GetObject(ctx, key, offset, limit)
+}
+
+func TestCompress_RangeRead(t *testing.T) {
+	random := make([]byte, 8*compressChunkSize)
+	_, _ = rand.Read(random)
+	backend := &rangeRecorder{ObjectStorage: &memoryStore{name: mockBucket, objects: make(map[string]*memoryObject)}}
+	zero := float64(0)
+	store, err := NewCompressStore(backend, CompressionConfig{MinSavingRatio: &zero})
+	assert.Nil(t, err)
+	assert.Nil(t, store.PutObject(context.TODO(), "random_s0", bytes.NewReader(random)))
+	// the incompressible piece is kept compressed if MinSavingRatio is 0
+	header, err := store.(*compressStore).readHeader(context.TODO(), "random_s0")
+	assert.Nil(t, err)
+	assert.Equal(t, byte(compressCodecZstd), header.codec)
+	for _, frame := range header.frames {
+		assert.Zero(t, frame&compressFrameRawFlag)
+	}
+
+	// the range read at the beginning only requests the header and the first chunk
+	backend.requested = 0
+	assert.Equal(t, random[10:110], readRange(t, store, "random_s0", 10, 100))
+	assert.True(t, backend.requested < 2*compressChunkSize+compressHeaderPrefetchSize)
+}
+
+func TestCompress_Coexist(t *testing.T) {
+	store, backend := setupCompressTest(t)
+	// the piece is written before compression is enabled
+	legacy := []byte(strings.Repeat("legacy piece", 10000))
+	backend.objects["legacy_s0"] = &memoryObject{data: legacy}
+	assert.Equal(t, legacy, readRange(t, store, "legacy_s0", 0, -1))
+	assert.Equal(t, legacy[compressChunkSize+1:compressChunkSize+11], readRange(t, store, "legacy_s0", compressChunkSize+1, 10))
+	obj, err := store.HeadObject(context.TODO(), "legacy_s0")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(legacy)), obj.Size())
+
+	// the corrupted header is not read as the uncompressed piece
+	assert.Nil(t, store.PutObject(context.TODO(), "log_s0", bytes.NewReader(legacy)))
+	backend.objects["log_s0"].data[compressPrefixSize-1] ^= 1
+	_, err = store.GetObject(context.TODO(), "log_s0", 0, -1)
+	assert.NotNil(t, err)
+	_, err = store.HeadObject(context.TODO(), "log_s0")
+	assert.NotNil(t, err)
+}
+
+func TestCompress_CorruptedTrailer(t *testing.T) {
+	store, backend := setupCompressTest(t)
+	data := []byte(strings.Repeat("compress me", 20000))
+	assert.Nil(t, store.PutObject(context.TODO(), "log_s0", bytes.NewReader(data)))
+	stored := backend.objects["log_s0"].data
+	stored[len(stored)-10] ^= 1
+
+	_, err := store.HeadObject(context.TODO(), "log_s0")
+	assert.NotNil(t, err)
+	_, err = store.GetObject(context.TODO(), "log_s0", compressChunkSize, 10)
+	assert.NotNil(t, err)
+	// the full read streams the chunks and fails when the trailer is verified
+	rc, err := store.GetObject(context.TODO(), "log_s0", 0, -1)
+	assert.Nil(t, err)
+	_, err = io.ReadAll(rc)
+	assert.NotNil(t, err)
+
+	// the truncated piece fails before the end frame
+	backend.objects["log_s0"].data = stored[:len(stored)/2]
+	rc, err = store.GetObject(context.TODO(), "log_s0", 0, -1)
+	assert.Nil(t, err)
+	_, err = io.ReadAll(rc)
+	assert.NotNil(t, err)
+}
+
+// putHeaderIndexObject writes the piece by the version 1 layout that the chunk lengths are in the header.
+func putHeaderIndexObject(store *compressStore, backend *memoryStore, key string, data []byte) {
+	var chunks []byte
+	var lengths []byte
+	for start := 0; start < len(data); start += compressChunkSize {
+		end := start + compressChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		n := len(chunks)
+		chunks = store.encoder.EncodeAll(data[start:end], chunks)
+		lengths = binary.BigEndian.AppendUint32(lengths, uint32(len(chunks)-n))
+	}
+	raw := append([]byte{}, compressMagic...)
+	raw = append(raw, compressHeaderIndexVersion, compressCodecZstd, 0, 0)
+	raw = binary.BigEndian.AppendUint32(raw, compressChunkSize)
+	raw = binary.BigEndian.AppendUint32(raw, uint32(len(lengths)/4))
+	raw = binary.BigEndian.AppendUint64(raw, uint64(len(data)))
+	raw = append(raw, lengths...)
+	raw = binary.BigEndian.AppendUint32(raw, crc32.Checksum(raw, crc32cTable))
+	backend.objects[key] = &memoryObject{data: append(raw, chunks...)}
+}
+
+func TestCompress_HeaderIndexVersion(t *testing.T) {
+	store, backend := setupCompressTest(t)
+	// the piece is written by the version 1 layout before the trailer is introduced
+	data := []byte(strings.Repeat(`{"level":"info","msg":"version 1"}`+"\n", 10000))
+	putHeaderIndexObject(store, backend, "v1_s0", data)
+	assert.Equal(t, data, readRange(t, store, "v1_s0", 0, -1))
+	assert.Equal(t, data[compressChunkSize+1:compressChunkSize+11], readRange(t, store, "v1_s0", compressChunkSize+1, 10))
+	obj, err := store.HeadObject(context.TODO(), "v1_s0")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(data)), obj.Size())
+}
+
+func TestCompress_LargeTrailer(t *testing.T) {
+	store, _ := setupCompressTest(t)
+	// the trailer of the piece with many chunks exceeds the prefetch size
+	chunks := compressHeaderPrefetchSize/4 + 10
+	var buf bytes.Buffer
+	for i := 0; i < chunks; i++ {
+		buf.WriteString(strings.Repeat(fmt.Sprintf("%08d", i), compressChunkSize/8))
+	}
+	data := buf.Bytes()
+	assert.Nil(t, store.PutObject(context.TODO(), "large_s0", bytes.NewReader(data)))
+	offset := int64(chunks-1) * compressChunkSize
+	assert.Equal(t, data[offset+10:offset+20], readRange(t, store, "large_s0", offset+10, 10))
+}
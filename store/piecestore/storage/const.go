@@ -1,5 +1,7 @@
 package storage
 
+import "time"
+
 // define storage type constants
 const (
 	// S3Store defines storage type for s3
@@ -14,6 +16,38 @@ const (
 	MemoryStore = "memory"
 )
 
+// define disk file fsync policy constants
+const (
+	// FsyncNone defines the fsync policy that the written files are not synced, the data is
+	// flushed by the os in background
+	FsyncNone = "none"
+	// FsyncFile defines the fsync policy that the written file is synced before it is renamed
+	FsyncFile = "file"
+	// FsyncAll defines the fsync policy that both the written file and its parent directory
+	// are synced, the rename is durable after PutObject returns
+	FsyncAll = "all"
+)
+
+// define fault injection operation constants
+const (
+	// FaultOpGet defines the operation of getting the object
+	FaultOpGet = "get"
+	// FaultOpPut defines the operation of putting the object
+	FaultOpPut = "put"
+	// FaultOpDelete defines the operation of deleting the objects
+	FaultOpDelete = "delete"
+	// FaultOpHead defines the operation of heading the object
+	FaultOpHead = "head"
+	// FaultOpList defines the operation of listing the objects
+	FaultOpList = "list"
+)
+
+// define key provider type constants
+const (
+	// LocalKeyProvider defines key provider type which loads the master keys from the local key file
+	LocalKeyProvider = "local"
+)
+
 // piece store storage config and environment constants
 const (
 	// AKSKIAMType defines IAM type config which uses access key and secret key to access aws s3
@@ -55,6 +89,31 @@ const (
 	OctetStream = "application/octet-stream"
 )
 
+// define batch delete constants.
+const (
+	// s3MaxDeleteObjects defines the max number of the objects that are deleted in one s3 request
+	s3MaxDeleteObjects = 1000
+	// diskFileDeleteConcurrency defines the max number of the files that are unlinked in parallel
+	diskFileDeleteConcurrency = 16
+)
+
+// define disk file constants.
+const (
+	// DefaultDiskFileFsyncPolicy defines the default fsync policy of the disk file storage
+	DefaultDiskFileFsyncPolicy = FsyncFile
+	// diskFileChecksumSuffix defines the name suffix of the sidecar checksum file
+	diskFileChecksumSuffix = ".sum"
+	// diskFileChecksumBlockSize defines the size of the block whose crc32c is stored in the sidecar
+	diskFileChecksumBlockSize = 64 * 1024
+	// diskFileChecksumHeaderSize defines the size of the sidecar header, file size and block size
+	diskFileChecksumHeaderSize = 8 + 4
+	// diskFileOrphanExpire defines the age that the temporary files and the sidecars without data
+	// files are removed, the files of the in-flight writes of other processes are kept
+	diskFileOrphanExpire = time.Hour
+	// DefaultOrphanRemoveInterval defines the default seconds interval of removing the orphan files
+	DefaultOrphanRemoveInterval = 3600
+)
+
 // define piece store constants.
 const (
 	// BufPoolSize define buffer pool size
@@ -62,3 +121,93 @@ const (
 	// ChecksumAlgo define validation algorithm name
 	ChecksumAlgo = "Crc32c"
 )
+
+// define pack constants.
+const (
+	// PackKeyPrefix defines the key prefix of the pack files in object storage
+	PackKeyPrefix = "pack/"
+	// DefaultPackMaxPieceSize defines the default max size of the packed piece
+	DefaultPackMaxPieceSize = 128 * 1024
+	// DefaultPackMaxPackSize defines the default max size of the pack file
+	DefaultPackMaxPackSize = 64 * 1024 * 1024
+	// DefaultPackFlushInterval defines the default max milliseconds that a packed piece waits for flushing
+	DefaultPackFlushInterval = 100
+	// DefaultPackCompactLiveRatio defines the default live size ratio that the pack is compacted
+	DefaultPackCompactLiveRatio = 0.5
+	// DefaultPackCompactInterval defines the default seconds interval of compacting the packs
+	DefaultPackCompactInterval = 3600
+	// packCompactBatch defines the max number of packs that are compacted in one round
+	packCompactBatch = 16
+)
+
+// define dedup constants.
+const (
+	// DedupKeyPrefix defines the key prefix of the deduplicated contents in object storage
+	DedupKeyPrefix = "dedup/"
+)
+
+// define encryption constants.
+const (
+	// encryptVersion defines the version of the encrypted object layout
+	encryptVersion = 1
+	// encryptHeaderSize defines the size of the encryption header, the header is padded to it
+	encryptHeaderSize = 512
+	// encryptHeaderFixedSize defines the size of the fixed fields in the encryption header
+	encryptHeaderFixedSize = 4 + 1 + encryptNonceSize + 1 + 2
+	// encryptChunkSize defines the size of the plaintext chunk that is sealed separately
+	encryptChunkSize = 64 * 1024
+	// encryptTagSize defines the size of the AES-GCM tag appended to each sealed chunk
+	encryptTagSize = 16
+	// encryptSealedChunkSize defines the size of the sealed chunk except the final one
+	encryptSealedChunkSize = encryptChunkSize + encryptTagSize
+	// encryptNonceSize defines the size of the AES-GCM nonce
+	encryptNonceSize = 12
+	// encryptKeySize defines the size of the AES-256 key
+	encryptKeySize = 32
+	// maxEncryptKeyIDLength defines the max length of the master key id
+	maxEncryptKeyIDLength = 64
+)
+
+// define compression constants.
+const (
+	// DefaultCompressLevel defines the default zstd compression level
+	DefaultCompressLevel = 3
+	// DefaultCompressMinSavingRatio defines the default min saving ratio to store the compressed chunk
+	DefaultCompressMinSavingRatio = 0.1
+	// compressHeaderIndexVersion defines the version of the layout that the chunk lengths are in the header
+	compressHeaderIndexVersion = 1
+	// compressTrailerIndexVersion defines the version of the layout that the chunk lengths are in the trailer
+	compressTrailerIndexVersion = 2
+	// compressCodecNone defines the codec that the object is stored uncompressed
+	compressCodecNone = 0
+	// compressCodecZstd defines the codec that the object is compressed by zstd
+	compressCodecZstd = 1
+	// compressHeaderFixedSize defines the size of the fixed fields in the compression header
+	compressHeaderFixedSize = 24
+	// compressPrefixSize defines the size of the compression prefix of the trailer index layout
+	compressPrefixSize = 16
+	// compressTrailerFixedSize defines the size of the fixed fields in the compression trailer
+	compressTrailerFixedSize = 20
+	// compressFrameRawFlag defines the flag of the frame header that the chunk is stored uncompressed
+	compressFrameRawFlag = 1 << 31
+	// compressHeaderPrefetchSize defines the size of the first read of the compression header
+	compressHeaderPrefetchSize = 4096
+	// compressChunkSize defines the size of the chunk that is compressed separately
+	compressChunkSize = 64 * 1024
+	// maxCompressChunkSize defines the max chunk size of the compressed object that can be read
+	maxCompressChunkSize = 16 * 1024 * 1024
+)
+
+// define tiering constants.
+const (
+	// DefaultTierColdAfterDays defines the default days that the object is not read before demoted to the cold storage
+	DefaultTierColdAfterDays = 90
+	// DefaultTierPromoteReadCount defines the default read times in the promote window that the cold object is promoted
+	DefaultTierPromoteReadCount = 3
+	// DefaultTierPromoteWindowDays defines the default days of the promote window
+	DefaultTierPromoteWindowDays = 7
+	// DefaultTierInterval defines the default seconds interval of scanning the objects to tier
+	DefaultTierInterval = 3600
+	// DefaultTierBatchSize defines the default max number of the objects to tier in each scan
+	DefaultTierBatchSize = 100
+)
@@ -0,0 +1,262 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"regexp"
+	"sync/atomic"
+	"time"
+
+	"github.com/bnb-chain/greenfield-storage-provider/core/spdb"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+)
+
+// segmentPieceKeyRegexp matches the segment piece key, e.g. 100_s2.
+var segmentPieceKeyRegexp = regexp.MustCompile(`^\d+_s\d+$`)
+
+// dedupStore stores the segment pieces of the same data once. The data of the segment piece
+// is streamed to a new object while its checksum is computed, the same checksum that is signed
+// as the integrity hash of the object, and the index maps the piece key to the content keyed
+// by the checksum and counts the references of the content. The new object becomes the data of
+// the content if the content does not exist, otherwise it is deleted. The content is deleted from the
+// backend storage when the last piece referencing it is deleted, the index locks the content
+// while it is deleted so that the processes sharing the index do not lose the content that is
+// referenced again concurrently. The other pieces, e.g. the
+// ec pieces, are stored by the underlying ObjectStorage directly.
+type dedupStore struct {
+	ObjectStorage
+	index spdb.DedupDB
+	// shadowSwept is set after the raw pieces shadowed by the deduplicated ones are removed
+	shadowSwept atomic.Bool
+}
+
+// NewDedupStore returns an ObjectStorage that deduplicates the segment pieces by checksum
+// in the underlying ObjectStorage, and the references of the contents are stored in index.
+func NewDedupStore(store ObjectStorage, index spdb.DedupDB, cfg DedupConfig) (ObjectStorage, error) {
+	if index == nil {
+		return nil, fmt.Errorf("dedup index is required to deduplicate pieces")
+	}
+	return &dedupStore{ObjectStorage: store, index: index}, nil
+}
+
+func (s *dedupStore) String() string {
+	return fmt.Sprintf("dedup://%s", s.ObjectStorage)
+}
+
+func (s *dedupStore) GetObject(ctx context.Context, key string, offset, limit int64) (io.ReadCloser, error) {
+	if !segmentPieceKeyRegexp.MatchString(key) {
+		return s.ObjectStorage.GetObject(ctx, key, offset, limit)
+	}
+	piece, err := s.index.GetDedupPiece(key)
+	if err != nil {
+		log.Errorw("failed to get dedup piece", "key", key, "error", err)
+		return nil, err
+	}
+	if piece == nil {
+		return s.ObjectStorage.GetObject(ctx, key, offset, limit)
+	}
+	return s.ObjectStorage.GetObject(ctx, piece.ObjectKey, offset, limit)
+}
+
+func (s *dedupStore) PutObject(ctx context.Context, key string, reader io.Reader) error {
+	if !segmentPieceKeyRegexp.MatchString(key) {
+		return s.ObjectStorage.PutObject(ctx, key, reader)
+	}
+	// the raw piece put before dedup is enabled is shadowed by the index if it is overwritten,
+	// it is removed by RemoveOrphanFiles instead of deleted by every put.
+	objectKey := fmt.Sprintf("%s%s_%d", DedupKeyPrefix, key, time.Now().UnixNano())
+	hr := &hashReader{reader: reader, hasher: sha256.New()}
+	if err := s.ObjectStorage.PutObject(ctx, objectKey, hr); err != nil {
+		return err
+	}
+	released, err := s.putContent(ctx, key, objectKey, hex.EncodeToString(hr.hasher.Sum(nil)), hr.size)
+	if err != nil {
+		s.deleteObject(ctx, objectKey)
+		return err
+	}
+	if released != "" {
+		s.deleteContent(ctx, released)
+	}
+	return nil
+}
+
+// putContent maps the piece to the content of the checksum whose data is the object if the
+// content does not exist, returns the previous content of the piece if it is released.
+func (s *dedupStore) putContent(ctx context.Context, key, objectKey, checksum string, size int64) (string, error) {
+	contentKey := DedupKeyPrefix + checksum
+	content, err := s.index.GetDedupContent(contentKey)
+	if err != nil {
+		log.Errorw("failed to get dedup content", "key", key, "content_key", contentKey, "error", err)
+		return "", err
+	}
+	created, released, err := s.index.InsertDedupPiece(&spdb.DedupPiece{
+		PieceKey:   key,
+		ContentKey: contentKey,
+		ObjectKey:  objectKey,
+		Size:       size,
+	})
+	if err != nil {
+		log.Errorw("failed to insert dedup piece", "key", key, "content_key", contentKey, "error", err)
+		return "", err
+	}
+	switch {
+	case !created:
+		// the data of the content exists, the object is a duplicate.
+		s.deleteObject(ctx, objectKey)
+	case content != nil && content.ObjectKey != objectKey:
+		// the content that is no longer referenced is created again with the object, the data
+		// of the released content is deleted if it is not yet.
+		s.deleteObject(ctx, content.ObjectKey)
+	}
+	return released, nil
+}
+
+// deleteObject deletes the object that is not referenced by any content, the failure only
+// leaves the orphan object.
+func (s *dedupStore) deleteObject(ctx context.Context, objectKey string) {
+	if err := s.ObjectStorage.DeleteObject(ctx, objectKey); err != nil {
+		log.Warnw("failed to delete the unreferenced dedup object", "object_key", objectKey, "error", err)
+	}
+}
+
+func (s *dedupStore) DeleteObject(ctx context.Context, key string) error {
+	if !segmentPieceKeyRegexp.MatchString(key) {
+		return s.ObjectStorage.DeleteObject(ctx, key)
+	}
+	found, released, err := s.index.DeleteDedupPiece(key)
+	if err != nil {
+		log.Errorw("failed to delete dedup piece", "key", key, "error", err)
+		return err
+	}
+	if !found {
+		return s.ObjectStorage.DeleteObject(ctx, key)
+	}
+	if released != "" {
+		s.deleteContent(ctx, released)
+	}
+	return nil
+}
+
+// DeleteObjects decreases the references of the deduplicated pieces, and deletes the other
+// pieces and the released contents from the underlying ObjectStorage in batch.
+func (s *dedupStore) DeleteObjects(ctx context.Context, keys []string) map[string]error {
+	var (
+		failed   = make(map[string]error)
+		raw      []string
+		released []string
+	)
+	for _, key := range keys {
+		if !segmentPieceKeyRegexp.MatchString(key) {
+			raw = append(raw, key)
+			continue
+		}
+		found, contentKey, err := s.index.DeleteDedupPiece(key)
+		if err != nil {
+			log.Errorw("failed to delete dedup piece", "key", key, "error", err)
+			failed[key] = err
+		} else if !found {
+			raw = append(raw, key)
+		} else if contentKey != "" {
+			released = append(released, contentKey)
+		}
+	}
+	if len(raw) > 0 {
+		for key, err := range s.ObjectStorage.DeleteObjects(ctx, raw) {
+			failed[key] = err
+		}
+	}
+	for _, contentKey := range released {
+		s.deleteContent(ctx, contentKey)
+	}
+	return failed
+}
+
+func (s *dedupStore) HeadObject(ctx context.Context, key string) (Object, error) {
+	if !segmentPieceKeyRegexp.MatchString(key) {
+		return s.ObjectStorage.HeadObject(ctx, key)
+	}
+	piece, err := s.index.GetDedupPiece(key)
+	if err != nil {
+		log.Errorw("failed to get dedup piece", "key", key, "error", err)
+		return nil, err
+	}
+	if piece == nil {
+		return s.ObjectStorage.HeadObject(ctx, key)
+	}
+	return &object{key, piece.Size, time.Time{}, false}, nil
+}
+
+// deleteContent deletes the content that is no longer referenced from the backend storage,
+// the content is kept if it is referenced again after it is released.
+func (s *dedupStore) deleteContent(ctx context.Context, contentKey string) {
+	_, err := s.index.ReleaseDedupContent(contentKey, func(objectKey string) error {
+		return s.ObjectStorage.DeleteObject(ctx, objectKey)
+	})
+	if err != nil {
+		log.Errorw("failed to delete the released dedup content", "content_key", contentKey, "error", err)
+	}
+}
+
+// RemoveOrphanFiles removes the raw segment pieces that are overwritten by the deduplicated
+// ones, they are left by the pieces put before dedup is enabled. The pieces are removed once
+// by the process, and the orphan files of the underlying ObjectStorage are removed by its own
+// remover.
+func (s *dedupStore) RemoveOrphanFiles(ctx context.Context) {
+	if s.shadowSwept.Load() {
+		return
+	}
+	// the listing is canceled if the removal stops halfway
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	objects, err := s.ObjectStorage.ListAllObjects(ctx, "", "")
+	if err != nil {
+		log.Errorw("failed to list objects to remove shadowed pieces", "error", err)
+		return
+	}
+	var removed int
+	for obj := range objects {
+		if obj == nil {
+			log.Errorw("failed to list all objects to remove shadowed pieces")
+			return
+		}
+		if !segmentPieceKeyRegexp.MatchString(obj.Key()) {
+			continue
+		}
+		piece, err := s.index.GetDedupPiece(obj.Key())
+		if err != nil {
+			log.Errorw("failed to get dedup piece", "key", obj.Key(), "error", err)
+			return
+		}
+		if piece == nil {
+			continue
+		}
+		if err = s.ObjectStorage.DeleteObject(ctx, obj.Key()); err != nil {
+			log.Errorw("failed to delete shadowed raw piece", "key", obj.Key(), "error", err)
+			return
+		}
+		removed++
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	s.shadowSwept.Store(true)
+	log.Infow("succeed to remove shadowed raw pieces", "count", removed)
+}
+
+// hashReader computes the checksum and the size of the data read through it.
+type hashReader struct {
+	reader io.Reader
+	hasher hash.Hash
+	size   int64
+}
+
+func (r *hashReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.hasher.Write(p[:n])
+	r.size += int64(n)
+	return n, err
+}
@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/greenfield-storage-provider/core/spdb"
+)
+
+// mockDedupIndex is the memory implementation of spdb.DedupDB that keeps the same semantics with sqldb.
+type mockDedupIndex struct {
+	mux      sync.Mutex
+	pieces   map[string]*spdb.DedupPiece
+	contents map[string]*spdb.DedupContent
+}
+
+func newMockDedupIndex() *mockDedupIndex {
+	return &mockDedupIndex{pieces: make(map[string]*spdb.DedupPiece), contents: make(map[string]*spdb.DedupContent)}
+}
+
+func (m *mockDedupIndex) GetDedupPiece(pieceKey string) (*spdb.DedupPiece, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	piece, ok := m.pieces[pieceKey]
+	if !ok {
+		return nil, nil
+	}
+	return &spdb.DedupPiece{PieceKey: piece.PieceKey, ContentKey: piece.ContentKey,
+		ObjectKey: m.contents[piece.ContentKey].ObjectKey, Size: piece.Size}, nil
+}
+
+func (m *mockDedupIndex) GetDedupContent(contentKey string) (*spdb.DedupContent, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	content, ok := m.contents[contentKey]
+	if !ok {
+		return nil, nil
+	}
+	copied := *content
+	return &copied, nil
+}
+
+func (m *mockDedupIndex) InsertDedupPiece(piece *spdb.DedupPiece) (bool, string, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	prev, ok := m.pieces[piece.PieceKey]
+	if ok && prev.ContentKey == piece.ContentKey {
+		return false, "", nil
+	}
+	content, created := m.contents[piece.ContentKey], false
+	if content == nil {
+		content = &spdb.DedupContent{ContentKey: piece.ContentKey}
+		m.contents[piece.ContentKey] = content
+	}
+	if content.RefCount <= 0 {
+		content.ObjectKey, content.Size, created = piece.ObjectKey, piece.Size, true
+	}
+	content.RefCount++
+	m.pieces[piece.PieceKey] = piece
+	if !ok {
+		return created, "", nil
+	}
+	return created, m.release(prev.ContentKey), nil
+}
+
+func (m *mockDedupIndex) DeleteDedupPiece(pieceKey string) (bool, string, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	prev, ok := m.pieces[pieceKey]
+	if !ok {
+		return false, "", nil
+	}
+	delete(m.pieces, pieceKey)
+	return true, m.release(prev.ContentKey), nil
+}
+
+func (m *mockDedupIndex) ReleaseDedupContent(contentKey string, deleteFn func(objectKey string) error) (bool, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	content, ok := m.contents[contentKey]
+	if !ok || content.RefCount > 0 {
+		return false, nil
+	}
+	if err := deleteFn(content.ObjectKey); err != nil {
+		return false, err
+	}
+	delete(m.contents, contentKey)
+	return true, nil
+}
+
+func (m *mockDedupIndex) release(contentKey string) string {
+	m.contents[contentKey].RefCount--
+	if m.contents[contentKey].RefCount > 0 {
+		return ""
+	}
+	return contentKey
+}
+
+func setupDedupTest(t *testing.T) (ObjectStorage, *memoryStore, *mockDedupIndex) {
+	backend := &memoryStore{name: mockBucket, objects: make(map[string]*memoryObject)}
+	index := newMockDedupIndex()
+	store, err := NewDedupStore(backend, index, DedupConfig{Enable: true})
+	assert.Nil(t, err)
+	return store, backend, index
+}
+
+func TestDedup_PutAndGet(t *testing.T) {
+	store, backend, index := setupDedupTest(t)
+	ctx := context.TODO()
+	assert.Nil(t, store.PutObject(ctx, "1_s0", strings.NewReader("segment")))
+	assert.Nil(t, store.PutObject(ctx, "2_s0", strings.NewReader("segment")))
+	assert.Nil(t, store.PutObject(ctx, "2_s1", strings.NewReader("another segment")))
+	assert.Nil(t, store.PutObject(ctx, "1_s0_p0", strings.NewReader("segment")))
+
+	// the same segments are stored once and the ec piece is stored as the single object
+	assert.Equal(t, 3, len(backend.objects))
+	_, ok := backend.objects["1_s0_p0"]
+	assert.True(t, ok)
+	piece, _ := index.GetDedupPiece("1_s0")
+	assert.True(t, strings.HasPrefix(piece.ContentKey, DedupKeyPrefix))
+	content, _ := index.GetDedupContent(piece.ContentKey)
+	assert.Equal(t, int64(2), content.RefCount)
+
+	assert.Equal(t, "segment", readPiece(t, store, "2_s0", 0, -1))
+	assert.Equal(t, "gme", readPiece(t, store, "1_s0", 2, 3))
+	assert.Equal(t, "another segment", readPiece(t, store, "2_s1", 0, -1))
+	obj, err := store.HeadObject(ctx, "2_s1")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len("another segment")), obj.Size())
+	assert.Equal(t, "2_s1", obj.Key())
+
+	// the piece stored before dedup is enabled is still readable
+	assert.Nil(t, backend.PutObject(ctx, "3_s0", strings.NewReader("legacy")))
+	assert.Equal(t, "legacy", readPiece(t, store, "3_s0", 0, -1))
+	assert.Nil(t, store.DeleteObject(ctx, "3_s0"))
+	_, ok = backend.objects["3_s0"]
+	assert.False(t, ok)
+
+	// the piece stored before dedup is enabled is shadowed after it is overwritten, and it is
+	// removed with the orphan files
+	assert.Nil(t, backend.PutObject(ctx, "4_s0", strings.NewReader("legacy")))
+	assert.Nil(t, store.PutObject(ctx, "4_s0", strings.NewReader("segment")))
+	_, ok = backend.objects["4_s0"]
+	assert.True(t, ok)
+	assert.Equal(t, "segment", readPiece(t, store, "4_s0", 0, -1))
+	store.(OrphanRemover).RemoveOrphanFiles(ctx)
+	_, ok = backend.objects["4_s0"]
+	assert.False(t, ok)
+	assert.Equal(t, "segment", readPiece(t, store, "4_s0", 0, -1))
+}
+
+func TestDedup_DeleteAndOverwrite(t *testing.T) {
+	store, backend, index := setupDedupTest(t)
+	ctx := context.TODO()
+	assert.Nil(t, store.PutObject(ctx, "1_s0", strings.NewReader("segment")))
+	assert.Nil(t, store.PutObject(ctx, "2_s0", strings.NewReader("segment")))
+	piece, _ := index.GetDedupPiece("1_s0")
+	contentKey, objectKey := piece.ContentKey, piece.ObjectKey
+
+	// the content is kept until the last piece referencing it is deleted
+	assert.Nil(t, store.DeleteObject(ctx, "1_s0"))
+	_, err := store.HeadObject(ctx, "1_s0")
+	assert.NotNil(t, err)
+	_, ok := backend.objects[objectKey]
+	assert.True(t, ok)
+	assert.Equal(t, "segment", readPiece(t, store, "2_s0", 0, -1))
+
+	// overwrite the last piece referencing the content, the content is released
+	assert.Nil(t, store.PutObject(ctx, "2_s0", strings.NewReader("new segment")))
+	_, ok = backend.objects[objectKey]
+	assert.False(t, ok)
+	assert.Equal(t, "new segment", readPiece(t, store, "2_s0", 0, -1))
+
+	// the content which is not released yet is created again with the new object, the data of
+	// the released content is deleted
+	content, _ := index.GetDedupContent(contentKey)
+	assert.Nil(t, content)
+	index.contents[contentKey] = &spdb.DedupContent{ContentKey: contentKey, ObjectKey: "dedup/released"}
+	assert.Nil(t, backend.PutObject(ctx, "dedup/released", strings.NewReader("segment")))
+	assert.Nil(t, store.PutObject(ctx, "3_s0", strings.NewReader("segment")))
+	assert.Equal(t, "segment", readPiece(t, store, "3_s0", 0, -1))
+	_, ok = backend.objects["dedup/released"]
+	assert.False(t, ok)
+	assert.Nil(t, store.DeleteObject(ctx, "3_s0"))
+
+	// put the same data again after it is released
+	assert.Nil(t, store.PutObject(ctx, "1_s0", strings.NewReader("segment")))
+	assert.Equal(t, "segment", readPiece(t, store, "1_s0", 0, -1))
+
+	assert.Nil(t, store.DeleteObject(ctx, "1_s0"))
+	assert.Nil(t, store.DeleteObject(ctx, "2_s0"))
+	assert.Equal(t, 0, len(backend.objects))
+	assert.Equal(t, 0, len(index.contents))
+}
+
+func TestDedup_DeleteObjects(t *testing.T) {
+	store, backend, index := setupDedupTest(t)
+	ctx := context.TODO()
+	assert.Nil(t, store.PutObject(ctx, "1_s0", strings.NewReader("segment")))
+	assert.Nil(t, store.PutObject(ctx, "2_s0", strings.NewReader("segment")))
+	assert.Nil(t, store.PutObject(ctx, "1_s0_p0", strings.NewReader("ec piece")))
+
+	failed := store.DeleteObjects(ctx, []string{"1_s0", "1_s0_p0", "3_s0"})
+	assert.Equal(t, 0, len(failed))
+	assert.Equal(t, 1, len(backend.objects))
+	assert.Equal(t, "segment", readPiece(t, store, "2_s0", 0, -1))
+
+	failed = store.DeleteObjects(ctx, []string{"2_s0"})
+	assert.Equal(t, 0, len(failed))
+	assert.Equal(t, 0, len(backend.objects))
+	assert.Equal(t, 0, len(index.contents))
+}
@@ -2,15 +2,23 @@ package storage
 
 import (
 	"bytes"
+	"container/heap"
 	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"hash/crc32"
+	"hash/fnv"
 	"io"
+	"io/fs"
 	"math/rand"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
 )
@@ -20,8 +28,13 @@ const (
 	windowsOS = "windows"
 )
 
+var errChecksumMismatch = errors.New("disk file checksum mismatch")
+
 type diskFileStore struct {
-	root string
+	root        string
+	fsyncPolicy string
+	fanOut      bool
+	checksum    bool
 	DefaultObjectStorage
 }
 
@@ -31,7 +44,20 @@ func newDiskFileStore(cfg ObjectStorageConfig) (ObjectStorage, error) {
 	if runtime.GOOS == windowsOS && strings.HasPrefix(endPoint, "/") {
 		endPoint = endPoint[1:]
 	}
-	return &diskFileStore{root: endPoint}, nil
+	fsyncPolicy := cfg.DiskFile.FsyncPolicy
+	if fsyncPolicy == "" {
+		fsyncPolicy = DefaultDiskFileFsyncPolicy
+	}
+	if fsyncPolicy != FsyncNone && fsyncPolicy != FsyncFile && fsyncPolicy != FsyncAll {
+		return nil, fmt.Errorf("invalid disk file fsync policy: %s", fsyncPolicy)
+	}
+	store := &diskFileStore{
+		root:        endPoint,
+		fsyncPolicy: fsyncPolicy,
+		fanOut:      cfg.DiskFile.FanOut,
+		checksum:    cfg.DiskFile.Checksum,
+	}
+	return store, nil
 }
 
 func (d *diskFileStore) String() string {
@@ -51,7 +77,7 @@ func (d *diskFileStore) CreateBucket(ctx context.Context) error {
 }
 
 func (d *diskFileStore) GetObject(ctx context.Context, key string, offset, limit int64) (io.ReadCloser, error) {
-	p := d.path(key)
+	p := d.locate(key)
 
 	f, err := os.Open(p)
 	if err != nil {
@@ -70,6 +96,17 @@ func (d *diskFileStore) GetObject(ctx context.Context, key string, offset, limit
 		return io.NopCloser(bytes.NewBuffer([]byte{})), nil
 	}
 
+	sums, err := readChecksums(checksumPath(p), info.Size())
+	if err != nil && !os.IsNotExist(err) {
+		_ = f.Close()
+		log.Errorw("failed to get object due to read checksums", "key", key, "error", err)
+		return nil, err
+	}
+	if err == nil {
+		return getVerifiedObject(f, info.Size(), sums, offset, limit)
+	}
+
+	// the file is written without checksums, it is read as is.
 	if offset > 0 {
 		if _, err = f.Seek(offset, 0); err != nil {
 			_ = f.Close()
@@ -89,25 +126,138 @@ func (d *diskFileStore) GetObject(ctx context.Context, key string, offset, limit
 	return f, nil
 }
 
-func (d *diskFileStore) PutObject(ctx context.Context, key string, reader io.Reader) error {
-	p := d.path(key)
+// getVerifiedObject reads the range of the file from the start of the block that the offset
+// is in, every block read is verified by its checksum.
+func getVerifiedObject(f *os.File, size int64, sums []uint32, offset, limit int64) (io.ReadCloser, error) {
+	if offset >= size {
+		_ = f.Close()
+		if limit > 0 {
+			return nil, io.EOF
+		}
+		return io.NopCloser(bytes.NewBuffer([]byte{})), nil
+	}
+	block := offset / diskFileChecksumBlockSize
+	if _, err := f.Seek(block*diskFileChecksumBlockSize, io.SeekStart); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	reader := &diskChecksumReader{
+		f:         f,
+		sums:      sums,
+		block:     int(block),
+		buf:       make([]byte, diskFileChecksumBlockSize),
+		skip:      offset - block*diskFileChecksumBlockSize,
+		remaining: -1,
+	}
+	if limit <= 0 {
+		return reader, nil
+	}
+	defer f.Close()
+	reader.remaining = limit
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		log.Errorw("failed to get object due to read file", "file", f.Name(), "error", err)
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewBuffer(data)), nil
+}
 
+func (d *diskFileStore) PutObject(ctx context.Context, key string, reader io.Reader) error {
 	if strings.HasSuffix(key, dirSuffix) || key == "" && strings.HasSuffix(d.root, dirSuffix) {
-		return os.MkdirAll(p, os.FileMode(0755))
+		return os.MkdirAll(d.path(key), os.FileMode(0755))
+	}
+
+	p := d.writePath(key)
+	var sums *blockChecksums
+	if d.checksum {
+		sums = &blockChecksums{}
+		reader = io.TeeReader(reader, sums)
+	}
+	tmp, err := d.writeTempFile(p, reader)
+	if err != nil {
+		return err
+	}
+	// the sidecar is replaced before the file, a crash between the renames leaves the old
+	// file with the new checksums, it is reported as mismatch instead of being read silently.
+	// The old sidecar is kept to be restored if the file fails to be renamed.
+	oldSums, err := os.ReadFile(checksumPath(p))
+	if err != nil && !os.IsNotExist(err) {
+		log.Errorw("failed to put object due to read checksums", "key", key, "error", err)
+		_ = os.Remove(tmp)
+		return err
+	}
+	if sums == nil {
+		err = removeFile(checksumPath(p))
+	} else {
+		err = d.writeChecksums(p, sums.encode())
+	}
+	if err != nil {
+		log.Errorw("failed to put object due to write checksums", "key", key, "error", err)
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err = os.Rename(tmp, p); err != nil {
+		log.Errorw("failed to put object due to rename file", "key", key, "error", err)
+		_ = os.Remove(tmp)
+		d.restoreChecksums(p, oldSums)
+		return err
+	}
+	if d.fsyncPolicy == FsyncAll {
+		if err = syncDir(filepath.Dir(p)); err != nil {
+			log.Errorw("failed to put object due to sync directory", "key", key, "error", err)
+			return err
+		}
+	}
+	if d.fanOut {
+		// the file written before the fan-out is enabled is replaced by the new one.
+		_ = removeFile(d.path(key))
+		_ = removeFile(checksumPath(d.path(key)))
+	}
+	return nil
+}
+
+// writeChecksums replaces the sidecar checksum file of the data file.
+func (d *diskFileStore) writeChecksums(p string, sums []byte) error {
+	tmp, err := d.writeTempFile(checksumPath(p), bytes.NewReader(sums))
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, checksumPath(p)); err != nil {
+		_ = os.Remove(tmp)
+		return err
 	}
+	return nil
+}
+
+// restoreChecksums restores the sidecar of the data file that fails to be replaced, the sidecar
+// is removed if the old file has no checksums.
+func (d *diskFileStore) restoreChecksums(p string, sums []byte) {
+	var err error
+	if sums == nil {
+		err = removeFile(checksumPath(p))
+	} else {
+		err = d.writeChecksums(p, sums)
+	}
+	if err != nil {
+		log.Errorw("failed to restore checksums", "file", p, "error", err)
+	}
+}
 
-	tmp := filepath.Join(filepath.Dir(p), "."+filepath.Base(p)+".tmp"+strconv.Itoa(rand.Int()))
+// writeTempFile writes the data to the temporary file in the directory of the path, the
+// temporary file is synced according to the fsync policy and removed if failed.
+func (d *diskFileStore) writeTempFile(p string, reader io.Reader) (tmp string, err error) {
+	tmp = filepath.Join(filepath.Dir(p), "."+filepath.Base(p)+".tmp"+strconv.Itoa(rand.Int()))
 	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil && os.IsNotExist(err) {
 		if err = os.MkdirAll(filepath.Dir(p), os.FileMode(0755)); err != nil {
 			log.Errorw("failed to put object due to mkdir", "error", err)
-			return err
+			return "", err
 		}
 		f, err = os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	}
 	if err != nil {
 		log.Errorw("failed to put object due to open file", "error", err)
-		return err
+		return "", err
 	}
 	defer func() {
 		if err != nil {
@@ -117,27 +267,47 @@ func (d *diskFileStore) PutObject(ctx context.Context, key string, reader io.Rea
 
 	buf := bufPool.Get().(*[]byte)
 	defer bufPool.Put(buf)
-	_, err = io.CopyBuffer(f, reader, *buf)
-	if err != nil {
+	if _, err = io.CopyBuffer(f, reader, *buf); err != nil {
 		log.Errorw("failed to put object due to copy buffer", "error", err)
 		_ = f.Close()
-		return err
+		return "", err
+	}
+	if d.fsyncPolicy != FsyncNone {
+		if err = f.Sync(); err != nil {
+			log.Errorw("failed to put object due to sync file", "error", err)
+			_ = f.Close()
+			return "", err
+		}
 	}
 	if err = f.Close(); err != nil {
 		log.Errorw("failed to put object due to close file", "error", err)
-		return err
+		return "", err
 	}
-
-	return os.Rename(tmp, p)
+	return tmp, nil
 }
 
 func (d *diskFileStore) DeleteObject(ctx context.Context, key string) error {
-	err := os.Remove(d.path(key))
-	if err != nil && os.IsNotExist(err) {
-		log.Errorw("failed to delete object due to remove file", "error", err)
-		err = nil
+	paths := []string{d.path(key)}
+	if d.fanOut {
+		paths = append(paths, d.fanOutPath(key))
+	}
+	for _, p := range paths {
+		// the file is removed before its sidecar, the sidecar left by a crash is removed by RemoveOrphanFiles.
+		if err := removeFile(p); err != nil {
+			log.Errorw("failed to delete object due to remove file", "error", err)
+			return err
+		}
+		if err := removeFile(checksumPath(p)); err != nil {
+			log.Errorw("failed to delete object due to remove checksum file", "error", err)
+			return err
+		}
 	}
-	return err
+	return nil
+}
+
+// DeleteObjects unlinks the files in parallel.
+func (d *diskFileStore) DeleteObjects(ctx context.Context, keys []string) map[string]error {
+	return deleteObjects(ctx, keys, diskFileDeleteConcurrency, d.DeleteObject)
 }
 
 func (d *diskFileStore) HeadBucket(ctx context.Context) error {
@@ -151,7 +321,7 @@ func (d *diskFileStore) HeadBucket(ctx context.Context) error {
 }
 
 func (d *diskFileStore) HeadObject(ctx context.Context, key string) (Object, error) {
-	p := d.path(key)
+	p := d.locate(key)
 	fileInfo, err := os.Stat(p)
 	if err != nil {
 		log.Errorw("failed to Head object due to Stat file", "error", err)
@@ -178,6 +348,478 @@ func (d *diskFileStore) HeadObject(ctx context.Context, key string) (Object, err
 	}, nil
 }
 
+func (d *diskFileStore) ListAllObjects(ctx context.Context, prefix, marker string) (<-chan Object, error) {
+	if _, err := os.Stat(d.root); err != nil {
+		return nil, err
+	}
+	ch := make(chan Object, listObjectsPageSize)
+	go func() {
+		defer close(ch)
+		send := func(obj Object) error {
+			select {
+			case ch <- obj:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			return nil
+		}
+		var err error
+		if d.fanOut {
+			err = d.listFanOutObjects(ctx, prefix, marker, send)
+		} else {
+			// walkInKeyOrder walks the files in the lexical order of the keys.
+			err = walkInKeyOrder(d.root, func(p string, entry fs.DirEntry) error {
+				if entry.IsDir() || isTempFile(entry.Name()) || isChecksumFile(entry.Name()) {
+					return nil
+				}
+				rel, err := filepath.Rel(d.root, p)
+				if err != nil {
+					return err
+				}
+				key := filepath.ToSlash(rel)
+				if !strings.HasPrefix(key, prefix) || key <= marker {
+					return nil
+				}
+				info, err := entry.Info()
+				if err != nil {
+					return err
+				}
+				return send(&object{key, info.Size(), info.ModTime(), false})
+			})
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Errorw("failed to list all objects due to walk dir", "root", d.root, "error", err)
+			ch <- nil
+		}
+	}()
+	return ch, nil
+}
+
+// listFanOutObjects sends the objects in key order by merging the keys of the fan-out
+// directories and the keys written before the fan-out is enabled. The keys of each directory
+// are sorted by walkInKeyOrder, and the directories are merged by a heap, so the objects are
+// sent without sorting all the keys.
+func (d *diskFileStore) listFanOutObjects(ctx context.Context, prefix, marker string, send func(Object) error) error {
+	var (
+		dirs = &keyIteratorHeap{}
+		// collect returns the iterator of the keys under the dir, the legacy keys are collected
+		// from the root without walking into the fan-out directories.
+		collect = func(dir string, fanned bool) (*keyIterator, error) {
+			it := &keyIterator{fanned: fanned}
+			err := walkInKeyOrder(dir, func(p string, entry fs.DirEntry) error {
+				if entry.IsDir() {
+					if !fanned && filepath.Dir(p) == filepath.Clean(d.root) && isFanOutDirName(entry.Name()) {
+						return fs.SkipDir
+					}
+					return nil
+				}
+				if isTempFile(entry.Name()) || isChecksumFile(entry.Name()) {
+					return nil
+				}
+				rel, err := filepath.Rel(d.root, p)
+				if err != nil {
+					return err
+				}
+				key, ok := d.parseKey(filepath.ToSlash(rel))
+				if ok == fanned && strings.HasPrefix(key, prefix) && key > marker {
+					it.keys = append(it.keys, key)
+				}
+				return nil
+			})
+			return it, err
+		}
+	)
+	// the keys written before the fan-out is enabled are not in the fan-out directories.
+	legacy, err := collect(d.root, false)
+	if err != nil {
+		return err
+	}
+	dirs.push(legacy)
+	level1, err := os.ReadDir(d.root)
+	if err != nil {
+		return err
+	}
+	for _, entry1 := range level1 {
+		if !entry1.IsDir() || !isFanOutDirName(entry1.Name()) {
+			continue
+		}
+		level2, err := os.ReadDir(filepath.Join(d.root, entry1.Name()))
+		if err != nil {
+			return err
+		}
+		for _, entry2 := range level2 {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if !entry2.IsDir() || !isFanOutDirName(entry2.Name()) {
+				continue
+			}
+			it, err := collect(filepath.Join(d.root, entry1.Name(), entry2.Name()), true)
+			if err != nil {
+				return err
+			}
+			dirs.push(it)
+		}
+	}
+	for dirs.Len() > 0 {
+		it := (*dirs)[0]
+		key := it.keys[0]
+		it.keys = it.keys[1:]
+		if len(it.keys) == 0 {
+			heap.Pop(dirs)
+		} else {
+			heap.Fix(dirs, 0)
+		}
+		p := d.fanOutPath(key)
+		if !it.fanned {
+			// the legacy file is shadowed by the fanned out file of the same key
+			if d.exists(p) {
+				continue
+			}
+			p = d.path(key)
+		}
+		info, err := os.Stat(p)
+		if os.IsNotExist(err) {
+			// the file is deleted after listed
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err = send(&object{key, info.Size(), info.ModTime(), false}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keyIterator iterates the sorted keys of a directory.
+type keyIterator struct {
+	keys   []string
+	fanned bool
+}
+
+// keyIteratorHeap is the min heap of the key iterators ordered by their next keys.
+type keyIteratorHeap []*keyIterator
+
+func (h keyIteratorHeap) Len() int           { return len(h) }
+func (h keyIteratorHeap) Less(i, j int) bool { return h[i].keys[0] < h[j].keys[0] }
+func (h keyIteratorHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *keyIteratorHeap) Push(x any) { *h = append(*h, x.(*keyIterator)) }
+
+func (h *keyIteratorHeap) Pop() any {
+	old := *h
+	it := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return it
+}
+
+// push pushes the iterator if it has any key.
+func (h *keyIteratorHeap) push(it *keyIterator) {
+	if len(it.keys) > 0 {
+		heap.Push(h, it)
+	}
+}
+
+// isFanOutDirName returns an indicator whether the directory name is the level of the fan-out
+// directories, the hex of one byte.
+func isFanOutDirName(name string) bool {
+	if len(name) != 2 {
+		return false
+	}
+	_, err := strconv.ParseUint(name, 16, 8)
+	return err == nil
+}
+
+// Capacity returns the total and available bytes of the file system that the root is on.
+func (d *diskFileStore) Capacity(ctx context.Context) (uint64, uint64, error) {
+	return diskCapacity(d.root)
+}
+
+// RemoveOrphanFiles removes the orphan files that are older than diskFileOrphanExpire, it
+// walks the whole root and should be called periodically by only one process.
+func (d *diskFileStore) RemoveOrphanFiles(ctx context.Context) {
+	d.removeOrphanFiles(ctx, diskFileOrphanExpire)
+}
+
+// removeOrphanFiles removes the temporary files and the sidecars without data files that are
+// older than the expiration, they are left by the writes and deletes interrupted by crash.
+func (d *diskFileStore) removeOrphanFiles(ctx context.Context, expire time.Duration) {
+	if _, err := os.Stat(d.root); err != nil {
+		return
+	}
+	var (
+		deadline = time.Now().Add(-expire)
+		removed  int
+	)
+	err := filepath.WalkDir(d.root, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		name := entry.Name()
+		orphan := isTempFile(name)
+		if !orphan && isChecksumFile(name) {
+			dataPath := filepath.Join(filepath.Dir(p), strings.TrimSuffix(name[1:], diskFileChecksumSuffix))
+			orphan = !d.exists(dataPath)
+		}
+		if !orphan {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(deadline) {
+			return nil
+		}
+		if err = os.Remove(p); err != nil && !os.IsNotExist(err) {
+			log.Errorw("failed to remove orphan file", "file", p, "error", err)
+			return nil
+		}
+		removed++
+		return nil
+	})
+	if err != nil {
+		log.Errorw("failed to remove orphan files due to walk dir", "root", d.root, "error", err)
+	}
+	log.Infow("finished to remove orphan files", "root", d.root, "removed", removed)
+}
+
+// isTempFile returns an indicator whether the file is the temporary file of PutObject.
+// walkInKeyOrder calls fn for the files under the dir in the lexical order of their relative
+// paths. filepath.WalkDir sorts the entries by name, it visits "a/b" before "a-c" though "a-c"
+// is less than "a/b", so the entries are sorted as if the directory names end with "/". The fn
+// is also called for the directories before walking them, and the directory is skipped if fn
+// returns fs.SkipDir.
+func walkInKeyOrder(dir string, fn func(p string, entry fs.DirEntry) error) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	sortName := func(entry fs.DirEntry) string {
+		if entry.IsDir() {
+			return entry.Name() + "/"
+		}
+		return entry.Name()
+	}
+	sort.Slice(entries, func(i, j int) bool { return sortName(entries[i]) < sortName(entries[j]) })
+	for _, entry := range entries {
+		p := filepath.Join(dir, entry.Name())
+		err = fn(p, entry)
+		if entry.IsDir() {
+			if err == fs.SkipDir {
+				continue
+			}
+			if err == nil {
+				err = walkInKeyOrder(p, fn)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isTempFile(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.Contains(name, ".tmp")
+}
+
+// isChecksumFile returns an indicator whether the file is the sidecar checksum file.
+func isChecksumFile(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.HasSuffix(name, diskFileChecksumSuffix)
+}
+
+// checksumPath returns the path of the sidecar checksum file of the data file.
+func checksumPath(p string) string {
+	return filepath.Join(filepath.Dir(p), "."+filepath.Base(p)+diskFileChecksumSuffix)
+}
+
 func (d *diskFileStore) path(key string) string {
 	return filepath.Join(d.root, key)
 }
+
+// fanOutPath returns the path of the key in the two-level sub directories named by the hash
+// of the key, e.g. <root>/3f/a2/<key>.
+func (d *diskFileStore) fanOutPath(key string) string {
+	dir := fanOutDir(key)
+	return filepath.Join(d.root, dir[:2], dir[2:], key)
+}
+
+// writePath returns the path that the object of the key is written to.
+func (d *diskFileStore) writePath(key string) string {
+	if d.fanOut {
+		return d.fanOutPath(key)
+	}
+	return d.path(key)
+}
+
+// locate returns the path of the existing object of the key, the files written before the
+// fan-out is enabled are still read from the root.
+func (d *diskFileStore) locate(key string) string {
+	if d.fanOut {
+		if p := d.fanOutPath(key); d.exists(p) {
+			return p
+		}
+	}
+	return d.path(key)
+}
+
+// parseKey returns the key of the relative path, and whether the path is in the fan-out
+// sub directories of the key.
+func (d *diskFileStore) parseKey(rel string) (string, bool) {
+	if !d.fanOut {
+		return rel, false
+	}
+	parts := strings.SplitN(rel, "/", 3)
+	if len(parts) == 3 && parts[0]+parts[1] == fanOutDir(parts[2]) {
+		return parts[2], true
+	}
+	return rel, false
+}
+
+func (d *diskFileStore) exists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
+
+// fanOutDir returns the hex of the first two bytes of the key hash.
+func fanOutDir(key string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return fmt.Sprintf("%04x", h.Sum32()>>16)
+}
+
+// removeFile removes the file, the not existed file is ignored.
+func removeFile(p string) error {
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// syncDir syncs the directory to make the renames in it durable.
+func syncDir(dir string) error {
+	if runtime.GOOS == windowsOS {
+		return nil
+	}
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
+
+// blockChecksums computes the crc32c of every block of the written data, the sidecar layout
+// is | file size(8) | block size(4) | crc32c of blocks(4 each) |.
+type blockChecksums struct {
+	size int64
+	crc  uint32
+	sums []uint32
+}
+
+func (b *blockChecksums) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		k := diskFileChecksumBlockSize - int(b.size%diskFileChecksumBlockSize)
+		if len(p) < k {
+			k = len(p)
+		}
+		b.crc = crc32.Update(b.crc, crc32c, p[:k])
+		b.size += int64(k)
+		p = p[k:]
+		if b.size%diskFileChecksumBlockSize == 0 {
+			b.sums = append(b.sums, b.crc)
+			b.crc = 0
+		}
+	}
+	return n, nil
+}
+
+func (b *blockChecksums) encode() []byte {
+	sums := b.sums
+	if b.size%diskFileChecksumBlockSize != 0 {
+		sums = append(sums, b.crc)
+	}
+	data := make([]byte, diskFileChecksumHeaderSize+4*len(sums))
+	binary.BigEndian.PutUint64(data[0:8], uint64(b.size))
+	binary.BigEndian.PutUint32(data[8:12], diskFileChecksumBlockSize)
+	for i, sum := range sums {
+		binary.BigEndian.PutUint32(data[diskFileChecksumHeaderSize+4*i:], sum)
+	}
+	return data
+}
+
+// readChecksums reads the block checksums from the sidecar, the sidecar must match the size
+// of the data file.
+func readChecksums(p string, size int64) ([]uint32, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < diskFileChecksumHeaderSize || (len(data)-diskFileChecksumHeaderSize)%4 != 0 {
+		return nil, fmt.Errorf("%w: invalid checksum file %s", errChecksumMismatch, p)
+	}
+	if binary.BigEndian.Uint32(data[8:12]) != diskFileChecksumBlockSize {
+		return nil, fmt.Errorf("unsupported checksum block size: %d", binary.BigEndian.Uint32(data[8:12]))
+	}
+	blocks := (size + diskFileChecksumBlockSize - 1) / diskFileChecksumBlockSize
+	if int64(binary.BigEndian.Uint64(data[0:8])) != size || int64(len(data)-diskFileChecksumHeaderSize)/4 != blocks {
+		return nil, fmt.Errorf("%w: file size %d does not match the checksum file", errChecksumMismatch, size)
+	}
+	sums := make([]uint32, blocks)
+	for i := range sums {
+		sums[i] = binary.BigEndian.Uint32(data[diskFileChecksumHeaderSize+4*i:])
+	}
+	return sums, nil
+}
+
+// diskChecksumReader reads the file block by block and verifies every block by its checksum.
+type diskChecksumReader struct {
+	f         *os.File
+	sums      []uint32
+	block     int
+	buf       []byte
+	pending   []byte
+	skip      int64
+	remaining int64 // -1 means reading to the end of file
+}
+
+func (r *diskChecksumReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.remaining == 0 || r.block >= len(r.sums) {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(r.f, r.buf)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			if err == io.EOF {
+				err = fmt.Errorf("%w: block %d of %s is truncated", errChecksumMismatch, r.block, r.f.Name())
+			}
+			return 0, err
+		}
+		if crc32.Checksum(r.buf[:n], crc32c) != r.sums[r.block] {
+			return 0, fmt.Errorf("%w: block %d of %s", errChecksumMismatch, r.block, r.f.Name())
+		}
+		r.block++
+		r.pending = r.buf[r.skip:n]
+		r.skip = 0
+		if r.remaining > 0 && int64(len(r.pending)) > r.remaining {
+			r.pending = r.pending[:r.remaining]
+		}
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	if r.remaining > 0 {
+		r.remaining -= int64(n)
+	}
+	return n, nil
+}
+
+func (r *diskChecksumReader) Close() error {
+	return r.f.Close()
+}
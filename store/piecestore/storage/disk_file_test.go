@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -167,11 +169,12 @@ func TestDiskFile_PutSuccess(t *testing.T) {
 
 func TestDiskFile_PutError(t *testing.T) {
 	defer func() {
+		// the temporary file is removed if the rename fails
 		fileList, err := filepath.Glob("...tmp*")
 		if err != nil {
 			t.Fatalf("filepath.Glob error: %s", err)
 		}
-		_ = os.Remove(fileList[0])
+		assert.Equal(t, 0, len(fileList))
 	}()
 	cases := []struct {
 		name         string
@@ -226,6 +229,23 @@ func TestDiskFile_Delete(t *testing.T) {
 	}
 }
 
+func TestDiskFile_DeleteObjects(t *testing.T) {
+	store := &diskFileStore{root: t.TempDir() + "/"}
+	var keys []string
+	for i := 0; i < 2*diskFileDeleteConcurrency; i++ {
+		key := fmt.Sprintf("%d_s0", i)
+		assert.Nil(t, store.PutObject(context.TODO(), key, strings.NewReader("piece")))
+		keys = append(keys, key)
+	}
+	keys = append(keys, "non_existed_object")
+	failed := store.DeleteObjects(context.TODO(), keys)
+	assert.Equal(t, 0, len(failed))
+	for _, key := range keys {
+		_, err := store.HeadObject(context.TODO(), key)
+		assert.NotNil(t, err)
+	}
+}
+
 func TestDiskFile_HeadSuccess(t *testing.T) {
 	f := createTempFile(t)
 	cases := []struct {
@@ -291,9 +311,227 @@ func TestDiskFile_List(t *testing.T) {
 }
 
 func TestDiskFile_ListAll(t *testing.T) {
-	store := setupDiskFileTest(t)
-	_, err := store.ListAllObjects(context.TODO(), emptyString, emptyString)
-	assert.Equal(t, ErrUnsupportedMethod, err)
+	store := &diskFileStore{root: t.TempDir() + "/"}
+	keys := []string{"1_s0", "1_s1", "2_s0_p1", "sub/3_s0"}
+	for _, key := range keys {
+		err := store.PutObject(context.TODO(), key, strings.NewReader(mockAccessKey))
+		assert.Nil(t, err)
+	}
+	ch, err := store.ListAllObjects(context.TODO(), emptyString, "1_s0")
+	assert.Nil(t, err)
+	result := make([]string, 0)
+	for obj := range ch {
+		assert.NotNil(t, obj)
+		assert.Equal(t, int64(len(mockAccessKey)), obj.Size())
+		result = append(result, obj.Key())
+	}
+	assert.Equal(t, keys[1:], result)
+
+	// the keys with "/" are listed in the lexical order of the keys rather than the paths
+	store = &diskFileStore{root: t.TempDir() + "/"}
+	keys = []string{"a-c", "a/b", "a0"}
+	for _, key := range []string{"a/b", "a0", "a-c"} {
+		assert.Nil(t, store.PutObject(context.TODO(), key, strings.NewReader(mockAccessKey)))
+	}
+	ch, err = store.ListAllObjects(context.TODO(), emptyString, emptyString)
+	assert.Nil(t, err)
+	result = result[:0]
+	for obj := range ch {
+		result = append(result, obj.Key())
+	}
+	assert.Equal(t, keys, result)
+
+	store = &diskFileStore{root: filepath.Join(t.TempDir(), "not_exist")}
+	_, err = store.ListAllObjects(context.TODO(), emptyString, emptyString)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDiskFile_FsyncPolicy(t *testing.T) {
+	for _, policy := range []string{"", FsyncNone, FsyncFile, FsyncAll} {
+		store, err := newDiskFileStore(ObjectStorageConfig{
+			BucketURL: t.TempDir() + "/",
+			DiskFile:  DiskFileConfig{FsyncPolicy: policy},
+		})
+		assert.Nil(t, err)
+		assert.Nil(t, store.PutObject(context.TODO(), "1_s0", strings.NewReader(mockAccessKey)))
+		data, err := store.GetObject(context.TODO(), "1_s0", 0, 0)
+		assert.Nil(t, err)
+		result, err := io.ReadAll(data)
+		assert.Nil(t, err)
+		assert.Equal(t, mockAccessKey, string(result))
+	}
+	_, err := newDiskFileStore(ObjectStorageConfig{DiskFile: DiskFileConfig{FsyncPolicy: "always"}})
+	assert.NotNil(t, err)
+}
+
+func TestDiskFile_FanOut(t *testing.T) {
+	root := t.TempDir() + "/"
+	legacy := &diskFileStore{root: root}
+	assert.Nil(t, legacy.PutObject(context.TODO(), "1_s0", strings.NewReader("legacy")))
+	assert.Nil(t, legacy.PutObject(context.TODO(), "2_s0", strings.NewReader("legacy")))
+
+	store := &diskFileStore{root: root, fanOut: true}
+	keys := []string{"3_s0", "4_s0_p1", "sub/5_s0"}
+	for _, key := range keys {
+		assert.Nil(t, store.PutObject(context.TODO(), key, strings.NewReader(mockAccessKey)))
+		_, err := os.Stat(store.fanOutPath(key))
+		assert.Nil(t, err)
+		_, err = os.Stat(store.path(key))
+		assert.True(t, os.IsNotExist(err))
+	}
+	// the legacy file is replaced by the fanned out file
+	assert.Nil(t, store.PutObject(context.TODO(), "2_s0", strings.NewReader(mockAccessKey)))
+	_, err := os.Stat(store.path("2_s0"))
+	assert.True(t, os.IsNotExist(err))
+
+	data, err := store.GetObject(context.TODO(), "1_s0", 0, 0)
+	assert.Nil(t, err)
+	result, err := io.ReadAll(data)
+	assert.Nil(t, err)
+	assert.Equal(t, "legacy", string(result))
+	obj, err := store.HeadObject(context.TODO(), "sub/5_s0")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(mockAccessKey)), obj.Size())
+
+	ch, err := store.ListAllObjects(context.TODO(), emptyString, "1_s0")
+	assert.Nil(t, err)
+	var listed []string
+	for obj := range ch {
+		assert.NotNil(t, obj)
+		listed = append(listed, obj.Key())
+	}
+	assert.Equal(t, []string{"2_s0", "3_s0", "4_s0_p1", "sub/5_s0"}, listed)
+
+	assert.Nil(t, store.DeleteObject(context.TODO(), "1_s0"))
+	assert.Nil(t, store.DeleteObject(context.TODO(), "3_s0"))
+	for _, key := range []string{"1_s0", "3_s0"} {
+		_, err = store.HeadObject(context.TODO(), key)
+		assert.True(t, os.IsNotExist(err))
+	}
+}
+
+func TestDiskFile_Checksum(t *testing.T) {
+	store := &diskFileStore{root: t.TempDir() + "/", checksum: true}
+	content := make([]byte, 2*diskFileChecksumBlockSize+100)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	assert.Nil(t, store.PutObject(context.TODO(), "1_s0", bytes.NewReader(content)))
+	_, err := os.Stat(checksumPath(store.path("1_s0")))
+	assert.Nil(t, err)
+
+	cases := []struct {
+		offset int64
+		limit  int64
+	}{
+		{0, 0},
+		{0, 10},
+		{diskFileChecksumBlockSize - 5, 10},
+		{2*diskFileChecksumBlockSize + 50, 0},
+		{100, int64(len(content))},
+	}
+	for _, c := range cases {
+		result := readDiskFile(t, store, "1_s0", c.offset, c.limit)
+		end := int64(len(content))
+		if c.limit > 0 && c.offset+c.limit < end {
+			end = c.offset + c.limit
+		}
+		assert.Equal(t, content[c.offset:end], result)
+	}
+	_, err = store.GetObject(context.TODO(), "1_s0", int64(len(content)), 1)
+	assert.Equal(t, io.EOF, err)
+
+	// the corrupted block is detected when it is read
+	f, err := os.OpenFile(store.path("1_s0"), os.O_WRONLY, 0644)
+	assert.Nil(t, err)
+	_, err = f.WriteAt([]byte{0xff}, diskFileChecksumBlockSize+1)
+	assert.Nil(t, err)
+	assert.Nil(t, f.Close())
+	assert.Equal(t, content[:10], readDiskFile(t, store, "1_s0", 0, 10))
+	_, err = store.GetObject(context.TODO(), "1_s0", diskFileChecksumBlockSize, 10)
+	assert.True(t, errors.Is(err, errChecksumMismatch))
+	data, err := store.GetObject(context.TODO(), "1_s0", 0, 0)
+	assert.Nil(t, err)
+	_, err = io.ReadAll(data)
+	assert.True(t, errors.Is(err, errChecksumMismatch))
+	assert.Nil(t, data.Close())
+
+	// the truncated file does not match the checksum file
+	assert.Nil(t, os.Truncate(store.path("1_s0"), 10))
+	_, err = store.GetObject(context.TODO(), "1_s0", 0, 0)
+	assert.True(t, errors.Is(err, errChecksumMismatch))
+
+	// the stale checksum file is removed if the file is overwritten without checksum
+	store.checksum = false
+	assert.Nil(t, store.PutObject(context.TODO(), "1_s0", strings.NewReader(mockAccessKey)))
+	assert.Equal(t, []byte(mockAccessKey), readDiskFile(t, store, "1_s0", 0, 0))
+	assert.Nil(t, store.DeleteObject(context.TODO(), "1_s0"))
+	_, err = os.Stat(checksumPath(store.path("1_s0")))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDiskFile_ChecksumRenameError(t *testing.T) {
+	if runtime.GOOS == windowsOS {
+		t.Skip("the rename to the non-empty directory is not rejected on windows")
+	}
+	store := &diskFileStore{root: t.TempDir() + "/", checksum: true}
+	// the rename of the file fails as the path is a non-empty directory
+	p := store.path("1_s0")
+	assert.Nil(t, os.MkdirAll(filepath.Join(p, "sub"), 0755))
+
+	// the new sidecar is removed if the old file has no checksums
+	assert.NotNil(t, store.PutObject(context.TODO(), "1_s0", strings.NewReader(mockAccessKey)))
+	_, err := os.Stat(checksumPath(p))
+	assert.True(t, os.IsNotExist(err))
+
+	// the old sidecar is restored whether the new file has checksums or not
+	oldSums := []byte("old checksums")
+	assert.Nil(t, os.WriteFile(checksumPath(p), oldSums, 0644))
+	for _, checksum := range []bool{true, false} {
+		store.checksum = checksum
+		assert.NotNil(t, store.PutObject(context.TODO(), "1_s0", strings.NewReader(mockAccessKey)))
+		sums, err := os.ReadFile(checksumPath(p))
+		assert.Nil(t, err)
+		assert.Equal(t, oldSums, sums)
+	}
+	tmpFiles, err := filepath.Glob(filepath.Join(store.root, ".*.tmp*"))
+	assert.Nil(t, err)
+	assert.Empty(t, tmpFiles)
+}
+
+func TestDiskFile_RemoveOrphanFiles(t *testing.T) {
+	store := &diskFileStore{root: t.TempDir() + "/", checksum: true}
+	assert.Nil(t, store.PutObject(context.TODO(), "sub/1_s0", strings.NewReader(mockAccessKey)))
+	orphans := []string{
+		filepath.Join(store.root, ".2_s0.tmp123"),
+		filepath.Join(store.root, "sub", ".3_s0.sum"),
+	}
+	for _, p := range orphans {
+		assert.Nil(t, os.WriteFile(p, []byte(mockAccessKey), 0644))
+	}
+	// the fresh orphan files may be written by the other processes
+	store.removeOrphanFiles(context.TODO(), time.Hour)
+	for _, p := range orphans {
+		_, err := os.Stat(p)
+		assert.Nil(t, err)
+	}
+	store.removeOrphanFiles(context.TODO(), 0)
+	for _, p := range orphans {
+		_, err := os.Stat(p)
+		assert.True(t, os.IsNotExist(err))
+	}
+	assert.Equal(t, []byte(mockAccessKey), readDiskFile(t, store, "sub/1_s0", 0, 0))
+	_, err := os.Stat(checksumPath(store.path("sub/1_s0")))
+	assert.Nil(t, err)
+}
+
+func readDiskFile(t *testing.T, store *diskFileStore, key string, offset, limit int64) []byte {
+	data, err := store.GetObject(context.TODO(), key, offset, limit)
+	assert.Nil(t, err)
+	defer data.Close()
+	result, err := io.ReadAll(data)
+	assert.Nil(t, err)
+	return result
 }
 
 func TestPath(t *testing.T) {
@@ -317,3 +555,15 @@ func TestPath(t *testing.T) {
 		})
 	}
 }
+
+func TestDiskFile_Capacity(t *testing.T) {
+	store := &diskFileStore{root: t.TempDir()}
+	total, available, err := store.Capacity(context.TODO())
+	assert.Nil(t, err)
+	assert.True(t, total > 0)
+	assert.True(t, available <= total)
+
+	store = &diskFileStore{root: filepath.Join(t.TempDir(), "not_exist")}
+	_, _, err = store.Capacity(context.TODO())
+	assert.NotNil(t, err)
+}
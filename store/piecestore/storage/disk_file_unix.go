@@ -21,6 +21,14 @@ var (
 	mutex  sync.Mutex
 )
 
+func diskCapacity(path string) (uint64, uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, 0, err
+	}
+	return stat.Blocks * uint64(stat.Bsize), stat.Bavail * uint64(stat.Bsize), nil
+}
+
 func getOwnerGroup(info os.FileInfo) (string, string) {
 	mutex.Lock()
 	defer mutex.Unlock()
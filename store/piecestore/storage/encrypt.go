@@ -0,0 +1,461 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+)
+
+var (
+	// encryptMagic is the first bytes of the encrypted object.
+	encryptMagic = []byte("GFSE")
+	// errNotEncrypted is returned if the object does not start with the encryption header.
+	errNotEncrypted = errors.New("object is not encrypted")
+)
+
+// encryptStore encrypts the objects of the underlying ObjectStorage by AES-GCM. Each object is
+// encrypted by a new data key which is encrypted by the KeyProvider and stored in the header.
+// The plaintext is split into chunks of encryptChunkSize and each chunk is sealed separately,
+// so the range reads only need to decrypt the chunks covering the range.
+//
+// The layout of the encrypted object is:
+//
+//	header: magic(4) | version(1) | nonce(12) | key id length(1) | key id | data key length(2) | data key | padding
+//	chunks: sealed chunk 0 | sealed chunk 1 | ... | sealed final chunk
+//
+// The header is padded to encryptHeaderSize, and every sealed chunk except the final one has
+// the same size, so the plaintext size and the position of a chunk can be computed without
+// reading the header. The object key, the chunk index and whether it is the final chunk are
+// authenticated as the additional data to detect the swapped, reordered or truncated chunks.
+type encryptStore struct {
+	ObjectStorage
+	provider       KeyProvider
+	allowPlaintext bool
+}
+
+// encryptHeader is the decoded header of the encrypted object.
+type encryptHeader struct {
+	nonce   []byte
+	keyID   string
+	dataKey []byte
+}
+
+// NewEncryptStore returns an ObjectStorage that encrypts the objects of the underlying ObjectStorage.
+func NewEncryptStore(store ObjectStorage, cfg EncryptionConfig) (ObjectStorage, error) {
+	provider, err := NewKeyProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptStore{ObjectStorage: store, provider: provider, allowPlaintext: cfg.AllowPlaintextRead}, nil
+}
+
+func (s *encryptStore) String() string {
+	return fmt.Sprintf("encrypt://%s", s.ObjectStorage)
+}
+
+func (s *encryptStore) PutObject(ctx context.Context, key string, reader io.Reader) error {
+	keyID, dataKey, sealedKey, err := s.provider.GenerateDataKey(ctx)
+	if err != nil {
+		log.Errorw("failed to generate data key", "key", key, "error", err)
+		return err
+	}
+	header := &encryptHeader{nonce: make([]byte, encryptNonceSize), keyID: keyID, dataKey: sealedKey}
+	if _, err = rand.Read(header.nonce); err != nil {
+		return err
+	}
+	raw, err := header.marshal()
+	if err != nil {
+		return err
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return err
+	}
+	return s.ObjectStorage.PutObject(ctx, key, &encryptReader{
+		src:    bufio.NewReaderSize(reader, encryptChunkSize),
+		aead:   aead,
+		nonce:  header.nonce,
+		key:    key,
+		out:    raw,
+		plain:  make([]byte, encryptChunkSize),
+		sealed: make([]byte, 0, encryptSealedChunkSize),
+	})
+}
+
+func (s *encryptStore) GetObject(ctx context.Context, key string, offset, limit int64) (io.ReadCloser, error) {
+	if offset < 0 {
+		offset = 0
+	}
+	first := offset / encryptChunkSize
+	last := uint64(math.MaxUint64)
+	// read one more byte after the last chunk to know whether the last chunk is the final one.
+	rangeLimit := int64(-1)
+	if limit > 0 {
+		last = uint64((offset + limit - 1) / encryptChunkSize)
+		rangeLimit = (int64(last)-first+1)*encryptSealedChunkSize + 1
+	}
+
+	var (
+		header *encryptHeader
+		rc     io.ReadCloser
+		br     *bufio.Reader
+		err    error
+	)
+	if first == 0 {
+		// read the header and the chunks by one request.
+		if rangeLimit > 0 {
+			rangeLimit += encryptHeaderSize
+		}
+		if rc, err = s.ObjectStorage.GetObject(ctx, key, 0, rangeLimit); err != nil {
+			return nil, err
+		}
+		br = bufio.NewReaderSize(rc, encryptSealedChunkSize+1)
+		raw := make([]byte, encryptHeaderSize)
+		n, readErr := io.ReadFull(br, raw)
+		if header, err = unmarshalEncryptHeader(raw[:n]); errors.Is(err, errNotEncrypted) && s.allowPlaintext {
+			return newPlaintextReader(io.MultiReader(bytes.NewReader(raw[:n]), br), rc, offset, limit), nil
+		}
+		if err == nil && readErr != nil {
+			err = readErr
+		}
+	} else {
+		if header, err = s.readHeader(ctx, key); errors.Is(err, errNotEncrypted) && s.allowPlaintext {
+			return s.ObjectStorage.GetObject(ctx, key, offset, limit)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if rc, err = s.ObjectStorage.GetObject(ctx, key, encryptHeaderSize+first*encryptSealedChunkSize,
+			rangeLimit); err != nil {
+			return nil, err
+		}
+		br = bufio.NewReaderSize(rc, encryptSealedChunkSize+1)
+	}
+	if err != nil {
+		_ = rc.Close()
+		log.Errorw("failed to read encryption header", "key", key, "error", err)
+		return nil, err
+	}
+
+	dataKey, err := s.provider.DecryptDataKey(ctx, header.keyID, header.dataKey)
+	if err != nil {
+		_ = rc.Close()
+		log.Errorw("failed to decrypt data key", "key", key, "key_id", header.keyID, "error", err)
+		return nil, err
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		_ = rc.Close()
+		return nil, err
+	}
+	remain := int64(-1)
+	if limit > 0 {
+		remain = limit
+	}
+	return &decryptReader{
+		rc:     rc,
+		src:    br,
+		aead:   aead,
+		nonce:  header.nonce,
+		key:    key,
+		index:  uint64(first),
+		last:   last,
+		skip:   int(offset % encryptChunkSize),
+		remain: remain,
+		sealed: make([]byte, encryptSealedChunkSize),
+	}, nil
+}
+
+func (s *encryptStore) HeadObject(ctx context.Context, key string) (Object, error) {
+	obj, err := s.ObjectStorage.HeadObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if s.allowPlaintext {
+		if _, err = s.readHeader(ctx, key); errors.Is(err, errNotEncrypted) {
+			return obj, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return newPlaintextObject(obj), nil
+}
+
+func (s *encryptStore) ListObjects(ctx context.Context, prefix, marker, delimiter string, limit int64) ([]Object, error) {
+	objs, err := s.ObjectStorage.ListObjects(ctx, prefix, marker, delimiter, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i, obj := range objs {
+		objs[i] = newPlaintextObject(obj)
+	}
+	return objs, nil
+}
+
+func (s *encryptStore) ListAllObjects(ctx context.Context, prefix, marker string) (<-chan Object, error) {
+	objs, err := s.ObjectStorage.ListAllObjects(ctx, prefix, marker)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan Object, listObjectsPageSize)
+	go func() {
+		defer close(ch)
+		for obj := range objs {
+			if obj != nil {
+				obj = newPlaintextObject(obj)
+			}
+			select {
+			case ch <- obj:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// readHeader reads the header of the encrypted object.
+func (s *encryptStore) readHeader(ctx context.Context, key string) (*encryptHeader, error) {
+	rc, err := s.ObjectStorage.GetObject(ctx, key, 0, encryptHeaderSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	raw, err := io.ReadAll(io.LimitReader(rc, encryptHeaderSize))
+	if err != nil {
+		return nil, err
+	}
+	header, err := unmarshalEncryptHeader(raw)
+	if err == nil && len(raw) < encryptHeaderSize {
+		err = io.ErrUnexpectedEOF
+	}
+	return header, err
+}
+
+func (h *encryptHeader) marshal() ([]byte, error) {
+	if len(h.keyID) > maxEncryptKeyIDLength || len(h.keyID)+len(h.dataKey) > encryptHeaderSize-encryptHeaderFixedSize {
+		return nil, fmt.Errorf("key id or data key is too long to fit in the encryption header")
+	}
+	raw := make([]byte, 0, encryptHeaderSize)
+	raw = append(raw, encryptMagic...)
+	raw = append(raw, encryptVersion)
+	raw = append(raw, h.nonce...)
+	raw = append(raw, byte(len(h.keyID)))
+	raw = append(raw, h.keyID...)
+	raw = binary.BigEndian.AppendUint16(raw, uint16(len(h.dataKey)))
+	raw = append(raw, h.dataKey...)
+	return raw[:encryptHeaderSize], nil
+}
+
+func unmarshalEncryptHeader(raw []byte) (*encryptHeader, error) {
+	if len(raw) < len(encryptMagic)+1 || !bytes.Equal(raw[:len(encryptMagic)], encryptMagic) {
+		return nil, errNotEncrypted
+	}
+	if raw[len(encryptMagic)] != encryptVersion {
+		return nil, fmt.Errorf("unsupported encryption version: %d", raw[len(encryptMagic)])
+	}
+	if len(raw) < encryptHeaderFixedSize {
+		return nil, io.ErrUnexpectedEOF
+	}
+	raw = raw[len(encryptMagic)+1:]
+	h := &encryptHeader{nonce: raw[:encryptNonceSize]}
+	raw = raw[encryptNonceSize:]
+	idLen := int(raw[0])
+	raw = raw[1:]
+	if len(raw) < idLen+2 {
+		return nil, fmt.Errorf("invalid encryption header")
+	}
+	h.keyID, raw = string(raw[:idLen]), raw[idLen:]
+	keyLen := int(binary.BigEndian.Uint16(raw))
+	raw = raw[2:]
+	if len(raw) < keyLen {
+		return nil, fmt.Errorf("invalid encryption header")
+	}
+	h.dataKey = raw[:keyLen]
+	return h, nil
+}
+
+// chunkNonceAndData returns the nonce and the additional data to seal the chunk.
+func chunkNonceAndData(base []byte, key string, index uint64, final bool) ([]byte, []byte) {
+	nonce := make([]byte, len(base))
+	copy(nonce, base)
+	idx := nonce[len(nonce)-8:]
+	binary.BigEndian.PutUint64(idx, binary.BigEndian.Uint64(idx)^index)
+	data := binary.BigEndian.AppendUint64([]byte(key), index)
+	if final {
+		data = append(data, 1)
+	} else {
+		data = append(data, 0)
+	}
+	return nonce, data
+}
+
+// encryptReader reads the plaintext from src and returns the encrypted object.
+type encryptReader struct {
+	src    *bufio.Reader
+	aead   cipher.AEAD
+	nonce  []byte
+	key    string
+	index  uint64
+	out    []byte // the encrypted bytes that are not read
+	plain  []byte
+	sealed []byte
+	done   bool
+}
+
+func (r *encryptReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(r.src, r.plain)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return 0, err
+		}
+		final := n < len(r.plain)
+		if !final {
+			if _, err = r.src.Peek(1); err == io.EOF {
+				final = true
+			} else if err != nil {
+				return 0, err
+			}
+		}
+		nonce, data := chunkNonceAndData(r.nonce, r.key, r.index, final)
+		r.out = r.aead.Seal(r.sealed[:0], nonce, r.plain[:n], data)
+		r.index++
+		r.done = final
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// decryptReader reads the encrypted chunks from src and returns the plaintext.
+type decryptReader struct {
+	rc     io.ReadCloser
+	src    *bufio.Reader
+	aead   cipher.AEAD
+	nonce  []byte
+	key    string
+	index  uint64 // the index of the next chunk
+	last   uint64 // the index of the last chunk to read
+	skip   int    // the bytes to skip in the first chunk
+	remain int64  // the plaintext bytes to read, -1 means reading to the end
+	buf    []byte // the plaintext bytes that are not read
+	sealed []byte
+	done   bool
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.done || r.remain == 0 {
+			return 0, io.EOF
+		}
+		if err := r.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+	if r.remain >= 0 && int64(len(r.buf)) > r.remain {
+		r.buf = r.buf[:r.remain]
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	if r.remain > 0 {
+		r.remain -= int64(n)
+	}
+	return n, nil
+}
+
+func (r *decryptReader) readChunk() error {
+	n, err := io.ReadFull(r.src, r.sealed)
+	if err == io.EOF {
+		// reading from the beginning must have the final chunk, or the range is beyond the end.
+		if r.index == 0 {
+			return io.ErrUnexpectedEOF
+		}
+		r.done = true
+		return nil
+	}
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	final := n < len(r.sealed)
+	if !final {
+		if _, err = r.src.Peek(1); err == io.EOF {
+			final = true
+		} else if err != nil {
+			return err
+		}
+	}
+	nonce, data := chunkNonceAndData(r.nonce, r.key, r.index, final)
+	plain, err := r.aead.Open(r.sealed[:0], nonce, r.sealed[:n], data)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt chunk %d of %s: %s", r.index, r.key, err)
+	}
+	if r.skip > len(plain) {
+		r.skip = len(plain)
+	}
+	r.buf, r.skip = plain[r.skip:], 0
+	r.done = final || r.index == r.last
+	r.index++
+	return nil
+}
+
+func (r *decryptReader) Close() error {
+	return r.rc.Close()
+}
+
+// plaintextReader reads the range of the object that is not encrypted.
+type plaintextReader struct {
+	io.Reader
+	io.Closer
+}
+
+func newPlaintextReader(r io.Reader, c io.Closer, offset, limit int64) io.ReadCloser {
+	if _, err := io.CopyN(io.Discard, r, offset); err != nil && err != io.EOF {
+		r = &errReader{err: err}
+	}
+	if limit > 0 {
+		r = io.LimitReader(r, limit)
+	}
+	return &plaintextReader{Reader: r, Closer: c}
+}
+
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read([]byte) (int, error) { return 0, r.err }
+
+// plaintextObject returns the plaintext size of the encrypted object.
+type plaintextObject struct {
+	Object
+}
+
+func newPlaintextObject(obj Object) Object {
+	if obj.Size() < encryptHeaderSize+encryptTagSize {
+		// it is not an encrypted object, e.g. the directory or the object written before encryption.
+		return obj
+	}
+	return &plaintextObject{obj}
+}
+
+func (o *plaintextObject) Size() int64 {
+	body := o.Object.Size() - encryptHeaderSize
+	chunks, rest := body/encryptSealedChunkSize, body%encryptSealedChunkSize
+	size := chunks * encryptChunkSize
+	if rest > encryptTagSize {
+		size += rest - encryptTagSize
+	}
+	return size
+}
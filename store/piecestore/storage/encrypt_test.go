@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeKeyFile(t *testing.T, ids ...string) string {
+	var buf bytes.Buffer
+	buf.WriteString("# test keys\n")
+	for _, id := range ids {
+		key := make([]byte, encryptKeySize)
+		_, _ = rand.Read(key)
+		buf.WriteString(fmt.Sprintf("%s:%s\n", id, hex.EncodeToString(key)))
+	}
+	keyFile := filepath.Join(t.TempDir(), "piece.key")
+	assert.Nil(t, os.WriteFile(keyFile, buf.Bytes(), 0600))
+	return keyFile
+}
+
+func setupEncryptTest(t *testing.T, allowPlaintext bool) (*encryptStore, *memoryStore) {
+	backend := &memoryStore{name: mockBucket, objects: make(map[string]*memoryObject)}
+	store, err := NewEncryptStore(backend, EncryptionConfig{KeyFile: writeKeyFile(t, "key1"),
+		AllowPlaintextRead: allowPlaintext})
+	assert.Nil(t, err)
+	return store.(*encryptStore), backend
+}
+
+func TestEncrypt_PutAndGet(t *testing.T) {
+	store, backend := setupEncryptTest(t, false)
+	sizes := []int{0, 1, encryptChunkSize - 1, encryptChunkSize, encryptChunkSize + 1, 3*encryptChunkSize + 100}
+	for _, size := range sizes {
+		data := make([]byte, size)
+		_, _ = rand.Read(data)
+		key := fmt.Sprintf("%d_s0", size)
+		assert.Nil(t, store.PutObject(context.TODO(), key, bytes.NewReader(data)))
+		// the plaintext is not written to the backend storage
+		if size > encryptTagSize {
+			assert.False(t, bytes.Contains(backend.objects[key].data, data))
+		}
+
+		obj, err := store.HeadObject(context.TODO(), key)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(size), obj.Size())
+
+		ranges := [][2]int64{{0, -1}, {0, 10}, {1, 100}, {encryptChunkSize - 5, 10}, {encryptChunkSize, -1},
+			{2*encryptChunkSize + 7, encryptChunkSize}, {int64(size) + 10, 10}}
+		for _, r := range ranges {
+			rc, err := store.GetObject(context.TODO(), key, r[0], r[1])
+			assert.Nil(t, err)
+			result, err := io.ReadAll(rc)
+			assert.Nil(t, err)
+			start, end := r[0], int64(size)
+			if start > end {
+				start = end
+			}
+			if r[1] > 0 && start+r[1] < end {
+				end = start + r[1]
+			}
+			assert.Equal(t, data[start:end], result, "size %d range %v", size, r)
+		}
+	}
+	objs, err := store.ListObjects(context.TODO(), "", "", "", 100)
+	assert.Nil(t, err)
+	assert.Equal(t, len(sizes), len(objs))
+	for _, obj := range objs {
+		var size int64
+		_, _ = fmt.Sscanf(obj.Key(), "%d_s0", &size)
+		assert.Equal(t, size, obj.Size())
+	}
+}
+
+func TestEncrypt_Tampered(t *testing.T) {
+	store, backend := setupEncryptTest(t, false)
+	data := bytes.Repeat([]byte("a"), 2*encryptChunkSize)
+	assert.Nil(t, store.PutObject(context.TODO(), "a_s0", bytes.NewReader(data)))
+	assert.Nil(t, store.PutObject(context.TODO(), "b_s0", bytes.NewReader(data)))
+
+	readAll := func(key string) error {
+		rc, err := store.GetObject(context.TODO(), key, 0, -1)
+		if err != nil {
+			return err
+		}
+		_, err = io.ReadAll(rc)
+		return err
+	}
+	// the swapped object fails to decrypt
+	backend.objects["c_s0"] = backend.objects["a_s0"]
+	assert.NotNil(t, readAll("c_s0"))
+	// the truncated object at the chunk boundary fails to decrypt
+	sealed := backend.objects["b_s0"].data
+	backend.objects["b_s0"].data = sealed[:encryptHeaderSize+encryptSealedChunkSize]
+	assert.NotNil(t, readAll("b_s0"))
+	// the modified object fails to decrypt
+	sealed[encryptHeaderSize+1] ^= 1
+	backend.objects["b_s0"].data = sealed
+	assert.NotNil(t, readAll("b_s0"))
+	// the plaintext object is rejected
+	backend.objects["d_s0"] = &memoryObject{data: data}
+	assert.NotNil(t, readAll("d_s0"))
+	assert.Nil(t, readAll("a_s0"))
+}
+
+func TestEncrypt_AllowPlaintextRead(t *testing.T) {
+	store, backend := setupEncryptTest(t, true)
+	backend.objects["plain_s0"] = &memoryObject{data: []byte("plaintext piece")}
+	rc, err := store.GetObject(context.TODO(), "plain_s0", 5, 4)
+	assert.Nil(t, err)
+	result, err := io.ReadAll(rc)
+	assert.Nil(t, err)
+	assert.Equal(t, "text", string(result))
+
+	obj, err := store.HeadObject(context.TODO(), "plain_s0")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len("plaintext piece")), obj.Size())
+}
+
+func TestLocalKeyProvider_Rotation(t *testing.T) {
+	keyFile := writeKeyFile(t, "key1")
+	provider, err := NewKeyProvider(EncryptionConfig{KeyFile: keyFile})
+	assert.Nil(t, err)
+	keyID, dataKey, sealed, err := provider.GenerateDataKey(context.TODO())
+	assert.Nil(t, err)
+	assert.Equal(t, "key1", keyID)
+
+	// prepend a new key and keep the old key to decrypt the previous data keys
+	old, err := os.ReadFile(keyFile)
+	assert.Nil(t, err)
+	newKey := make([]byte, encryptKeySize)
+	_, _ = rand.Read(newKey)
+	assert.Nil(t, os.WriteFile(keyFile, append([]byte("key2:"+hex.EncodeToString(newKey)+"\n"), old...), 0600))
+	provider, err = NewKeyProvider(EncryptionConfig{KeyFile: keyFile})
+	assert.Nil(t, err)
+	result, err := provider.DecryptDataKey(context.TODO(), keyID, sealed)
+	assert.Nil(t, err)
+	assert.Equal(t, dataKey, result)
+	keyID, _, _, err = provider.GenerateDataKey(context.TODO())
+	assert.Nil(t, err)
+	assert.Equal(t, "key2", keyID)
+
+	_, err = provider.DecryptDataKey(context.TODO(), "key3", sealed)
+	assert.NotNil(t, err)
+
+	// the master key id selects the key to encrypt the new data keys
+	provider, err = NewKeyProvider(EncryptionConfig{KeyFile: keyFile, MasterKeyID: "key1"})
+	assert.Nil(t, err)
+	keyID, _, _, err = provider.GenerateDataKey(context.TODO())
+	assert.Nil(t, err)
+	assert.Equal(t, "key1", keyID)
+	_, err = NewKeyProvider(EncryptionConfig{KeyFile: keyFile, MasterKeyID: "key3"})
+	assert.NotNil(t, err)
+	_, err = NewKeyProvider(EncryptionConfig{KeyProvider: "kms"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+)
+
+// ErrInjectedFault defines the error returned by the operation that a fault is injected into
+var ErrInjectedFault = errors.New("injected fault")
+
+// faultStore injects latency, errors and partial reads into the operations of the backend
+// storage by rules, it is used to test the resilience of the callers without network.
+type faultStore struct {
+	ObjectStorage
+	rules []*faultRule
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+type faultRule struct {
+	FaultRule
+	ops     map[string]bool
+	pattern *regexp.Regexp
+}
+
+// NewFaultStore returns the object storage that injects the faults of the rules into store.
+func NewFaultStore(store ObjectStorage, cfg FaultConfig) (ObjectStorage, error) {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	f := &faultStore{ObjectStorage: store, rand: rand.New(rand.NewSource(seed))}
+	for i, rule := range cfg.Rules {
+		if rule.ErrorRate < 0 || rule.ErrorRate > 1 || rule.PartialReadRate < 0 || rule.PartialReadRate > 1 {
+			return nil, fmt.Errorf("invalid fault rate of rule %d", i)
+		}
+		r := &faultRule{FaultRule: rule, ops: make(map[string]bool)}
+		for _, op := range rule.Operations {
+			switch op {
+			case FaultOpGet, FaultOpPut, FaultOpDelete, FaultOpHead, FaultOpList:
+				r.ops[op] = true
+			default:
+				return nil, fmt.Errorf("invalid fault operation of rule %d: %s", i, op)
+			}
+		}
+		if rule.KeyPattern != "" {
+			pattern, err := regexp.Compile(rule.KeyPattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid fault key pattern of rule %d: %s", i, err)
+			}
+			r.pattern = pattern
+		}
+		f.rules = append(f.rules, r)
+	}
+	log.Warnw("fault injection is enabled on the object storage", "storage", store.String(), "rules", len(f.rules))
+	return f, nil
+}
+
+func (f *faultStore) String() string {
+	return fmt.Sprintf("fault(%s)", f.ObjectStorage)
+}
+
+// inject waits for the latency of the rules that match the operation and the key, returns
+// ErrInjectedFault if the operation fails by the error rate, and whether the read is partial.
+func (f *faultStore) inject(ctx context.Context, op, key string) (partial bool, err error) {
+	var latency time.Duration
+	f.mu.Lock()
+	for _, r := range f.rules {
+		if len(r.ops) > 0 && !r.ops[op] {
+			continue
+		}
+		if r.pattern != nil && !r.pattern.MatchString(key) {
+			continue
+		}
+		latency += time.Duration(r.Latency) * time.Millisecond
+		if r.ErrorRate > 0 && f.rand.Float64() < r.ErrorRate {
+			err = fmt.Errorf("%w: %s %s", ErrInjectedFault, op, key)
+		}
+		if op == FaultOpGet && r.PartialReadRate > 0 && f.rand.Float64() < r.PartialReadRate {
+			partial = true
+		}
+	}
+	f.mu.Unlock()
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+	return partial, err
+}
+
+func (f *faultStore) GetObject(ctx context.Context, key string, offset, limit int64) (io.ReadCloser, error) {
+	partial, err := f.inject(ctx, FaultOpGet, key)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := f.ObjectStorage.GetObject(ctx, key, offset, limit)
+	if err != nil || !partial {
+		return rc, err
+	}
+	return &partialReader{ReadCloser: rc, key: key}, nil
+}
+
+func (f *faultStore) PutObject(ctx context.Context, key string, reader io.Reader) error {
+	if _, err := f.inject(ctx, FaultOpPut, key); err != nil {
+		return err
+	}
+	return f.ObjectStorage.PutObject(ctx, key, reader)
+}
+
+func (f *faultStore) DeleteObject(ctx context.Context, key string) error {
+	if _, err := f.inject(ctx, FaultOpDelete, key); err != nil {
+		return err
+	}
+	return f.ObjectStorage.DeleteObject(ctx, key)
+}
+
+// DeleteObjects injects the faults into every key, the keys without faults are deleted in batch.
+func (f *faultStore) DeleteObjects(ctx context.Context, keys []string) map[string]error {
+	failed := make(map[string]error)
+	deleting := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if _, err := f.inject(ctx, FaultOpDelete, key); err != nil {
+			failed[key] = err
+			continue
+		}
+		deleting = append(deleting, key)
+	}
+	for key, err := range f.ObjectStorage.DeleteObjects(ctx, deleting) {
+		failed[key] = err
+	}
+	return failed
+}
+
+func (f *faultStore) HeadObject(ctx context.Context, key string) (Object, error) {
+	if _, err := f.inject(ctx, FaultOpHead, key); err != nil {
+		return nil, err
+	}
+	return f.ObjectStorage.HeadObject(ctx, key)
+}
+
+func (f *faultStore) ListObjects(ctx context.Context, prefix, marker, delimiter string, limit int64) ([]Object, error) {
+	if _, err := f.inject(ctx, FaultOpList, prefix); err != nil {
+		return nil, err
+	}
+	return f.ObjectStorage.ListObjects(ctx, prefix, marker, delimiter, limit)
+}
+
+func (f *faultStore) ListAllObjects(ctx context.Context, prefix, marker string) (<-chan Object, error) {
+	if _, err := f.inject(ctx, FaultOpList, prefix); err != nil {
+		return nil, err
+	}
+	return f.ObjectStorage.ListAllObjects(ctx, prefix, marker)
+}
+
+// partialReader returns the first half of the data of the first read, then fails as if the
+// connection is broken halfway.
+type partialReader struct {
+	io.ReadCloser
+	key  string
+	done bool
+}
+
+func (p *partialReader) Read(buf []byte) (int, error) {
+	if p.done {
+		return 0, fmt.Errorf("%w: partial read %s: %s", ErrInjectedFault, p.key, io.ErrUnexpectedEOF)
+	}
+	p.done = true
+	n, err := p.ReadCloser.Read(buf)
+	if err != nil && err != io.EOF {
+		return n, err
+	}
+	return n / 2, nil
+}
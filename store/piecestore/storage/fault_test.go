@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupFaultTest(t *testing.T, rules ...FaultRule) (ObjectStorage, *memoryStore) {
+	mem := &memoryStore{name: mockBucket, objects: make(map[string]*memoryObject)}
+	store, err := NewFaultStore(mem, FaultConfig{Enable: true, Seed: 1, Rules: rules})
+	assert.Nil(t, err)
+	return store, mem
+}
+
+func TestFault_InvalidRule(t *testing.T) {
+	mem := &memoryStore{name: mockBucket, objects: make(map[string]*memoryObject)}
+	for _, rule := range []FaultRule{
+		{ErrorRate: 1.5},
+		{PartialReadRate: -1},
+		{Operations: []string{"copy"}},
+		{KeyPattern: "(["},
+	} {
+		_, err := NewFaultStore(mem, FaultConfig{Enable: true, Rules: []FaultRule{rule}})
+		assert.NotNil(t, err)
+	}
+}
+
+func TestFault_Error(t *testing.T) {
+	store, mem := setupFaultTest(t, FaultRule{
+		Operations: []string{FaultOpPut, FaultOpDelete},
+		KeyPattern: `^1_`,
+		ErrorRate:  1,
+	})
+	ctx := context.TODO()
+	err := store.PutObject(ctx, "1_s0", strings.NewReader("piece"))
+	assert.True(t, errors.Is(err, ErrInjectedFault))
+	assert.Equal(t, 0, len(mem.objects))
+
+	// the unmatched operations and keys are not affected
+	assert.Nil(t, store.PutObject(ctx, "2_s0", strings.NewReader("piece")))
+	assert.Nil(t, mem.PutObject(ctx, "1_s0", strings.NewReader("piece")))
+	_, err = store.HeadObject(ctx, "1_s0")
+	assert.Nil(t, err)
+
+	failed := store.DeleteObjects(ctx, []string{"1_s0", "2_s0"})
+	assert.Equal(t, 1, len(failed))
+	assert.True(t, errors.Is(failed["1_s0"], ErrInjectedFault))
+	_, err = mem.HeadObject(ctx, "2_s0")
+	assert.NotNil(t, err)
+}
+
+func TestFault_ErrorRate(t *testing.T) {
+	store, _ := setupFaultTest(t, FaultRule{Operations: []string{FaultOpHead}, ErrorRate: 0.5})
+	var failed int
+	for i := 0; i < 1000; i++ {
+		if _, err := store.HeadObject(context.TODO(), mockKey); errors.Is(err, ErrInjectedFault) {
+			failed++
+		}
+	}
+	assert.True(t, failed > 400 && failed < 600, failed)
+}
+
+func TestFault_Latency(t *testing.T) {
+	store, _ := setupFaultTest(t, FaultRule{Operations: []string{FaultOpPut}, Latency: 50})
+	start := time.Now()
+	assert.Nil(t, store.PutObject(context.TODO(), "1_s0", strings.NewReader("piece")))
+	assert.True(t, time.Since(start) >= 50*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	err := store.PutObject(ctx, "1_s0", strings.NewReader("piece"))
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestFault_PartialRead(t *testing.T) {
+	store, mem := setupFaultTest(t, FaultRule{PartialReadRate: 1})
+	assert.Nil(t, mem.PutObject(context.TODO(), "1_s0", strings.NewReader("segment")))
+	rc, err := store.GetObject(context.TODO(), "1_s0", 0, -1)
+	assert.Nil(t, err)
+	data, err := io.ReadAll(rc)
+	assert.True(t, errors.Is(err, ErrInjectedFault))
+	assert.True(t, len(data) < len("segment"))
+	assert.Equal(t, "segment"[:len(data)], string(data))
+}
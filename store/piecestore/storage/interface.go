@@ -21,6 +21,9 @@ type ObjectStorage interface {
 	PutObject(ctx context.Context, key string, reader io.Reader) error
 	// DeleteObject deletes an object
 	DeleteObject(ctx context.Context, key string) error
+	// DeleteObjects deletes the objects in batch, returns the errors of the keys that fail to
+	// be deleted, the keys that do not exist are regarded as deleted.
+	DeleteObjects(ctx context.Context, keys []string) map[string]error
 
 	// HeadBucket determines if a bucket exists and have permission to access it
 	HeadBucket(ctx context.Context) error
@@ -28,8 +31,41 @@ type ObjectStorage interface {
 	HeadObject(ctx context.Context, key string) (Object, error)
 	// ListObjects lists returns a list of objects
 	ListObjects(ctx context.Context, prefix, marker, delimiter string, limit int64) ([]Object, error)
-	// ListAllObjects returns all the objects as a channel
+	// ListAllObjects returns all the objects whose key is greater than marker as a channel
+	// in key order, a nil object is sent to the channel if listing fails halfway.
 	ListAllObjects(ctx context.Context, prefix, marker string) (<-chan Object, error)
+	// Capacity returns the total and available bytes of the backend storage, returns
+	// ErrUnsupportedMethod if the backend storage does not have capacity limit, e.g. s3
+	Capacity(ctx context.Context) (total uint64, available uint64, err error)
+}
+
+// Presigner is the optional interface of ObjectStorage that generates the short-lived url
+// to read the object from the backend storage directly, it is only implemented by the
+// backend storages that store the piece data as is, e.g. s3, minio and b2.
+type Presigner interface {
+	// PresignGet returns the presigned url to get the object, the response headers of
+	// the url are overridden by the non-empty fields of header.
+	PresignGet(ctx context.Context, key string, expire time.Duration, header PresignHeader) (string, error)
+}
+
+// PackCompactor is the optional interface of ObjectStorage that compacts the pack files of
+// the packed small pieces, it should be called periodically by only one process.
+type PackCompactor interface {
+	// CompactPacks compacts the pack files that have too many deleted pieces.
+	CompactPacks(ctx context.Context)
+}
+
+// OrphanRemover is the optional interface of ObjectStorage that removes the orphan files left
+// by the writes interrupted by crash, it should be called periodically by only one process.
+type OrphanRemover interface {
+	// RemoveOrphanFiles removes the orphan files until the ctx is done.
+	RemoveOrphanFiles(ctx context.Context)
+}
+
+// PresignHeader contains the response headers overridden by the presigned url.
+type PresignHeader struct {
+	ContentType        string
+	ContentDisposition string
 }
 
 // Object
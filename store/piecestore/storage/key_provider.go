@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// KeyProvider provides the data keys to encrypt the pieces. Each piece is encrypted by
+// a new data key, and the data key is encrypted by the master key of the key provider
+// and stored with the encrypted piece, which is also called envelope encryption.
+type KeyProvider interface {
+	// GenerateDataKey returns a new data key and its ciphertext that is encrypted by the
+	// master key, keyID is the id of the master key.
+	GenerateDataKey(ctx context.Context) (keyID string, plaintext []byte, ciphertext []byte, err error)
+	// DecryptDataKey decrypts the ciphertext of the data key by the master key with keyID.
+	DecryptDataKey(ctx context.Context, keyID string, ciphertext []byte) ([]byte, error)
+}
+
+// KeyProviderFn creates a KeyProvider by the encryption config.
+type KeyProviderFn func(cfg EncryptionConfig) (KeyProvider, error)
+
+var (
+	keyProviderMux sync.RWMutex
+	keyProviderMap = map[string]KeyProviderFn{
+		LocalKeyProvider: newLocalKeyProvider,
+	}
+)
+
+// RegisterKeyProvider registers a KeyProvider with the name, it is used to plug in the
+// kms key providers, and the registered name is used as EncryptionConfig.KeyProvider.
+func RegisterKeyProvider(name string, fn KeyProviderFn) {
+	keyProviderMux.Lock()
+	defer keyProviderMux.Unlock()
+	keyProviderMap[strings.ToLower(name)] = fn
+}
+
+// NewKeyProvider returns the KeyProvider specified by EncryptionConfig.KeyProvider.
+func NewKeyProvider(cfg EncryptionConfig) (KeyProvider, error) {
+	name := cfg.KeyProvider
+	if name == "" {
+		name = LocalKeyProvider
+	}
+	keyProviderMux.RLock()
+	fn, ok := keyProviderMap[strings.ToLower(name)]
+	keyProviderMux.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("invalid key provider: %s", name)
+	}
+	return fn(cfg)
+}
+
+// localKeyProvider loads the master keys from the local key file. Each line of the key file
+// is <key_id>:<hex encoded 32 bytes key>, the key of MasterKeyID is used to encrypt the new
+// data keys, or the first key if MasterKeyID is empty, and the others are kept to decrypt the
+// data keys encrypted by the rotated keys. The keys are rotated by adding the new key to the
+// key file and pointing MasterKeyID to it.
+type localKeyProvider struct {
+	activeID string
+	keys     map[string]cipher.AEAD
+}
+
+func newLocalKeyProvider(cfg EncryptionConfig) (KeyProvider, error) {
+	if cfg.KeyFile == "" {
+		return nil, fmt.Errorf("key file is required by the local key provider")
+	}
+	f, err := os.Open(cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open key file: %s", err)
+	}
+	defer f.Close()
+
+	p := &localKeyProvider{keys: make(map[string]cipher.AEAD)}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		id, hexKey, found := strings.Cut(text, ":")
+		id = strings.TrimSpace(id)
+		if !found || id == "" || len(id) > maxEncryptKeyIDLength {
+			return nil, fmt.Errorf("invalid key id at line %d of key file", line)
+		}
+		key, err := hex.DecodeString(strings.TrimSpace(hexKey))
+		if err != nil || len(key) != encryptKeySize {
+			return nil, fmt.Errorf("invalid key at line %d of key file, it should be %d bytes hex", line, encryptKeySize)
+		}
+		if _, ok := p.keys[id]; ok {
+			return nil, fmt.Errorf("duplicate key id %s in key file", id)
+		}
+		if p.keys[id], err = newGCM(key); err != nil {
+			return nil, err
+		}
+		if p.activeID == "" {
+			p.activeID = id
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read key file: %s", err)
+	}
+	if p.activeID == "" {
+		return nil, fmt.Errorf("no key in key file %s", cfg.KeyFile)
+	}
+	if cfg.MasterKeyID != "" {
+		if _, ok := p.keys[cfg.MasterKeyID]; !ok {
+			return nil, fmt.Errorf("master key id %s is not in key file %s", cfg.MasterKeyID, cfg.KeyFile)
+		}
+		p.activeID = cfg.MasterKeyID
+	}
+	return p, nil
+}
+
+func (p *localKeyProvider) GenerateDataKey(ctx context.Context) (string, []byte, []byte, error) {
+	dataKey := make([]byte, encryptKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", nil, nil, err
+	}
+	aead := p.keys[p.activeID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, nil, err
+	}
+	return p.activeID, dataKey, aead.Seal(nonce, nonce, dataKey, []byte(p.activeID)), nil
+}
+
+func (p *localKeyProvider) DecryptDataKey(ctx context.Context, keyID string, ciphertext []byte) ([]byte, error) {
+	aead, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown master key id: %s", keyID)
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, fmt.Errorf("invalid data key ciphertext")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, []byte(keyID))
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -84,6 +84,10 @@ func (m *memoryStore) DeleteObject(ctx context.Context, key string) error {
 	return nil
 }
 
+func (m *memoryStore) DeleteObjects(ctx context.Context, keys []string) map[string]error {
+	return deleteObjects(ctx, keys, 1, m.DeleteObject)
+}
+
 func (m *memoryStore) HeadBucket(ctx context.Context) error {
 	return nil
 }
@@ -136,3 +140,7 @@ func (m *memoryStore) ListObjects(ctx context.Context, prefix, marker, delimiter
 	}
 	return objs, nil
 }
+
+func (m *memoryStore) ListAllObjects(ctx context.Context, prefix, marker string) (<-chan Object, error) {
+	return listAllObjects(ctx, m, prefix, marker)
+}
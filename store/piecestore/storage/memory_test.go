@@ -2,8 +2,10 @@ package storage
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strings"
 	"testing"
 
@@ -243,6 +245,23 @@ func TestMemory_ListError(t *testing.T) {
 
 func TestMemory_ListAll(t *testing.T) {
 	store := setupMemoryTest(t)
-	_, err := store.ListAllObjects(context.TODO(), emptyString, emptyString)
+	store.objects = make(map[string]*memoryObject)
+	for i := 0; i < listObjectsPageSize+10; i++ {
+		store.objects[fmt.Sprintf("%05d", i)] = &memoryObject{data: []byte(mockAccessKey)}
+	}
+	ch, err := store.ListAllObjects(context.TODO(), emptyString, "00004")
+	assert.Nil(t, err)
+	keys := make([]string, 0)
+	for obj := range ch {
+		keys = append(keys, obj.Key())
+	}
+	assert.Equal(t, listObjectsPageSize+5, len(keys))
+	assert.Equal(t, "00005", keys[0])
+	assert.True(t, sort.StringsAreSorted(keys))
+}
+
+func TestMemory_Capacity(t *testing.T) {
+	store := setupMemoryTest(t)
+	_, _, err := store.Capacity(context.TODO())
 	assert.Equal(t, ErrUnsupportedMethod, err)
 }
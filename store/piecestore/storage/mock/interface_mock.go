@@ -37,6 +37,22 @@ func (m *MockObjectStorage) EXPECT() *MockObjectStorageMockRecorder {
 	return m.recorder
 }
 
+// Capacity mocks base method.
+func (m *MockObjectStorage) Capacity(ctx context.Context) (uint64, uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Capacity", ctx)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(uint64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Capacity indicates an expected call of Capacity.
+func (mr *MockObjectStorageMockRecorder) Capacity(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Capacity", reflect.TypeOf((*MockObjectStorage)(nil).Capacity), ctx)
+}
+
 // CreateBucket mocks base method.
 func (m *MockObjectStorage) CreateBucket(ctx context.Context) error {
 	m.ctrl.T.Helper()
@@ -65,6 +81,20 @@ func (mr *MockObjectStorageMockRecorder) DeleteObject(ctx, key interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteObject", reflect.TypeOf((*MockObjectStorage)(nil).DeleteObject), ctx, key)
 }
 
+// DeleteObjects mocks base method.
+func (m *MockObjectStorage) DeleteObjects(ctx context.Context, keys []string) map[string]error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteObjects", ctx, keys)
+	ret0, _ := ret[0].(map[string]error)
+	return ret0
+}
+
+// DeleteObjects indicates an expected call of DeleteObjects.
+func (mr *MockObjectStorageMockRecorder) DeleteObjects(ctx, keys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteObjects", reflect.TypeOf((*MockObjectStorage)(nil).DeleteObjects), ctx, keys)
+}
+
 // GetObject mocks base method.
 func (m *MockObjectStorage) GetObject(ctx context.Context, key string, offset, limit int64) (io.ReadCloser, error) {
 	m.ctrl.T.Helper()
@@ -28,6 +28,73 @@ var storageMap = map[string]StorageFn{
 	MemoryStore:   newMemoryStore,
 }
 
+// listObjectsPageSize defines the page size of listing all objects by ListObjects.
+const listObjectsPageSize = 1000
+
+// listAllObjects lists all the objects by paging ListObjects, the objects are sent
+// in key order, and a nil object is sent if listing fails halfway.
+func listAllObjects(ctx context.Context, store ObjectStorage, prefix, marker string) (<-chan Object, error) {
+	objs, err := store.ListObjects(ctx, prefix, marker, "", listObjectsPageSize)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan Object, listObjectsPageSize)
+	go func() {
+		defer close(ch)
+		for {
+			for _, obj := range objs {
+				select {
+				case ch <- obj:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if len(objs) < listObjectsPageSize {
+				return
+			}
+			marker = objs[len(objs)-1].Key()
+			if objs, err = store.ListObjects(ctx, prefix, marker, "", listObjectsPageSize); err != nil {
+				log.Errorw("failed to list objects", "store", store.String(), "marker", marker, "error", err)
+				select {
+				case ch <- nil:
+				case <-ctx.Done():
+				}
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// deleteObjects deletes the objects one by one with the max concurrency, and returns the
+// errors of the keys that fail to be deleted.
+func deleteObjects(ctx context.Context, keys []string, concurrency int,
+	deleteObject func(ctx context.Context, key string) error) map[string]error {
+	var (
+		mux    sync.Mutex
+		wg     sync.WaitGroup
+		failed = make(map[string]error)
+		limit  = make(chan struct{}, concurrency)
+	)
+	for _, key := range keys {
+		limit <- struct{}{}
+		wg.Add(1)
+		go func(key string) {
+			defer func() {
+				<-limit
+				wg.Done()
+			}()
+			if err := deleteObject(ctx, key); err != nil {
+				mux.Lock()
+				failed[key] = err
+				mux.Unlock()
+			}
+		}(key)
+	}
+	wg.Wait()
+	return failed
+}
+
 type DefaultObjectStorage struct{}
 
 func (s DefaultObjectStorage) CreateBucket(ctx context.Context) error {
@@ -42,6 +109,10 @@ func (s DefaultObjectStorage) ListAllObjects(ctx context.Context, prefix, marker
 	return nil, ErrUnsupportedMethod
 }
 
+func (s DefaultObjectStorage) Capacity(ctx context.Context) (uint64, uint64, error) {
+	return 0, 0, ErrUnsupportedMethod
+}
+
 type file struct {
 	object
 	group     string
@@ -0,0 +1,403 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bnb-chain/greenfield-storage-provider/core/spdb"
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/log"
+)
+
+// packStore packs the small pieces into large pack files to reduce the request count and
+// the per object overhead of the backend storage. The small pieces are appended to the
+// pending pack, and the pending pack is written to the backend storage as an immutable
+// pack file when its size reaches MaxPackSize or it waits for FlushInterval, PutObject
+// returns after the pack file and its index are written. The pieces that are deleted or
+// overwritten leave holes in the pack files, and the pack files are compacted by moving
+// the live pieces to the new pack files. The compaction is driven by the caller of
+// CompactPacks, so that it runs in only one of the processes sharing the pack index.
+type packStore struct {
+	ObjectStorage
+	index spdb.PackDB
+	cfg   PackConfig
+
+	mux     sync.Mutex
+	pending *pendingPack
+	seq     uint64
+	// shadowSwept is set after the unpacked pieces shadowed by the packed ones are removed
+	shadowSwept atomic.Bool
+}
+
+// pendingPack is the pack that is not written to the backend storage.
+type pendingPack struct {
+	buf    bytes.Buffer
+	pieces []*spdb.PackedPiece
+	keys   map[string]int // keys is the index of the piece in pieces by piece key
+	timer  *time.Timer
+	done   chan struct{}
+	err    error
+}
+
+// NewPackStore returns an ObjectStorage that packs the small pieces into pack files
+// of the underlying ObjectStorage, and the index of the packed pieces is stored in index.
+func NewPackStore(store ObjectStorage, index spdb.PackDB, cfg PackConfig) (ObjectStorage, error) {
+	if index == nil {
+		return nil, fmt.Errorf("pack index is required to pack pieces")
+	}
+	if cfg.MaxPieceSize <= 0 {
+		cfg.MaxPieceSize = DefaultPackMaxPieceSize
+	}
+	if cfg.MaxPackSize <= 0 {
+		cfg.MaxPackSize = DefaultPackMaxPackSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultPackFlushInterval
+	}
+	if cfg.CompactLiveRatio <= 0 {
+		cfg.CompactLiveRatio = DefaultPackCompactLiveRatio
+	}
+	if cfg.CompactInterval <= 0 {
+		cfg.CompactInterval = DefaultPackCompactInterval
+	}
+	return &packStore{ObjectStorage: store, index: index, cfg: cfg}, nil
+}
+
+func (s *packStore) String() string {
+	return fmt.Sprintf("pack://%s", s.ObjectStorage)
+}
+
+func (s *packStore) GetObject(ctx context.Context, key string, offset, limit int64) (io.ReadCloser, error) {
+	piece, err := s.index.GetPackedPiece(key)
+	if err != nil {
+		log.Errorw("failed to get packed piece", "key", key, "error", err)
+		return nil, err
+	}
+	if piece == nil {
+		return s.ObjectStorage.GetObject(ctx, key, offset, limit)
+	}
+	rc, err := s.getPackedPiece(ctx, piece, offset, limit)
+	if err == nil {
+		return rc, nil
+	}
+	// the piece may be moved to another pack by compaction, retry with the latest location.
+	latest, indexErr := s.index.GetPackedPiece(key)
+	if indexErr != nil || latest == nil || latest.PackKey == piece.PackKey {
+		log.Errorw("failed to get packed piece from pack", "key", key, "pack_key", piece.PackKey, "error", err)
+		return nil, err
+	}
+	return s.getPackedPiece(ctx, latest, offset, limit)
+}
+
+func (s *packStore) getPackedPiece(ctx context.Context, piece *spdb.PackedPiece, offset, limit int64) (
+	io.ReadCloser, error) {
+	if offset > piece.Length {
+		offset = piece.Length
+	}
+	length := piece.Length - offset
+	if limit > 0 && limit < length {
+		length = limit
+	}
+	if length == 0 {
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	}
+	return s.ObjectStorage.GetObject(ctx, piece.PackKey, piece.Offset+offset, length)
+}
+
+func (s *packStore) PutObject(ctx context.Context, key string, reader io.Reader) error {
+	data, err := io.ReadAll(io.LimitReader(reader, s.cfg.MaxPieceSize+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) <= s.cfg.MaxPieceSize {
+		// the unpacked piece put before packing is enabled is shadowed by the index if it is
+		// overwritten, it is removed by the compaction instead of deleted by every put.
+		return s.wait(ctx, s.append(&spdb.PackedPiece{PieceKey: key}, data))
+	}
+	if err = s.ObjectStorage.PutObject(ctx, key, io.MultiReader(bytes.NewReader(data), reader)); err != nil {
+		return err
+	}
+	// the piece is overwritten by a large piece, remove the packed one to not shadow it.
+	if _, err = s.index.DeletePackedPiece(key); err != nil {
+		log.Errorw("failed to delete the overwritten packed piece", "key", key, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (s *packStore) DeleteObject(ctx context.Context, key string) error {
+	found, err := s.index.DeletePackedPiece(key)
+	if err != nil {
+		log.Errorw("failed to delete packed piece", "key", key, "error", err)
+		return err
+	}
+	if found {
+		return nil
+	}
+	return s.ObjectStorage.DeleteObject(ctx, key)
+}
+
+// DeleteObjects deletes the index of the packed pieces, and deletes the other pieces from
+// the underlying ObjectStorage in batch.
+func (s *packStore) DeleteObjects(ctx context.Context, keys []string) map[string]error {
+	var (
+		failed   = make(map[string]error)
+		unpacked []string
+	)
+	for _, key := range keys {
+		found, err := s.index.DeletePackedPiece(key)
+		if err != nil {
+			log.Errorw("failed to delete packed piece", "key", key, "error", err)
+			failed[key] = err
+		} else if !found {
+			unpacked = append(unpacked, key)
+		}
+	}
+	if len(unpacked) > 0 {
+		for key, err := range s.ObjectStorage.DeleteObjects(ctx, unpacked) {
+			failed[key] = err
+		}
+	}
+	return failed
+}
+
+// ListObjects lists the objects of the underlying ObjectStorage except the pack files.
+func (s *packStore) ListObjects(ctx context.Context, prefix, marker, delimiter string, limit int64) ([]Object, error) {
+	objects, err := s.ObjectStorage.ListObjects(ctx, prefix, marker, delimiter, limit)
+	if err != nil {
+		return nil, err
+	}
+	filtered := objects[:0]
+	for _, obj := range objects {
+		if !isPackFile(obj.Key()) {
+			filtered = append(filtered, obj)
+		}
+	}
+	return filtered, nil
+}
+
+// ListAllObjects lists all the objects of the underlying ObjectStorage except the pack files.
+func (s *packStore) ListAllObjects(ctx context.Context, prefix, marker string) (<-chan Object, error) {
+	objects, err := s.ObjectStorage.ListAllObjects(ctx, prefix, marker)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan Object, listObjectsPageSize)
+	go func() {
+		defer close(ch)
+		for obj := range objects {
+			if obj != nil && isPackFile(obj.Key()) {
+				continue
+			}
+			select {
+			case ch <- obj:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func isPackFile(key string) bool {
+	return strings.HasPrefix(key, PackKeyPrefix)
+}
+
+func (s *packStore) HeadObject(ctx context.Context, key string) (Object, error) {
+	piece, err := s.index.GetPackedPiece(key)
+	if err != nil {
+		log.Errorw("failed to get packed piece", "key", key, "error", err)
+		return nil, err
+	}
+	if piece == nil {
+		return s.ObjectStorage.HeadObject(ctx, key)
+	}
+	return &object{key, piece.Length, time.Time{}, false}, nil
+}
+
+// append appends the piece to the pending pack, and returns the pending pack.
+func (s *packStore) append(piece *spdb.PackedPiece, data []byte) *pendingPack {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.pending == nil {
+		pending := &pendingPack{keys: make(map[string]int), done: make(chan struct{})}
+		pending.timer = time.AfterFunc(time.Duration(s.cfg.FlushInterval)*time.Millisecond, func() {
+			s.flush(pending)
+		})
+		s.pending = pending
+	}
+	pending := s.pending
+	piece.Offset = int64(pending.buf.Len())
+	piece.Length = int64(len(data))
+	pending.buf.Write(data)
+	if idx, ok := pending.keys[piece.PieceKey]; ok {
+		// the moved piece by compaction does not overwrite the piece put by user.
+		if piece.PrevPackKey == "" || pending.pieces[idx].PrevPackKey != "" {
+			pending.pieces[idx] = piece
+		}
+	} else {
+		pending.keys[piece.PieceKey] = len(pending.pieces)
+		pending.pieces = append(pending.pieces, piece)
+	}
+	if int64(pending.buf.Len()) >= s.cfg.MaxPackSize {
+		s.pending = nil
+		if pending.timer.Stop() {
+			go s.flush(pending)
+		}
+	}
+	return pending
+}
+
+// flush writes the pending pack to the backend storage and inserts the index.
+func (s *packStore) flush(pending *pendingPack) {
+	s.mux.Lock()
+	if s.pending == pending {
+		s.pending = nil
+	}
+	s.mux.Unlock()
+
+	defer close(pending.done)
+	ctx := context.Background()
+	pack := &spdb.PackFile{
+		PackKey: fmt.Sprintf("%s%d_%d", PackKeyPrefix, time.Now().UnixNano(), atomic.AddUint64(&s.seq, 1)),
+		Size:    int64(pending.buf.Len()),
+	}
+	if pending.err = s.ObjectStorage.PutObject(ctx, pack.PackKey, bytes.NewReader(pending.buf.Bytes())); pending.err != nil {
+		log.Errorw("failed to put pack file", "pack_key", pack.PackKey, "error", pending.err)
+		return
+	}
+	if pending.err = s.index.InsertPack(pack, pending.pieces); pending.err != nil {
+		log.Errorw("failed to insert pack index", "pack_key", pack.PackKey, "error", pending.err)
+		if err := s.ObjectStorage.DeleteObject(ctx, pack.PackKey); err != nil {
+			log.Errorw("failed to delete the pack file without index", "pack_key", pack.PackKey, "error", err)
+		}
+		return
+	}
+	log.Debugw("succeed to flush pack file", "pack_key", pack.PackKey, "size", pack.Size,
+		"piece_count", len(pending.pieces))
+}
+
+func (s *packStore) wait(ctx context.Context, pending *pendingPack) error {
+	select {
+	case <-pending.done:
+		return pending.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CompactPacks compacts the packs whose live size ratio is less than CompactLiveRatio, it
+// returns when ctx is done. The unpacked pieces shadowed by the packed ones are removed by
+// the first compaction of the process.
+func (s *packStore) CompactPacks(ctx context.Context) {
+	if !s.shadowSwept.Load() && s.removeShadowedPieces(ctx) {
+		s.shadowSwept.Store(true)
+	}
+	packs, err := s.index.GetPacksToCompact(s.cfg.CompactLiveRatio, packCompactBatch)
+	if err != nil {
+		log.Errorw("failed to get packs to compact", "error", err)
+		return
+	}
+	for _, pack := range packs {
+		if ctx.Err() != nil {
+			return
+		}
+		if err = s.compact(ctx, pack); err != nil {
+			log.Errorw("failed to compact pack", "pack_key", pack.PackKey, "error", err)
+			continue
+		}
+		log.Infow("succeed to compact pack", "pack_key", pack.PackKey, "size", pack.Size,
+			"live_size", pack.LiveSize)
+	}
+}
+
+// removeShadowedPieces deletes the unpacked pieces that are overwritten by the packed ones,
+// they are left by the pieces put before packing is enabled. It returns whether all the
+// pieces are checked.
+func (s *packStore) removeShadowedPieces(ctx context.Context) bool {
+	// the listing is canceled if the removal stops halfway
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	objects, err := s.ObjectStorage.ListAllObjects(ctx, "", "")
+	if err != nil {
+		log.Errorw("failed to list objects to remove shadowed pieces", "error", err)
+		return false
+	}
+	var removed int
+	for obj := range objects {
+		if obj == nil {
+			log.Errorw("failed to list all objects to remove shadowed pieces")
+			return false
+		}
+		if isPackFile(obj.Key()) {
+			continue
+		}
+		piece, err := s.index.GetPackedPiece(obj.Key())
+		if err != nil {
+			log.Errorw("failed to get packed piece", "key", obj.Key(), "error", err)
+			return false
+		}
+		if piece == nil {
+			continue
+		}
+		if err = s.ObjectStorage.DeleteObject(ctx, obj.Key()); err != nil {
+			log.Errorw("failed to delete shadowed unpacked piece", "key", obj.Key(), "error", err)
+			return false
+		}
+		removed++
+	}
+	if ctx.Err() != nil {
+		return false
+	}
+	log.Infow("succeed to remove shadowed unpacked pieces", "count", removed)
+	return true
+}
+
+// compact moves the live pieces of the pack to the pending pack, and deletes the pack
+// after all the live pieces are moved.
+func (s *packStore) compact(ctx context.Context, pack *spdb.PackFile) error {
+	pieces, err := s.index.ListPackedPieces(pack.PackKey)
+	if err != nil {
+		return err
+	}
+	if len(pieces) != 0 {
+		rc, err := s.ObjectStorage.GetObject(ctx, pack.PackKey, 0, -1)
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return err
+		}
+		pendings := make(map[*pendingPack]struct{})
+		for _, piece := range pieces {
+			if piece.Offset+piece.Length > int64(len(data)) {
+				return fmt.Errorf("packed piece %s exceeds the pack size %d", piece.PieceKey, len(data))
+			}
+			pending := s.append(&spdb.PackedPiece{PieceKey: piece.PieceKey, PrevPackKey: pack.PackKey},
+				data[piece.Offset:piece.Offset+piece.Length])
+			pendings[pending] = struct{}{}
+		}
+		for pending := range pendings {
+			if err = s.wait(ctx, pending); err != nil {
+				return err
+			}
+		}
+		if pieces, err = s.index.ListPackedPieces(pack.PackKey); err != nil {
+			return err
+		}
+		if len(pieces) != 0 {
+			return fmt.Errorf("pack still has %d pieces after moving", len(pieces))
+		}
+	}
+	if err = s.ObjectStorage.DeleteObject(ctx, pack.PackKey); err != nil {
+		return err
+	}
+	return s.index.DeletePackFile(pack.PackKey)
+}
@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/greenfield-storage-provider/core/spdb"
+)
+
+// mockPackIndex is the memory implementation of spdb.PackDB that keeps the same semantics with sqldb.
+type mockPackIndex struct {
+	mux    sync.Mutex
+	pieces map[string]*spdb.PackedPiece
+	packs  map[string]*spdb.PackFile
+}
+
+func newMockPackIndex() *mockPackIndex {
+	return &mockPackIndex{pieces: make(map[string]*spdb.PackedPiece), packs: make(map[string]*spdb.PackFile)}
+}
+
+func (m *mockPackIndex) InsertPack(pack *spdb.PackFile, pieces []*spdb.PackedPiece) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.packs[pack.PackKey] = &spdb.PackFile{PackKey: pack.PackKey, Size: pack.Size}
+	for _, piece := range pieces {
+		prev, ok := m.pieces[piece.PieceKey]
+		if piece.PrevPackKey != "" && (!ok || prev.PackKey != piece.PrevPackKey) {
+			continue
+		}
+		if ok {
+			m.packs[prev.PackKey].LiveSize -= prev.Length
+		}
+		m.pieces[piece.PieceKey] = &spdb.PackedPiece{PieceKey: piece.PieceKey, PackKey: pack.PackKey,
+			Offset: piece.Offset, Length: piece.Length}
+		m.packs[pack.PackKey].LiveSize += piece.Length
+	}
+	return nil
+}
+
+func (m *mockPackIndex) GetPackedPiece(pieceKey string) (*spdb.PackedPiece, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	return m.pieces[pieceKey], nil
+}
+
+func (m *mockPackIndex) DeletePackedPiece(pieceKey string) (bool, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	prev, ok := m.pieces[pieceKey]
+	if !ok {
+		return false, nil
+	}
+	delete(m.pieces, pieceKey)
+	m.packs[prev.PackKey].LiveSize -= prev.Length
+	return true, nil
+}
+
+func (m *mockPackIndex) ListPackedPieces(packKey string) ([]*spdb.PackedPiece, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	var pieces []*spdb.PackedPiece
+	for _, piece := range m.pieces {
+		if piece.PackKey == packKey {
+			pieces = append(pieces, piece)
+		}
+	}
+	return pieces, nil
+}
+
+func (m *mockPackIndex) GetPacksToCompact(liveRatio float64, limit int) ([]*spdb.PackFile, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	var packs []*spdb.PackFile
+	for _, pack := range m.packs {
+		if float64(pack.LiveSize) < float64(pack.Size)*liveRatio && len(packs) < limit {
+			packs = append(packs, pack)
+		}
+	}
+	return packs, nil
+}
+
+func (m *mockPackIndex) DeletePackFile(packKey string) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	delete(m.packs, packKey)
+	return nil
+}
+
+func setupPackTest(t *testing.T) (*packStore, *memoryStore, *mockPackIndex) {
+	backend := &memoryStore{name: mockBucket, objects: make(map[string]*memoryObject)}
+	index := newMockPackIndex()
+	store, err := NewPackStore(backend, index, PackConfig{MaxPieceSize: 16, MaxPackSize: 64, FlushInterval: 10})
+	assert.Nil(t, err)
+	return store.(*packStore), backend, index
+}
+
+func readPiece(t *testing.T, store ObjectStorage, key string, offset, limit int64) string {
+	rc, err := store.GetObject(context.TODO(), key, offset, limit)
+	assert.Nil(t, err)
+	data, err := io.ReadAll(rc)
+	assert.Nil(t, err)
+	return string(data)
+}
+
+func TestPack_PutAndGet(t *testing.T) {
+	store, backend, _ := setupPackTest(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := store.PutObject(context.TODO(), fmt.Sprintf("%d_s0", i), strings.NewReader(fmt.Sprintf("piece-%d", i)))
+			assert.Nil(t, err)
+		}(i)
+	}
+	wg.Wait()
+	err := store.PutObject(context.TODO(), "large_s0", strings.NewReader(strings.Repeat("a", 32)))
+	assert.Nil(t, err)
+
+	// the small pieces are packed and only the large piece is stored as the single object
+	_, ok := backend.objects["1_s0"]
+	assert.False(t, ok)
+	_, ok = backend.objects["large_s0"]
+	assert.True(t, ok)
+	assert.True(t, len(backend.objects) < 10)
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, fmt.Sprintf("piece-%d", i), readPiece(t, store, fmt.Sprintf("%d_s0", i), 0, -1))
+	}
+	assert.Equal(t, "ece-", readPiece(t, store, "3_s0", 2, 4))
+	assert.Equal(t, "", readPiece(t, store, "3_s0", 100, 4))
+	assert.Equal(t, strings.Repeat("a", 32), readPiece(t, store, "large_s0", 0, -1))
+
+	obj, err := store.HeadObject(context.TODO(), "3_s0")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len("piece-3")), obj.Size())
+
+	// the small piece overwrites the unpacked piece, the unpacked one is shadowed by the index
+	// and deleted by the compaction
+	assert.Nil(t, backend.PutObject(context.TODO(), "raw_s0", strings.NewReader("raw-piece-data-overwritten")))
+	assert.Nil(t, store.PutObject(context.TODO(), "raw_s0", strings.NewReader("packed")))
+	_, ok = backend.objects["raw_s0"]
+	assert.True(t, ok)
+	assert.Equal(t, "packed", readPiece(t, store, "raw_s0", 0, -1))
+	store.CompactPacks(context.TODO())
+	_, ok = backend.objects["raw_s0"]
+	assert.False(t, ok)
+	assert.True(t, store.shadowSwept.Load())
+	assert.Equal(t, "packed", readPiece(t, store, "raw_s0", 0, -1))
+
+	// the pack files are not listed
+	ch, err := store.ListAllObjects(context.TODO(), "", "")
+	assert.Nil(t, err)
+	var listed []string
+	for obj := range ch {
+		listed = append(listed, obj.Key())
+	}
+	assert.Equal(t, []string{"large_s0"}, listed)
+}
+
+func TestPack_DeleteAndCompact(t *testing.T) {
+	store, backend, index := setupPackTest(t)
+	for i := 0; i < 4; i++ {
+		err := store.PutObject(context.TODO(), fmt.Sprintf("%d_s0", i), strings.NewReader(fmt.Sprintf("piece-%d", i)))
+		assert.Nil(t, err)
+	}
+	piece, _ := index.GetPackedPiece("0_s0")
+	oldPack := piece.PackKey
+
+	assert.Nil(t, store.DeleteObject(context.TODO(), "0_s0"))
+	_, err := store.HeadObject(context.TODO(), "0_s0")
+	assert.NotNil(t, err)
+	for i := 1; i < 4; i++ {
+		assert.Nil(t, store.DeleteObject(context.TODO(), fmt.Sprintf("%d_s0", i)))
+	}
+	// overwrite a piece, the previous location becomes garbage
+	assert.Nil(t, store.PutObject(context.TODO(), "5_s0", bytes.NewReader([]byte("piece-5"))))
+	assert.Nil(t, store.PutObject(context.TODO(), "5_s0", bytes.NewReader([]byte("piece-55"))))
+
+	// pack two pieces into the same pack and delete one of them
+	store.append(&spdb.PackedPiece{PieceKey: "6_s0"}, []byte("piece-6-garbage"))
+	assert.Nil(t, store.wait(context.TODO(), store.append(&spdb.PackedPiece{PieceKey: "7_s0"}, []byte("piece-7"))))
+	piece, _ = index.GetPackedPiece("7_s0")
+	movedPack := piece.PackKey
+	assert.Nil(t, store.DeleteObject(context.TODO(), "6_s0"))
+
+	store.CompactPacks(context.TODO())
+	_, ok := backend.objects[oldPack]
+	assert.False(t, ok)
+	_, ok = backend.objects[movedPack]
+	assert.False(t, ok)
+	piece, _ = index.GetPackedPiece("7_s0")
+	assert.NotEqual(t, movedPack, piece.PackKey)
+	assert.Equal(t, "piece-7", readPiece(t, store, "7_s0", 0, -1))
+	assert.Equal(t, "piece-55", readPiece(t, store, "5_s0", 0, -1))
+	for _, pack := range index.packs {
+		assert.True(t, float64(pack.LiveSize) >= float64(pack.Size)*DefaultPackCompactLiveRatio)
+	}
+}
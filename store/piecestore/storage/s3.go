@@ -140,6 +140,60 @@ func (s *s3Store) DeleteObject(ctx context.Context, key string) error {
 	return err
 }
 
+// DeleteObjects deletes the objects by the multi-object delete api, each request deletes
+// at most s3MaxDeleteObjects objects.
+func (s *s3Store) DeleteObjects(ctx context.Context, keys []string) map[string]error {
+	failed := make(map[string]error)
+	for start := 0; start < len(keys); start += s3MaxDeleteObjects {
+		end := start + s3MaxDeleteObjects
+		if end > len(keys) {
+			end = len(keys)
+		}
+		objects := make([]*s3.ObjectIdentifier, 0, end-start)
+		for _, key := range keys[start:end] {
+			objects = append(objects, &s3.ObjectIdentifier{Key: aws.String(key)})
+		}
+		output, err := s.api.DeleteObjectsWithContext(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(s.bucketName),
+			Delete: &s3.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			log.Errorw("S3 failed to delete objects", "count", end-start, "error", err)
+			for _, key := range keys[start:end] {
+				failed[key] = err
+			}
+			continue
+		}
+		for _, e := range output.Errors {
+			if aws.StringValue(e.Code) == "NoSuchKey" {
+				continue
+			}
+			failed[aws.StringValue(e.Key)] = fmt.Errorf("failed to delete object, code: %s, message: %s",
+				aws.StringValue(e.Code), aws.StringValue(e.Message))
+		}
+	}
+	return failed
+}
+
+// PresignGet returns the presigned url to get the object, the url is signed locally and
+// expires after expire.
+func (s *s3Store) PresignGet(ctx context.Context, key string, expire time.Duration, header PresignHeader) (
+	string, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	}
+	if header.ContentType != "" {
+		input.ResponseContentType = aws.String(header.ContentType)
+	}
+	if header.ContentDisposition != "" {
+		input.ResponseContentDisposition = aws.String(header.ContentDisposition)
+	}
+	req, _ := s.api.GetObjectRequest(input)
+	req.SetContext(ctx)
+	return req.Presign(expire)
+}
+
 func (s *s3Store) HeadBucket(ctx context.Context) error {
 	if _, err := s.api.HeadBucketWithContext(ctx, &s3.HeadBucketInput{
 		Bucket: aws.String(s.bucketName),
@@ -215,7 +269,11 @@ func (s *s3Store) ListObjects(ctx context.Context, prefix, marker, delimiter str
 }
 
 func (s *s3Store) ListAllObjects(ctx context.Context, prefix, marker string) (<-chan Object, error) {
-	return nil, ErrUnsupportedMethod
+	return listAllObjects(ctx, s, prefix, marker)
+}
+
+func (s *s3Store) Capacity(ctx context.Context) (uint64, uint64, error) {
+	return 0, 0, ErrUnsupportedMethod
 }
 
 // SessionCache holds session.Session according to ObjectStorageConfig and it synchronizes access/modification
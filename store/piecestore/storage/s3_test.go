@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"strings"
@@ -12,6 +13,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/stretchr/testify/assert"
@@ -70,6 +72,24 @@ func (m mockS3Client) DeleteObjectWithContext(aws.Context, *s3.DeleteObjectInput
 	return &m.deleteObjectResp, nil
 }
 
+func (m mockS3Client) DeleteObjectsWithContext(_ aws.Context, input *s3.DeleteObjectsInput, _ ...request.Option) (
+	*s3.DeleteObjectsOutput, error) {
+	if len(input.Delete.Objects) > s3MaxDeleteObjects {
+		return nil, errors.New("MalformedXML")
+	}
+	output := &s3.DeleteObjectsOutput{}
+	for _, obj := range input.Delete.Objects {
+		switch *obj.Key {
+		case "non_existed_object":
+			output.Errors = append(output.Errors, &s3.Error{Key: obj.Key, Code: aws.String("NoSuchKey")})
+		case "failed_object":
+			output.Errors = append(output.Errors, &s3.Error{Key: obj.Key, Code: aws.String("AccessDenied"),
+				Message: aws.String("Access Denied")})
+		}
+	}
+	return output, nil
+}
+
 func (m mockS3Client) HeadObjectWithContext(aws.Context, *s3.HeadObjectInput, ...request.Option) (
 	*s3.HeadObjectOutput, error) {
 	return &m.headObjectResp, nil
@@ -219,6 +239,35 @@ func TestS3_DeleteSuccess(t *testing.T) {
 	}
 }
 
+func TestS3_DeleteObjects(t *testing.T) {
+	store := setupS3Test(t)
+	store.api = mockS3Client{}
+	keys := []string{"non_existed_object", "failed_object"}
+	for i := 0; i < s3MaxDeleteObjects; i++ {
+		keys = append(keys, fmt.Sprintf("%s_%d", mockKey, i))
+	}
+	failed := store.DeleteObjects(context.TODO(), keys)
+	assert.Equal(t, 1, len(failed))
+	assert.NotNil(t, failed["failed_object"])
+}
+
+func TestS3_PresignGet(t *testing.T) {
+	store := setupS3Test(t)
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("mock-region-1"),
+		Credentials: credentials.NewStaticCredentials(mockAccessKey, mockSecretKey, ""),
+	}))
+	store.api = s3.New(sess)
+	url, err := store.PresignGet(context.TODO(), mockKey, time.Minute, PresignHeader{
+		ContentDisposition: "attachment; filename=\"mock\"",
+	})
+	assert.Nil(t, err)
+	assert.Contains(t, url, mockKey)
+	assert.Contains(t, url, "X-Amz-Expires=60")
+	assert.Contains(t, url, "response-content-disposition=")
+	assert.NotContains(t, url, "response-content-type=")
+}
+
 func TestS3_HeadSuccess(t *testing.T) {
 	store := setupS3Test(t)
 	cases := []struct {
@@ -282,8 +331,24 @@ func TestS3_ListSuccess(t *testing.T) {
 
 func TestS3_ListAll(t *testing.T) {
 	store := setupS3Test(t)
-	_, err := store.ListAllObjects(context.TODO(), emptyString, emptyString)
-	assert.Equal(t, ErrUnsupportedMethod, err)
+	store.api = mockS3Client{listObjectsResp: s3.ListObjectsOutput{Contents: []*s3.Object{{
+		Key:          aws.String(mockKey),
+		LastModified: aws.Time(mockModifiedTime),
+		Size:         aws.Int64(mockSize),
+	}}}}
+	ch, err := store.ListAllObjects(context.TODO(), emptyString, emptyString)
+	assert.Nil(t, err)
+	objs := make([]Object, 0)
+	for obj := range ch {
+		objs = append(objs, obj)
+	}
+	assert.Equal(t, 1, len(objs))
+	assert.Equal(t, mockKey, objs[0].Key())
+	assert.Equal(t, int64(mockSize), objs[0].Size())
+
+	store.api = mockS3ClientError{}
+	_, err = store.ListAllObjects(context.TODO(), emptyString, emptyString)
+	assert.Equal(t, errors.New("List objects error"), err)
 }
 
 type mockS3ClientError struct {
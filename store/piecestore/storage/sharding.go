@@ -6,6 +6,7 @@ import (
 	"hash/fnv"
 	"io"
 	"strings"
+	"time"
 )
 
 type sharded struct {
@@ -63,6 +64,32 @@ func (s *sharded) DeleteObject(ctx context.Context, key string) error {
 	return s.pick(key).DeleteObject(ctx, key)
 }
 
+// DeleteObjects deletes the objects of each shard in batch.
+func (s *sharded) DeleteObjects(ctx context.Context, keys []string) map[string]error {
+	shards := make(map[ObjectStorage][]string)
+	for _, key := range keys {
+		store := s.pick(key)
+		shards[store] = append(shards[store], key)
+	}
+	failed := make(map[string]error)
+	for store, shardKeys := range shards {
+		for key, err := range store.DeleteObjects(ctx, shardKeys) {
+			failed[key] = err
+		}
+	}
+	return failed
+}
+
+// PresignGet returns the presigned url of the shard that stores the object.
+func (s *sharded) PresignGet(ctx context.Context, key string, expire time.Duration, header PresignHeader) (
+	string, error) {
+	presigner, ok := s.pick(key).(Presigner)
+	if !ok {
+		return "", ErrUnsupportedMethod
+	}
+	return presigner.PresignGet(ctx, key, expire, header)
+}
+
 func (s *sharded) HeadBucket(ctx context.Context) error {
 	for _, o := range s.stores {
 		if err := o.HeadBucket(ctx); err != nil {
@@ -75,3 +102,91 @@ func (s *sharded) HeadBucket(ctx context.Context) error {
 func (s *sharded) HeadObject(ctx context.Context, key string) (Object, error) {
 	return s.pick(key).HeadObject(ctx, key)
 }
+
+// RemoveOrphanFiles removes the orphan files of the shards one by one.
+func (s *sharded) RemoveOrphanFiles(ctx context.Context) {
+	for _, store := range s.stores {
+		if ctx.Err() != nil {
+			return
+		}
+		if remover, ok := store.(OrphanRemover); ok {
+			remover.RemoveOrphanFiles(ctx)
+		}
+	}
+}
+
+// ListAllObjects merges the objects of all shards in key order.
+func (s *sharded) ListAllObjects(ctx context.Context, prefix, marker string) (<-chan Object, error) {
+	shards := make([]<-chan Object, len(s.stores))
+	for i, store := range s.stores {
+		ch, err := store.ListAllObjects(ctx, prefix, marker)
+		if err != nil {
+			return nil, err
+		}
+		shards[i] = ch
+	}
+	ch := make(chan Object, listObjectsPageSize)
+	go func() {
+		defer close(ch)
+		heads := make([]Object, len(shards))
+		// next receives the next object of the shard, returns false if the shard fails halfway.
+		next := func(i int) bool {
+			obj, ok := <-shards[i]
+			if !ok {
+				shards[i] = nil
+				return true
+			}
+			heads[i] = obj
+			return obj != nil
+		}
+		for i := range shards {
+			if !next(i) {
+				ch <- nil
+				return
+			}
+		}
+		for {
+			min := -1
+			for i := range shards {
+				if shards[i] != nil && (min < 0 || heads[i].Key() < heads[min].Key()) {
+					min = i
+				}
+			}
+			if min < 0 {
+				return
+			}
+			select {
+			case ch <- heads[min]:
+			case <-ctx.Done():
+				return
+			}
+			if !next(min) {
+				ch <- nil
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// Capacity returns the sum of the capacity of all shards, the shards that report the
+// same capacity are regarded as on the same disk and are counted once.
+func (s *sharded) Capacity(ctx context.Context) (uint64, uint64, error) {
+	var (
+		total, available uint64
+		counted          = make(map[[2]uint64]struct{})
+	)
+	for _, o := range s.stores {
+		t, a, err := o.Capacity(ctx)
+		if err != nil {
+			return 0, 0, err
+		}
+		if _, ok := counted[[2]uint64{t, a}]; ok {
+			continue
+		}
+		counted[[2]uint64{t, a}] = struct{}{}
+		total += t
+		available += a
+	}
+	return total, available, nil
+}
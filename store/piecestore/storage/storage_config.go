@@ -2,8 +2,14 @@ package storage
 
 // PieceStoreConfig contains some parameters which are used to run PieceStore
 type PieceStoreConfig struct {
-	Shards int                 // store the blocks into N buckets by hash of key
-	Store  ObjectStorageConfig // config of object storage
+	Shards      int                 // store the blocks into N buckets by hash of key
+	Store       ObjectStorageConfig // config of object storage
+	Pack        PackConfig          // config of packing small pieces into pack files
+	Dedup       DedupConfig         // config of deduplicating the segment pieces by checksum
+	Encryption  EncryptionConfig    // config of encrypting pieces at rest
+	Compression CompressionConfig   // config of compressing pieces before writing to storage
+	Tiering     TieringConfig       // config of migrating the pieces of cold objects to the cold storage
+	Fault       FaultConfig         // config of injecting faults into the object storage, only for resilience testing
 }
 
 // ObjectStorageConfig object storage config
@@ -14,4 +20,73 @@ type ObjectStorageConfig struct {
 	MinRetryDelay         int64  // the minimum retry delay after which retry will be performed
 	TLSInsecureSkipVerify bool   // whether skip the certificate verification of HTTPS requests
 	IAMType               string // IAMType is identity and access management type which contains two types: AKSKIAMType/SAIAMType
+	DiskFile              DiskFileConfig
+}
+
+// DiskFileConfig contains some parameters which are used by the local disk file storage
+type DiskFileConfig struct {
+	FsyncPolicy string // when the written files are synced: none, file or all(the file and its parent directory)
+	FanOut      bool   // whether store the files in the two-level hashed sub directories, it can not be disabled once enabled
+	Checksum    bool   // whether write the sidecar crc32c checksums of the files, the checksums are verified on read
+}
+
+// PackConfig contains some parameters which are used to pack small pieces into pack files
+type PackConfig struct {
+	Enable           bool    // whether pack the small pieces into pack files, the index of pieces is stored in sp db
+	MaxPieceSize     int64   // the pieces whose size is less than or equal to it are packed
+	MaxPackSize      int64   // the pending pack is flushed when its size reaches it
+	FlushInterval    int64   // the max milliseconds that a packed piece waits for flushing
+	CompactLiveRatio float64 // the pack is compacted when the ratio of its live size is less than it
+	CompactInterval  int64   // the seconds interval of compacting the packs
+}
+
+// DedupConfig contains some parameters which are used to deduplicate the segment pieces
+type DedupConfig struct {
+	Enable bool // whether store the segment pieces of the same data once, the references of data are counted in sp db
+}
+
+// EncryptionConfig contains some parameters which are used to encrypt pieces at rest
+type EncryptionConfig struct {
+	Enable             bool   // whether encrypt the pieces by AES-GCM before writing to the backend storage
+	KeyProvider        string // the key provider of the master keys, local or the registered kms key provider
+	KeyFile            string // the key file of the local key provider, each line is <key_id>:<hex encoded 32 bytes key>
+	MasterKeyID        string // the master key id to encrypt the new data keys, the local key provider uses the first key in key file if it is empty
+	AllowPlaintextRead bool   // whether read the pieces written before encryption is enabled as plaintext
+}
+
+// CompressionConfig contains some parameters which are used to compress pieces
+type CompressionConfig struct {
+	Enable         bool     // whether compress the pieces by zstd before writing to the backend storage
+	Level          int      // the zstd compression level from 1 to 22
+	MinSavingRatio *float64 // the chunk of the piece is stored uncompressed if compression saves less than the ratio of its size, 0 always keeps it compressed, the default is used if it is unset
+}
+
+// TieringConfig contains some parameters which are used to migrate the pieces of cold objects
+// between the hot storage and the cold storage
+type TieringConfig struct {
+	Enable            bool                // whether migrate the pieces of the cold objects to the cold storage
+	ColdStore         ObjectStorageConfig // config of the cold object storage
+	ColdAfterDays     int                 // the object is demoted to the cold storage if it is not read in the days
+	PromoteReadCount  int                 // the cold object is promoted if it is read the times in the promote window
+	PromoteWindowDays int                 // the days of the promote window
+	Interval          int64               // the seconds interval of scanning the objects to tier
+	BatchSize         int                 // the max number of the objects to tier in each scan
+}
+
+// FaultConfig contains some parameters which are used to inject faults into the operations of
+// the object storage, it simulates the slow disks and the intermittent errors of the backend
+// storage in the tests and must not be enabled in production
+type FaultConfig struct {
+	Enable bool        // whether inject the faults into the object storage
+	Seed   int64       // the seed of the random faults, 0 means seeded by the current time
+	Rules  []FaultRule // the faults of all the matched rules are injected
+}
+
+// FaultRule defines the faults injected into the matched operations
+type FaultRule struct {
+	Operations      []string // the operations that the rule matches: get, put, delete, head and list, empty matches all
+	KeyPattern      string   // the regexp of the keys that the rule matches, empty matches all
+	Latency         int64    // the milliseconds delay before the operation
+	ErrorRate       float64  // the probability that the operation fails with ErrInjectedFault
+	PartialReadRate float64  // the probability that the get returns part of the data then fails
 }
@@ -6,6 +6,26 @@ const (
 	UploadObjectProgressTableName = "upload_object_progress"
 	// GCObjectProgressTableName defines the gc object task table name.
 	GCObjectProgressTableName = "gc_object_progress"
+	// GCZombiePieceTableName defines the gc zombie piece table name, which records the pieces that fail to be deleted by gc.
+	GCZombiePieceTableName = "gc_zombie_piece"
+	// DiscontinueBucketFlagTableName defines the discontinue bucket flag table name, which records the buckets flagged by the operators.
+	DiscontinueBucketFlagTableName = "discontinue_bucket_flag"
+	// DiscontinueBucketCursorTableName defines the discontinue bucket cursor table name, which records the last listed bucket.
+	DiscontinueBucketCursorTableName = "discontinue_bucket_cursor"
+	// TaskEventTableName defines the task state transition event table name.
+	TaskEventTableName = "task_event"
+	// PieceUsageTableName defines the piece usage table name, which is used for accounting the piece store usage.
+	PieceUsageTableName = "piece_usage"
+	// ObjectTierTableName defines the object tier table name, which records the piece store tier of the objects.
+	ObjectTierTableName = "object_tier"
+	// PackedPieceTableName defines the packed piece table name, which is the index of the small pieces in pack files.
+	PackedPieceTableName = "packed_piece"
+	// PackFileTableName defines the pack file table name.
+	PackFileTableName = "pack_file"
+	// DedupPieceTableName defines the dedup piece table name, which maps the deduplicated pieces to the contents.
+	DedupPieceTableName = "dedup_piece"
+	// DedupContentTableName defines the dedup content table name, which counts the references of the contents.
+	DedupContentTableName = "dedup_content"
 	// PieceHashTableName defines the piece hash table name.
 	PieceHashTableName = "piece_hash"
 	// IntegrityMetaTableName defines the integrity meta table name.
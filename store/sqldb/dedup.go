@@ -0,0 +1,225 @@
+package sqldb
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	corespdb "github.com/bnb-chain/greenfield-storage-provider/core/spdb"
+)
+
+// GetDedupPiece returns the content mapping of the piece, returns nil if the piece is not deduplicated.
+func (s *SpDBImpl) GetDedupPiece(pieceKey string) (*corespdb.DedupPiece, error) {
+	var queryReturn struct {
+		PieceKey   string
+		ContentKey string
+		ObjectKey  string
+		Size       int64
+	}
+	result := s.db.Model(&DedupPieceTable{}).
+		Select(fmt.Sprintf("%s.piece_key, %s.content_key, %s.object_key, %s.size", DedupPieceTableName,
+			DedupPieceTableName, DedupContentTableName, DedupPieceTableName)).
+		Joins(fmt.Sprintf("left join %s on %s.content_key = %s.content_key",
+			DedupContentTableName, DedupContentTableName, DedupPieceTableName)).
+		Where(DedupPieceTableName+".piece_key = ?", pieceKey).Limit(1).Scan(&queryReturn)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to query dedup piece table: %s", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &corespdb.DedupPiece{
+		PieceKey:   queryReturn.PieceKey,
+		ContentKey: queryReturn.ContentKey,
+		ObjectKey:  dedupObjectKey(queryReturn.ContentKey, queryReturn.ObjectKey),
+		Size:       queryReturn.Size,
+	}, nil
+}
+
+// dedupObjectKey returns the object key of the content, the data of the content created before
+// the object key is recorded is stored under the content key.
+func dedupObjectKey(contentKey, objectKey string) string {
+	if objectKey == "" {
+		return contentKey
+	}
+	return objectKey
+}
+
+// GetDedupContent returns the content, returns nil if the content does not exist. The content
+// whose reference count is zero is being released.
+func (s *SpDBImpl) GetDedupContent(contentKey string) (*corespdb.DedupContent, error) {
+	var queryReturn DedupContentTable
+	result := s.db.Where("content_key = ?", contentKey).Limit(1).Find(&queryReturn)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to query dedup content table: %s", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &corespdb.DedupContent{
+		ContentKey:            queryReturn.ContentKey,
+		ObjectKey:             dedupObjectKey(queryReturn.ContentKey, queryReturn.ObjectKey),
+		Size:                  queryReturn.Size,
+		RefCount:              queryReturn.RefCount,
+		CreateTimestampSecond: queryReturn.CreateTimestampSecond,
+	}, nil
+}
+
+// InsertDedupPiece maps the piece to the content and increases the reference count of the
+// content, the content is created if it is not referenced before, and the data of the created
+// content is the object of the piece. If the piece is overwritten, the reference count of the
+// previous content is decreased. It returns whether the content is created and the previous
+// content key if the previous content is no longer referenced.
+func (s *SpDBImpl) InsertDedupPiece(piece *corespdb.DedupPiece) (bool, string, error) {
+	var (
+		created  bool
+		released string
+	)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var prev DedupPieceTable
+		result := tx.Where("piece_key = ?", piece.PieceKey).Limit(1).Find(&prev)
+		if result.Error != nil {
+			return result.Error
+		}
+		exists := result.RowsAffected == 1
+		if exists && prev.ContentKey == piece.ContentKey {
+			return nil
+		}
+		var err error
+		if created, err = increaseDedupContentRef(tx, piece.ContentKey, piece.ObjectKey, piece.Size); err != nil {
+			return err
+		}
+		if !exists {
+			return tx.Create(&DedupPieceTable{
+				PieceKey:   piece.PieceKey,
+				ContentKey: piece.ContentKey,
+				Size:       piece.Size,
+			}).Error
+		}
+		if err = tx.Model(&DedupPieceTable{}).Where("piece_key = ?", piece.PieceKey).
+			Updates(map[string]interface{}{
+				"content_key": piece.ContentKey,
+				"size":        piece.Size,
+			}).Error; err != nil {
+			return err
+		}
+		released, err = decreaseDedupContentRef(tx, prev.ContentKey)
+		return err
+	})
+	if err != nil {
+		return false, "", fmt.Errorf("failed to insert dedup piece record: %s", err)
+	}
+	return created, released, nil
+}
+
+// DeleteDedupPiece deletes the content mapping of the piece and decreases the reference count
+// of the content. It returns false if the piece is not deduplicated, and the content key if
+// the content is no longer referenced.
+func (s *SpDBImpl) DeleteDedupPiece(pieceKey string) (bool, string, error) {
+	var (
+		found    bool
+		released string
+	)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var prev DedupPieceTable
+		result := tx.Where("piece_key = ?", pieceKey).Limit(1).Find(&prev)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		found = true
+		if err := tx.Delete(&DedupPieceTable{PieceKey: pieceKey}).Error; err != nil {
+			return err
+		}
+		var err error
+		released, err = decreaseDedupContentRef(tx, prev.ContentKey)
+		return err
+	})
+	if err != nil {
+		return false, "", fmt.Errorf("failed to delete dedup piece record: %s", err)
+	}
+	return found, released, nil
+}
+
+// increaseDedupContentRef increases the reference count of the content, and creates the
+// content with the object if it does not exist. The content that is no longer referenced is
+// regarded as created again with the object, its data may be deleted by the release.
+func increaseDedupContentRef(tx *gorm.DB, contentKey, objectKey string, size int64) (bool, error) {
+	result := tx.Model(&DedupContentTable{}).Where("content_key = ? and ref_count <= 0", contentKey).
+		Updates(map[string]interface{}{
+			"object_key": objectKey,
+			"size":       size,
+			"ref_count":  1,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return true, nil
+	}
+	result = tx.Model(&DedupContentTable{}).Where("content_key = ?", contentKey).
+		Update("ref_count", gorm.Expr("ref_count + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return false, nil
+	}
+	result = tx.Create(&DedupContentTable{
+		ContentKey:            contentKey,
+		ObjectKey:             objectKey,
+		Size:                  size,
+		RefCount:              1,
+		CreateTimestampSecond: GetCurrentUnixTime(),
+	})
+	if result.Error != nil && MysqlErrCode(result.Error) == ErrDuplicateEntryCode {
+		// the content is created by another piece concurrently
+		return false, tx.Model(&DedupContentTable{}).Where("content_key = ?", contentKey).
+			Update("ref_count", gorm.Expr("ref_count + 1")).Error
+	}
+	return result.Error == nil, result.Error
+}
+
+// decreaseDedupContentRef decreases the reference count of the content, returns the content
+// key if it is no longer referenced. The content record is kept until it is released by
+// ReleaseDedupContent.
+func decreaseDedupContentRef(tx *gorm.DB, contentKey string) (string, error) {
+	if err := tx.Model(&DedupContentTable{}).Where("content_key = ?", contentKey).
+		Update("ref_count", gorm.Expr("ref_count - 1")).Error; err != nil {
+		return "", err
+	}
+	var content DedupContentTable
+	result := tx.Where("content_key = ? and ref_count <= 0", contentKey).Limit(1).Find(&content)
+	if result.Error != nil {
+		return "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		return "", nil
+	}
+	return contentKey, nil
+}
+
+// ReleaseDedupContent deletes the content that is no longer referenced. The content record is
+// locked while deleteFn deletes the data of the content by its object key, so the concurrent
+// references of the content wait until the content is deleted and create it again. It returns
+// false if the content is referenced again.
+func (s *SpDBImpl) ReleaseDedupContent(contentKey string, deleteFn func(objectKey string) error) (bool, error) {
+	var released bool
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var content DedupContentTable
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("content_key = ? and ref_count <= 0", contentKey).Limit(1).Find(&content)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if err := deleteFn(dedupObjectKey(content.ContentKey, content.ObjectKey)); err != nil {
+			return err
+		}
+		released = true
+		return tx.Where("content_key = ?", contentKey).Delete(&DedupContentTable{}).Error
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to release dedup content: %s", err)
+	}
+	return released, nil
+}
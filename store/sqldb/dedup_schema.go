@@ -0,0 +1,27 @@
+package sqldb
+
+// DedupPieceTable table schema
+type DedupPieceTable struct {
+	PieceKey   string `gorm:"primary_key;type:varchar(256)"`
+	ContentKey string `gorm:"index:content_to_dedup_piece;type:varchar(256)"`
+	Size       int64
+}
+
+// TableName is used to set DedupPieceTable schema's table name in database
+func (DedupPieceTable) TableName() string {
+	return DedupPieceTableName
+}
+
+// DedupContentTable table schema
+type DedupContentTable struct {
+	ContentKey            string `gorm:"primary_key;type:varchar(256)"`
+	ObjectKey             string `gorm:"type:varchar(256)"`
+	Size                  int64
+	RefCount              int64
+	CreateTimestampSecond int64
+}
+
+// TableName is used to set DedupContentTable schema's table name in database
+func (DedupContentTable) TableName() string {
+	return DedupContentTableName
+}
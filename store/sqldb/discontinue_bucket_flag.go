@@ -0,0 +1,106 @@
+package sqldb
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	corespdb "github.com/bnb-chain/greenfield-storage-provider/core/spdb"
+)
+
+// InsertDiscontinueBucketFlag inserts or updates the flag of the bucket.
+func (s *SpDBImpl) InsertDiscontinueBucketFlag(flag *corespdb.DiscontinueBucketFlag) error {
+	timestamp := GetCurrentUnixTime()
+	result := s.db.Create(&DiscontinueBucketFlagTable{
+		BucketName:            flag.BucketName,
+		Rule:                  flag.Rule,
+		Reason:                flag.Reason,
+		CreateTimestampSecond: timestamp,
+		UpdateTimestampSecond: timestamp,
+	})
+	if result.Error != nil && MysqlErrCode(result.Error) == ErrDuplicateEntryCode {
+		result = s.db.Model(&DiscontinueBucketFlagTable{}).Where("bucket_name = ?", flag.BucketName).
+			Updates(map[string]interface{}{
+				"rule":                    flag.Rule,
+				"reason":                  flag.Reason,
+				"update_timestamp_second": timestamp,
+			})
+	}
+	if result.Error != nil {
+		return fmt.Errorf("failed to insert discontinue bucket flag record: %s", result.Error)
+	}
+	return nil
+}
+
+// ListDiscontinueBucketFlags returns the earliest flagged buckets.
+func (s *SpDBImpl) ListDiscontinueBucketFlags(limit int) ([]*corespdb.DiscontinueBucketFlag, error) {
+	var (
+		flags        []*corespdb.DiscontinueBucketFlag
+		queryReturns []DiscontinueBucketFlagTable
+	)
+	result := s.db.Order("create_timestamp_second ASC").Limit(limit).Find(&queryReturns)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to query discontinue bucket flag table: %s", result.Error)
+	}
+	for _, flag := range queryReturns {
+		flags = append(flags, &corespdb.DiscontinueBucketFlag{
+			BucketName:            flag.BucketName,
+			Rule:                  flag.Rule,
+			Reason:                flag.Reason,
+			CreateTimestampSecond: flag.CreateTimestampSecond,
+		})
+	}
+	return flags, nil
+}
+
+// DeleteDiscontinueBucketFlag deletes the flag of the bucket.
+func (s *SpDBImpl) DeleteDiscontinueBucketFlag(bucketName string) error {
+	if err := s.db.Where("bucket_name = ?", bucketName).Delete(&DiscontinueBucketFlagTable{}).Error; err != nil {
+		return fmt.Errorf("failed to delete discontinue bucket flag record: %s", err)
+	}
+	return nil
+}
+
+// discontinueBucketCursorID is the id of the only cursor record.
+const discontinueBucketCursorID = 1
+
+// GetDiscontinueBucketCursor returns the cursor of the listed buckets, returns the zero cursor
+// if it is not recorded.
+func (s *SpDBImpl) GetDiscontinueBucketCursor() (*corespdb.DiscontinueBucketCursor, error) {
+	queryReturn := &DiscontinueBucketCursorTable{}
+	result := s.db.First(queryReturn, "id = ?", discontinueBucketCursorID)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return &corespdb.DiscontinueBucketCursor{}, nil
+	}
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to query discontinue bucket cursor table: %s", result.Error)
+	}
+	return &corespdb.DiscontinueBucketCursor{
+		CreateAt: queryReturn.CreateAt,
+		BucketID: queryReturn.BucketID,
+	}, nil
+}
+
+// UpdateDiscontinueBucketCursor inserts or updates the cursor of the listed buckets.
+func (s *SpDBImpl) UpdateDiscontinueBucketCursor(cursor *corespdb.DiscontinueBucketCursor) error {
+	timestamp := GetCurrentUnixTime()
+	result := s.db.Create(&DiscontinueBucketCursorTable{
+		ID:                    discontinueBucketCursorID,
+		CreateAt:              cursor.CreateAt,
+		BucketID:              cursor.BucketID,
+		UpdateTimestampSecond: timestamp,
+	})
+	if result.Error != nil && MysqlErrCode(result.Error) == ErrDuplicateEntryCode {
+		result = s.db.Model(&DiscontinueBucketCursorTable{}).Where("id = ?", discontinueBucketCursorID).
+			Updates(map[string]interface{}{
+				"create_at":               cursor.CreateAt,
+				"bucket_id":               cursor.BucketID,
+				"update_timestamp_second": timestamp,
+			})
+	}
+	if result.Error != nil {
+		return fmt.Errorf("failed to update discontinue bucket cursor record: %s", result.Error)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package sqldb
+
+// DiscontinueBucketFlagTable table schema
+type DiscontinueBucketFlagTable struct {
+	BucketName            string `gorm:"primary_key;type:varchar(64)"`
+	Rule                  string `gorm:"type:varchar(64)"`
+	Reason                string `gorm:"type:varchar(256)"`
+	CreateTimestampSecond int64  `gorm:"index:create_timestamp_index"`
+	UpdateTimestampSecond int64
+}
+
+// TableName is used to set DiscontinueBucketFlagTable schema's table name in database
+func (DiscontinueBucketFlagTable) TableName() string {
+	return DiscontinueBucketFlagTableName
+}
+
+// DiscontinueBucketCursorTable table schema, it has only one record
+type DiscontinueBucketCursorTable struct {
+	ID                    int8 `gorm:"primary_key"`
+	CreateAt              int64
+	BucketID              int64
+	UpdateTimestampSecond int64
+}
+
+// TableName is used to set DiscontinueBucketCursorTable schema's table name in database
+func (DiscontinueBucketCursorTable) TableName() string {
+	return DiscontinueBucketCursorTableName
+}
@@ -0,0 +1,79 @@
+package sqldb
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	corespdb "github.com/bnb-chain/greenfield-storage-provider/core/spdb"
+)
+
+// maxGCZombieErrorLength defines the max length of the error description of the zombie piece.
+const maxGCZombieErrorLength = 1024
+
+// InsertGCZombiePieces inserts or updates the zombie pieces.
+func (s *SpDBImpl) InsertGCZombiePieces(pieces []*corespdb.GCZombiePiece) error {
+	timestamp := GetCurrentUnixTime()
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, piece := range pieces {
+			errDescription := piece.ErrorDescription
+			if len(errDescription) > maxGCZombieErrorLength {
+				errDescription = errDescription[:maxGCZombieErrorLength]
+			}
+			result := tx.Create(&GCZombiePieceTable{
+				PieceKey:              piece.PieceKey,
+				ObjectID:              piece.ObjectID,
+				ErrorDescription:      errDescription,
+				CreateTimestampSecond: timestamp,
+				UpdateTimestampSecond: timestamp,
+			})
+			if result.Error != nil && MysqlErrCode(result.Error) == ErrDuplicateEntryCode {
+				result = tx.Model(&GCZombiePieceTable{}).Where("piece_key = ?", piece.PieceKey).
+					Updates(map[string]interface{}{
+						"error_description":       errDescription,
+						"update_timestamp_second": timestamp,
+					})
+			}
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert gc zombie piece record: %s", err)
+	}
+	return nil
+}
+
+// ListGCZombiePieces returns the earliest recorded zombie pieces.
+func (s *SpDBImpl) ListGCZombiePieces(limit int) ([]*corespdb.GCZombiePiece, error) {
+	var (
+		pieces       []*corespdb.GCZombiePiece
+		queryReturns []GCZombiePieceTable
+	)
+	result := s.db.Order("create_timestamp_second ASC").Limit(limit).Find(&queryReturns)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to query gc zombie piece table: %s", result.Error)
+	}
+	for _, piece := range queryReturns {
+		pieces = append(pieces, &corespdb.GCZombiePiece{
+			PieceKey:              piece.PieceKey,
+			ObjectID:              piece.ObjectID,
+			ErrorDescription:      piece.ErrorDescription,
+			CreateTimestampSecond: piece.CreateTimestampSecond,
+		})
+	}
+	return pieces, nil
+}
+
+// DeleteGCZombiePieces deletes the records of the zombie pieces.
+func (s *SpDBImpl) DeleteGCZombiePieces(pieceKeys []string) error {
+	if len(pieceKeys) == 0 {
+		return nil
+	}
+	if err := s.db.Where("piece_key in ?", pieceKeys).Delete(&GCZombiePieceTable{}).Error; err != nil {
+		return fmt.Errorf("failed to delete gc zombie piece record: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package sqldb
+
+// GCZombiePieceTable table schema
+type GCZombiePieceTable struct {
+	PieceKey              string `gorm:"primary_key;type:varchar(256)"`
+	ObjectID              uint64
+	ErrorDescription      string `gorm:"type:varchar(1024)"`
+	CreateTimestampSecond int64  `gorm:"index:create_timestamp_index"`
+	UpdateTimestampSecond int64
+}
+
+// TableName is used to set GCZombiePieceTable schema's table name in database
+func (GCZombiePieceTable) TableName() string {
+	return GCZombiePieceTableName
+}
@@ -0,0 +1,90 @@
+package sqldb
+
+import (
+	"fmt"
+)
+
+// GetObjectTier returns the piece store tier of the object, returns the empty string if
+// the object has no tier record.
+func (s *SpDBImpl) GetObjectTier(objectID uint64) (string, error) {
+	var queryReturn ObjectTierTable
+	result := s.db.Where("object_id = ?", objectID).Limit(1).Find(&queryReturn)
+	if result.Error != nil {
+		return "", fmt.Errorf("failed to query object tier table: %s", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return "", nil
+	}
+	return queryReturn.Tier, nil
+}
+
+// SetObjectTier inserts or updates the piece store tier of the object.
+func (s *SpDBImpl) SetObjectTier(objectID uint64, tier string) error {
+	timestamp := GetCurrentUnixTime()
+	result := s.db.Create(&ObjectTierTable{
+		ObjectID:              objectID,
+		Tier:                  tier,
+		UpdateTimestampSecond: timestamp,
+	})
+	if result.Error != nil && MysqlErrCode(result.Error) == ErrDuplicateEntryCode {
+		result = s.db.Model(&ObjectTierTable{}).Where("object_id = ?", objectID).
+			Updates(map[string]interface{}{
+				"tier":                    tier,
+				"update_timestamp_second": timestamp,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update object tier record: %s", result.Error)
+		}
+		return nil
+	}
+	if result.Error != nil || result.RowsAffected != 1 {
+		return fmt.Errorf("failed to insert object tier record: %s", result.Error)
+	}
+	return nil
+}
+
+// DeleteObjectTier deletes the tier record of the object.
+func (s *SpDBImpl) DeleteObjectTier(objectID uint64) error {
+	return s.db.Delete(&ObjectTierTable{
+		ObjectID: objectID, // should be the primary key
+	}).Error
+}
+
+// GetObjectsToDemote returns the objects that are stored in piece store but not in the
+// tier, and have been neither written nor read since the timestamp.
+func (s *SpDBImpl) GetObjectsToDemote(tier string, sinceTimestampSecond int64, limit int) ([]uint64, error) {
+	var objectIDs []uint64
+	result := s.db.Model(&PieceUsageTable{}).
+		Select(PieceUsageTableName+".object_id").
+		Joins(fmt.Sprintf("left join %s on %s.object_id = %s.object_id",
+			ObjectTierTableName, ObjectTierTableName, PieceUsageTableName)).
+		Where(fmt.Sprintf("(%s.tier is null or %s.tier <> ?)", ObjectTierTableName, ObjectTierTableName), tier).
+		Where(fmt.Sprintf("not exists (select 1 from %s where %s.object_id = %s.object_id and %s.read_timestamp_us >= ?)",
+			ReadRecordTableName, ReadRecordTableName, PieceUsageTableName, ReadRecordTableName), sinceTimestampSecond*1000*1000).
+		Group(PieceUsageTableName+".object_id").
+		Having(fmt.Sprintf("max(%s.update_timestamp_second) < ?", PieceUsageTableName), sinceTimestampSecond).
+		Order(PieceUsageTableName+".object_id ASC").Limit(limit).Pluck("object_id", &objectIDs)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to query the objects to demote: %s", result.Error)
+	}
+	return objectIDs, nil
+}
+
+// GetObjectsToPromote returns the objects that are in the tier and have been read at least
+// minReadCount times since the timestamp.
+func (s *SpDBImpl) GetObjectsToPromote(tier string, sinceTimestampSecond int64, minReadCount int, limit int) ([]uint64, error) {
+	var objectIDs []uint64
+	result := s.db.Model(&ObjectTierTable{}).
+		Select(ObjectTierTableName+".object_id").
+		Joins(fmt.Sprintf("join %s on %s.object_id = %s.object_id",
+			ReadRecordTableName, ReadRecordTableName, ObjectTierTableName)).
+		Where(fmt.Sprintf("%s.tier = ? and %s.read_timestamp_us >= ?", ObjectTierTableName, ReadRecordTableName),
+			tier, sinceTimestampSecond*1000*1000).
+		Group(ObjectTierTableName+".object_id").
+		Having("count(*) >= ?", minReadCount).
+		Order(ObjectTierTableName+".object_id ASC").Limit(limit).Pluck("object_id", &objectIDs)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to query the objects to promote: %s", result.Error)
+	}
+	return objectIDs, nil
+}
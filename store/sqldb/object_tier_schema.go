@@ -0,0 +1,13 @@
+package sqldb
+
+// ObjectTierTable table schema
+type ObjectTierTable struct {
+	ObjectID              uint64 `gorm:"primary_key"`
+	Tier                  string `gorm:"index:tier_to_object_tier"`
+	UpdateTimestampSecond int64
+}
+
+// TableName is used to set ObjectTierTable schema's table name in database
+func (ObjectTierTable) TableName() string {
+	return ObjectTierTableName
+}
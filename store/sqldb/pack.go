@@ -0,0 +1,158 @@
+package sqldb
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	corespdb "github.com/bnb-chain/greenfield-storage-provider/core/spdb"
+)
+
+// InsertPack inserts the pack file and the index of the pieces in it, the previous
+// location of the overwritten pieces becomes garbage of the previous pack.
+func (s *SpDBImpl) InsertPack(pack *corespdb.PackFile, pieces []*corespdb.PackedPiece) error {
+	timestamp := pack.CreateTimestampSecond
+	if timestamp == 0 {
+		timestamp = GetCurrentUnixTime()
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&PackFileTable{
+			PackKey:               pack.PackKey,
+			Size:                  pack.Size,
+			CreateTimestampSecond: timestamp,
+		}).Error; err != nil {
+			return err
+		}
+		var liveSize int64
+		for _, piece := range pieces {
+			var prev PackedPieceTable
+			result := tx.Where("piece_key = ?", piece.PieceKey).Limit(1).Find(&prev)
+			if result.Error != nil {
+				return result.Error
+			}
+			exists := result.RowsAffected == 1
+			if piece.PrevPackKey != "" && (!exists || prev.PackKey != piece.PrevPackKey) {
+				// the moved piece has been deleted or overwritten during compaction
+				continue
+			}
+			if exists {
+				if err := tx.Model(&PackFileTable{}).Where("pack_key = ?", prev.PackKey).
+					Update("live_size", gorm.Expr("live_size - ?", prev.Length)).Error; err != nil {
+					return err
+				}
+				if err := tx.Model(&PackedPieceTable{}).Where("piece_key = ?", piece.PieceKey).
+					Updates(map[string]interface{}{
+						"pack_key": pack.PackKey,
+						"offset":   piece.Offset,
+						"length":   piece.Length,
+					}).Error; err != nil {
+					return err
+				}
+			} else if err := tx.Create(&PackedPieceTable{
+				PieceKey: piece.PieceKey,
+				PackKey:  pack.PackKey,
+				Offset:   piece.Offset,
+				Length:   piece.Length,
+			}).Error; err != nil {
+				return err
+			}
+			liveSize += piece.Length
+		}
+		return tx.Model(&PackFileTable{}).Where("pack_key = ?", pack.PackKey).
+			Update("live_size", gorm.Expr("live_size + ?", liveSize)).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert pack record: %s", err)
+	}
+	return nil
+}
+
+// GetPackedPiece returns the location of the piece, returns nil if the piece is not packed.
+func (s *SpDBImpl) GetPackedPiece(pieceKey string) (*corespdb.PackedPiece, error) {
+	var queryReturn PackedPieceTable
+	result := s.db.Where("piece_key = ?", pieceKey).Limit(1).Find(&queryReturn)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to query packed piece table: %s", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &corespdb.PackedPiece{
+		PieceKey: queryReturn.PieceKey,
+		PackKey:  queryReturn.PackKey,
+		Offset:   queryReturn.Offset,
+		Length:   queryReturn.Length,
+	}, nil
+}
+
+// DeletePackedPiece deletes the index of the piece, returns false if the piece is not packed.
+func (s *SpDBImpl) DeletePackedPiece(pieceKey string) (bool, error) {
+	var found bool
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var prev PackedPieceTable
+		result := tx.Where("piece_key = ?", pieceKey).Limit(1).Find(&prev)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		found = true
+		if err := tx.Delete(&PackedPieceTable{PieceKey: pieceKey}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&PackFileTable{}).Where("pack_key = ?", prev.PackKey).
+			Update("live_size", gorm.Expr("live_size - ?", prev.Length)).Error
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete packed piece record: %s", err)
+	}
+	return found, nil
+}
+
+// ListPackedPieces returns all the pieces in the pack file.
+func (s *SpDBImpl) ListPackedPieces(packKey string) ([]*corespdb.PackedPiece, error) {
+	var (
+		pieces       []*corespdb.PackedPiece
+		queryReturns []PackedPieceTable
+	)
+	result := s.db.Where("pack_key = ?", packKey).Order("offset ASC").Find(&queryReturns)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to query packed piece table: %s", result.Error)
+	}
+	for _, piece := range queryReturns {
+		pieces = append(pieces, &corespdb.PackedPiece{
+			PieceKey: piece.PieceKey,
+			PackKey:  piece.PackKey,
+			Offset:   piece.Offset,
+			Length:   piece.Length,
+		})
+	}
+	return pieces, nil
+}
+
+// GetPacksToCompact returns the pack files whose live size ratio is less than liveRatio.
+func (s *SpDBImpl) GetPacksToCompact(liveRatio float64, limit int) ([]*corespdb.PackFile, error) {
+	var (
+		packs        []*corespdb.PackFile
+		queryReturns []PackFileTable
+	)
+	result := s.db.Where("live_size < size * ?", liveRatio).
+		Order("create_timestamp_second ASC").Limit(limit).Find(&queryReturns)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to query pack file table: %s", result.Error)
+	}
+	for _, pack := range queryReturns {
+		packs = append(packs, &corespdb.PackFile{
+			PackKey:               pack.PackKey,
+			Size:                  pack.Size,
+			LiveSize:              pack.LiveSize,
+			CreateTimestampSecond: pack.CreateTimestampSecond,
+		})
+	}
+	return packs, nil
+}
+
+// DeletePackFile deletes the pack file record.
+func (s *SpDBImpl) DeletePackFile(packKey string) error {
+	return s.db.Delete(&PackFileTable{
+		PackKey: packKey, // should be the primary key
+	}).Error
+}
@@ -0,0 +1,27 @@
+package sqldb
+
+// PackedPieceTable table schema
+type PackedPieceTable struct {
+	PieceKey string `gorm:"primary_key;type:varchar(256)"`
+	PackKey  string `gorm:"index:pack_to_packed_piece;type:varchar(256)"`
+	Offset   int64
+	Length   int64
+}
+
+// TableName is used to set PackedPieceTable schema's table name in database
+func (PackedPieceTable) TableName() string {
+	return PackedPieceTableName
+}
+
+// PackFileTable table schema
+type PackFileTable struct {
+	PackKey               string `gorm:"primary_key;type:varchar(256)"`
+	Size                  int64
+	LiveSize              int64
+	CreateTimestampSecond int64
+}
+
+// TableName is used to set PackFileTable schema's table name in database
+func (PackFileTable) TableName() string {
+	return PackFileTableName
+}
@@ -0,0 +1,110 @@
+package sqldb
+
+import (
+	"fmt"
+
+	corespdb "github.com/bnb-chain/greenfield-storage-provider/core/spdb"
+)
+
+// SetPieceUsage inserts or updates the piece usage of the object by object id and replicate idx.
+func (s *SpDBImpl) SetPieceUsage(usage *corespdb.PieceUsage) error {
+	timestamp := GetCurrentUnixTime()
+	result := s.db.Create(&PieceUsageTable{
+		ObjectID:              usage.ObjectID,
+		ReplicateIndex:        usage.ReplicateIdx,
+		BucketName:            usage.BucketName,
+		PaymentAccount:        usage.PaymentAccount,
+		Size:                  usage.Size,
+		UpdateTimestampSecond: timestamp,
+	})
+	if result.Error != nil && MysqlErrCode(result.Error) == ErrDuplicateEntryCode {
+		result = s.db.Model(&PieceUsageTable{}).
+			Where("object_id = ? and replicate_index = ?", usage.ObjectID, usage.ReplicateIdx).
+			Updates(map[string]interface{}{
+				"bucket_name":             usage.BucketName,
+				"payment_account":         usage.PaymentAccount,
+				"size":                    usage.Size,
+				"update_timestamp_second": timestamp,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update piece usage record: %s", result.Error)
+		}
+		return nil
+	}
+	if result.Error != nil || result.RowsAffected != 1 {
+		return fmt.Errorf("failed to insert piece usage record: %s", result.Error)
+	}
+	return nil
+}
+
+// DeletePieceUsage deletes the piece usage of the object by object id and replicate idx.
+func (s *SpDBImpl) DeletePieceUsage(objectID uint64, replicateIdx int32) error {
+	return s.db.Delete(&PieceUsageTable{
+		ObjectID:       objectID,     // should be the primary key
+		ReplicateIndex: replicateIdx, // should be the primary key
+	}).Error
+}
+
+// GetPieceUsageByObjectID returns all the piece usages of the object.
+func (s *SpDBImpl) GetPieceUsageByObjectID(objectID uint64) ([]*corespdb.PieceUsage, error) {
+	var (
+		usages       []*corespdb.PieceUsage
+		queryReturns []PieceUsageTable
+	)
+	result := s.db.Where("object_id = ?", objectID).Order("replicate_index ASC").Find(&queryReturns)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to query piece usage table: %s", result.Error)
+	}
+	for _, usage := range queryReturns {
+		usages = append(usages, &corespdb.PieceUsage{
+			ObjectID:       usage.ObjectID,
+			ReplicateIdx:   usage.ReplicateIndex,
+			BucketName:     usage.BucketName,
+			PaymentAccount: usage.PaymentAccount,
+			Size:           usage.Size,
+		})
+	}
+	return usages, nil
+}
+
+// GetPieceUsageStatsByBucket returns the piece usage stats grouped by bucket.
+func (s *SpDBImpl) GetPieceUsageStatsByBucket(bucketName string) ([]*corespdb.PieceUsageStat, error) {
+	return s.getPieceUsageStats("bucket_name", bucketName)
+}
+
+// GetPieceUsageStatsByPaymentAccount returns the piece usage stats grouped by payment account.
+func (s *SpDBImpl) GetPieceUsageStatsByPaymentAccount(paymentAccount string) ([]*corespdb.PieceUsageStat, error) {
+	return s.getPieceUsageStats("payment_account", paymentAccount)
+}
+
+func (s *SpDBImpl) getPieceUsageStats(column, value string) ([]*corespdb.PieceUsageStat, error) {
+	var (
+		stats        []*corespdb.PieceUsageStat
+		queryReturns []struct {
+			Key           string
+			ObjectCount   int64
+			PrimarySize   int64
+			SecondarySize int64
+		}
+	)
+	query := s.db.Model(&PieceUsageTable{}).Select(column + " as `key`, " +
+		"count(distinct object_id) as object_count, " +
+		"sum(case when replicate_index < 0 then size else 0 end) as primary_size, " +
+		"sum(case when replicate_index >= 0 then size else 0 end) as secondary_size")
+	if value != "" {
+		query = query.Where(column+" = ?", value)
+	}
+	result := query.Group(column).Order(column + " ASC").Scan(&queryReturns)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to query piece usage stats: %s", result.Error)
+	}
+	for _, stat := range queryReturns {
+		stats = append(stats, &corespdb.PieceUsageStat{
+			Key:           stat.Key,
+			ObjectCount:   stat.ObjectCount,
+			PrimarySize:   stat.PrimarySize,
+			SecondarySize: stat.SecondarySize,
+		})
+	}
+	return stats, nil
+}
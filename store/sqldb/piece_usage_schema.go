@@ -0,0 +1,16 @@
+package sqldb
+
+// PieceUsageTable table schema
+type PieceUsageTable struct {
+	ObjectID              uint64 `gorm:"primary_key"`
+	ReplicateIndex        int32  `gorm:"primary_key"`
+	BucketName            string `gorm:"index:bucket_to_piece_usage"`
+	PaymentAccount        string `gorm:"index:payment_to_piece_usage"`
+	Size                  int64
+	UpdateTimestampSecond int64
+}
+
+// TableName is used to set PieceUsageTable schema's table name in database
+func (PieceUsageTable) TableName() string {
+	return PieceUsageTableName
+}
@@ -78,6 +78,46 @@ func InitDB(config *config.SQLDBConfig) (*gorm.DB, error) {
 		log.Errorw("failed to gc object progress table", "error", err)
 		return nil, err
 	}
+	if err = db.AutoMigrate(&GCZombiePieceTable{}); err != nil {
+		log.Errorw("failed to create gc zombie piece table", "error", err)
+		return nil, err
+	}
+	if err = db.AutoMigrate(&DiscontinueBucketFlagTable{}); err != nil {
+		log.Errorw("failed to create discontinue bucket flag table", "error", err)
+		return nil, err
+	}
+	if err = db.AutoMigrate(&DiscontinueBucketCursorTable{}); err != nil {
+		log.Errorw("failed to create discontinue bucket cursor table", "error", err)
+		return nil, err
+	}
+	if err = db.AutoMigrate(&TaskEventTable{}); err != nil {
+		log.Errorw("failed to create task event table", "error", err)
+		return nil, err
+	}
+	if err = db.AutoMigrate(&PieceUsageTable{}); err != nil {
+		log.Errorw("failed to create piece usage table", "error", err)
+		return nil, err
+	}
+	if err = db.AutoMigrate(&ObjectTierTable{}); err != nil {
+		log.Errorw("failed to create object tier table", "error", err)
+		return nil, err
+	}
+	if err = db.AutoMigrate(&PackedPieceTable{}); err != nil {
+		log.Errorw("failed to create packed piece table", "error", err)
+		return nil, err
+	}
+	if err = db.AutoMigrate(&PackFileTable{}); err != nil {
+		log.Errorw("failed to create pack file table", "error", err)
+		return nil, err
+	}
+	if err = db.AutoMigrate(&DedupPieceTable{}); err != nil {
+		log.Errorw("failed to create dedup piece table", "error", err)
+		return nil, err
+	}
+	if err = db.AutoMigrate(&DedupContentTable{}); err != nil {
+		log.Errorw("failed to create dedup content table", "error", err)
+		return nil, err
+	}
 	if err = db.AutoMigrate(&SpInfoTable{}); err != nil {
 		log.Errorw("failed to create sp info table", "error", err)
 		return nil, err
@@ -0,0 +1,90 @@
+package sqldb
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	corespdb "github.com/bnb-chain/greenfield-storage-provider/core/spdb"
+)
+
+// InsertTaskEvents inserts the task state transition events in batch.
+func (s *SpDBImpl) InsertTaskEvents(events []*corespdb.TaskEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	rows := make([]*TaskEventTable, 0, len(events))
+	for _, event := range events {
+		timestamp := event.TimestampSecond
+		if timestamp == 0 {
+			timestamp = GetCurrentUnixTime()
+		}
+		rows = append(rows, &TaskEventTable{
+			TaskKey:               event.TaskKey,
+			TaskType:              event.TaskType,
+			ObjectID:              event.ObjectID,
+			Event:                 string(event.Event),
+			Address:               event.Address,
+			Retry:                 event.Retry,
+			ErrorDescription:      event.ErrorDescription,
+			CreateTimestampSecond: timestamp,
+		})
+	}
+	if result := s.db.CreateInBatches(rows, len(rows)); result.Error != nil {
+		return fmt.Errorf("failed to insert task event records: %s", result.Error)
+	}
+	return nil
+}
+
+// DeleteTaskEventsBefore deletes at most limit task events created before the timestamp.
+func (s *SpDBImpl) DeleteTaskEventsBefore(timestampSecond int64, limit int) (int64, error) {
+	result := s.db.Where("create_timestamp_second < ?", timestampSecond).Limit(limit).Delete(&TaskEventTable{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete task event records: %s", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// GetTaskEventsByObjectID return the task events of the object by time range.
+func (s *SpDBImpl) GetTaskEventsByObjectID(objectID uint64, timeRange *corespdb.TaskEventTimeRange) (
+	[]*corespdb.TaskEvent, error) {
+	return s.getTaskEvents(s.db.Where("object_id = ?", objectID), timeRange)
+}
+
+// GetTaskEventsByTimeRange return the task events of all objects by time range.
+func (s *SpDBImpl) GetTaskEventsByTimeRange(timeRange *corespdb.TaskEventTimeRange) ([]*corespdb.TaskEvent, error) {
+	return s.getTaskEvents(s.db, timeRange)
+}
+
+func (s *SpDBImpl) getTaskEvents(query *gorm.DB, timeRange *corespdb.TaskEventTimeRange) (
+	[]*corespdb.TaskEvent, error) {
+	var (
+		result       *gorm.DB
+		events       []*corespdb.TaskEvent
+		queryReturns []TaskEventTable
+	)
+	query = query.Where("create_timestamp_second >= ? and create_timestamp_second < ?",
+		timeRange.StartTimestampSecond, timeRange.EndTimestampSecond).
+		Order("create_timestamp_second ASC, task_event_id ASC")
+	if timeRange.LimitNum <= 0 {
+		result = query.Find(&queryReturns)
+	} else {
+		result = query.Limit(timeRange.LimitNum).Find(&queryReturns)
+	}
+	if result.Error != nil {
+		return events, fmt.Errorf("failed to query task event table: %s", result.Error)
+	}
+	for _, event := range queryReturns {
+		events = append(events, &corespdb.TaskEvent{
+			TaskKey:          event.TaskKey,
+			TaskType:         event.TaskType,
+			ObjectID:         event.ObjectID,
+			Event:            corespdb.TaskEventType(event.Event),
+			Address:          event.Address,
+			Retry:            event.Retry,
+			ErrorDescription: event.ErrorDescription,
+			TimestampSecond:  event.CreateTimestampSecond,
+		})
+	}
+	return events, nil
+}
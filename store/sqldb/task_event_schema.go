@@ -0,0 +1,19 @@
+package sqldb
+
+// TaskEventTable table schema
+type TaskEventTable struct {
+	TaskEventID           uint64 `gorm:"primary_key;autoIncrement"`
+	TaskKey               string `gorm:"index:task_key_to_task_event"`
+	TaskType              string
+	ObjectID              uint64 `gorm:"index:object_to_task_event"`
+	Event                 string
+	Address               string
+	Retry                 int64
+	ErrorDescription      string
+	CreateTimestampSecond int64 `gorm:"index:time_to_task_event"`
+}
+
+// TableName is used to set TaskEventTable Schema's table name in database
+func (TaskEventTable) TableName() string {
+	return TaskEventTableName
+}
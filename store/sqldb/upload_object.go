@@ -111,3 +111,26 @@ func (s *SpDBImpl) GetUploadMetasToSeal(limit int) ([]*corespdb.UploadObjectMeta
 	}
 	return returnUploadObjectMetas, nil
 }
+
+func (s *SpDBImpl) GetUploadMetasToReject(updatedBefore int64, limit int) ([]*corespdb.UploadObjectMeta, error) {
+	var (
+		result                  *gorm.DB
+		uploadObjectProgresses  []UploadObjectProgressTable
+		returnUploadObjectMetas []*corespdb.UploadObjectMeta
+	)
+	result = s.db.Where("task_state NOT IN ? AND update_timestamp_second < ?", []string{
+		util.Uint32ToString(uint32(storetypes.TaskState_TASK_STATE_SEAL_OBJECT_DONE)),
+		util.Uint32ToString(uint32(storetypes.TaskState_TASK_STATE_REJECT_UNSEAL_OBJECT_DONE)),
+	}, updatedBefore).Order("update_timestamp_second ASC").Limit(limit).Find(&uploadObjectProgresses)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to query upload table: %s", result.Error)
+	}
+	for _, u := range uploadObjectProgresses {
+		returnUploadObjectMetas = append(returnUploadObjectMetas, &corespdb.UploadObjectMeta{
+			ObjectID:         u.ObjectID,
+			TaskState:        storetypes.TaskState(u.TaskState),
+			ErrorDescription: u.ErrorDescription,
+		})
+	}
+	return returnUploadObjectMetas, nil
+}
@@ -9,6 +9,7 @@ const (
 	UploadProgressSealing         readableUploadProgressType = 3
 	UploadProgressCompleted       readableUploadProgressType = 4
 	UploadProgressFailed          readableUploadProgressType = 5
+	UploadProgressRejected        readableUploadProgressType = 6
 )
 
 // ToReadableDescription convects readable type to the description string
@@ -19,6 +20,7 @@ var ToReadableDescription = map[readableUploadProgressType]string{
 	UploadProgressSealing:         "object meta is sealing onto the chain in the background",
 	UploadProgressCompleted:       "object is succeed to upload",
 	UploadProgressFailed:          "something is wrong in the upload progress",
+	UploadProgressRejected:        "object is stuck in the upload progress and rejected to seal onto the chain",
 }
 
 // StateToProgressType convents inner state to the readable type
@@ -39,6 +41,9 @@ var StateToProgressType = map[TaskState]readableUploadProgressType{
 	TaskState_TASK_STATE_SEAL_OBJECT_DOING:      UploadProgressSealing,
 	TaskState_TASK_STATE_SEAL_OBJECT_DONE:       UploadProgressCompleted,
 	TaskState_TASK_STATE_SEAL_OBJECT_ERROR:      UploadProgressFailed,
+
+	TaskState_TASK_STATE_REJECT_UNSEAL_OBJECT_DONE:  UploadProgressRejected,
+	TaskState_TASK_STATE_REJECT_UNSEAL_OBJECT_ERROR: UploadProgressFailed,
 }
 
 // StateToDescription convents state to description.
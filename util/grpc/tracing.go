@@ -0,0 +1,119 @@
+package grpc
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/bnb-chain/greenfield-storage-provider/pkg/tracing"
+)
+
+// rpcMethodAttribute defines the span attribute key of the gRPC full method name.
+const rpcMethodAttribute = attribute.Key("rpc.method")
+
+// metadataCarrier adapts the gRPC metadata to the propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func injectOutgoing(ctx context.Context) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		md = metadata.MD{}
+	} else {
+		md = md.Copy()
+	}
+	tracing.Inject(ctx, metadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+func extractIncoming(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return tracing.Extract(ctx, metadataCarrier(md))
+}
+
+// tracingServerStream wraps the grpc.ServerStream to replace the context.
+type tracingServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tracingServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// GetDefaultServerTraceInterceptor returns default gRPC server interceptor that
+// extracts the trace context from the request metadata and starts a server span.
+func GetDefaultServerTraceInterceptor() []grpc.ServerOption {
+	unary := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, span := tracing.StartSpanWithKind(extractIncoming(ctx), info.FullMethod,
+			trace.SpanKindServer, rpcMethodAttribute.String(info.FullMethod))
+		resp, err := handler(ctx, req)
+		tracing.EndSpan(span, err)
+		return resp, err
+	}
+	stream := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		ctx, span := tracing.StartSpanWithKind(extractIncoming(ss.Context()), info.FullMethod,
+			trace.SpanKindServer, rpcMethodAttribute.String(info.FullMethod))
+		err := handler(srv, &tracingServerStream{ServerStream: ss, ctx: ctx})
+		tracing.EndSpan(span, err)
+		return err
+	}
+	options := []grpc.ServerOption{}
+	options = append(options, grpc.ChainUnaryInterceptor(unary))
+	options = append(options, grpc.ChainStreamInterceptor(stream))
+	return options
+}
+
+// GetDefaultClientTraceInterceptor returns default gRPC client interceptor that
+// starts a client span and injects the trace context to the request metadata.
+func GetDefaultClientTraceInterceptor() []grpc.DialOption {
+	unary := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, span := tracing.StartSpanWithKind(ctx, method, trace.SpanKindClient,
+			rpcMethodAttribute.String(method))
+		err := invoker(injectOutgoing(ctx), method, req, reply, cc, opts...)
+		tracing.EndSpan(span, err)
+		return err
+	}
+	stream := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, span := tracing.StartSpanWithKind(ctx, method, trace.SpanKindClient,
+			rpcMethodAttribute.String(method))
+		// the stream span only covers the stream creation, the stream lifecycle is
+		// driven by the caller and the server span records the whole stream.
+		cs, err := streamer(injectOutgoing(ctx), desc, cc, method, opts...)
+		tracing.EndSpan(span, err)
+		return cs, err
+	}
+	options := []grpc.DialOption{}
+	options = append(options, grpc.WithChainUnaryInterceptor(unary))
+	options = append(options, grpc.WithChainStreamInterceptor(stream))
+	return options
+}